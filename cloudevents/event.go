@@ -0,0 +1,256 @@
+// Package cloudevents implements the CloudEvents 1.0 envelope shared by the services,
+// with the structured and binary bindings for Kafka messages.
+package cloudevents
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SpecVersion is the CloudEvents version implemented by this package
+const SpecVersion = "1.0"
+
+// ContentTypeJSON is the data content type of JSON encoded data
+const ContentTypeJSON = "application/json"
+
+// ErrInvalidEvent is returned for events that violate the specification
+var ErrInvalidEvent = errors.New("invalid cloudevent")
+
+// extensionName is the format of extension attribute names
+var extensionName = regexp.MustCompile(`^[a-z0-9]{1,20}$`)
+
+// reserved are the attribute names defined by the specification, which extensions cannot use
+var reserved = map[string]bool{
+	"id": true, "source": true, "specversion": true, "type": true, "datacontenttype": true,
+	"dataschema": true, "subject": true, "time": true, "data": true, "data_base64": true,
+}
+
+// Event is a CloudEvent. Data holds the encoded payload described by DataContentType.
+type Event struct {
+	ID              string
+	Source          string
+	SpecVersion     string
+	Type            string
+	DataContentType string
+	DataSchema      string
+	Subject         string
+	Time            time.Time
+	// Extensions are additional context attributes, e.g. "correlationid"
+	Extensions map[string]string
+	Data       []byte
+}
+
+// New creates an event with a random ID and the current time, carrying the data encoded as JSON
+func New(source, eventType string, data interface{}) (Event, error) {
+	event := Event{
+		ID:          uuid.NewString(),
+		Source:      source,
+		SpecVersion: SpecVersion,
+		Type:        eventType,
+		Time:        time.Now().UTC(),
+	}
+	if err := event.SetData(data); err != nil {
+		return Event{}, err
+	}
+	return event, nil
+}
+
+// SetData encodes the data as JSON
+func (e *Event) SetData(data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data of %s event: %w", e.Type, err)
+	}
+	e.Data = encoded
+	e.DataContentType = ContentTypeJSON
+	return nil
+}
+
+// DataAs decodes JSON data into v
+func (e Event) DataAs(v interface{}) error {
+	if !isJSON(e.DataContentType) {
+		return fmt.Errorf("cannot decode %s data of %s event as JSON", e.DataContentType, e.Type)
+	}
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return fmt.Errorf("failed to unmarshal data of %s event: %w", e.Type, err)
+	}
+	return nil
+}
+
+// SetExtension sets an extension attribute, an empty value removes it
+func (e *Event) SetExtension(name, value string) {
+	if value == "" {
+		delete(e.Extensions, name)
+		return
+	}
+	if e.Extensions == nil {
+		e.Extensions = make(map[string]string)
+	}
+	e.Extensions[name] = value
+}
+
+// Extension returns the value of an extension attribute, empty if it is not set
+func (e Event) Extension(name string) string {
+	return e.Extensions[name]
+}
+
+// Validate checks the required attributes and the extension names
+func (e Event) Validate() error {
+	switch {
+	case e.ID == "":
+		return fmt.Errorf("%w: missing id", ErrInvalidEvent)
+	case e.Source == "":
+		return fmt.Errorf("%w: missing source", ErrInvalidEvent)
+	case e.SpecVersion != SpecVersion:
+		return fmt.Errorf("%w: unsupported specversion %q", ErrInvalidEvent, e.SpecVersion)
+	case e.Type == "":
+		return fmt.Errorf("%w: missing type", ErrInvalidEvent)
+	}
+
+	for name := range e.Extensions {
+		if !extensionName.MatchString(name) || reserved[name] {
+			return fmt.Errorf("%w: invalid extension name %q", ErrInvalidEvent, name)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the event in the JSON event format. JSON data is embedded
+// as it is, any other data is base64 encoded.
+func (e Event) MarshalJSON() ([]byte, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+
+	attributes := make(map[string]interface{}, len(e.Extensions)+9)
+	for name, value := range e.Extensions {
+		attributes[name] = value
+	}
+	attributes["id"] = e.ID
+	attributes["source"] = e.Source
+	attributes["specversion"] = e.SpecVersion
+	attributes["type"] = e.Type
+	setString(attributes, "datacontenttype", e.DataContentType)
+	setString(attributes, "dataschema", e.DataSchema)
+	setString(attributes, "subject", e.Subject)
+	if !e.Time.IsZero() {
+		attributes["time"] = e.Time.Format(time.RFC3339Nano)
+	}
+
+	if len(e.Data) > 0 {
+		if isJSON(e.DataContentType) {
+			attributes["data"] = json.RawMessage(e.Data)
+		} else {
+			attributes["data_base64"] = base64.StdEncoding.EncodeToString(e.Data)
+		}
+	}
+
+	return json.Marshal(attributes)
+}
+
+// UnmarshalJSON decodes an event in the JSON event format
+func (e *Event) UnmarshalJSON(b []byte) error {
+	var attributes map[string]json.RawMessage
+	if err := json.Unmarshal(b, &attributes); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+
+	event := Event{}
+	for name, raw := range attributes {
+		switch name {
+		case "data":
+			event.Data = raw
+			continue
+		case "data_base64":
+			var encoded string
+			if err := json.Unmarshal(raw, &encoded); err != nil {
+				return fmt.Errorf("%w: data_base64 is not a string", ErrInvalidEvent)
+			}
+			data, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+			}
+			event.Data = data
+			continue
+		}
+
+		value, err := attributeString(raw)
+		if err != nil {
+			return fmt.Errorf("%w: attribute %s: %v", ErrInvalidEvent, name, err)
+		}
+		if err := event.setAttribute(name, value); err != nil {
+			return err
+		}
+	}
+
+	if err := event.Validate(); err != nil {
+		return err
+	}
+	*e = event
+	return nil
+}
+
+// setAttribute sets a context attribute from its string form
+func (e *Event) setAttribute(name, value string) error {
+	switch name {
+	case "id":
+		e.ID = value
+	case "source":
+		e.Source = value
+	case "specversion":
+		e.SpecVersion = value
+	case "type":
+		e.Type = value
+	case "datacontenttype":
+		e.DataContentType = value
+	case "dataschema":
+		e.DataSchema = value
+	case "subject":
+		e.Subject = value
+	case "time":
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return fmt.Errorf("%w: invalid time %q", ErrInvalidEvent, value)
+		}
+		e.Time = t
+	default:
+		e.SetExtension(name, value)
+	}
+	return nil
+}
+
+// attributeString returns the string form of an attribute value.
+// Extensions may be encoded as JSON numbers or booleans.
+func attributeString(raw json.RawMessage) (string, error) {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", err
+	}
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case float64, bool:
+		return string(raw), nil
+	default:
+		return "", errors.New("not a string, number or boolean")
+	}
+}
+
+func setString(attributes map[string]interface{}, name, value string) {
+	if value != "" {
+		attributes[name] = value
+	}
+}
+
+// isJSON reports whether the content type is JSON, an empty type is assumed to be JSON
+func isJSON(contentType string) bool {
+	mediaType := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	return mediaType == "" || mediaType == ContentTypeJSON || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,79 @@
+package cloudevents_test
+
+import (
+	"cloudevents"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderCreated struct {
+	OrderID string `json:"order_id"`
+}
+
+func TestStructuredJSONRoundTrip(t *testing.T) {
+	event, err := cloudevents.New("/order-service", "order.created", orderCreated{OrderID: "order-1"})
+	require.NoError(t, err)
+	event.Subject = "order-1"
+	event.SetExtension("correlationid", "request-1")
+
+	encoded, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	var attributes map[string]interface{}
+	require.NoError(t, json.Unmarshal(encoded, &attributes))
+	assert.Equal(t, "1.0", attributes["specversion"])
+	assert.Equal(t, "request-1", attributes["correlationid"])
+	// JSON data is embedded as it is
+	assert.Equal(t, map[string]interface{}{"order_id": "order-1"}, attributes["data"])
+
+	var decoded cloudevents.Event
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, event.ID, decoded.ID)
+	assert.Equal(t, event.Type, decoded.Type)
+	assert.Equal(t, "order-1", decoded.Subject)
+	assert.Equal(t, "request-1", decoded.Extension("correlationid"))
+	assert.True(t, event.Time.Equal(decoded.Time))
+
+	var data orderCreated
+	require.NoError(t, decoded.DataAs(&data))
+	assert.Equal(t, "order-1", data.OrderID)
+}
+
+func TestBinaryDataIsBase64Encoded(t *testing.T) {
+	event := cloudevents.Event{
+		ID:              "1",
+		Source:          "/inventory-service",
+		SpecVersion:     cloudevents.SpecVersion,
+		Type:            "inventory.snapshot",
+		DataContentType: "application/octet-stream",
+		Time:            time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Data:            []byte{0, 1, 2},
+	}
+
+	encoded, err := json.Marshal(event)
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"data_base64":"AAEC"`)
+
+	var decoded cloudevents.Event
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, event, decoded)
+}
+
+func TestValidate(t *testing.T) {
+	valid := cloudevents.Event{ID: "1", Source: "/order-service", SpecVersion: "1.0", Type: "order.created"}
+	assert.NoError(t, valid.Validate())
+
+	for name, event := range map[string]cloudevents.Event{
+		"missing id":       {Source: "/order-service", SpecVersion: "1.0", Type: "order.created"},
+		"old specversion":  {ID: "1", Source: "/order-service", SpecVersion: "0.3", Type: "order.created"},
+		"bad extension":    {ID: "1", Source: "/order-service", SpecVersion: "1.0", Type: "t", Extensions: map[string]string{"trace-id": "x"}},
+		"reserved name":    {ID: "1", Source: "/order-service", SpecVersion: "1.0", Type: "t", Extensions: map[string]string{"data": "x"}},
+		"missing the type": {ID: "1", Source: "/order-service", SpecVersion: "1.0"},
+	} {
+		assert.ErrorIs(t, event.Validate(), cloudevents.ErrInvalidEvent, name)
+	}
+}
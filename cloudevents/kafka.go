@@ -0,0 +1,148 @@
+package cloudevents
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Mode is the way an event is bound to a Kafka message
+type Mode int
+
+const (
+	// Binary puts the attributes into ce_ headers and the data into the message value,
+	// so consumers unaware of CloudEvents still read the plain payload
+	Binary Mode = iota
+	// Structured puts the whole event in the JSON event format into the message value
+	Structured
+)
+
+// Kafka binding header names
+const (
+	HeaderPrefix      = "ce_"
+	HeaderContentType = "content-type"
+
+	// ContentTypeStructured is the content type of messages in structured mode
+	ContentTypeStructured = "application/cloudevents+json; charset=UTF-8"
+)
+
+// ExtensionPartitionKey is the extension attribute the message key is taken from
+const ExtensionPartitionKey = "partitionkey"
+
+// ErrNotCloudEvent is returned for messages that carry no CloudEvent
+var ErrNotCloudEvent = errors.New("message is not a cloudevent")
+
+// ParseMode parses "binary" or "structured", binary when empty
+func ParseMode(name string) (Mode, error) {
+	switch name {
+	case "", "binary":
+		return Binary, nil
+	case "structured":
+		return Structured, nil
+	default:
+		return 0, fmt.Errorf("unknown cloudevents mode %q", name)
+	}
+}
+
+// WriteMessage binds the event to the message. The message key is set from the
+// partitionkey extension unless the message already has a key.
+func WriteMessage(msg *sarama.ProducerMessage, event Event, mode Mode) error {
+	if err := event.Validate(); err != nil {
+		return err
+	}
+
+	if msg.Key == nil {
+		if key := event.Extension(ExtensionPartitionKey); key != "" {
+			msg.Key = sarama.StringEncoder(key)
+		}
+	}
+
+	if mode == Structured {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s event: %w", event.Type, err)
+		}
+		msg.Value = sarama.ByteEncoder(value)
+		msg.Headers = append(msg.Headers, header(HeaderContentType, ContentTypeStructured))
+		return nil
+	}
+
+	attributes := map[string]string{
+		"id":          event.ID,
+		"source":      event.Source,
+		"specversion": event.SpecVersion,
+		"type":        event.Type,
+		"dataschema":  event.DataSchema,
+		"subject":     event.Subject,
+	}
+	if !event.Time.IsZero() {
+		attributes["time"] = event.Time.Format(time.RFC3339Nano)
+	}
+	for name, value := range event.Extensions {
+		attributes[name] = value
+	}
+
+	// Sorted so identical events produce identical messages
+	names := make([]string, 0, len(attributes))
+	for name, value := range attributes {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if event.DataContentType != "" {
+		msg.Headers = append(msg.Headers, header(HeaderContentType, event.DataContentType))
+	}
+	for _, name := range names {
+		msg.Headers = append(msg.Headers, header(HeaderPrefix+name, attributes[name]))
+	}
+	msg.Value = sarama.ByteEncoder(event.Data)
+	return nil
+}
+
+// ReadMessage reads the event of a message in either mode
+func ReadMessage(msg *sarama.ConsumerMessage) (Event, error) {
+	headers := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		if h != nil {
+			headers[strings.ToLower(string(h.Key))] = string(h.Value)
+		}
+	}
+
+	if strings.HasPrefix(headers[HeaderContentType], "application/cloudevents") {
+		var event Event
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			return Event{}, err
+		}
+		return event, nil
+	}
+
+	if _, ok := headers[HeaderPrefix+"specversion"]; !ok {
+		return Event{}, ErrNotCloudEvent
+	}
+
+	event := Event{DataContentType: headers[HeaderContentType], Data: msg.Value}
+	for name, value := range headers {
+		if !strings.HasPrefix(name, HeaderPrefix) {
+			continue
+		}
+		if err := event.setAttribute(strings.TrimPrefix(name, HeaderPrefix), value); err != nil {
+			return Event{}, err
+		}
+	}
+
+	if err := event.Validate(); err != nil {
+		return Event{}, err
+	}
+	return event, nil
+}
+
+func header(key, value string) sarama.RecordHeader {
+	return sarama.RecordHeader{Key: []byte(key), Value: []byte(value)}
+}
@@ -0,0 +1,79 @@
+package cloudevents_test
+
+import (
+	"cloudevents"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// consumed turns a produced message into the message a consumer receives
+func consumed(t *testing.T, msg *sarama.ProducerMessage) *sarama.ConsumerMessage {
+	value, err := msg.Value.Encode()
+	require.NoError(t, err)
+
+	received := &sarama.ConsumerMessage{Topic: msg.Topic, Value: value}
+	for i := range msg.Headers {
+		received.Headers = append(received.Headers, &msg.Headers[i])
+	}
+	return received
+}
+
+func TestKafkaBindingRoundTrip(t *testing.T) {
+	for _, mode := range []cloudevents.Mode{cloudevents.Binary, cloudevents.Structured} {
+		event, err := cloudevents.New("/order-service", "order.created", orderCreated{OrderID: "order-1"})
+		require.NoError(t, err)
+		event.SetExtension(cloudevents.ExtensionPartitionKey, "order-1")
+		event.SetExtension("sequence", "3")
+
+		msg := &sarama.ProducerMessage{Topic: "orders"}
+		require.NoError(t, cloudevents.WriteMessage(msg, event, mode))
+
+		key, err := msg.Key.Encode()
+		require.NoError(t, err)
+		assert.Equal(t, "order-1", string(key))
+
+		decoded, err := cloudevents.ReadMessage(consumed(t, msg))
+		require.NoError(t, err)
+		assert.Equal(t, event.ID, decoded.ID)
+		assert.Equal(t, event.Type, decoded.Type)
+		assert.Equal(t, "3", decoded.Extension("sequence"))
+		assert.JSONEq(t, `{"order_id":"order-1"}`, string(decoded.Data))
+	}
+}
+
+func TestBinaryModeKeepsPlainPayload(t *testing.T) {
+	event, err := cloudevents.New("/order-service", "order.created", orderCreated{OrderID: "order-1"})
+	require.NoError(t, err)
+
+	msg := &sarama.ProducerMessage{Topic: "orders"}
+	require.NoError(t, cloudevents.WriteMessage(msg, event, cloudevents.Binary))
+
+	value, err := msg.Value.Encode()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"order_id":"order-1"}`, string(value))
+
+	headers := make(map[string]string)
+	for _, h := range msg.Headers {
+		headers[string(h.Key)] = string(h.Value)
+	}
+	assert.Equal(t, "order.created", headers["ce_type"])
+	assert.Equal(t, "1.0", headers["ce_specversion"])
+	assert.Equal(t, cloudevents.ContentTypeJSON, headers["content-type"])
+}
+
+func TestReadMessageRejectsPlainMessages(t *testing.T) {
+	_, err := cloudevents.ReadMessage(&sarama.ConsumerMessage{Value: []byte(`{}`)})
+	assert.ErrorIs(t, err, cloudevents.ErrNotCloudEvent)
+}
+
+func TestParseMode(t *testing.T) {
+	mode, err := cloudevents.ParseMode("structured")
+	require.NoError(t, err)
+	assert.Equal(t, cloudevents.Structured, mode)
+
+	_, err = cloudevents.ParseMode("batched")
+	assert.Error(t, err)
+}
@@ -1,14 +1,14 @@
 package main
 
 import (
+	"cloudevents"
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
+	"kafkaconsumer"
 	"log"
 	"os"
 	"os/signal"
-	"sync"
+	"schemaregistry"
 	"time"
 
 	"github.com/IBM/sarama"
@@ -25,15 +25,8 @@ const (
 	OrderPlaced EventType = "order.placed"
 )
 
-// Event is the base event structure
-type Event struct {
-	ID          string      `json:"id"`
-	Type        EventType   `json:"type"`
-	Source      string      `json:"source"`
-	Timestamp   time.Time   `json:"timestamp"`
-	Data        interface{} `json:"data"`
-	SpecVersion string      `json:"spec_version"`
-}
+// Event is the base event structure, a CloudEvent shared with the other services
+type Event = cloudevents.Event
 
 // UserCreatedEvent represents an event of a user being created
 type UserCreatedEvent struct {
@@ -87,26 +80,19 @@ func NewEventProducer(brokers []string, topic string, source string) (*EventProd
 	return &EventProducer{producer: producer, topic: topic, source: source}, nil
 }
 
-// Emit publishes an event to Kafka
+// Emit publishes an event to Kafka as a CloudEvent in binary mode
 func (p *EventProducer) Emit(ctx context.Context, eventType EventType, data interface{}) error {
-	event := Event{
-		ID:          uuid.New().String(),
-		Type:        eventType,
-		Source:      p.source,
-		Timestamp:   time.Now(),
-		Data:        data,
-		SpecVersion: "1.0.0",
-	}
-
-	eventJson, err := json.Marshal(event)
+	event, err := cloudevents.New(p.source, string(eventType), data)
 	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
+		return err
 	}
 
 	msg := &sarama.ProducerMessage{
 		Topic: p.topic,
 		Key:   sarama.StringEncoder(event.ID),
-		Value: sarama.ByteEncoder(eventJson),
+	}
+	if err := cloudevents.WriteMessage(msg, event, cloudevents.Binary); err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
 	}
 
 	partition, offset, err := p.producer.SendMessage(msg)
@@ -124,154 +110,68 @@ func (p *EventProducer) Close() error {
 	return p.producer.Close()
 }
 
-// EventConsumer handles event consumption
+// EventConsumer handles event consumption, with a consumer shared with the other services
 type EventConsumer struct {
-	consumer  sarama.ConsumerGroup
-	topics    []string
-	handler   map[EventType]EventHandler
-	cancelled bool
-	mu        sync.Mutex
+	consumer *kafkaconsumer.Consumer
 }
 
+// Upcaster converts the JSON data of an event to the next schema version.
+type Upcaster = kafkaconsumer.Upcaster
+
 // EventHandler is the interface for handling events
-type EventHandler interface {
-	Handle(ctx context.Context, event Event) error
-}
+type EventHandler = kafkaconsumer.Handler
 
 // EventHandlerFunc is a function type that implements the EventHandler interface
-type EventHandlerFunc func(ctx context.Context, event Event) error
-
-// Handle implements the EventHandler interface
-func (f EventHandlerFunc) Handle(ctx context.Context, event Event) error {
-	return f(ctx, event)
-}
+type EventHandlerFunc = kafkaconsumer.HandlerFunc
 
 // NewEventConsumer creates a new event consumer
 func NewEventConsumer(brokers []string, groupID string, topics []string) (*EventConsumer, error) {
-	config := sarama.NewConfig()
-	config.Consumer.Return.Errors = true
-	config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
-
-	consumer, err := sarama.NewConsumerGroup(brokers, groupID, config)
+	consumer, err := kafkaconsumer.New(kafkaconsumer.Config{
+		Brokers:         brokers,
+		GroupID:         groupID,
+		Topics:          topics,
+		AutoOffsetReset: "earliest",
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create consumer group: %w", err)
+		return nil, err
 	}
 
-	return &EventConsumer{
-		consumer: consumer,
-		topics:   topics,
-		handler:  make(map[EventType]EventHandler),
-	}, nil
+	return &EventConsumer{consumer: consumer}, nil
 }
 
 // RegisterEventHandler registers a handler for a specific event type
 func (c *EventConsumer) RegisterHandler(eventType EventType, handler EventHandler) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.handler[eventType] = handler
+	c.consumer.Register(string(eventType), handler)
 }
 
-// Start beging consuming events from Kafka
-func (c *EventConsumer) Start(ctx context.Context) error {
-	handler := &consumerGroupHandler{
-		consumer: c,
-		ready:    make(chan bool),
-	}
+// Use wraps every handler with the middlewares, e.g. to recover from panics or log the events
+func (c *EventConsumer) Use(middlewares ...kafkaconsumer.Middleware) {
+	c.consumer.Use(middlewares...)
+}
 
-	wg := &sync.WaitGroup{}
-	wg.Add(1)
+// UseDeserializer decodes payloads serialized with their schemas in the schema registry,
+// so handlers read them as JSON like any other payload
+func (c *EventConsumer) UseDeserializer(deserializer *schemaregistry.Deserializer) {
+	c.consumer.UseDeserializer(deserializer)
+}
 
-	go func() {
-		defer wg.Done()
-		for {
-			// check if we need to stop
-			c.mu.Lock()
-			if c.cancelled {
-				c.mu.Unlock()
-				return
-			}
-			c.mu.Unlock()
-
-			// consume events
-			if err := c.consumer.Consume(ctx, c.topics, handler); err != nil {
-				if errors.Is(err, sarama.ErrClosedConsumerGroup) {
-					return
-				}
-				log.Printf("Error from consumer: %v", err)
-			}
-
-			if ctx.Err() != nil {
-				return
-			}
-		}
-	}()
+// RegisterUpcasters registers the chain of upcasters of an event type, the handlers expect
+// the version after the last one. upcasters[i] converts data of version i+1 to version i+2.
+func (c *EventConsumer) RegisterUpcasters(eventType EventType, upcasters ...Upcaster) {
+	c.consumer.RegisterUpcasters(string(eventType), upcasters...)
+}
 
-	<-handler.ready
+// Start beging consuming events from Kafka
+func (c *EventConsumer) Start(ctx context.Context) error {
 	log.Println("Consumer up and running")
-
-	select {
-	case <-ctx.Done():
-		log.Println("Context cancelled, stopping consumer")
-		c.Stop()
-	}
-
-	wg.Wait()
-	return nil
+	return c.consumer.Run(ctx)
 }
 
 // Stop stops the consumer
 func (c *EventConsumer) Stop() {
-	c.mu.Lock()
-	c.cancelled = true
-	c.mu.Unlock()
 	c.consumer.Close()
 }
 
-// consumerGroupHandler implements sarama.ConsumerGroupHandler
-type consumerGroupHandler struct {
-	consumer *EventConsumer
-	ready    chan bool
-}
-
-// Setup is run at the beginning of a new session
-func (h *consumerGroupHandler) Setup(session sarama.ConsumerGroupSession) error {
-	close(h.ready)
-	return nil
-}
-
-// Cleanup is run at the end of a session, either successfully or unsuccessfully
-func (h *consumerGroupHandler) Cleanup(session sarama.ConsumerGroupSession) error {
-	return nil
-}
-
-// ConsumeClaim handles the consumption of messages
-func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	for msg := range claim.Messages() {
-		event := Event{}
-		err := json.Unmarshal(msg.Value, &event)
-		if err != nil {
-			log.Printf("Error unmarshalling event: %v", err)
-			session.MarkMessage(msg, "")
-			continue
-		}
-
-		log.Printf("Received event: %s, Type: %s", event.ID, event.Type)
-
-		if handler, ok := h.consumer.handler[event.Type]; ok {
-			if err = handler.Handle(session.Context(), event); err != nil {
-				log.Printf("Error handling event: %v", err)
-			}
-		} else {
-			log.Printf("No handler registered for event type: %s", event.Type)
-		}
-
-		// mark the message as processed
-		session.MarkMessage(msg, "")
-	}
-
-	return nil
-}
-
 func main() {
 	// Configuration
 	brokers := []string{"localhost:29092"}
@@ -327,30 +227,24 @@ func main() {
 		log.Fatalf("Failed to create consumer: %v", err)
 	}
 
+	// Decode payloads of producers using a schema registry
+	if url := os.Getenv("SCHEMA_REGISTRY_URL"); url != "" {
+		consumer.UseDeserializer(schemaregistry.NewDeserializer(schemaregistry.NewClient(schemaregistry.ClientConfig{URL: url})))
+	}
+
+	// Handle every event the same way
+	consumer.Use(kafkaconsumer.Recover(), kafkaconsumer.Logging(), kafkaconsumer.Timeout(30*time.Second))
+
 	// Register handlers
-	consumer.RegisterHandler(UserCreated, EventHandlerFunc(func(ctx context.Context, event Event) error {
-		var userData UserCreatedEvent
-		if data, err := json.Marshal(event.Data); err == nil {
-			if err := json.Unmarshal(data, &userData); err != nil {
-				return fmt.Errorf("error unmarshaling user data: %w", err)
-			}
-			log.Printf("User created: %s (%s %s)", userData.UserID, userData.FirstName, userData.LastName)
-			return nil
-		}
-		return fmt.Errorf("error processing user data")
+	consumer.RegisterHandler(UserCreated, kafkaconsumer.Typed(func(ctx context.Context, event Event, userData UserCreatedEvent) error {
+		log.Printf("User created: %s (%s %s)", userData.UserID, userData.FirstName, userData.LastName)
+		return nil
 	}))
 
-	consumer.RegisterHandler(OrderPlaced, EventHandlerFunc(func(ctx context.Context, event Event) error {
-		var orderData OrderPlacedEvent
-		if data, err := json.Marshal(event.Data); err == nil {
-			if err := json.Unmarshal(data, &orderData); err != nil {
-				return fmt.Errorf("error unmarshaling order data: %w", err)
-			}
-			log.Printf("Order placed: %s for user %s with total amount %.2f",
-				orderData.OrderID, orderData.UserID, orderData.TotalAmount)
-			return nil
-		}
-		return fmt.Errorf("error processing order data")
+	consumer.RegisterHandler(OrderPlaced, kafkaconsumer.Typed(func(ctx context.Context, event Event, orderData OrderPlacedEvent) error {
+		log.Printf("Order placed: %s for user %s with total amount %.2f",
+			orderData.OrderID, orderData.UserID, orderData.TotalAmount)
+		return nil
 	}))
 
 	// Start consumer in a goroutine
@@ -1,9 +1,9 @@
 package main
 
 import (
+	"cloudevents"
 	"context"
 	"testing"
-	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/IBM/sarama/mocks"
@@ -15,7 +15,22 @@ import (
 func TestEventProducer_Emit(t *testing.T) {
 	// Create a mock Kafka producer
 	mockProducer := mocks.NewSyncProducer(t, nil)
-	mockProducer.ExpectSendMessageAndSucceed()
+	mockProducer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		// The message carries a CloudEvent in binary mode
+		value, _ := msg.Value.Encode()
+		received := &sarama.ConsumerMessage{Value: value}
+		for i := range msg.Headers {
+			received.Headers = append(received.Headers, &msg.Headers[i])
+		}
+
+		event, err := cloudevents.ReadMessage(received)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, string(UserCreated), event.Type)
+		assert.Equal(t, "test-source", event.Source)
+		return nil
+	})
 
 	producer := &EventProducer{
 		producer: mockProducer,
@@ -80,11 +95,7 @@ func (m *MockEventHandler) Handle(ctx context.Context, event Event) error {
 // TestEventConsumer tests the Start method of EventConsumer
 func TestEventConsumer(t *testing.T) {
 	mockHandler := new(MockEventHandler)
-	consumer := &EventConsumer{
-		handler: make(map[EventType]EventHandler),
-	}
-
-	consumer.RegisterHandler(UserCreated, mockHandler)
+	var handler EventHandler = mockHandler
 
 	userData := UserCreatedEvent{
 		UserID:    uuid.New().String(),
@@ -93,18 +104,12 @@ func TestEventConsumer(t *testing.T) {
 		LastName:  "Doe",
 	}
 
-	event := Event{
-		ID:          uuid.New().String(),
-		Type:        UserCreated,
-		Source:      "test-source",
-		Timestamp:   time.Now(),
-		Data:        userData,
-		SpecVersion: "1.0.0",
-	}
+	event, err := cloudevents.New("test-source", string(UserCreated), userData)
+	assert.NoError(t, err)
 
 	mockHandler.On("Handle", mock.Anything, event).Return(nil)
 
-	err := mockHandler.Handle(context.Background(), event)
+	err = handler.Handle(context.Background(), event)
 	assert.NoError(t, err, "Expected no error when handling event")
 	mockHandler.AssertExpectations(t)
-}
\ No newline at end of file
+}
@@ -0,0 +1,2 @@
+ALTER TABLE products DROP COLUMN IF EXISTS currency;
+ALTER TABLE products ALTER COLUMN price TYPE DECIMAL(10, 2);
@@ -0,0 +1,3 @@
+-- Store prices with enough precision for every currency and record the currency next to each price
+ALTER TABLE products ALTER COLUMN price TYPE NUMERIC(19, 4);
+ALTER TABLE products ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
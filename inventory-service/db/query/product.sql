@@ -1,7 +1,7 @@
 -- name: CreateProduct :one
 INSERT INTO products (
-    id, sku, name, description, category, price, created_at, updated_at
+    id, sku, name, description, category, price, currency, created_at, updated_at
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
 )
 RETURNING *;
\ No newline at end of file
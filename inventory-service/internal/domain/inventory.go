@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"money"
 	"time"
 
 	"github.com/google/uuid"
@@ -24,7 +25,7 @@ type Product struct {
 	Name        string
 	Description string
 	Category    string
-	Price       float64
+	Price       money.Money
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
@@ -47,4 +47,5 @@ type Product struct {
 	Price       string         `json:"price"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
+	Currency    string         `json:"currency"`
 }
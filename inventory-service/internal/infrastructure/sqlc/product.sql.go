@@ -15,11 +15,11 @@ import (
 
 const createProduct = `-- name: CreateProduct :one
 INSERT INTO products (
-    id, sku, name, description, category, price, created_at, updated_at
+    id, sku, name, description, category, price, currency, created_at, updated_at
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
 )
-RETURNING id, sku, name, description, category, price, created_at, updated_at
+RETURNING id, sku, name, description, category, price, created_at, updated_at, currency
 `
 
 type CreateProductParams struct {
@@ -29,6 +29,7 @@ type CreateProductParams struct {
 	Description sql.NullString `json:"description"`
 	Category    string         `json:"category"`
 	Price       string         `json:"price"`
+	Currency    string         `json:"currency"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 }
@@ -41,6 +42,7 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 		arg.Description,
 		arg.Category,
 		arg.Price,
+		arg.Currency,
 		arg.CreatedAt,
 		arg.UpdatedAt,
 	)
@@ -54,6 +56,7 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 		&i.Price,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Currency,
 	)
 	return i, err
 }
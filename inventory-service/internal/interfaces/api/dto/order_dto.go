@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"money"
 	"order-service/internal/domain"
 	"time"
 
@@ -19,7 +20,7 @@ type CreateOrderRequest struct {
 type CreateOrderItemRequest struct {
 	ProductID string `json:"product_id"`
 	Quantity int `json:"quantity"`
-	Price float64 `json:"price"`
+	Price money.Money `json:"price"`
 }
 
 // UpdateOrderStatusRequest represents the request to update an order's status
@@ -34,7 +35,7 @@ type OrderResponse struct {
 	ID uuid.UUID
 	CustomerID string
 	Status string
-	TotalPrice float64
+	TotalPrice money.Money
 	Items []OrderItemResponse
 	CreatedAt time.Time
 	UpdatedAt time.Time
@@ -45,7 +46,7 @@ type OrderItemResponse struct {
 	ID uuid.UUID
 	ProductID string
 	Quantity int
-	Price float64
+	Price money.Money
 }
 
 
@@ -0,0 +1,249 @@
+package kafkaconsumer
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// groupHandler implements sarama.ConsumerGroupHandler. Handlers run with the context of Run
+// rather than the session, so a rebalance lets them finish the events they started.
+type groupHandler struct {
+	consumer *Consumer
+	ctx      context.Context
+}
+
+// Setup is run at the beginning of a new session, once the partitions are assigned
+func (h *groupHandler) Setup(session sarama.ConsumerGroupSession) error {
+	log.Printf("Consumer %s assigned partitions %v", session.MemberID(), session.Claims())
+	return nil
+}
+
+// Cleanup is run at the end of a session, once the partitions finished their events
+func (h *groupHandler) Cleanup(session sarama.ConsumerGroupSession) error {
+	log.Printf("Consumer %s released partitions %v", session.MemberID(), session.Claims())
+	return nil
+}
+
+// ConsumeClaim hands the messages of a partition to its workers and commits the offsets of the
+// handled events. When the partition is revoked the workers finish the events they started
+// and leave the others to the next owner of the partition.
+func (h *groupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	p := newPartition(h.ctx, h.consumer, session, claim.Topic(), claim.Partition())
+	defer p.stop()
+
+	ticker := time.NewTicker(h.consumer.config.CommitInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			if !p.dispatch(msg, ticker.C) {
+				return nil
+			}
+		case <-ticker.C:
+			p.commit()
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
+
+// partition is the worker pool handling the events of one claimed partition
+type partition struct {
+	ctx      context.Context
+	consumer *Consumer
+	session  sarama.ConsumerGroupSession
+	topic    string
+	id       int32
+
+	offsets *offsetTracker
+	queues  []chan *sarama.ConsumerMessage
+	wg      sync.WaitGroup
+}
+
+func newPartition(ctx context.Context, consumer *Consumer, session sarama.ConsumerGroupSession, topic string, id int32) *partition {
+	p := &partition{
+		ctx:      ctx,
+		consumer: consumer,
+		session:  session,
+		topic:    topic,
+		id:       id,
+		offsets:  newOffsetTracker(),
+		queues:   make([]chan *sarama.ConsumerMessage, consumer.config.Workers),
+	}
+	for i := range p.queues {
+		p.queues[i] = make(chan *sarama.ConsumerMessage, 1)
+		p.wg.Add(1)
+		go p.work(p.queues[i])
+	}
+	return p
+}
+
+// dispatch queues the message at the worker of its key. While the worker is busy the events
+// the other workers handled are still committed on every tick. It reports false when the
+// session ended while the worker was busy.
+func (p *partition) dispatch(msg *sarama.ConsumerMessage, ticks <-chan time.Time) bool {
+	p.offsets.add(msg.Offset)
+
+	queue := p.queues[p.worker(msg)]
+	for {
+		select {
+		case queue <- msg:
+			return true
+		case <-ticks:
+			p.commit()
+		case <-p.session.Context().Done():
+			return false
+		}
+	}
+}
+
+// worker selects the worker of a message, messages without a key are spread by offset
+func (p *partition) worker(msg *sarama.ConsumerMessage) int {
+	if len(p.queues) == 1 {
+		return 0
+	}
+	if len(msg.Key) == 0 {
+		return int(msg.Offset % int64(len(p.queues)))
+	}
+	hash := fnv.New32a()
+	hash.Write(msg.Key)
+	return int(hash.Sum32() % uint32(len(p.queues)))
+}
+
+func (p *partition) work(queue <-chan *sarama.ConsumerMessage) {
+	defer p.wg.Done()
+	for msg := range queue {
+		// Events not started before the partition was revoked are left to its next owner
+		if p.session.Context().Err() != nil {
+			continue
+		}
+		if p.consumer.process(p.ctx, p.session.Context(), msg) {
+			p.offsets.done(msg.Offset)
+		}
+	}
+}
+
+// commit marks and commits the offset after the events handled in order so far
+func (p *partition) commit() {
+	if next, ok := p.offsets.advance(); ok {
+		p.session.MarkOffset(p.topic, p.id, next, "")
+		p.session.Commit()
+	}
+}
+
+// stop waits for the workers to finish their events and commits them
+func (p *partition) stop() {
+	for _, queue := range p.queues {
+		close(queue)
+	}
+	p.wg.Wait()
+	p.commit()
+}
+
+// process decodes and handles one message. It reports whether the message is settled, which
+// messages without a handler and poison messages without a dead letter topic are as well.
+// Unless failed events are forwarded to retry topics, a failing handler is retried with a
+// growing delay up to MaxAttempts times, after which the event is skipped.
+// The retries end early when the session ends.
+func (c *Consumer) process(ctx, sessionCtx context.Context, msg *sarama.ConsumerMessage) bool {
+	if !due(sessionCtx, msg) {
+		return false
+	}
+
+	event, err := c.decode(ctx, msg)
+	if err != nil {
+		log.Printf("Error decoding event at %s/%d/%d: %v", msg.Topic, msg.Partition, msg.Offset, err)
+		return c.poison(sessionCtx, msg, err)
+	}
+
+	handler, ok := c.handler(event.Type)
+	if !ok {
+		log.Printf("No handler registered for event type: %s", event.Type)
+		return true
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := handler.Handle(ctx, event)
+		if err == nil {
+			return true
+		}
+		if errors.Is(err, ErrUndecodable) {
+			log.Printf("Error decoding event %s: %v", event.ID, err)
+			return c.poison(sessionCtx, msg, err)
+		}
+		if c.producer != nil {
+			return c.fail(sessionCtx, msg, event, err)
+		}
+		if attempt >= c.config.MaxAttempts {
+			log.Printf("Skipping event %s after %d failed attempts: %v", event.ID, attempt, err)
+			return true
+		}
+
+		delay := c.backoff(attempt)
+		log.Printf("Error handling event %s (attempt %d), retrying in %s: %v", event.ID, attempt, delay, err)
+		if !sleep(sessionCtx, delay) {
+			return false
+		}
+	}
+}
+
+// backoff returns the delay before the next attempt, doubling from the initial backoff
+func (c *Consumer) backoff(attempt int) time.Duration {
+	delay := c.config.RetryInitialBackoff
+	for i := 1; i < attempt && delay < c.config.RetryMaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, c.config.RetryMaxBackoff)
+}
+
+// offsetTracker finds the offset up to which the events of a partition were handled.
+// Workers finish out of order and offsets may have gaps, so it keeps the dispatched
+// offsets in order and the ones handled since.
+type offsetTracker struct {
+	mu         sync.Mutex
+	dispatched []int64
+	handled    map[int64]bool
+	// next is the offset after the last event handled in order, -1 before the first
+	next int64
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{handled: make(map[int64]bool), next: -1}
+}
+
+func (t *offsetTracker) add(offset int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.dispatched = append(t.dispatched, offset)
+}
+
+func (t *offsetTracker) done(offset int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.handled[offset] = true
+}
+
+// advance returns the offset to commit and whether it moved since the last call
+func (t *offsetTracker) advance() (int64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	moved := false
+	for len(t.dispatched) > 0 && t.handled[t.dispatched[0]] {
+		delete(t.handled, t.dispatched[0])
+		t.next = t.dispatched[0] + 1
+		t.dispatched = t.dispatched[1:]
+		moved = true
+	}
+	return t.next, moved
+}
@@ -0,0 +1,280 @@
+package kafkaconsumer
+
+import (
+	"cloudevents"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSession records the offsets marked and committed in a consumer group session
+type fakeSession struct {
+	ctx     context.Context
+	mu      sync.Mutex
+	marked  map[int32]int64
+	commits int
+}
+
+func newFakeSession(ctx context.Context) *fakeSession {
+	return &fakeSession{ctx: ctx, marked: make(map[int32]int64)}
+}
+
+func (s *fakeSession) Claims() map[string][]int32 { return nil }
+func (s *fakeSession) MemberID() string           { return "member-1" }
+func (s *fakeSession) GenerationID() int32        { return 1 }
+func (s *fakeSession) Context() context.Context   { return s.ctx }
+
+func (s *fakeSession) MarkOffset(topic string, partition int32, offset int64, metadata string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.marked[partition] = offset
+}
+
+func (s *fakeSession) ResetOffset(topic string, partition int32, offset int64, metadata string) {}
+
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.MarkOffset(msg.Topic, msg.Partition, msg.Offset+1, metadata)
+}
+
+func (s *fakeSession) Commit() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commits++
+}
+
+func (s *fakeSession) committed(partition int32) (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	offset, ok := s.marked[partition]
+	return offset, ok && s.commits > 0
+}
+
+type fakeClaim struct {
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c *fakeClaim) Topic() string                            { return "orders" }
+func (c *fakeClaim) Partition() int32                         { return 0 }
+func (c *fakeClaim) InitialOffset() int64                     { return 0 }
+func (c *fakeClaim) HighWaterMarkOffset() int64               { return 0 }
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+// eventMessage encodes an event of the type as a binary mode message at the offset
+func eventMessage(t *testing.T, offset int64, key, eventType string, data interface{}) *sarama.ConsumerMessage {
+	t.Helper()
+
+	event, err := cloudevents.New("test-source", eventType, data)
+	require.NoError(t, err)
+	event.ID = fmt.Sprintf("event-%d", offset)
+
+	produced := &sarama.ProducerMessage{Topic: "orders"}
+	require.NoError(t, cloudevents.WriteMessage(produced, event, cloudevents.Binary))
+	value, _ := produced.Value.Encode()
+
+	msg := &sarama.ConsumerMessage{Topic: "orders", Offset: offset, Key: []byte(key), Value: value}
+	for i := range produced.Headers {
+		msg.Headers = append(msg.Headers, &produced.Headers[i])
+	}
+	return msg
+}
+
+func newTestConsumer(workers int) *Consumer {
+	return newConsumer(nil, Config{
+		Workers:             workers,
+		CommitInterval:      time.Hour,
+		RetryInitialBackoff: time.Millisecond,
+		RetryMaxBackoff:     time.Millisecond,
+	})
+}
+
+func TestConsumeClaimHandlesEventsInKeyOrder(t *testing.T) {
+	consumer := newTestConsumer(4)
+
+	var mu sync.Mutex
+	handled := make(map[string][]int)
+	failed := false
+	consumer.Register("order.updated", HandlerFunc(func(ctx context.Context, event Event) error {
+		var data struct {
+			OrderID string
+			Step    int
+		}
+		require.NoError(t, event.DataAs(&data))
+
+		mu.Lock()
+		defer mu.Unlock()
+		// The first attempt of one event fails and is retried in place
+		if data.OrderID == "order-2" && data.Step == 1 && !failed {
+			failed = true
+			return errors.New("database unavailable")
+		}
+		handled[data.OrderID] = append(handled[data.OrderID], data.Step)
+		return nil
+	}))
+
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 64)}
+	offset := int64(10)
+	for step := 0; step < 5; step++ {
+		for _, orderID := range []string{"order-1", "order-2", "order-3"} {
+			claim.messages <- eventMessage(t, offset, orderID, "order.updated", map[string]interface{}{"OrderID": orderID, "Step": step})
+			offset++
+		}
+	}
+	// Events without handler or that cannot be decoded are skipped
+	claim.messages <- eventMessage(t, offset, "order-1", "order.archived", nil)
+	claim.messages <- &sarama.ConsumerMessage{Topic: "orders", Offset: offset + 1, Value: []byte("not an event")}
+	close(claim.messages)
+
+	session := newFakeSession(context.Background())
+	handler := &groupHandler{consumer: consumer, ctx: context.Background()}
+	require.NoError(t, handler.ConsumeClaim(session, claim))
+
+	for _, orderID := range []string{"order-1", "order-2", "order-3"} {
+		assert.Equal(t, []int{0, 1, 2, 3, 4}, handled[orderID], orderID)
+	}
+	committed, ok := session.committed(0)
+	assert.True(t, ok)
+	assert.Equal(t, offset+2, committed)
+}
+
+func TestConsumeClaimLeavesFailingEventsOnRebalance(t *testing.T) {
+	consumer := newTestConsumer(2)
+	// Keep retrying the failing event until the partition is revoked
+	consumer.config.MaxAttempts = 1 << 20
+
+	handled := make(chan struct{})
+	failing := make(chan struct{}, 1)
+	consumer.Register("order.updated", HandlerFunc(func(ctx context.Context, event Event) error {
+		switch event.ID {
+		case "event-0":
+			close(handled)
+		case "event-1":
+			select {
+			case failing <- struct{}{}:
+			default:
+			}
+			return errors.New("database unavailable")
+		}
+		return nil
+	}))
+
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 8)}
+	claim.messages <- eventMessage(t, 0, "a", "order.updated", nil)
+	claim.messages <- eventMessage(t, 1, "b", "order.updated", nil)
+	claim.messages <- eventMessage(t, 2, "a", "order.updated", nil)
+
+	ctx, revoke := context.WithCancel(context.Background())
+	session := newFakeSession(ctx)
+	handler := &groupHandler{consumer: consumer, ctx: context.Background()}
+
+	done := make(chan error)
+	go func() { done <- handler.ConsumeClaim(session, claim) }()
+
+	<-handled
+	<-failing
+	revoke()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the claim did not end after the partition was revoked")
+	}
+
+	// The failing event and the events after it are left to the next owner of the partition
+	committed, ok := session.committed(0)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), committed)
+}
+
+func TestConsumeClaimCommitsWhileWorkerIsBusy(t *testing.T) {
+	consumer := newTestConsumer(1)
+	consumer.config.CommitInterval = time.Millisecond
+
+	release := make(chan struct{})
+	consumer.Register("order.updated", HandlerFunc(func(ctx context.Context, event Event) error {
+		if event.ID == "event-1" {
+			<-release
+		}
+		return nil
+	}))
+
+	// The worker blocks on the second event with the third queued, so the fourth waits in dispatch
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 8)}
+	for offset := int64(0); offset < 4; offset++ {
+		claim.messages <- eventMessage(t, offset, "a", "order.updated", nil)
+	}
+
+	session := newFakeSession(context.Background())
+	handler := &groupHandler{consumer: consumer, ctx: context.Background()}
+	done := make(chan error)
+	go func() { done <- handler.ConsumeClaim(session, claim) }()
+
+	assert.Eventually(t, func() bool {
+		committed, ok := session.committed(0)
+		return ok && committed == 1
+	}, 5*time.Second, time.Millisecond)
+
+	close(release)
+	close(claim.messages)
+	require.NoError(t, <-done)
+	committed, _ := session.committed(0)
+	assert.Equal(t, int64(4), committed)
+}
+
+func TestProcessSkipsEventAfterMaxAttempts(t *testing.T) {
+	consumer := newTestConsumer(1)
+	consumer.config.MaxAttempts = 3
+
+	attempts := 0
+	consumer.Register("order.updated", HandlerFunc(func(ctx context.Context, event Event) error {
+		attempts++
+		return errors.New("database unavailable")
+	}))
+
+	// Without a dead letter topic the event is given up on instead of blocking its partition
+	settled := consumer.process(context.Background(), context.Background(), eventMessage(t, 0, "a", "order.updated", nil))
+	assert.True(t, settled)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestOffsetTracker(t *testing.T) {
+	tracker := newOffsetTracker()
+
+	_, moved := tracker.advance()
+	assert.False(t, moved)
+
+	// Offsets may have gaps, e.g. where transaction markers were
+	for _, offset := range []int64{3, 4, 6, 7} {
+		tracker.add(offset)
+	}
+	tracker.done(4)
+	_, moved = tracker.advance()
+	assert.False(t, moved, "offset 3 is still being handled")
+
+	tracker.done(3)
+	tracker.done(7)
+	next, moved := tracker.advance()
+	assert.True(t, moved)
+	assert.Equal(t, int64(5), next)
+
+	tracker.done(6)
+	next, moved = tracker.advance()
+	assert.True(t, moved)
+	assert.Equal(t, int64(8), next)
+}
+
+func TestBackoff(t *testing.T) {
+	consumer := newConsumer(nil, Config{RetryInitialBackoff: time.Second, RetryMaxBackoff: 5 * time.Second})
+
+	assert.Equal(t, time.Second, consumer.backoff(1))
+	assert.Equal(t, 2*time.Second, consumer.backoff(2))
+	assert.Equal(t, 4*time.Second, consumer.backoff(3))
+	assert.Equal(t, 5*time.Second, consumer.backoff(4))
+	assert.Equal(t, 5*time.Second, consumer.backoff(40))
+}
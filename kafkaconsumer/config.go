@@ -0,0 +1,171 @@
+package kafkaconsumer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Config configures a consumer group member. Zero values keep the defaults of sarama.
+type Config struct {
+	Brokers []string
+	GroupID string
+	Topics  []string
+	// AutoOffsetReset is where a group without committed offsets starts, "earliest" or "latest"
+	AutoOffsetReset   string
+	HeartbeatInterval time.Duration
+	SessionTimeout    time.Duration
+	MaxWaitTime       time.Duration
+	MinFetchBytes     int
+	MaxFetchBytes     int
+
+	// Workers is the number of events of one partition handled at the same time, 1 when zero.
+	// Events with the same key are handled by the same worker, so they keep their order.
+	Workers int
+	// CommitInterval is how often the offsets of handled events are committed, every second when zero
+	CommitInterval time.Duration
+	// RetryInitialBackoff and RetryMaxBackoff bound the exponential delay between the attempts
+	// to handle a failing event, 100ms and 30s when zero
+	RetryInitialBackoff time.Duration
+	RetryMaxBackoff     time.Duration
+
+	// DeadLetterTopic receives the events that cannot be decoded and the events whose handler
+	// failed MaxAttempts times. Without it a failing event is retried in place up to MaxAttempts
+	// times and then skipped.
+	DeadLetterTopic string
+	// RetryDelays are the tiers of retry topics, named <GroupID>.retry-<delay>, a failed event
+	// is handled again from after its delay. The tier of the last delay is used for the attempts
+	// after it. Without delays a failed event goes to the dead letter topic at once.
+	RetryDelays []time.Duration
+	// MaxAttempts is the number of times an event is handled before it is dead lettered,
+	// one more than the number of retry delays when zero. Without a dead letter topic it is
+	// the number of attempts in place before the event is skipped, 10 when zero.
+	MaxAttempts int
+
+	// Sarama is the client configuration the consumer settings are applied to,
+	// e.g. with the client ID and security settings. A default configuration when nil.
+	Sarama *sarama.Config
+}
+
+// Defaults applied to zero values of Config
+const (
+	defaultCommitInterval      = time.Second
+	defaultRetryInitialBackoff = 100 * time.Millisecond
+	defaultRetryMaxBackoff     = 30 * time.Second
+	defaultInPlaceAttempts     = 10
+)
+
+// withDefaults returns the configuration with the defaults applied
+func (c Config) withDefaults() Config {
+	if c.Workers < 1 {
+		c.Workers = 1
+	}
+	if c.CommitInterval <= 0 {
+		c.CommitInterval = defaultCommitInterval
+	}
+	if c.RetryInitialBackoff <= 0 {
+		c.RetryInitialBackoff = defaultRetryInitialBackoff
+	}
+	if c.RetryMaxBackoff <= 0 {
+		c.RetryMaxBackoff = defaultRetryMaxBackoff
+	}
+	if c.MaxAttempts < 1 {
+		c.MaxAttempts = len(c.RetryDelays) + 1
+		if c.DeadLetterTopic == "" {
+			c.MaxAttempts = defaultInPlaceAttempts
+		}
+	}
+	return c
+}
+
+// retryTopic returns the name of the retry topic of a delay, e.g. order-service.retry-5s
+func (c Config) retryTopic(delay time.Duration) string {
+	var name string
+	switch {
+	case delay%time.Hour == 0:
+		name = fmt.Sprintf("%dh", delay/time.Hour)
+	case delay%time.Minute == 0:
+		name = fmt.Sprintf("%dm", delay/time.Minute)
+	case delay%time.Second == 0:
+		name = fmt.Sprintf("%ds", delay/time.Second)
+	default:
+		name = fmt.Sprintf("%dms", delay/time.Millisecond)
+	}
+	return c.GroupID + ".retry-" + name
+}
+
+// consumedTopics returns the topics of the events and the retry topics
+func (c Config) consumedTopics() []string {
+	topics := append([]string(nil), c.Topics...)
+	if c.DeadLetterTopic == "" {
+		return topics
+	}
+	for _, delay := range c.RetryDelays {
+		topics = append(topics, c.retryTopic(delay))
+	}
+	return topics
+}
+
+// saramaConfig builds the client configuration. Offsets are only committed by the consumer
+// once their events were handled, and the sticky strategy keeps partitions with their member
+// across rebalances where it can.
+func (c Config) saramaConfig() (*sarama.Config, error) {
+	saramaConfig := sarama.NewConfig()
+	if c.Sarama != nil {
+		copied := *c.Sarama
+		saramaConfig = &copied
+	}
+
+	saramaConfig.Consumer.Return.Errors = true
+	saramaConfig.Consumer.Offsets.AutoCommit.Enable = false
+	saramaConfig.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategySticky()}
+
+	switch c.AutoOffsetReset {
+	case "earliest":
+		saramaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+	case "latest", "":
+		saramaConfig.Consumer.Offsets.Initial = sarama.OffsetNewest
+	default:
+		return nil, fmt.Errorf("unknown auto offset reset %q, expected earliest or latest", c.AutoOffsetReset)
+	}
+
+	if c.HeartbeatInterval > 0 {
+		saramaConfig.Consumer.Group.Heartbeat.Interval = c.HeartbeatInterval
+	}
+	if c.SessionTimeout > 0 {
+		saramaConfig.Consumer.Group.Session.Timeout = c.SessionTimeout
+	}
+	if c.MaxWaitTime > 0 {
+		saramaConfig.Consumer.MaxWaitTime = c.MaxWaitTime
+	}
+	if c.MinFetchBytes > 0 {
+		saramaConfig.Consumer.Fetch.Min = int32(c.MinFetchBytes)
+	}
+	if c.MaxFetchBytes > 0 {
+		saramaConfig.Consumer.Fetch.Max = int32(c.MaxFetchBytes)
+		if saramaConfig.Consumer.Fetch.Default > saramaConfig.Consumer.Fetch.Max {
+			saramaConfig.Consumer.Fetch.Default = saramaConfig.Consumer.Fetch.Max
+		}
+	}
+
+	if err := saramaConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid consumer configuration: %w", err)
+	}
+	return saramaConfig, nil
+}
+
+// producerConfig builds the client configuration of the producer forwarding failed events.
+// An event is only settled once all in-sync replicas have its forwarded copy.
+func (c Config) producerConfig() *sarama.Config {
+	producerConfig := sarama.NewConfig()
+	if c.Sarama != nil {
+		copied := *c.Sarama
+		producerConfig = &copied
+	}
+
+	producerConfig.Producer.RequiredAcks = sarama.WaitForAll
+	producerConfig.Producer.Return.Successes = true
+	producerConfig.Producer.Return.Errors = true
+	return producerConfig
+}
@@ -0,0 +1,76 @@
+package kafkaconsumer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaramaConfig(t *testing.T) {
+	base := sarama.NewConfig()
+	base.ClientID = "order-service"
+
+	saramaConfig, err := Config{
+		AutoOffsetReset:   "earliest",
+		HeartbeatInterval: 2 * time.Second,
+		SessionTimeout:    20 * time.Second,
+		MaxWaitTime:       time.Second,
+		MinFetchBytes:     1,
+		MaxFetchBytes:     1024,
+		Sarama:            base,
+	}.saramaConfig()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "order-service", saramaConfig.ClientID)
+	assert.Equal(t, sarama.OffsetOldest, saramaConfig.Consumer.Offsets.Initial)
+	assert.False(t, saramaConfig.Consumer.Offsets.AutoCommit.Enable, "offsets are committed once handled")
+	assert.True(t, saramaConfig.Consumer.Return.Errors)
+	assert.Equal(t, 2*time.Second, saramaConfig.Consumer.Group.Heartbeat.Interval)
+	assert.Equal(t, 20*time.Second, saramaConfig.Consumer.Group.Session.Timeout)
+	assert.Equal(t, int32(1024), saramaConfig.Consumer.Fetch.Max)
+	assert.Equal(t, int32(1024), saramaConfig.Consumer.Fetch.Default)
+	// The base configuration is left as it was
+	assert.True(t, base.Consumer.Offsets.AutoCommit.Enable)
+
+	_, err = Config{AutoOffsetReset: "beginning"}.saramaConfig()
+	assert.Error(t, err)
+}
+
+func TestConfigDefaults(t *testing.T) {
+	cfg := Config{}.withDefaults()
+
+	assert.Equal(t, 1, cfg.Workers)
+	assert.Equal(t, time.Second, cfg.CommitInterval)
+	assert.Equal(t, 100*time.Millisecond, cfg.RetryInitialBackoff)
+	assert.Equal(t, 30*time.Second, cfg.RetryMaxBackoff)
+}
+
+func TestRetryTopics(t *testing.T) {
+	cfg := Config{
+		GroupID:         "order-service",
+		Topics:          []string{"payments"},
+		DeadLetterTopic: "order-service.dlq",
+		RetryDelays:     []time.Duration{5 * time.Second, time.Minute, 90 * time.Second, 2 * time.Hour, 250 * time.Millisecond},
+	}
+
+	assert.Equal(t, []string{
+		"payments",
+		"order-service.retry-5s",
+		"order-service.retry-1m",
+		"order-service.retry-90s",
+		"order-service.retry-2h",
+		"order-service.retry-250ms",
+	}, cfg.consumedTopics())
+	assert.Equal(t, 6, cfg.withDefaults().MaxAttempts)
+
+	consumer := newConsumer(nil, cfg)
+	assert.Contains(t, consumer.Topics(), "order-service.dlq")
+
+	// Retry topics are only used with a dead letter topic
+	cfg.DeadLetterTopic = ""
+	assert.Equal(t, []string{"payments"}, cfg.consumedTopics())
+	// Failing events are retried in place a limited number of times instead
+	assert.Equal(t, 10, cfg.withDefaults().MaxAttempts)
+}
@@ -0,0 +1,170 @@
+// Package kafkaconsumer consumes CloudEvents from Kafka topics as a member of a consumer group
+// and hands them to the handler registered for their type.
+//
+// The events of a partition are handled by a pool of workers, events with the same key by
+// the same worker in order. An offset is committed once its event and all events before it
+// in the partition were handled, so events are handled at least once.
+//
+// With a dead letter topic, failed events are forwarded to tiers of retry topics and handled
+// again once their delay passed, and events that cannot be decoded are dead lettered at once.
+package kafkaconsumer
+
+import (
+	"cloudevents"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"schemaregistry"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+// Event is a consumed CloudEvent
+type Event = cloudevents.Event
+
+// Handler handles the events of one type
+type Handler interface {
+	Handle(ctx context.Context, event Event) error
+}
+
+// HandlerFunc is a function type that implements the Handler interface
+type HandlerFunc func(ctx context.Context, event Event) error
+
+// Handle implements Handler.
+func (f HandlerFunc) Handle(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// Consumer hands the events of its topics to the handlers registered for their types.
+// Handlers are registered before Run.
+type Consumer struct {
+	group  sarama.ConsumerGroup
+	config Config
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	// middlewares wrap every handler
+	middlewares []Middleware
+	// upcasters convert event data of older schema versions to the version handlers expect
+	upcasters map[string][]Upcaster
+	// deserializer decodes payloads serialized with a schema registry
+	deserializer *schemaregistry.Deserializer
+	// producer forwards failed events to the retry and dead letter topics
+	producer sarama.SyncProducer
+}
+
+// New joins the configured consumer group
+func New(cfg Config) (*Consumer, error) {
+	if cfg.GroupID == "" || len(cfg.Topics) == 0 {
+		return nil, errors.New("a consumer needs a group ID and topics")
+	}
+	saramaConfig, err := cfg.saramaConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var producer sarama.SyncProducer
+	if cfg.DeadLetterTopic != "" {
+		producer, err = sarama.NewSyncProducer(cfg.Brokers, cfg.producerConfig())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create dead letter producer: %w", err)
+		}
+	}
+
+	group, err := sarama.NewConsumerGroup(cfg.Brokers, cfg.GroupID, saramaConfig)
+	if err != nil {
+		if producer != nil {
+			producer.Close()
+		}
+		return nil, fmt.Errorf("failed to create consumer group: %w", err)
+	}
+
+	consumer := newConsumer(group, cfg)
+	consumer.producer = producer
+	return consumer, nil
+}
+
+// newConsumer creates a consumer of the group, whose errors have to be returned
+func newConsumer(group sarama.ConsumerGroup, cfg Config) *Consumer {
+	return &Consumer{
+		group:     group,
+		config:    cfg.withDefaults(),
+		handlers:  make(map[string]Handler),
+		upcasters: make(map[string][]Upcaster),
+	}
+}
+
+// Register registers the handler of an event type, replacing the one registered before.
+// Events of types without a handler are skipped.
+func (c *Consumer) Register(eventType string, handler Handler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers[eventType] = handler
+}
+
+// UseDeserializer decodes payloads serialized with their schemas in the schema registry,
+// so handlers read them as JSON like any other payload
+func (c *Consumer) UseDeserializer(deserializer *schemaregistry.Deserializer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deserializer = deserializer
+}
+
+// Topics returns the topics the consumer reads and writes, so they can be created before Run
+func (c *Consumer) Topics() []string {
+	topics := c.config.consumedTopics()
+	if c.config.DeadLetterTopic != "" {
+		topics = append(topics, c.config.DeadLetterTopic)
+	}
+	return topics
+}
+
+// handler returns the handler of an event type wrapped with the middlewares
+func (c *Consumer) handler(eventType string) (Handler, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	handler, ok := c.handlers[eventType]
+	if !ok {
+		return nil, false
+	}
+	return Chain(handler, c.middlewares...), true
+}
+
+// Run consumes events until the context is cancelled or the consumer is closed.
+// Partitions revoked by a rebalance finish the events their workers started and commit them.
+func (c *Consumer) Run(ctx context.Context) error {
+	go c.logErrors()
+
+	handler := &groupHandler{consumer: c, ctx: ctx}
+	topics := c.config.consumedTopics()
+	for {
+		if err := c.group.Consume(ctx, topics, handler); err != nil {
+			if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+				return nil
+			}
+			log.Printf("Error from consumer: %v", err)
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// logErrors logs the errors returned by the consumer group until it is closed
+func (c *Consumer) logErrors() {
+	for err := range c.group.Errors() {
+		log.Printf("Consumer group error: %v", err)
+	}
+}
+
+// Close leaves the consumer group
+func (c *Consumer) Close() error {
+	err := c.group.Close()
+	if c.producer != nil {
+		err = errors.Join(err, c.producer.Close())
+	}
+	return err
+}
@@ -0,0 +1,103 @@
+package kafkaconsumer
+
+import (
+	"bytes"
+	"cloudevents"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/IBM/sarama"
+)
+
+// ExtensionSchemaVersion is the extension attribute carrying the schema version of the event data
+const ExtensionSchemaVersion = "schemaversion"
+
+// ErrUnknownSchemaVersion is returned for event data of a version newer than the handlers know
+var ErrUnknownSchemaVersion = errors.New("unknown schema version")
+
+// Upcaster converts the JSON data of an event to the next schema version.
+// Numbers are json.Number, so they keep their precision.
+type Upcaster func(data map[string]interface{}) (map[string]interface{}, error)
+
+// RegisterUpcasters registers the chain of upcasters of an event type, the handlers expect
+// the version after the last one. upcasters[i] converts data of version i+1 to version i+2.
+func (c *Consumer) RegisterUpcasters(eventType string, upcasters ...Upcaster) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.upcasters[eventType] = upcasters
+}
+
+// decode reads the event of a message, with its data as JSON in the version handlers expect
+func (c *Consumer) decode(ctx context.Context, msg *sarama.ConsumerMessage) (Event, error) {
+	event, err := cloudevents.ReadMessage(msg)
+	if err != nil {
+		return Event{}, err
+	}
+	if err := c.decodeData(ctx, &event); err != nil {
+		return Event{}, err
+	}
+	if err := c.upcast(&event); err != nil {
+		return Event{}, err
+	}
+	return event, nil
+}
+
+// decodeData turns a payload serialized with a schema registry into JSON
+func (c *Consumer) decodeData(ctx context.Context, event *Event) error {
+	c.mu.RLock()
+	deserializer := c.deserializer
+	c.mu.RUnlock()
+	if deserializer == nil || !deserializer.Accepts(event.DataContentType) {
+		return nil
+	}
+
+	var data json.RawMessage
+	if err := deserializer.Deserialize(ctx, event.Data, &data); err != nil {
+		return fmt.Errorf("failed to deserialize data of %s event: %w", event.Type, err)
+	}
+	event.Data = data
+	event.DataContentType = cloudevents.ContentTypeJSON
+	return nil
+}
+
+// upcast converts the data of an event written with an older schema version to the current one.
+// Events without a version and events of types without upcasters are passed on as they are.
+func (c *Consumer) upcast(event *Event) error {
+	c.mu.RLock()
+	upcasters, ok := c.upcasters[event.Type]
+	c.mu.RUnlock()
+
+	attribute := event.Extension(ExtensionSchemaVersion)
+	if !ok || attribute == "" {
+		return nil
+	}
+	current := len(upcasters) + 1
+	version, err := strconv.Atoi(attribute)
+	if err != nil || version < 1 || version > current {
+		return fmt.Errorf("%w: %s version %s, current version is %d", ErrUnknownSchemaVersion, event.Type, attribute, current)
+	}
+	if version == current {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(event.Data))
+	decoder.UseNumber()
+	var data map[string]interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return fmt.Errorf("failed to decode data of %s event: %w", event.Type, err)
+	}
+	for _, upcaster := range upcasters[version-1:] {
+		if data, err = upcaster(data); err != nil {
+			return fmt.Errorf("failed to upcast %s event from version %d: %w", event.Type, version, err)
+		}
+	}
+
+	if err := event.SetData(data); err != nil {
+		return err
+	}
+	event.SetExtension(ExtensionSchemaVersion, strconv.Itoa(current))
+	return nil
+}
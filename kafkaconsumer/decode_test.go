@@ -0,0 +1,91 @@
+package kafkaconsumer
+
+import (
+	"cloudevents"
+	"context"
+	"encoding/json"
+	"os"
+	"schemaregistry"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeSchemaRegistryData(t *testing.T) {
+	type userDeleted struct {
+		UserID string `json:"user_id"`
+	}
+
+	ctx := context.Background()
+	registry := schemaregistry.NewMemoryRegistry(schemaregistry.CompatibilityBackward)
+	serializer, err := schemaregistry.NewSerializer(registry, schemaregistry.Avro, schemaregistry.TopicNameStrategy,
+		map[string]string{"user.deleted": `{"type": "record", "name": "UserDeleted", "fields": [{"name": "user_id", "type": "string"}]}`})
+	assert.NoError(t, err)
+
+	data, err := serializer.Serialize(ctx, "bussiness-events", "user.deleted", userDeleted{UserID: "user-1"})
+	assert.NoError(t, err)
+
+	event, err := cloudevents.New("test-source", "user.deleted", nil)
+	assert.NoError(t, err)
+	event.Data = data
+	event.DataContentType = serializer.ContentType()
+
+	consumer := newConsumer(nil, Config{})
+	consumer.UseDeserializer(schemaregistry.NewDeserializer(registry))
+	assert.NoError(t, consumer.decodeData(ctx, &event))
+
+	var user userDeleted
+	assert.NoError(t, event.DataAs(&user))
+	assert.Equal(t, "user-1", user.UserID)
+}
+
+func TestUpcastHistoricalData(t *testing.T) {
+	// order.item_removed events carried a float total before version 2
+	const itemRemoved = "order.item_removed"
+	type itemRemovedEvent struct {
+		TotalPrice struct {
+			Amount   string `json:"amount"`
+			Currency string `json:"currency"`
+		}
+	}
+
+	consumer := newConsumer(nil, Config{})
+	consumer.RegisterUpcasters(itemRemoved, func(data map[string]interface{}) (map[string]interface{}, error) {
+		total, err := strconv.ParseFloat(data["TotalPrice"].(json.Number).String(), 64)
+		if err != nil {
+			return nil, err
+		}
+		data["TotalPrice"] = map[string]interface{}{"amount": strconv.FormatFloat(total, 'f', 2, 64), "currency": "USD"}
+		return data, nil
+	})
+
+	fixture, err := os.ReadFile("testdata/order.item_removed.v1.json")
+	assert.NoError(t, err)
+
+	event := Event{ID: "1", Type: itemRemoved, DataContentType: cloudevents.ContentTypeJSON, Data: fixture}
+	event.SetExtension(ExtensionSchemaVersion, "1")
+	assert.NoError(t, consumer.upcast(&event))
+	assert.Equal(t, "2", event.Extension(ExtensionSchemaVersion))
+
+	var data itemRemovedEvent
+	assert.NoError(t, event.DataAs(&data))
+	assert.Equal(t, "56.48", data.TotalPrice.Amount)
+	assert.Equal(t, "USD", data.TotalPrice.Currency)
+
+	// Current data is passed on untouched
+	current := Event{ID: "2", Type: itemRemoved, Data: []byte(`{"TotalPrice":{"amount":"1.00","currency":"EUR"}}`)}
+	current.SetExtension(ExtensionSchemaVersion, "2")
+	assert.NoError(t, consumer.upcast(&current))
+	assert.JSONEq(t, `{"TotalPrice":{"amount":"1.00","currency":"EUR"}}`, string(current.Data))
+
+	// Data of a newer version than the handlers know is rejected
+	newer := Event{ID: "3", Type: itemRemoved, Data: []byte(`{}`)}
+	newer.SetExtension(ExtensionSchemaVersion, "3")
+	assert.ErrorIs(t, consumer.upcast(&newer), ErrUnknownSchemaVersion)
+
+	// Types without upcasters are not versioned by the consumer
+	other := Event{ID: "4", Type: "user.created", Data: []byte(`{}`)}
+	other.SetExtension(ExtensionSchemaVersion, "5")
+	assert.NoError(t, consumer.upcast(&other))
+}
@@ -0,0 +1,142 @@
+package kafkaconsumer
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+)
+
+// ErrUndecodable is returned for event data that cannot be decoded into the payload of its handler.
+// Handling the event again would fail all the same, so it is dead lettered as a poison message.
+var ErrUndecodable = errors.New("undecodable event data")
+
+// Middleware wraps a handler with behaviour applied to every event it handles
+type Middleware func(next Handler) Handler
+
+// Chain wraps the handler with the middlewares, the first middleware sees the event first
+func Chain(handler Handler, middlewares ...Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// Use wraps every handler of the consumer with the middlewares, after the ones used before
+func (c *Consumer) Use(middlewares ...Middleware) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.middlewares = append(c.middlewares, middlewares...)
+}
+
+// Typed returns a handler that decodes the data of events into a T for fn.
+// Data that cannot be decoded is an ErrUndecodable error.
+func Typed[T any](fn func(ctx context.Context, event Event, payload T) error) Handler {
+	return HandlerFunc(func(ctx context.Context, event Event) error {
+		var payload T
+		if err := event.DataAs(&payload); err != nil {
+			return fmt.Errorf("%w: %s event %s: %v", ErrUndecodable, event.Type, event.ID, err)
+		}
+		return fn(ctx, event, payload)
+	})
+}
+
+// Logging logs every event handled, with how long it took
+func Logging() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, event Event) error {
+			start := time.Now()
+			err := next.Handle(ctx, event)
+			if err != nil {
+				log.Printf("Failed to handle event %s of type %s after %s: %v", event.ID, event.Type, time.Since(start), err)
+				return err
+			}
+			log.Printf("Handled event %s of type %s in %s", event.ID, event.Type, time.Since(start))
+			return nil
+		})
+	}
+}
+
+// Recover turns a panicking handler into an error, so the event is retried like any other
+// failure instead of stopping the consumer
+func Recover() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, event Event) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("handler of %s event %s panicked: %v\n%s", event.Type, event.ID, r, debug.Stack())
+				}
+			}()
+			return next.Handle(ctx, event)
+		})
+	}
+}
+
+// Timeout cancels the context of a handler that takes longer than the timeout
+func Timeout(timeout time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, event Event) error {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return next.Handle(ctx, event)
+		})
+	}
+}
+
+// Tracing puts the tracing context carried by an event, e.g. in its traceparent or correlation
+// extensions, into the context of its handler with extract
+func Tracing(extract func(ctx context.Context, event Event) context.Context) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, event Event) error {
+			return next.Handle(extract(ctx, event), event)
+		})
+	}
+}
+
+// MetricsRecorder records the outcome of every event handled
+type MetricsRecorder interface {
+	ObserveEvent(eventType string, duration time.Duration, err error)
+}
+
+// Metrics records how long handling every event took and whether it failed
+func Metrics(recorder MetricsRecorder) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, event Event) error {
+			start := time.Now()
+			err := next.Handle(ctx, event)
+			recorder.ObserveEvent(event.Type, time.Since(start), err)
+			return err
+		})
+	}
+}
+
+// ExpvarMetrics records the events handled and failed, and the time spent on them,
+// per event type in expvar maps published under its name
+type ExpvarMetrics struct {
+	handled *expvar.Map
+	failed  *expvar.Map
+	seconds *expvar.Map
+}
+
+// NewExpvarMetrics publishes the maps <name>.handled, <name>.failed and <name>.seconds.
+// Names can only be published once per process.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	return &ExpvarMetrics{
+		handled: expvar.NewMap(name + ".handled"),
+		failed:  expvar.NewMap(name + ".failed"),
+		seconds: expvar.NewMap(name + ".seconds"),
+	}
+}
+
+// ObserveEvent implements MetricsRecorder.
+func (m *ExpvarMetrics) ObserveEvent(eventType string, duration time.Duration, err error) {
+	if err != nil {
+		m.failed.Add(eventType, 1)
+	} else {
+		m.handled.Add(eventType, 1)
+	}
+	m.seconds.AddFloat(eventType, duration.Seconds())
+}
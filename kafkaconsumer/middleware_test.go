@@ -0,0 +1,155 @@
+package kafkaconsumer
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMiddleware appends its name to the calls before and after the next handler
+func recordingMiddleware(name string, calls *[]string) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, event Event) error {
+			*calls = append(*calls, name)
+			err := next.Handle(ctx, event)
+			*calls = append(*calls, name+" done")
+			return err
+		})
+	}
+}
+
+type fakeRecorder struct {
+	eventTypes []string
+	errs       []error
+}
+
+func (r *fakeRecorder) ObserveEvent(eventType string, duration time.Duration, err error) {
+	r.eventTypes = append(r.eventTypes, eventType)
+	r.errs = append(r.errs, err)
+}
+
+func TestConsumerUsesMiddlewares(t *testing.T) {
+	var calls []string
+	consumer := newConsumer(nil, Config{})
+	consumer.Use(recordingMiddleware("first", &calls))
+	consumer.Use(recordingMiddleware("second", &calls))
+	consumer.Register("order.updated", HandlerFunc(func(ctx context.Context, event Event) error {
+		calls = append(calls, "handler")
+		return nil
+	}))
+
+	handler, ok := consumer.handler("order.updated")
+	require.True(t, ok)
+	assert.NoError(t, handler.Handle(context.Background(), Event{}))
+	assert.Equal(t, []string{"first", "second", "handler", "second done", "first done"}, calls)
+
+	_, ok = consumer.handler("order.archived")
+	assert.False(t, ok)
+}
+
+func TestTyped(t *testing.T) {
+	type paymentApproved struct {
+		OrderID string
+		Amount  string
+	}
+
+	var received paymentApproved
+	handler := Typed(func(ctx context.Context, event Event, payload paymentApproved) error {
+		received = payload
+		return nil
+	})
+
+	event := Event{ID: "event-1", Type: "payment.approved", Data: []byte(`{"OrderID":"order-1","Amount":"10.00"}`)}
+	assert.NoError(t, handler.Handle(context.Background(), event))
+	assert.Equal(t, paymentApproved{OrderID: "order-1", Amount: "10.00"}, received)
+
+	event.Data = []byte(`{"OrderID":42}`)
+	assert.ErrorIs(t, handler.Handle(context.Background(), event), ErrUndecodable)
+}
+
+func TestConsumeClaimDeadLettersUndecodablePayloads(t *testing.T) {
+	consumer, producer := newRetryingConsumer(t, 0)
+	consumer.Register("order.updated", Typed(func(ctx context.Context, event Event, payload struct{ Step int }) error {
+		return nil
+	}))
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		assert.Equal(t, "order-service.dlq", msg.Topic)
+		assert.Equal(t, ReasonPoison, producedHeader(msg, HeaderDeadLetterReason))
+		return nil
+	})
+
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 1)}
+	claim.messages <- eventMessage(t, 0, "order-1", "order.updated", map[string]interface{}{"Step": "first"})
+	close(claim.messages)
+
+	session := newFakeSession(context.Background())
+	handler := &groupHandler{consumer: consumer, ctx: context.Background()}
+	require.NoError(t, handler.ConsumeClaim(session, claim))
+	assert.NoError(t, producer.Close())
+}
+
+func TestRecover(t *testing.T) {
+	handler := Chain(HandlerFunc(func(ctx context.Context, event Event) error {
+		panic("nil map")
+	}), Recover())
+
+	err := handler.Handle(context.Background(), Event{ID: "event-1", Type: "order.updated"})
+	assert.ErrorContains(t, err, "panicked: nil map")
+}
+
+func TestTimeout(t *testing.T) {
+	handler := Chain(HandlerFunc(func(ctx context.Context, event Event) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}), Timeout(10*time.Millisecond))
+
+	assert.ErrorIs(t, handler.Handle(context.Background(), Event{}), context.DeadlineExceeded)
+}
+
+func TestTracing(t *testing.T) {
+	type traceKey struct{}
+	extract := func(ctx context.Context, event Event) context.Context {
+		return context.WithValue(ctx, traceKey{}, event.Extension("traceid"))
+	}
+
+	var traceID interface{}
+	handler := Chain(HandlerFunc(func(ctx context.Context, event Event) error {
+		traceID = ctx.Value(traceKey{})
+		return nil
+	}), Tracing(extract))
+
+	event := Event{}
+	event.SetExtension("traceid", "4bf92f3577b34da6a3ce929d0e0e4736")
+	assert.NoError(t, handler.Handle(context.Background(), event))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+}
+
+func TestMetrics(t *testing.T) {
+	failure := errors.New("database unavailable")
+	recorder := &fakeRecorder{}
+	handler := Chain(HandlerFunc(func(ctx context.Context, event Event) error {
+		if event.Type == "payment.declined" {
+			return failure
+		}
+		return nil
+	}), Metrics(recorder))
+
+	assert.NoError(t, handler.Handle(context.Background(), Event{Type: "payment.approved"}))
+	assert.ErrorIs(t, handler.Handle(context.Background(), Event{Type: "payment.declined"}), failure)
+	assert.Equal(t, []string{"payment.approved", "payment.declined"}, recorder.eventTypes)
+	assert.Equal(t, []error{nil, failure}, recorder.errs)
+
+	// Unpublished maps, names can only be published once per process
+	metrics := &ExpvarMetrics{handled: new(expvar.Map), failed: new(expvar.Map), seconds: new(expvar.Map)}
+	metrics.ObserveEvent("payment.approved", time.Second, nil)
+	metrics.ObserveEvent("payment.approved", time.Second, failure)
+	assert.Equal(t, "1", metrics.handled.Get("payment.approved").String())
+	assert.Equal(t, "1", metrics.failed.Get("payment.approved").String())
+	assert.Equal(t, "2", metrics.seconds.Get("payment.approved").String())
+}
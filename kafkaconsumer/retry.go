@@ -0,0 +1,150 @@
+package kafkaconsumer
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Headers added to the events forwarded to the retry and dead letter topics
+const (
+	// HeaderAttempts is the number of times handling the event failed
+	HeaderAttempts = "x-attempts"
+	// HeaderNotBefore is the time in Unix milliseconds at which a retry is due
+	HeaderNotBefore = "x-not-before"
+	// HeaderError is the error of the last attempt, or why the event could not be decoded
+	HeaderError = "x-error"
+	// HeaderDeadLetterReason is why the event was dead lettered, ReasonPoison or ReasonExhausted
+	HeaderDeadLetterReason = "x-dead-letter-reason"
+	// HeaderOriginalTopic, HeaderOriginalPartition and HeaderOriginalOffset locate the event
+	// where it was first consumed
+	HeaderOriginalTopic     = "x-original-topic"
+	HeaderOriginalPartition = "x-original-partition"
+	HeaderOriginalOffset    = "x-original-offset"
+)
+
+// Reasons for dead lettering an event
+const (
+	// ReasonPoison is for events that cannot be decoded, retrying them would fail all the same
+	ReasonPoison = "poison"
+	// ReasonExhausted is for events whose handler failed MaxAttempts times
+	ReasonExhausted = "attempts-exhausted"
+)
+
+// poison dead letters a message that cannot be decoded. Without a dead letter topic it is skipped.
+func (c *Consumer) poison(sessionCtx context.Context, msg *sarama.ConsumerMessage, err error) bool {
+	if c.producer == nil {
+		return true
+	}
+
+	log.Printf("Dead lettering undecodable message at %s/%d/%d: %v", msg.Topic, msg.Partition, msg.Offset, err)
+	return c.forward(sessionCtx, msg, c.config.DeadLetterTopic, map[string]string{
+		HeaderError:            err.Error(),
+		HeaderDeadLetterReason: ReasonPoison,
+	})
+}
+
+// fail forwards a message whose handler failed to the retry topic of its attempt,
+// or to the dead letter topic once the event was handled MaxAttempts times
+func (c *Consumer) fail(sessionCtx context.Context, msg *sarama.ConsumerMessage, event Event, err error) bool {
+	attempts := attemptsOf(msg) + 1
+	headers := map[string]string{
+		HeaderAttempts: strconv.Itoa(attempts),
+		HeaderError:    err.Error(),
+	}
+
+	if attempts >= c.config.MaxAttempts || len(c.config.RetryDelays) == 0 {
+		log.Printf("Dead lettering event %s after %d attempts: %v", event.ID, attempts, err)
+		headers[HeaderDeadLetterReason] = ReasonExhausted
+		return c.forward(sessionCtx, msg, c.config.DeadLetterTopic, headers)
+	}
+
+	delay := c.config.RetryDelays[min(attempts, len(c.config.RetryDelays))-1]
+	topic := c.config.retryTopic(delay)
+	log.Printf("Error handling event %s (attempt %d), retrying from %s: %v", event.ID, attempts, topic, err)
+	headers[HeaderNotBefore] = strconv.FormatInt(time.Now().Add(delay).UnixMilli(), 10)
+	return c.forward(sessionCtx, msg, topic, headers)
+}
+
+// forward produces a copy of the message to the topic, with the headers set. It is retried
+// until it succeeds or the session ends, and reports whether the message is settled.
+func (c *Consumer) forward(sessionCtx context.Context, msg *sarama.ConsumerMessage, topic string, headers map[string]string) bool {
+	if headerOf(msg, HeaderOriginalTopic) == "" {
+		headers[HeaderOriginalTopic] = msg.Topic
+		headers[HeaderOriginalPartition] = strconv.Itoa(int(msg.Partition))
+		headers[HeaderOriginalOffset] = strconv.FormatInt(msg.Offset, 10)
+	}
+
+	forwarded := &sarama.ProducerMessage{Topic: topic, Value: sarama.ByteEncoder(msg.Value)}
+	if msg.Key != nil {
+		forwarded.Key = sarama.ByteEncoder(msg.Key)
+	}
+	for _, header := range msg.Headers {
+		if _, ok := headers[string(header.Key)]; !ok {
+			forwarded.Headers = append(forwarded.Headers, *header)
+		}
+	}
+	for key, value := range headers {
+		forwarded.Headers = append(forwarded.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+	}
+
+	for attempt := 1; ; attempt++ {
+		_, _, err := c.producer.SendMessage(forwarded)
+		if err == nil {
+			return true
+		}
+
+		delay := c.backoff(attempt)
+		log.Printf("Error forwarding message at %s/%d/%d to %s, retrying in %s: %v", msg.Topic, msg.Partition, msg.Offset, topic, delay, err)
+		if !sleep(sessionCtx, delay) {
+			return false
+		}
+	}
+}
+
+// due waits until a message of a retry topic is due. It reports false when the session
+// ended first.
+func due(sessionCtx context.Context, msg *sarama.ConsumerMessage) bool {
+	notBefore, err := strconv.ParseInt(headerOf(msg, HeaderNotBefore), 10, 64)
+	if err != nil {
+		return true
+	}
+	return sleep(sessionCtx, time.Until(time.UnixMilli(notBefore)))
+}
+
+// attemptsOf returns the number of times handling the event of a message failed before
+func attemptsOf(msg *sarama.ConsumerMessage) int {
+	attempts, err := strconv.Atoi(headerOf(msg, HeaderAttempts))
+	if err != nil {
+		return 0
+	}
+	return attempts
+}
+
+func headerOf(msg *sarama.ConsumerMessage, key string) string {
+	for _, header := range msg.Headers {
+		if header != nil && string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// sleep waits for the delay. It reports false when the context ended first.
+func sleep(ctx context.Context, delay time.Duration) bool {
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
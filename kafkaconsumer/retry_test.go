@@ -0,0 +1,154 @@
+package kafkaconsumer
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRetryingConsumer(t *testing.T, maxAttempts int) (*Consumer, *mocks.SyncProducer) {
+	consumer := newConsumer(nil, Config{
+		GroupID:         "order-service",
+		Workers:         2,
+		CommitInterval:  time.Hour,
+		DeadLetterTopic: "order-service.dlq",
+		RetryDelays:     []time.Duration{5 * time.Second, time.Minute},
+		MaxAttempts:     maxAttempts,
+	})
+	producer := mocks.NewSyncProducer(t, nil)
+	consumer.producer = producer
+	return consumer, producer
+}
+
+// producedHeader returns the value of a header of a produced message
+func producedHeader(msg *sarama.ProducerMessage, key string) string {
+	for _, header := range msg.Headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func TestConsumeClaimForwardsFailedEvents(t *testing.T) {
+	consumer, producer := newRetryingConsumer(t, 0)
+	consumer.Register("order.updated", HandlerFunc(func(ctx context.Context, event Event) error {
+		if event.ID == "event-0" {
+			return errors.New("database unavailable")
+		}
+		return nil
+	}))
+
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		assert.Equal(t, "order-service.retry-5s", msg.Topic)
+		assert.Equal(t, "1", producedHeader(msg, HeaderAttempts))
+		assert.Equal(t, "database unavailable", producedHeader(msg, HeaderError))
+		assert.Equal(t, "orders", producedHeader(msg, HeaderOriginalTopic))
+		assert.Equal(t, "0", producedHeader(msg, HeaderOriginalOffset))
+		assert.NotEmpty(t, producedHeader(msg, HeaderNotBefore))
+		// The event is forwarded as it was consumed
+		assert.Equal(t, "order.updated", producedHeader(msg, "ce_type"))
+		key, _ := msg.Key.Encode()
+		assert.Equal(t, "order-1", string(key))
+		return nil
+	})
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		assert.Equal(t, "order-service.dlq", msg.Topic)
+		assert.Equal(t, ReasonPoison, producedHeader(msg, HeaderDeadLetterReason))
+		assert.NotEmpty(t, producedHeader(msg, HeaderError))
+		assert.Equal(t, "2", producedHeader(msg, HeaderOriginalOffset))
+		return nil
+	})
+
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 8)}
+	claim.messages <- eventMessage(t, 0, "order-1", "order.updated", nil)
+	claim.messages <- eventMessage(t, 1, "order-2", "order.updated", nil)
+	claim.messages <- &sarama.ConsumerMessage{Topic: "orders", Offset: 2, Key: []byte("order-1"), Value: []byte("not an event")}
+	close(claim.messages)
+
+	session := newFakeSession(context.Background())
+	handler := &groupHandler{consumer: consumer, ctx: context.Background()}
+	require.NoError(t, handler.ConsumeClaim(session, claim))
+
+	// Forwarded events are settled, so they do not hold up the partition
+	committed, ok := session.committed(0)
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), committed)
+	assert.NoError(t, producer.Close())
+}
+
+func TestFailDeadLettersAfterMaxAttempts(t *testing.T) {
+	failure := errors.New("payment declined")
+	retried := &sarama.ConsumerMessage{
+		Topic:  "order-service.retry-1m",
+		Offset: 7,
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte(HeaderAttempts), Value: []byte("2")},
+			{Key: []byte(HeaderOriginalTopic), Value: []byte("orders")},
+		},
+	}
+
+	// Attempts after the last delay stay in its tier
+	consumer, producer := newRetryingConsumer(t, 4)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		assert.Equal(t, "order-service.retry-1m", msg.Topic)
+		assert.Equal(t, "3", producedHeader(msg, HeaderAttempts))
+		assert.Equal(t, "orders", producedHeader(msg, HeaderOriginalTopic))
+		assert.Len(t, msg.Headers, 4, "headers of an earlier attempt are replaced")
+		return nil
+	})
+	assert.True(t, consumer.fail(context.Background(), retried, Event{ID: "event-7"}, failure))
+	assert.NoError(t, producer.Close())
+
+	consumer, producer = newRetryingConsumer(t, 3)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		assert.Equal(t, "order-service.dlq", msg.Topic)
+		assert.Equal(t, "3", producedHeader(msg, HeaderAttempts))
+		assert.Equal(t, ReasonExhausted, producedHeader(msg, HeaderDeadLetterReason))
+		return nil
+	})
+	assert.True(t, consumer.fail(context.Background(), retried, Event{ID: "event-7"}, failure))
+	assert.NoError(t, producer.Close())
+}
+
+func TestForwardLeavesEventOnRebalance(t *testing.T) {
+	consumer, producer := newRetryingConsumer(t, 0)
+	consumer.config.RetryInitialBackoff = time.Millisecond
+	consumer.config.RetryMaxBackoff = time.Millisecond
+	producer.ExpectSendMessageAndFail(sarama.ErrNotEnoughReplicas)
+	producer.ExpectSendMessageAndFail(sarama.ErrNotEnoughReplicas)
+
+	// The partition is revoked while the dead letter topic is unavailable
+	ctx, revoke := context.WithCancel(context.Background())
+	producer.ExpectSendMessageWithCheckerFunctionAndFail(func(val []byte) error {
+		revoke()
+		return nil
+	}, sarama.ErrNotEnoughReplicas)
+
+	msg := &sarama.ConsumerMessage{Topic: "orders", Value: []byte("not an event")}
+	assert.False(t, consumer.poison(ctx, msg, errors.New("invalid event")))
+	assert.NoError(t, producer.Close())
+}
+
+func TestDue(t *testing.T) {
+	retry := func(notBefore time.Time) *sarama.ConsumerMessage {
+		return &sarama.ConsumerMessage{Headers: []*sarama.RecordHeader{
+			{Key: []byte(HeaderNotBefore), Value: []byte(strconv.FormatInt(notBefore.UnixMilli(), 10))},
+		}}
+	}
+
+	assert.True(t, due(context.Background(), &sarama.ConsumerMessage{}))
+	assert.True(t, due(context.Background(), retry(time.Now().Add(-time.Second))))
+	assert.True(t, due(context.Background(), retry(time.Now().Add(20*time.Millisecond))))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.False(t, due(ctx, retry(time.Now().Add(time.Hour))), "the session ended before the retry was due")
+}
@@ -0,0 +1,331 @@
+// Package money provides an exact monetary amount in the minor units of a currency,
+// shared by the services so prices are never handled as floats.
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Money errors
+var (
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrInvalidCurrency  = errors.New("invalid currency")
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrMoneyOverflow    = errors.New("money amount overflow")
+)
+
+// Currency is an ISO 4217 currency code
+type Currency string
+
+// Commonly used currencies
+const (
+	CurrencyUSD Currency = "USD"
+	CurrencyEUR Currency = "EUR"
+	CurrencyGBP Currency = "GBP"
+	CurrencyJPY Currency = "JPY"
+	CurrencyVND Currency = "VND"
+)
+
+// currencyExponents maps currencies to the number of digits after the decimal point.
+// Currencies not listed use two digits.
+var currencyExponents = map[Currency]int{
+	"BHD": 3,
+	"CLP": 0,
+	"IQD": 3,
+	"ISK": 0,
+	"JOD": 3,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"OMR": 3,
+	"TND": 3,
+	"VND": 0,
+}
+
+// ParseCurrency validates and normalizes an ISO 4217 currency code
+func ParseCurrency(code string) (Currency, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCurrency, code)
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", fmt.Errorf("%w: %q", ErrInvalidCurrency, code)
+		}
+	}
+	return Currency(code), nil
+}
+
+// Exponent returns the number of minor unit digits of the currency
+func (c Currency) Exponent() int {
+	if exp, ok := currencyExponents[c]; ok {
+		return exp
+	}
+	return 2
+}
+
+// Money is an exact monetary amount stored in minor units (e.g. cents) of a currency
+type Money struct {
+	amount   int64
+	currency Currency
+}
+
+// NewMoney creates a Money value from an amount in minor units
+func NewMoney(minorUnits int64, currency Currency) Money {
+	return Money{amount: minorUnits, currency: currency}
+}
+
+// Zero returns a zero amount in the given currency
+func Zero(currency Currency) Money {
+	return Money{currency: currency}
+}
+
+// ParseMoney parses a decimal string such as "12.34" into Money.
+// Digits beyond the currency's precision are rounded half to even.
+func ParseMoney(amount string, currency Currency) (Money, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(amount))
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+	return fromRat(r, currency)
+}
+
+// MustParseMoney is like ParseMoney but panics on error. Intended for constants and tests.
+func MustParseMoney(amount string, currency Currency) Money {
+	m, err := ParseMoney(amount, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// fromRat converts a rational amount in major units into Money using banker's rounding
+func fromRat(r *big.Rat, currency Currency) (Money, error) {
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(currency.Exponent())))
+	minor := roundHalfEven(scaled)
+	if !minor.IsInt64() {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{amount: minor.Int64(), currency: currency}, nil
+}
+
+// roundHalfEven rounds a rational number to the nearest integer, ties to even
+func roundHalfEven(r *big.Rat) *big.Int {
+	num, den := r.Num(), r.Denom()
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() == 0 {
+		return quo
+	}
+
+	// Compare twice the remainder with the denominator to find the nearest integer
+	twiceRem := new(big.Int).Abs(rem)
+	twiceRem.Lsh(twiceRem, 1)
+	cmp := twiceRem.Cmp(den)
+	if cmp > 0 || (cmp == 0 && quo.Bit(0) == 1) {
+		if num.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return quo
+}
+
+// RoundHalfEven rounds r to the given number of decimal places, ties to even
+func RoundHalfEven(r *big.Rat, places int) *big.Rat {
+	scale := new(big.Rat).SetInt(pow10(places))
+	return new(big.Rat).SetFrac(roundHalfEven(new(big.Rat).Mul(r, scale)), pow10(places))
+}
+
+func pow10(exp int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+}
+
+// MinorUnits returns the amount in minor units of the currency
+func (m Money) MinorUnits() int64 {
+	return m.amount
+}
+
+// Currency returns the currency of the amount
+func (m Money) Currency() Currency {
+	return m.currency
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.amount == 0
+}
+
+// IsPositive reports whether the amount is greater than zero
+func (m Money) IsPositive() bool {
+	return m.amount > 0
+}
+
+// IsNegative reports whether the amount is less than zero
+func (m Money) IsNegative() bool {
+	return m.amount < 0
+}
+
+// Add returns the sum of two amounts of the same currency
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := m.sameCurrency(other)
+	if err != nil {
+		return Money{}, err
+	}
+	sum := m.amount + other.amount
+	if (other.amount > 0 && sum < m.amount) || (other.amount < 0 && sum > m.amount) {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{amount: sum, currency: currency}, nil
+}
+
+// Sub returns the difference of two amounts of the same currency
+func (m Money) Sub(other Money) (Money, error) {
+	if other.amount == math.MinInt64 {
+		return Money{}, ErrMoneyOverflow
+	}
+	return m.Add(Money{amount: -other.amount, currency: other.currency})
+}
+
+// Multiply returns the amount multiplied by an integer factor such as a quantity
+func (m Money) Multiply(factor int64) (Money, error) {
+	if factor == 0 || m.amount == 0 {
+		return Money{currency: m.currency}, nil
+	}
+	product := m.amount * factor
+	if product/factor != m.amount {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{amount: product, currency: m.currency}, nil
+}
+
+// MultiplyRat returns the amount multiplied by a rational factor, rounded half to even
+func (m Money) MultiplyRat(factor *big.Rat) (Money, error) {
+	product := new(big.Rat).Mul(new(big.Rat).SetInt64(m.amount), factor)
+	minor := roundHalfEven(product)
+	if !minor.IsInt64() {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{amount: minor.Int64(), currency: m.currency}, nil
+}
+
+// Convert converts the amount into the currency to, where rate is the price of one unit of
+// the amount's currency expressed in to. The result is rounded half to even.
+func (m Money) Convert(rate *big.Rat, to Currency) (Money, error) {
+	// Both amounts are in minor units, so adjust for the difference in precision
+	factor := new(big.Rat).Mul(rate, new(big.Rat).SetFrac(pow10(to.Exponent()), pow10(m.currency.Exponent())))
+	converted, err := m.MultiplyRat(factor)
+	if err != nil {
+		return Money{}, err
+	}
+
+	converted.currency = to
+	return converted, nil
+}
+
+// Equal reports whether both amounts and currencies are equal
+func (m Money) Equal(other Money) bool {
+	return m.amount == other.amount && m.currency == other.currency
+}
+
+// Cmp compares two amounts of the same currency and returns -1, 0 or +1
+func (m Money) Cmp(other Money) (int, error) {
+	if _, err := m.sameCurrency(other); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.amount < other.amount:
+		return -1, nil
+	case m.amount > other.amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// sameCurrency returns the common currency of two amounts.
+// A zero amount without a currency takes the currency of the other amount.
+func (m Money) sameCurrency(other Money) (Currency, error) {
+	switch {
+	case m.currency == other.currency:
+		return m.currency, nil
+	case m.currency == "" && m.amount == 0:
+		return other.currency, nil
+	case other.currency == "" && other.amount == 0:
+		return m.currency, nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency, other.currency)
+}
+
+// Decimal formats the amount in major units, e.g. "12.34"
+func (m Money) Decimal() string {
+	exp := m.currency.Exponent()
+	if exp == 0 {
+		return fmt.Sprintf("%d", m.amount)
+	}
+
+	sign := ""
+	amount := new(big.Int).SetInt64(m.amount)
+	if amount.Sign() < 0 {
+		sign = "-"
+		amount.Neg(amount)
+	}
+	quo, rem := new(big.Int).QuoRem(amount, pow10(exp), new(big.Int))
+	return fmt.Sprintf("%s%s.%0*s", sign, quo.String(), exp, rem.String())
+}
+
+// String formats the amount with its currency, e.g. "12.34 USD"
+func (m Money) String() string {
+	return fmt.Sprintf("%s %s", m.Decimal(), m.currency)
+}
+
+// MarshalJSON encodes the amount as {"amount": "12.34", "currency": "USD"}.
+// The amount is a string so that no precision is lost in JSON parsers using floats.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{
+		Amount:   m.Decimal(),
+		Currency: string(m.currency),
+	})
+}
+
+// UnmarshalJSON decodes {"amount": "12.34", "currency": "USD"}.
+// The amount may be given either as a string or as a JSON number.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Amount   json.RawMessage `json:"amount"`
+		Currency string          `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	amount := strings.Trim(string(raw.Amount), `"`)
+
+	// The zero value has no currency, so it is encoded without one
+	if raw.Currency == "" {
+		if r, ok := new(big.Rat).SetString(amount); ok && r.Sign() == 0 {
+			*m = Money{}
+			return nil
+		}
+	}
+
+	currency, err := ParseCurrency(raw.Currency)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := ParseMoney(amount, currency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
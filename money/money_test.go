@@ -0,0 +1,120 @@
+package money_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	testCases := []struct {
+		name     string
+		amount   string
+		currency money.Currency
+		minor    int64
+		decimal  string
+	}{
+		{name: "Whole amount", amount: "12", currency: money.CurrencyUSD, minor: 1200, decimal: "12.00"},
+		{name: "Cents", amount: "12.34", currency: money.CurrencyUSD, minor: 1234, decimal: "12.34"},
+		{name: "Database precision", amount: "12.3400", currency: money.CurrencyUSD, minor: 1234, decimal: "12.34"},
+		{name: "Negative", amount: "-0.05", currency: money.CurrencyUSD, minor: -5, decimal: "-0.05"},
+		{name: "Half rounds to even down", amount: "0.125", currency: money.CurrencyUSD, minor: 12, decimal: "0.12"},
+		{name: "Half rounds to even up", amount: "0.135", currency: money.CurrencyUSD, minor: 14, decimal: "0.14"},
+		{name: "Negative half rounds to even", amount: "-0.125", currency: money.CurrencyUSD, minor: -12, decimal: "-0.12"},
+		{name: "Above half rounds up", amount: "0.1251", currency: money.CurrencyUSD, minor: 13, decimal: "0.13"},
+		{name: "Zero exponent currency", amount: "1500.5", currency: money.CurrencyJPY, minor: 1500, decimal: "1500"},
+		{name: "Three digit currency", amount: "1.2345", currency: "KWD", minor: 1234, decimal: "1.234"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := money.ParseMoney(tc.amount, tc.currency)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.minor, m.MinorUnits())
+			assert.Equal(t, tc.currency, m.Currency())
+			assert.Equal(t, tc.decimal, m.Decimal())
+		})
+	}
+
+	_, err := money.ParseMoney("ten", money.CurrencyUSD)
+	assert.ErrorIs(t, err, money.ErrInvalidAmount)
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	a := money.MustParseMoney("10.10", money.CurrencyUSD)
+	b := money.MustParseMoney("0.20", money.CurrencyUSD)
+
+	sum, err := a.Add(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.30", sum.Decimal())
+
+	diff, err := a.Sub(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "9.90", diff.Decimal())
+
+	product, err := b.Multiply(3)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.60", product.Decimal())
+
+	// 1000 items at 0.10 add up exactly, unlike float64
+	total := money.Zero(money.CurrencyUSD)
+	for i := 0; i < 1000; i++ {
+		total, err = total.Add(money.MustParseMoney("0.10", money.CurrencyUSD))
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, "100.00", total.Decimal())
+
+	half, err := money.NewMoney(25, money.CurrencyUSD).MultiplyRat(big.NewRat(1, 10))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), half.MinorUnits())
+
+	_, err = a.Add(money.MustParseMoney("1.00", money.CurrencyEUR))
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+
+	_, err = money.NewMoney(1<<62, money.CurrencyUSD).Multiply(4)
+	assert.ErrorIs(t, err, money.ErrMoneyOverflow)
+}
+
+func TestMoneyJSON(t *testing.T) {
+	m := money.MustParseMoney("1234.56", money.CurrencyEUR)
+
+	data, err := json.Marshal(m)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"1234.56","currency":"EUR"}`, string(data))
+
+	var decoded money.Money
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.True(t, m.Equal(decoded))
+
+	// Amounts may also be sent as JSON numbers
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount":19.99,"currency":"usd"}`), &decoded))
+	assert.Equal(t, money.MustParseMoney("19.99", money.CurrencyUSD), decoded)
+
+	err = json.Unmarshal([]byte(`{"amount":"1.00","currency":"DOLLARS"}`), &decoded)
+	assert.ErrorIs(t, err, money.ErrInvalidCurrency)
+
+	// The zero value round trips without a currency
+	data, err = json.Marshal(money.Money{})
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.True(t, decoded.Equal(money.Money{}))
+}
+
+func TestMoneyConvert(t *testing.T) {
+	// 1 USD = 157.25 JPY, yen have no minor units
+	converted, err := money.MustParseMoney("10.00", money.CurrencyUSD).Convert(big.NewRat(15725, 100), money.CurrencyJPY)
+	assert.NoError(t, err)
+	assert.Equal(t, money.MustParseMoney("1572", money.CurrencyJPY), converted)
+
+	converted, err = money.MustParseMoney("1000", money.CurrencyJPY).Convert(big.NewRat(64, 10000), money.CurrencyUSD)
+	assert.NoError(t, err)
+	assert.Equal(t, money.MustParseMoney("6.40", money.CurrencyUSD), converted)
+}
+
+func TestRoundHalfEven(t *testing.T) {
+	assert.Equal(t, big.NewRat(12, 100), money.RoundHalfEven(big.NewRat(125, 1000), 2))
+	assert.Equal(t, big.NewRat(14, 100), money.RoundHalfEven(big.NewRat(135, 1000), 2))
+}
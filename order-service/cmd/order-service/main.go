@@ -17,13 +17,16 @@ import (
 	_ "github.com/lib/pq"
 
 	"order-service/internal/app/usecase"
+	"order-service/internal/events"
 	"order-service/internal/infrastructure/config"
+	exchangerate "order-service/internal/infrastructure/exchange_rate"
 	"order-service/internal/infrastructure/messaging/kafka"
 	"order-service/internal/infrastructure/repository"
 	unitofwork "order-service/internal/infrastructure/unit_of_work"
 	"order-service/internal/infrastructure/worker"
 	"order-service/internal/interfaces/api/handlers"
 	"order-service/internal/interfaces/api/router"
+	"order-service/internal/interfaces/consumer"
 )
 
 func main() {
@@ -58,14 +61,55 @@ func main() {
 		log.Fatalf("Failed to create Kafka producer: %v", err)
 	}
 
+	// Route every registered event to its configured topic
+	topics := kafka.NewTopicRouter(cfg.Kafka.Topics)
+	registry := events.NewDefaultRegistry()
+	if err := registry.Route(topics); err != nil {
+		log.Fatalf("Failed to route events to topics: %v", err)
+	}
+	if cfg.Kafka.Topics.AutoCreate {
+		if err := kafka.EnsureTopics(cfg.Kafka, topics.Topics()); err != nil {
+			log.Fatalf("Failed to create Kafka topics: %v", err)
+		}
+	}
+	producer.UseTopicRouter(registry)
+
+	// Serialize payloads with the event schemas, registered in the schema registry on first use
+	if cfg.Kafka.SchemaRegistry.URL != "" {
+		serializer, err := kafka.NewSerializer(cfg.Kafka.SchemaRegistry, registry)
+		if err != nil {
+			log.Fatalf("Failed to create event serializer: %v", err)
+		}
+		producer.UseSerializer(serializer)
+	}
+
+	if cfg.Outbox.Transactional && cfg.Kafka.Producer.TransactionalID == "" {
+		log.Println("No Kafka transactional id configured, outbox batches are published without transactions")
+	}
+
+	// Initialize exchange rates
+	exchangeRates := exchangerate.NewStaticRateProvider()
+	if cfg.ExchangeRates.RatesFile != "" {
+		exchangeRates, err = exchangerate.NewFileRateProvider(cfg.ExchangeRates.RatesFile)
+		if err != nil {
+			log.Fatalf("Failed to load exchange rates: %v", err)
+		}
+	}
+
 	// Initialize dependencies
-	workOfUnit := unitofwork.NewUnitOfWork(dbConn)
+	workOfUnit := unitofwork.NewSQLUnitOfWork(dbConn)
+	if cfg.Outbox.NotifyChannel != "" {
+		workOfUnit = unitofwork.NewNotifyingSQLUnitOfWork(dbConn, cfg.Outbox.NotifyChannel)
+	}
 	// orderRepo := repository.NewOrderRepository(dbConn)
 	outboxRepo := repository.NewOutboxRepository(dbConn)
 
-	orderUseCase := usecase.NewOrderUseCase(workOfUnit, producer)
+	orderUseCase := usecase.NewOrderUseCase(workOfUnit, exchangeRates)
 	orderHandler := handlers.NewOrderHandler(orderUseCase)
 
+	outboxAdminUseCase := usecase.NewOutboxAdminUseCase(repository.NewOutboxAdminRepository(dbConn), registry)
+	outboxAdminHandler := handlers.NewOutboxAdminHandler(outboxAdminUseCase)
+
 	// Setup router
 	r := router.Setup(orderHandler)
 
@@ -85,21 +129,98 @@ func main() {
 		}
 	}()
 
-	// Create the outbox worker
-	worker := worker.NewOutboxProcessor(
-		outboxRepo,
-		producer,
+	// Serve the operator endpoints on their own port, which is not exposed publicly
+	var adminServer *http.Server
+	if cfg.Server.AdminPort != 0 {
+		adminServer = &http.Server{
+			Addr:         fmt.Sprintf(":%d", cfg.Server.AdminPort),
+			Handler:      router.SetupAdmin(outboxAdminHandler),
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 15 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		}
 
-		100,
-		5*time.Second,
-		3,
-	)
+		go func() {
+			log.Printf("Starting admin server on port %d", cfg.Server.AdminPort)
+			if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Admin server failed to start: %v", err)
+			}
+		}()
+	}
 
 	// Start the worker with context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	go worker.Start(ctx)
+	if cfg.Outbox.Mode == config.OutboxModeCDC {
+		// Relay messages from the replication stream instead of polling the outbox table
+		relay := worker.NewOutboxRelay(
+			cfg.Database.URL,
+			repository.NewOutboxRelayRepository(dbConn),
+			repository.NewRelayOffsetRepository(dbConn),
+			producer,
+			registry,
+			cfg.Outbox,
+		)
+		go relay.Start(ctx)
+
+		// The relay only publishes, failed messages still have to reach the dead-letter topic
+		deadLetterWorker := worker.NewOutboxProcessor(
+			outboxRepo,
+			producer,
+			producer,
+			registry,
+			cfg.Outbox,
+		)
+		go deadLetterWorker.StartDeadLetters(ctx)
+	} else {
+		// Create the outbox worker
+		outboxWorker := worker.NewOutboxProcessor(
+			outboxRepo,
+			producer,
+			producer,
+			registry,
+			cfg.Outbox,
+		)
+
+		// Wake the outbox worker on new messages instead of waiting for the next poll
+		if cfg.Outbox.NotifyChannel != "" {
+			notifier, err := worker.NewPostgresNotifier(cfg.Database.URL, cfg.Outbox.NotifyChannel)
+			if err != nil {
+				log.Fatalf("Failed to listen for outbox notifications: %v", err)
+			}
+			defer notifier.Close()
+			outboxWorker.UseNotifier(notifier)
+		}
+
+		go outboxWorker.Start(ctx)
+	}
+
+	// Move processed messages out of the outbox table once they are old
+	retentionJob := worker.NewOutboxRetentionJob(repository.NewOutboxRetentionRepository(dbConn), cfg.Outbox)
+	go retentionJob.Start(ctx)
+
+	// Forget the events consumers processed once they can no longer be redelivered
+	inboxRetentionJob := worker.NewInboxRetentionJob(repository.NewInboxRetentionRepository(dbConn), cfg.Inbox)
+	go inboxRetentionJob.Start(ctx)
+
+	// Confirm or fail orders as the inventory and the payment service report their outcomes
+	sagaConsumer, err := kafka.NewConsumer(cfg.Kafka, kafka.SagaTopics(cfg.Kafka.Topics))
+	if err != nil {
+		log.Fatalf("Failed to create Kafka consumer: %v", err)
+	}
+	defer sagaConsumer.Close()
+	if cfg.Kafka.Topics.AutoCreate {
+		if err := kafka.EnsureTopics(cfg.Kafka, sagaConsumer.Topics()); err != nil {
+			log.Fatalf("Failed to create Kafka topics: %v", err)
+		}
+	}
+	consumer.NewOrderSagaHandler(workOfUnit, usecase.NewOrderSagaUseCase()).Register(sagaConsumer)
+	go func() {
+		if err := sagaConsumer.Run(ctx); err != nil {
+			log.Printf("Order saga consumer stopped: %v", err)
+		}
+	}()
 
 	// Wait for interrup signal to gracefully shut down the server
 	quit := make(chan os.Signal, 1)
@@ -114,6 +235,11 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
+	if adminServer != nil {
+		if err := adminServer.Shutdown(ctx); err != nil {
+			log.Fatalf("Admin server forced to shutdown: %v", err)
+		}
+	}
 
 	log.Println("Server exited properly")
 }
@@ -0,0 +1,238 @@
+// Command outbox-admin inspects and repairs outbox messages that ran out of attempts.
+//
+// Usage:
+//
+//	outbox-admin list    [-type event] [-since time] [-until time] [-limit n] [id...]
+//	outbox-admin show    id
+//	outbox-admin edit    [-file payload.json] id
+//	outbox-admin requeue [-type event] [-since time] [-until time] [id...]
+//	outbox-admin discard [-type event] [-since time] [-until time] [id...]
+//	outbox-admin purge   [-older-than duration] [-batch n] [-archive=false]
+//
+// Times are RFC 3339 and bound the time the messages failed. edit reads the new
+// payload from standard input unless -file is given, written in the current schema
+// version of the message's event type. purge runs the retention job
+// once and removes processed messages, by default as configured for the service.
+// The database is configured the same way as for the order service.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/google/uuid"
+
+	"order-service/internal/app/ports"
+	"order-service/internal/app/usecase"
+	"order-service/internal/domain"
+	"order-service/internal/events"
+	"order-service/internal/infrastructure/config"
+	"order-service/internal/infrastructure/repository"
+	"order-service/internal/infrastructure/worker"
+	"order-service/internal/interfaces/api/dto"
+)
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	dbConn, err := sql.Open("postgres", cfg.Database.URL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer dbConn.Close()
+
+	admin := usecase.NewOutboxAdminUseCase(repository.NewOutboxAdminRepository(dbConn), events.NewDefaultRegistry())
+	ctx := context.Background()
+
+	command, args := os.Args[1], os.Args[2:]
+	switch command {
+	case "list":
+		err = list(ctx, admin, args)
+	case "show":
+		err = show(ctx, admin, args)
+	case "edit":
+		err = edit(ctx, admin, args)
+	case "requeue":
+		err = bulk(ctx, command, admin.Requeue, args)
+	case "discard":
+		err = bulk(ctx, command, admin.Discard, args)
+	case "purge":
+		err = purge(ctx, repository.NewOutboxRetentionRepository(dbConn), cfg.Outbox, args)
+	default:
+		usage()
+	}
+
+	if err != nil {
+		log.Fatalf("%s: %v", command, err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: outbox-admin list|show|edit|requeue|discard|purge [flags] [id...]")
+	os.Exit(2)
+}
+
+// filterFlags registers the flags selecting failed messages
+func filterFlags(fs *flag.FlagSet) func() (domain.OutboxMessageFilter, error) {
+	eventType := fs.String("type", "", "only messages of this event type")
+	since := fs.String("since", "", "only messages that failed at or after this RFC 3339 time")
+	until := fs.String("until", "", "only messages that failed before this RFC 3339 time")
+
+	return func() (domain.OutboxMessageFilter, error) {
+		filter := domain.OutboxMessageFilter{EventType: *eventType}
+
+		var err error
+		if *since != "" {
+			if filter.FailedAfter, err = time.Parse(time.RFC3339, *since); err != nil {
+				return filter, fmt.Errorf("invalid -since: %w", err)
+			}
+		}
+		if *until != "" {
+			if filter.FailedBefore, err = time.Parse(time.RFC3339, *until); err != nil {
+				return filter, fmt.Errorf("invalid -until: %w", err)
+			}
+		}
+
+		filter.IDs, err = parseIDs(fs.Args())
+		return filter, err
+	}
+}
+
+func list(ctx context.Context, admin ports.OutboxAdminUseCase, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	limit := fs.Int("limit", domain.DefaultOutboxPageSize, "maximum number of messages")
+	filter := filterFlags(fs)
+	fs.Parse(args)
+
+	f, err := filter()
+	if err != nil {
+		return err
+	}
+
+	messages, err := admin.ListFailedMessages(ctx, f, *limit)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(dto.OutboxMessagesToListResponse(messages))
+}
+
+func show(ctx context.Context, admin ports.OutboxAdminUseCase, args []string) error {
+	ids, err := parseIDs(args)
+	if err != nil {
+		return err
+	}
+	if len(ids) != 1 {
+		return fmt.Errorf("expected one message ID")
+	}
+
+	msg, err := admin.GetMessage(ctx, ids[0])
+	if err != nil {
+		return err
+	}
+
+	return printJSON(dto.OutboxMessageToResponse(*msg))
+}
+
+func edit(ctx context.Context, admin ports.OutboxAdminUseCase, args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ExitOnError)
+	file := fs.String("file", "", "read the new payload from this file instead of standard input")
+	fs.Parse(args)
+
+	ids, err := parseIDs(fs.Args())
+	if err != nil {
+		return err
+	}
+	if len(ids) != 1 {
+		return fmt.Errorf("expected one message ID")
+	}
+
+	var payload []byte
+	if *file != "" {
+		payload, err = os.ReadFile(*file)
+	} else {
+		payload, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read payload: %w", err)
+	}
+
+	if err := admin.UpdatePayload(ctx, ids[0], payload); err != nil {
+		return err
+	}
+
+	return show(ctx, admin, fs.Args())
+}
+
+func bulk(ctx context.Context, name string, action func(context.Context, domain.OutboxMessageFilter) (int64, error), args []string) error {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	filter := filterFlags(fs)
+	fs.Parse(args)
+
+	f, err := filter()
+	if err != nil {
+		return err
+	}
+
+	affected, err := action(ctx, f)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(dto.OutboxBulkResponse{Affected: affected})
+}
+
+func purge(ctx context.Context, repo ports.OutboxRetentionRepository, cfg config.OutboxWorkerConfig, args []string) error {
+	fs := flag.NewFlagSet("purge", flag.ExitOnError)
+	fs.DurationVar(&cfg.RetentionPeriod, "older-than", cfg.RetentionPeriod, "remove messages processed longer ago than this")
+	fs.IntVar(&cfg.RetentionBatchSize, "batch", cfg.RetentionBatchSize, "number of messages removed per statement")
+	fs.BoolVar(&cfg.RetentionArchive, "archive", cfg.RetentionArchive, "move the messages to the archive table instead of deleting them")
+	fs.Parse(args)
+
+	if cfg.RetentionPeriod <= 0 {
+		return fmt.Errorf("-older-than has to be positive")
+	}
+
+	report, err := worker.NewOutboxRetentionJob(repo, cfg).RunOnce(ctx)
+	if printErr := printJSON(report); printErr != nil {
+		return printErr
+	}
+	return err
+}
+
+func parseIDs(args []string) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(args))
+	for _, arg := range args {
+		id, err := uuid.Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid message ID %q", arg)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
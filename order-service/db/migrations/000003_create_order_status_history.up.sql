@@ -0,0 +1,11 @@
+CREATE TABLE order_status_history (
+    id UUID PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    changed_at TIMESTAMP NOT NULL
+);
+
+-- Indexes for better performance
+CREATE INDEX idx_order_status_history_order_id ON order_status_history(order_id);
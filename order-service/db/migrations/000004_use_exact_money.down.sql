@@ -0,0 +1,5 @@
+ALTER TABLE order_items DROP COLUMN IF EXISTS currency;
+ALTER TABLE order_items ALTER COLUMN price TYPE DECIMAL(10, 2);
+
+ALTER TABLE orders DROP COLUMN IF EXISTS currency;
+ALTER TABLE orders ALTER COLUMN total_price TYPE DECIMAL(10, 2);
@@ -0,0 +1,6 @@
+-- Store prices with enough precision for every currency and record the currency next to each amount
+ALTER TABLE orders ALTER COLUMN total_price TYPE NUMERIC(19, 4);
+ALTER TABLE orders ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+
+ALTER TABLE order_items ALTER COLUMN price TYPE NUMERIC(19, 4);
+ALTER TABLE order_items ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
@@ -0,0 +1,8 @@
+CREATE TABLE order_exchange_rates (
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    from_currency TEXT NOT NULL,
+    to_currency TEXT NOT NULL,
+    rate NUMERIC(24, 12) NOT NULL,
+    as_of TIMESTAMP NOT NULL,
+    PRIMARY KEY (order_id, from_currency)
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_orders_customer_id_created_at_id;
+DROP INDEX IF EXISTS idx_orders_total_price_id;
+DROP INDEX IF EXISTS idx_orders_created_at_id;
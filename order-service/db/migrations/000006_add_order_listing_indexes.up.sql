@@ -0,0 +1,4 @@
+-- Indexes backing keyset pagination of orders
+CREATE INDEX idx_orders_created_at_id ON orders(created_at, id);
+CREATE INDEX idx_orders_total_price_id ON orders(total_price, id);
+CREATE INDEX idx_orders_customer_id_created_at_id ON orders(customer_id, created_at, id);
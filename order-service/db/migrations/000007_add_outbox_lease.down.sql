@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_outbox_messages_pending;
+
+ALTER TABLE outbox_messages DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE outbox_messages DROP COLUMN IF EXISTS locked_by;
@@ -0,0 +1,5 @@
+-- Workers claim outbox messages by leasing them until locked_until
+ALTER TABLE outbox_messages ADD COLUMN locked_by TEXT NULL;
+ALTER TABLE outbox_messages ADD COLUMN locked_until TIMESTAMP NULL;
+
+CREATE INDEX idx_outbox_messages_pending ON outbox_messages(created_at) WHERE status = 'PENDING';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_outbox_messages_pending;
+CREATE INDEX idx_outbox_messages_pending ON outbox_messages(created_at) WHERE status = 'PENDING';
+
+ALTER TABLE outbox_messages DROP COLUMN IF EXISTS next_attempt_at;
@@ -0,0 +1,5 @@
+-- Failed messages are retried with a backoff, error_message keeps the last error
+ALTER TABLE outbox_messages ADD COLUMN next_attempt_at TIMESTAMP NULL;
+
+DROP INDEX IF EXISTS idx_outbox_messages_pending;
+CREATE INDEX idx_outbox_messages_pending ON outbox_messages(next_attempt_at, created_at) WHERE status = 'PENDING';
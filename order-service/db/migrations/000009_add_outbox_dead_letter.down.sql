@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_outbox_messages_failed;
+
+ALTER TABLE outbox_messages DROP COLUMN IF EXISTS dead_letter_attempts;
+ALTER TABLE outbox_messages DROP COLUMN IF EXISTS dead_lettered_at;
+ALTER TABLE outbox_messages DROP COLUMN IF EXISTS failed_at;
@@ -0,0 +1,8 @@
+-- Failed messages are forwarded to the dead-letter topic and can be requeued or discarded
+ALTER TABLE outbox_messages ADD COLUMN failed_at TIMESTAMP NULL;
+ALTER TABLE outbox_messages ADD COLUMN dead_lettered_at TIMESTAMP NULL;
+ALTER TABLE outbox_messages ADD COLUMN dead_letter_attempts INTEGER NOT NULL DEFAULT 0;
+
+UPDATE outbox_messages SET failed_at = created_at WHERE status = 'FAILED';
+
+CREATE INDEX idx_outbox_messages_failed ON outbox_messages(failed_at, id) WHERE status = 'FAILED';
@@ -0,0 +1,6 @@
+-- Position of the CDC relay in each logical replication slot, so restarts resume where it left off
+CREATE TABLE outbox_relay_offsets (
+    slot_name TEXT PRIMARY KEY,
+    lsn BIGINT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
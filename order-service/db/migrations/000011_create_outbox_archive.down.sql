@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_outbox_messages_processed;
+
+DROP FUNCTION IF EXISTS create_outbox_archive_partitions(TIMESTAMP, TIMESTAMP);
+DROP TABLE IF EXISTS outbox_messages_archive;
@@ -0,0 +1,34 @@
+-- Processed outbox messages moved out of outbox_messages by the retention job.
+-- The archive is partitioned by month so old months can be dropped as a whole.
+CREATE TABLE outbox_messages_archive (
+    id UUID NOT NULL,
+    aggregate_id UUID NOT NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    message_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    processed_at TIMESTAMP NOT NULL,
+    attempt_count INTEGER NOT NULL,
+    error_message TEXT NULL,
+    archived_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id, processed_at)
+) PARTITION BY RANGE (processed_at);
+
+-- Creates the missing monthly archive partitions from the month of from_ts up to to_ts
+CREATE FUNCTION create_outbox_archive_partitions(from_ts TIMESTAMP, to_ts TIMESTAMP) RETURNS VOID AS $$
+DECLARE
+    month TIMESTAMP := date_trunc('month', from_ts);
+BEGIN
+    WHILE month IS NOT NULL AND month <= to_ts LOOP
+        EXECUTE format(
+            'CREATE TABLE IF NOT EXISTS %I PARTITION OF outbox_messages_archive FOR VALUES FROM (%L) TO (%L)',
+            'outbox_messages_archive_' || to_char(month, 'YYYY_MM'),
+            month,
+            month + INTERVAL '1 month'
+        );
+        month := month + INTERVAL '1 month';
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE INDEX idx_outbox_messages_processed ON outbox_messages(processed_at) WHERE status = 'PROCESSED';
@@ -0,0 +1,6 @@
+ALTER TABLE outbox_messages_archive DROP COLUMN IF EXISTS sequence_number;
+
+DROP INDEX IF EXISTS idx_outbox_messages_aggregate_sequence;
+ALTER TABLE outbox_messages DROP COLUMN IF EXISTS sequence_number;
+
+DROP TABLE IF EXISTS outbox_aggregate_sequences;
@@ -0,0 +1,27 @@
+-- Messages of one aggregate are numbered so they can be published in order.
+-- The counter survives the retention job deleting old messages.
+CREATE TABLE outbox_aggregate_sequences (
+    aggregate_id UUID PRIMARY KEY,
+    last_sequence BIGINT NOT NULL
+);
+
+ALTER TABLE outbox_messages ADD COLUMN sequence_number BIGINT;
+
+UPDATE outbox_messages m
+SET sequence_number = numbered.sequence_number
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY aggregate_id ORDER BY created_at, id) AS sequence_number
+    FROM outbox_messages
+) numbered
+WHERE m.id = numbered.id;
+
+INSERT INTO outbox_aggregate_sequences (aggregate_id, last_sequence)
+SELECT aggregate_id, MAX(sequence_number)
+FROM outbox_messages
+GROUP BY aggregate_id;
+
+ALTER TABLE outbox_messages ALTER COLUMN sequence_number SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_outbox_messages_aggregate_sequence ON outbox_messages(aggregate_id, sequence_number);
+
+ALTER TABLE outbox_messages_archive ADD COLUMN sequence_number BIGINT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE outbox_messages_archive DROP COLUMN IF EXISTS schema_version;
+
+ALTER TABLE outbox_messages DROP COLUMN IF EXISTS schema_version;
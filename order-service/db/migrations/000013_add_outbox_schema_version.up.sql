@@ -0,0 +1,18 @@
+-- Messages record the schema version their payload was written with, so payloads written
+-- before an event type changed are upcast to its current version on read.
+-- Messages written so far carry the versions the event types had when the column was added.
+ALTER TABLE outbox_messages ADD COLUMN schema_version INT;
+
+UPDATE outbox_messages
+SET schema_version = CASE event_type
+    WHEN 'order.created' THEN 3
+    WHEN 'order.item_added' THEN 2
+    WHEN 'order.item_removed' THEN 2
+    WHEN 'order.cancelled' THEN 2
+    ELSE 1
+END;
+
+ALTER TABLE outbox_messages ALTER COLUMN schema_version SET DEFAULT 1;
+ALTER TABLE outbox_messages ALTER COLUMN schema_version SET NOT NULL;
+
+ALTER TABLE outbox_messages_archive ADD COLUMN schema_version INT NULL;
@@ -0,0 +1,11 @@
+-- The inbox of the event consumers: an event is recorded in the transaction of its side effects,
+-- so a redelivered event is recognised and skipped.
+CREATE TABLE processed_events (
+    event_id TEXT NOT NULL,
+    consumer_name TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    processed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (event_id, consumer_name)
+);
+
+CREATE INDEX idx_processed_events_processed_at ON processed_events(processed_at);
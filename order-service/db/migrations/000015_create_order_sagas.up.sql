@@ -0,0 +1,15 @@
+-- Progress of the saga of each order. The inventory and the payment service report their
+-- outcomes in any order, the order is confirmed once its products are reserved and its
+-- payment is approved.
+CREATE TABLE order_sagas (
+    order_id UUID PRIMARY KEY REFERENCES orders(id) ON DELETE CASCADE,
+    saga_id UUID NOT NULL UNIQUE,
+    products_reserved BOOLEAN NOT NULL DEFAULT FALSE,
+    payment_approved BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Orders placed before sagas were recorded get a saga of their own
+INSERT INTO order_sagas (order_id, saga_id)
+SELECT id, gen_random_uuid() FROM orders;
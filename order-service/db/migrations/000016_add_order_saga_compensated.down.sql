@@ -0,0 +1 @@
+ALTER TABLE order_sagas DROP COLUMN IF EXISTS compensated;
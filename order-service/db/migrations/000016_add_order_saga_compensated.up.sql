@@ -0,0 +1,10 @@
+-- A saga emits order.cancelled to compensate its succeeded steps only once
+ALTER TABLE order_sagas ADD COLUMN compensated BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Sagas of orders that ended without being fulfilled were compensated on their last outcome
+UPDATE order_sagas
+SET compensated = TRUE
+FROM orders
+WHERE orders.id = order_sagas.order_id
+  AND orders.status IN ('FAILED', 'CANCELLED')
+  AND (order_sagas.products_reserved OR order_sagas.payment_approved);
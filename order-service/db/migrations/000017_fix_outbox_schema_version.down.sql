@@ -0,0 +1 @@
+-- The derived versions match the payloads, there is nothing to revert
@@ -0,0 +1,26 @@
+-- 000013 gave every message the version its event type had when the column was added,
+-- but messages written before the payload changes still hold older payloads.
+-- The version is derived from the shape of the payload instead: version 1 wrote prices
+-- as floats, and order.created payloads of version 2 have no order currency.
+UPDATE outbox_messages
+SET schema_version = CASE
+    WHEN event_type = 'order.created' AND jsonb_typeof(payload->'TotalPrice') = 'number' THEN 1
+    WHEN event_type = 'order.created' AND NOT payload ? 'Currency' THEN 2
+    WHEN event_type = 'order.created' THEN 3
+    WHEN event_type IN ('order.item_added', 'order.item_removed') AND jsonb_typeof(payload->'TotalPrice') = 'number' THEN 1
+    WHEN event_type = 'order.cancelled' AND jsonb_typeof(payload->'Items'->0->'Price') = 'number' THEN 1
+    ELSE 2
+END
+WHERE event_type IN ('order.created', 'order.item_added', 'order.item_removed', 'order.cancelled');
+
+UPDATE outbox_messages_archive
+SET schema_version = CASE
+    WHEN event_type = 'order.created' AND jsonb_typeof(payload->'TotalPrice') = 'number' THEN 1
+    WHEN event_type = 'order.created' AND NOT payload ? 'Currency' THEN 2
+    WHEN event_type = 'order.created' THEN 3
+    WHEN event_type IN ('order.item_added', 'order.item_removed') AND jsonb_typeof(payload->'TotalPrice') = 'number' THEN 1
+    WHEN event_type = 'order.cancelled' AND jsonb_typeof(payload->'Items'->0->'Price') = 'number' THEN 1
+    ELSE 2
+END
+WHERE schema_version IS NOT NULL
+  AND event_type IN ('order.created', 'order.item_added', 'order.item_removed', 'order.cancelled');
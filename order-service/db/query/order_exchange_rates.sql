@@ -0,0 +1,17 @@
+-- name: CreateOrderExchangeRate :exec
+INSERT INTO order_exchange_rates (
+    order_id, from_currency, to_currency, rate, as_of
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (order_id, from_currency) DO NOTHING;
+
+-- name: GetOrderExchangeRates :many
+SELECT * FROM order_exchange_rates
+WHERE order_id = $1
+ORDER BY from_currency ASC;
+
+-- name: GetOrderExchangeRatesByOrderIDs :many
+SELECT * FROM order_exchange_rates
+WHERE order_id = ANY(sqlc.arg('order_ids')::uuid[])
+ORDER BY order_id, from_currency ASC;
@@ -0,0 +1,20 @@
+-- name: CreateOrderSaga :exec
+INSERT INTO order_sagas (
+    order_id, saga_id, products_reserved, payment_approved, compensated, created_at, updated_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+);
+
+-- name: GetOrderSagaForUpdate :one
+-- Locks the saga, so outcomes of the same order are applied one after the other
+SELECT * FROM order_sagas
+WHERE order_id = $1
+FOR UPDATE;
+
+-- name: UpdateOrderSaga :exec
+UPDATE order_sagas
+SET products_reserved = $2,
+    payment_approved = $3,
+    compensated = $4,
+    updated_at = $5
+WHERE order_id = $1;
@@ -0,0 +1,12 @@
+-- name: CreateOrderStatusTransition :exec
+INSERT INTO order_status_history (
+    id, order_id, from_status, to_status, reason, changed_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: GetOrderStatusHistory :many
+SELECT * FROM order_status_history
+WHERE order_id = $1
+ORDER BY changed_at ASC;
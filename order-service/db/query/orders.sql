@@ -1,15 +1,21 @@
 -- db/queries.sql
 -- name: CreateOrder :exec
 INSERT INTO orders (
-    id, customer_id, status, total_price, created_at, updated_at
+    id, customer_id, status, total_price, currency, created_at, updated_at
 ) VALUES (
-    $1, $2, $3, $4, $5, $6
+    $1, $2, $3, $4, $5, $6, $7
 );
 
 -- name: GetOrder :one
 SELECT * FROM orders
 WHERE id = $1;
 
+-- name: GetOrderForUpdate :one
+-- Locks the order, so changes of the same order are applied one after the other
+SELECT * FROM orders
+WHERE id = $1
+FOR UPDATE;
+
 -- name: UpdateOrder :exec
 UPDATE orders
 SET status = $1, total_price = $2, updated_at = $3
@@ -19,22 +25,74 @@ WHERE id = $4;
 DELETE FROM orders
 WHERE id = $1;
 
--- name: ListOrders :many
+-- name: ListOrdersByCreatedAtAsc :many
+SELECT * FROM orders
+WHERE (sqlc.narg('customer_id')::text IS NULL OR customer_id = sqlc.narg('customer_id'))
+  AND (cardinality(sqlc.arg('statuses')::text[]) = 0 OR status = ANY(sqlc.arg('statuses')::text[]))
+  AND (sqlc.narg('created_after')::timestamp IS NULL OR created_at >= sqlc.narg('created_after'))
+  AND (sqlc.narg('created_before')::timestamp IS NULL OR created_at < sqlc.narg('created_before'))
+  AND (sqlc.narg('currency')::text IS NULL OR currency = sqlc.narg('currency'))
+  AND (sqlc.narg('min_total')::numeric IS NULL OR total_price >= sqlc.narg('min_total'))
+  AND (sqlc.narg('max_total')::numeric IS NULL OR total_price <= sqlc.narg('max_total'))
+  AND (sqlc.narg('after_created_at')::timestamp IS NULL OR (created_at, id) > (sqlc.narg('after_created_at'), sqlc.narg('after_id')::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: ListOrdersByCreatedAtDesc :many
+SELECT * FROM orders
+WHERE (sqlc.narg('customer_id')::text IS NULL OR customer_id = sqlc.narg('customer_id'))
+  AND (cardinality(sqlc.arg('statuses')::text[]) = 0 OR status = ANY(sqlc.arg('statuses')::text[]))
+  AND (sqlc.narg('created_after')::timestamp IS NULL OR created_at >= sqlc.narg('created_after'))
+  AND (sqlc.narg('created_before')::timestamp IS NULL OR created_at < sqlc.narg('created_before'))
+  AND (sqlc.narg('currency')::text IS NULL OR currency = sqlc.narg('currency'))
+  AND (sqlc.narg('min_total')::numeric IS NULL OR total_price >= sqlc.narg('min_total'))
+  AND (sqlc.narg('max_total')::numeric IS NULL OR total_price <= sqlc.narg('max_total'))
+  AND (sqlc.narg('after_created_at')::timestamp IS NULL OR (created_at, id) < (sqlc.narg('after_created_at'), sqlc.narg('after_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: ListOrdersByTotalPriceAsc :many
+SELECT * FROM orders
+WHERE (sqlc.narg('customer_id')::text IS NULL OR customer_id = sqlc.narg('customer_id'))
+  AND (cardinality(sqlc.arg('statuses')::text[]) = 0 OR status = ANY(sqlc.arg('statuses')::text[]))
+  AND (sqlc.narg('created_after')::timestamp IS NULL OR created_at >= sqlc.narg('created_after'))
+  AND (sqlc.narg('created_before')::timestamp IS NULL OR created_at < sqlc.narg('created_before'))
+  AND (sqlc.narg('currency')::text IS NULL OR currency = sqlc.narg('currency'))
+  AND (sqlc.narg('min_total')::numeric IS NULL OR total_price >= sqlc.narg('min_total'))
+  AND (sqlc.narg('max_total')::numeric IS NULL OR total_price <= sqlc.narg('max_total'))
+  AND (sqlc.narg('after_total_price')::numeric IS NULL OR (total_price, id) > (sqlc.narg('after_total_price'), sqlc.narg('after_id')::uuid))
+ORDER BY total_price ASC, id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: ListOrdersByTotalPriceDesc :many
 SELECT * FROM orders
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2;
+WHERE (sqlc.narg('customer_id')::text IS NULL OR customer_id = sqlc.narg('customer_id'))
+  AND (cardinality(sqlc.arg('statuses')::text[]) = 0 OR status = ANY(sqlc.arg('statuses')::text[]))
+  AND (sqlc.narg('created_after')::timestamp IS NULL OR created_at >= sqlc.narg('created_after'))
+  AND (sqlc.narg('created_before')::timestamp IS NULL OR created_at < sqlc.narg('created_before'))
+  AND (sqlc.narg('currency')::text IS NULL OR currency = sqlc.narg('currency'))
+  AND (sqlc.narg('min_total')::numeric IS NULL OR total_price >= sqlc.narg('min_total'))
+  AND (sqlc.narg('max_total')::numeric IS NULL OR total_price <= sqlc.narg('max_total'))
+  AND (sqlc.narg('after_total_price')::numeric IS NULL OR (total_price, id) < (sqlc.narg('after_total_price'), sqlc.narg('after_id')::uuid))
+ORDER BY total_price DESC, id DESC
+LIMIT sqlc.arg('page_size');
 
 -- name: CreateOrderItem :exec
 INSERT INTO order_items (
-    id, order_id, product_id, quantity, price
+    id, order_id, product_id, quantity, price, currency
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6
 );
 
 -- name: GetOrderItems :many
 SELECT * FROM order_items
 WHERE order_id = $1;
 
+-- name: GetOrderItemsByOrderIDs :many
+SELECT * FROM order_items
+WHERE order_id = ANY(sqlc.arg('order_ids')::uuid[])
+ORDER BY order_id;
+
 -- name: DeleteOrderItems :exec
 DELETE FROM order_items
 WHERE order_id = $1;
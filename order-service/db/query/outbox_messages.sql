@@ -1,33 +1,80 @@
 -- name: CreateOutboxMessage :exec
+-- Numbers the message after the previous message of its aggregate. The counter row
+-- stays locked until the transaction ends, so concurrent writers get consecutive numbers.
+WITH next_sequence AS (
+    INSERT INTO outbox_aggregate_sequences (aggregate_id, last_sequence)
+    VALUES ($2, 1)
+    ON CONFLICT (aggregate_id) DO UPDATE
+    SET last_sequence = outbox_aggregate_sequences.last_sequence + 1
+    RETURNING last_sequence
+)
 INSERT INTO outbox_messages (
     id,
     aggregate_id,
     event_type,
     payload,
+    message_id,
     created_at,
-    status
-) VALUES (
-    $1, $2, $3, $4, $5, $6
-);
+    status,
+    schema_version,
+    sequence_number
+)
+SELECT $1::uuid, $2::uuid, $3::text, $4::jsonb, $5::uuid, $6::timestamp, $7::text, $8::int, last_sequence
+FROM next_sequence;
 
 -- name: GetPendingOutboxMessages :many
 SELECT *
 FROM outbox_messages
-WHERE status = 'pending'
+WHERE status = 'PENDING'
+  AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
 ORDER BY created_at ASC
 LIMIT $1;
 
+-- name: ClaimOutboxMessages :many
+-- Claims a batch of pending messages for one worker. Rows locked by another
+-- transaction are skipped and claimed rows stay leased until locked_until, so
+-- concurrent workers never receive the same message while its lease is valid.
+-- A message is held back while an earlier message of its aggregate is pending, parked
+-- or failed, so the messages of one aggregate are published in sequence.
+UPDATE outbox_messages
+SET locked_by = sqlc.narg('locked_by'),
+    locked_until = NOW() + make_interval(secs => sqlc.arg('lease_seconds')::double precision)
+WHERE id IN (
+    SELECT id
+    FROM outbox_messages candidate
+    WHERE status = 'PENDING'
+      AND (locked_until IS NULL OR locked_until < NOW())
+      AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
+      AND NOT EXISTS (
+          SELECT 1
+          FROM outbox_messages earlier
+          WHERE earlier.aggregate_id = candidate.aggregate_id
+            AND earlier.sequence_number < candidate.sequence_number
+            AND earlier.status IN ('PENDING', 'PARKED', 'FAILED')
+      )
+    ORDER BY created_at ASC
+    LIMIT sqlc.arg('batch_size')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
 -- name: MarkOutboxMessageProcessed :exec
 UPDATE outbox_messages
-SET status = 'processed',
-    processed_at = $2
+SET status = 'PROCESSED',
+    processed_at = $2,
+    locked_by = NULL,
+    locked_until = NULL
 WHERE id = $1;
 
 -- name: MarkOutboxMessageFailed :exec
 UPDATE outbox_messages
-SET status = 'failed',
+SET status = 'FAILED',
     attempt_count = attempt_count + 1,
-    error_message = $2
+    error_message = $2,
+    failed_at = NOW(),
+    next_attempt_at = NULL,
+    locked_by = NULL,
+    locked_until = NULL
 WHERE id = $1;
 
 -- name: GetOutboxMessageByID :one
@@ -39,9 +86,169 @@ WHERE id = $1;
 DELETE FROM outbox_messages
 WHERE id = $1;
 
+-- name: ScheduleOutboxRetry :exec
+-- Records a failed attempt and releases the message until the retry is due
+UPDATE outbox_messages
+SET attempt_count = attempt_count + 1,
+    error_message = sqlc.narg('error_message'),
+    next_attempt_at = NOW() + make_interval(secs => sqlc.arg('delay_seconds')::double precision),
+    locked_by = NULL,
+    locked_until = NULL
+WHERE id = sqlc.arg('id');
+
+-- name: ClaimDeadLetterOutboxMessages :many
+-- Claims failed messages that still have to be forwarded to the dead-letter topic
+UPDATE outbox_messages
+SET locked_by = sqlc.narg('locked_by'),
+    locked_until = NOW() + make_interval(secs => sqlc.arg('lease_seconds')::double precision)
+WHERE id IN (
+    SELECT id
+    FROM outbox_messages
+    WHERE status = 'FAILED'
+      AND dead_lettered_at IS NULL
+      AND dead_letter_attempts < sqlc.arg('max_attempts')
+      AND (locked_until IS NULL OR locked_until < NOW())
+    ORDER BY failed_at ASC
+    LIMIT sqlc.arg('batch_size')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkOutboxMessageDeadLettered :exec
+UPDATE outbox_messages
+SET dead_lettered_at = NOW(),
+    locked_by = NULL,
+    locked_until = NULL
+WHERE id = $1;
 
--- name: IncrementAttempt :exec
+-- name: RecordOutboxDeadLetterFailure :exec
 UPDATE outbox_messages
-SET attempt_count = attempt_count + 1
+SET dead_letter_attempts = dead_letter_attempts + 1,
+    locked_by = NULL,
+    locked_until = NULL
 WHERE id = $1;
-    
\ No newline at end of file
+
+-- name: ListFailedOutboxMessages :many
+SELECT *
+FROM outbox_messages
+WHERE status = 'FAILED'
+  AND (cardinality(sqlc.arg('ids')::uuid[]) = 0 OR id = ANY(sqlc.arg('ids')::uuid[]))
+  AND (sqlc.narg('event_type')::text IS NULL OR event_type = sqlc.narg('event_type'))
+  AND (sqlc.narg('failed_after')::timestamp IS NULL OR failed_at >= sqlc.narg('failed_after'))
+  AND (sqlc.narg('failed_before')::timestamp IS NULL OR failed_at < sqlc.narg('failed_before'))
+ORDER BY failed_at DESC, id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: UpdateFailedOutboxMessagePayload :execrows
+-- The new payload is written with the schema version given, the current one of its event type
+UPDATE outbox_messages
+SET payload = $2,
+    schema_version = $3
+WHERE id = $1 AND status = 'FAILED';
+
+-- name: RequeueFailedOutboxMessages :execrows
+-- Moves failed messages back to PENDING with a fresh set of attempts
+UPDATE outbox_messages
+SET status = 'PENDING',
+    attempt_count = 0,
+    next_attempt_at = NULL,
+    failed_at = NULL,
+    dead_lettered_at = NULL,
+    dead_letter_attempts = 0,
+    locked_by = NULL,
+    locked_until = NULL
+WHERE status = 'FAILED'
+  AND (cardinality(sqlc.arg('ids')::uuid[]) = 0 OR id = ANY(sqlc.arg('ids')::uuid[]))
+  AND (sqlc.narg('event_type')::text IS NULL OR event_type = sqlc.narg('event_type'))
+  AND (sqlc.narg('failed_after')::timestamp IS NULL OR failed_at >= sqlc.narg('failed_after'))
+  AND (sqlc.narg('failed_before')::timestamp IS NULL OR failed_at < sqlc.narg('failed_before'));
+
+-- name: DiscardFailedOutboxMessages :execrows
+-- Gives up on failed messages, they stay in the table for reference
+UPDATE outbox_messages
+SET status = 'DISCARDED',
+    locked_by = NULL,
+    locked_until = NULL
+WHERE status = 'FAILED'
+  AND (cardinality(sqlc.arg('ids')::uuid[]) = 0 OR id = ANY(sqlc.arg('ids')::uuid[]))
+  AND (sqlc.narg('event_type')::text IS NULL OR event_type = sqlc.narg('event_type'))
+  AND (sqlc.narg('failed_after')::timestamp IS NULL OR failed_at >= sqlc.narg('failed_after'))
+  AND (sqlc.narg('failed_before')::timestamp IS NULL OR failed_at < sqlc.narg('failed_before'));
+
+-- name: ParkOutboxMessage :exec
+-- Sets aside a message of an event type this service cannot publish
+UPDATE outbox_messages
+SET status = 'PARKED',
+    error_message = $2,
+    locked_by = NULL,
+    locked_until = NULL
+WHERE id = $1;
+
+-- name: UnparkOutboxMessages :execrows
+-- Returns parked messages of event types that can be published again
+UPDATE outbox_messages
+SET status = 'PENDING'
+WHERE status = 'PARKED'
+  AND event_type = ANY(sqlc.arg('event_types')::text[]);
+
+-- name: HasUnpublishedOutboxPredecessor :one
+-- Reports whether an earlier message of the aggregate is still pending, parked or failed
+SELECT EXISTS (
+    SELECT 1
+    FROM outbox_messages
+    WHERE aggregate_id = $1
+      AND sequence_number < $2
+      AND status IN ('PENDING', 'PARKED', 'FAILED')
+);
+
+-- name: ListHeldOutboxMessages :many
+-- Lists the pending messages of the aggregate after the sequence number, in sequence
+SELECT *
+FROM outbox_messages
+WHERE aggregate_id = $1
+  AND sequence_number > $2
+  AND status = 'PENDING'
+ORDER BY sequence_number ASC;
+
+-- name: NotifyOutboxMessage :exec
+-- Wakes listening workers once the surrounding transaction commits
+SELECT pg_notify(sqlc.arg('channel')::text, sqlc.arg('payload')::text);
+
+-- name: CreateOutboxArchivePartitions :exec
+-- Creates the archive partitions for the messages processed before the cutoff
+SELECT create_outbox_archive_partitions(
+    (SELECT MIN(processed_at) FROM outbox_messages WHERE status = 'PROCESSED' AND processed_at < sqlc.arg('cutoff')::timestamp),
+    sqlc.arg('cutoff')::timestamp
+);
+
+-- name: ArchiveProcessedOutboxMessages :execrows
+-- Moves a batch of messages processed before the cutoff to the archive
+WITH archived AS (
+    DELETE FROM outbox_messages
+    WHERE id IN (
+        SELECT id FROM outbox_messages
+        WHERE status = 'PROCESSED'
+          AND processed_at < sqlc.arg('cutoff')::timestamp
+        ORDER BY processed_at
+        LIMIT sqlc.arg('batch_size')
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING id, aggregate_id, event_type, payload, message_id, created_at, processed_at, attempt_count, error_message, sequence_number, schema_version
+)
+INSERT INTO outbox_messages_archive (
+    id, aggregate_id, event_type, payload, message_id, created_at, processed_at, attempt_count, error_message, sequence_number, schema_version
+)
+SELECT id, aggregate_id, event_type, payload, message_id, created_at, processed_at, attempt_count, error_message, sequence_number, schema_version
+FROM archived;
+
+-- name: DeleteProcessedOutboxMessages :execrows
+-- Deletes a batch of messages processed before the cutoff without archiving them
+DELETE FROM outbox_messages
+WHERE id IN (
+    SELECT id FROM outbox_messages
+    WHERE status = 'PROCESSED'
+      AND processed_at < sqlc.arg('cutoff')::timestamp
+    ORDER BY processed_at
+    LIMIT sqlc.arg('batch_size')
+    FOR UPDATE SKIP LOCKED
+);
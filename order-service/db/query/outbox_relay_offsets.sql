@@ -0,0 +1,13 @@
+-- name: GetOutboxRelayOffset :one
+SELECT lsn FROM outbox_relay_offsets
+WHERE slot_name = $1;
+
+-- name: SaveOutboxRelayOffset :exec
+INSERT INTO outbox_relay_offsets (
+    slot_name, lsn, updated_at
+) VALUES (
+    $1, $2, NOW()
+)
+ON CONFLICT (slot_name) DO UPDATE
+SET lsn = EXCLUDED.lsn,
+    updated_at = EXCLUDED.updated_at;
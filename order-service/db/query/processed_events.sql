@@ -0,0 +1,19 @@
+-- name: CreateProcessedEvent :execrows
+-- Records the event as processed by the consumer, no row is inserted when it was before
+INSERT INTO processed_events (
+    event_id, consumer_name, event_type, processed_at
+) VALUES (
+    $1, $2, $3, NOW()
+)
+ON CONFLICT (event_id, consumer_name) DO NOTHING;
+
+-- name: DeleteProcessedEvents :execrows
+-- Deletes a batch of events processed before the cutoff
+DELETE FROM processed_events
+WHERE (event_id, consumer_name) IN (
+    SELECT event_id, consumer_name FROM processed_events
+    WHERE processed_at < sqlc.arg('cutoff')::timestamp
+    ORDER BY processed_at
+    LIMIT sqlc.arg('batch_size')
+    FOR UPDATE SKIP LOCKED
+);
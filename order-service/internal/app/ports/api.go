@@ -3,14 +3,34 @@ package ports
 import (
 	"context"
 	"order-service/internal/domain"
+
+	"github.com/google/uuid"
 )
 
 type OrderUseCase interface {
-	CreateOrder(ctx context.Context, customerID string, items []domain.OrderItem) (*domain.Order, error)
+	CreateOrder(ctx context.Context, customerID string, currency domain.Currency, items []domain.OrderItem) (*domain.Order, error)
 	GetOrder(ctx context.Context, id string) (*domain.Order, error)
 	UpdateOrderStatus(ctx context.Context, id string, status domain.OrderStatus) error
-	AddOrderItem(ctx context.Context, orderID string, productID string, quantity int32, price float64) error
+	AddOrderItem(ctx context.Context, orderID string, productID string, quantity int32, price domain.Money) error
 	RemoveOrderItem(ctx context.Context, orderID string, itemID string) error
 	CancelOrder(ctx context.Context, id string) error
-	ListOrders(ctx context.Context, limit, offset int) ([]*domain.Order, error)
+	ListOrders(ctx context.Context, query domain.OrderListQuery) (*domain.OrderPage, error)
+}
+
+// OrderSagaUseCase advances the saga of an order with the outcomes reported by the inventory
+// and the payment service. Every step runs with the repositories of the caller's transaction.
+type OrderSagaUseCase interface {
+	ProductsReserved(ctx context.Context, factory RepositoryFactory, orderID uuid.UUID) error
+	ProductsReservationFailed(ctx context.Context, factory RepositoryFactory, orderID uuid.UUID, reason string) error
+	PaymentApproved(ctx context.Context, factory RepositoryFactory, orderID uuid.UUID) error
+	PaymentDeclined(ctx context.Context, factory RepositoryFactory, orderID uuid.UUID, reason string) error
+}
+
+// OutboxAdminUseCase lets operators inspect and repair failed outbox messages
+type OutboxAdminUseCase interface {
+	ListFailedMessages(ctx context.Context, filter domain.OutboxMessageFilter, limit int) ([]domain.OutboxMessage, error)
+	GetMessage(ctx context.Context, id uuid.UUID) (*domain.OutboxMessage, error)
+	UpdatePayload(ctx context.Context, id uuid.UUID, payload []byte) error
+	Requeue(ctx context.Context, filter domain.OutboxMessageFilter) (int64, error)
+	Discard(ctx context.Context, filter domain.OutboxMessageFilter) (int64, error)
 }
@@ -0,0 +1,13 @@
+package ports
+
+import (
+	"context"
+	"order-service/internal/domain"
+)
+
+// ExchangeRateProvider supplies the rates used to convert item prices into the order currency
+type ExchangeRateProvider interface {
+	// GetRate returns the price of one unit of from expressed in to.
+	// It returns domain.ErrExchangeRateNotFound if the pair is not known.
+	GetRate(ctx context.Context, from, to domain.Currency) (domain.ExchangeRate, error)
+}
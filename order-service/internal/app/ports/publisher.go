@@ -1,7 +1,94 @@
 package ports
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"order-service/internal/domain"
 
+	"github.com/google/uuid"
+)
+
+// EventPublisher publishes events to a topic. Events implementing AggregateEvent
+// are keyed by their aggregate, so the events of one aggregate keep their order.
+// Events implementing TypedEvent are published with their type,
+// events implementing VersionedEvent with their schema version.
 type EventPublisher interface {
 	Publish(ctx context.Context, topic string, event interface{}) error
 }
+
+// TypedEvent is an event that knows its event type
+type TypedEvent interface {
+	EventType() string
+}
+
+// VersionedEvent is an event that knows the schema version of its payload
+type VersionedEvent interface {
+	SchemaVersion() int
+}
+
+// TopicRouter resolves the topic events of a type are published to
+type TopicRouter interface {
+	Topic(eventType string) (string, error)
+}
+
+// AggregateEvent is an event about a single aggregate
+type AggregateEvent interface {
+	AggregateID() uuid.UUID
+}
+
+// Envelope is an event together with the metadata it is published with.
+// Publishers send it as a CloudEvent.
+type Envelope struct {
+	// ID identifies the event, consumers use it to drop duplicates. A random ID is used when empty.
+	ID    string
+	Topic string
+	// Key selects the partition. Events with the same key keep their order.
+	Key string
+	// AggregateID identifies the aggregate the event is about, it becomes the event's subject
+	AggregateID   string
+	EventType     string
+	SchemaVersion int
+	// CorrelationID and CausationID default to the IDs carried by the context
+	CorrelationID string
+	CausationID   string
+	// ContentType describes the encoding of the payload, JSON when empty
+	ContentType string
+	// Extensions are added to the event as CloudEvents extension attributes.
+	// Names consist of lowercase letters and digits.
+	Extensions map[string]string
+	Event      interface{}
+}
+
+// EnvelopePublisher publishes events with a key and headers supplied by the caller
+type EnvelopePublisher interface {
+	EventPublisher
+	PublishEnvelope(ctx context.Context, envelope Envelope) error
+}
+
+// BatchPublisher publishes several envelopes in order. A transactional publisher makes
+// them visible to consumers reading committed messages all together or not at all.
+// Otherwise the envelopes before a failed one stay published, which PublishBatch
+// reports with a *BatchError.
+type BatchPublisher interface {
+	PublishBatch(ctx context.Context, envelopes []Envelope) error
+}
+
+// BatchError reports that the envelopes of a batch before Index were published
+// and the envelope at Index failed with Err. The envelopes after it were not published.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("envelope %d of batch: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// DeadLetterPublisher forwards outbox messages that ran out of attempts to a dead-letter topic
+type DeadLetterPublisher interface {
+	PublishDeadLetter(ctx context.Context, topic string, msg domain.OutboxMessage) error
+}
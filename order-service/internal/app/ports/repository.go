@@ -1,25 +1,110 @@
-package domain
+package ports
 
 import (
 	"context"
+	"order-service/internal/domain"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 // OrderRepository defines the interface for order data access
 type OrderRepository interface {
-	Create(ctx context.Context, order *Order) error
-	GetByID(ctx context.Context, id string) (*Order, error)
-	Update(ctx context.Context, order *Order) error
+	Create(ctx context.Context, order *domain.Order) error
+	GetByID(ctx context.Context, id string) (*domain.Order, error)
+	// GetByIDForUpdate returns the order and locks it until the transaction ends.
+	// Every read-modify-write of an order loads it this way, so concurrent changes are not lost.
+	GetByIDForUpdate(ctx context.Context, id string) (*domain.Order, error)
+	Update(ctx context.Context, order *domain.Order) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, limit, offset int) ([]*Order, error)
+	List(ctx context.Context, query domain.OrderListQuery) (*domain.OrderPage, error)
 }
 
 // OutboxRepository defines the interface for outbox operations
 type OutboxRepository interface {
+	// CreateMessage stores the payload with its schema version, 1 unless it implements VersionedEvent
 	CreateMessage(ctx context.Context, aggregateID uuid.UUID, eventType string, payload interface{}) error
-	GetPendingMessages(ctx context.Context, limit int) ([]OutboxMessage, error)
+	// ClaimPendingMessages leases up to limit pending messages to the worker.
+	// A message is handed to at most one worker until its lease expires.
+	ClaimPendingMessages(ctx context.Context, workerID string, limit int, lease time.Duration) ([]domain.OutboxMessage, error)
 	MarkMessageAsProcessed(ctx context.Context, messageID uuid.UUID) error
+	// MarkMessageAsFailed moves the message to the terminal FAILED state
 	MarkMessageAsFailed(ctx context.Context, messageID uuid.UUID, reason string) error
-	IncrementAttempt(ctx context.Context, messageID uuid.UUID) error
+	// ScheduleRetry records a failed attempt and makes the message claimable again after the delay
+	ScheduleRetry(ctx context.Context, messageID uuid.UUID, delay time.Duration, lastError string) error
+	// ClaimDeadLetters leases up to limit failed messages that have not been forwarded
+	// to the dead-letter topic yet and were tried there fewer than maxAttempts times
+	ClaimDeadLetters(ctx context.Context, workerID string, limit, maxAttempts int, lease time.Duration) ([]domain.OutboxMessage, error)
+	MarkMessageAsDeadLettered(ctx context.Context, messageID uuid.UUID) error
+	// RecordDeadLetterFailure counts a failed attempt to forward the message to the dead-letter topic
+	RecordDeadLetterFailure(ctx context.Context, messageID uuid.UUID) error
+	// ParkMessage sets aside a message that cannot be published, without using up its attempts
+	ParkMessage(ctx context.Context, messageID uuid.UUID, reason string) error
+	// UnparkMessages returns parked messages of the given event types to PENDING
+	UnparkMessages(ctx context.Context, eventTypes []string) (int64, error)
+}
+
+// OutboxRelayRepository is the outbox access of the relay. Besides the inserted messages
+// the relay publishes messages that return to PENDING and the messages held back behind them.
+type OutboxRelayRepository interface {
+	OutboxRepository
+	GetMessage(ctx context.Context, messageID uuid.UUID) (*domain.OutboxMessage, error)
+	// HasUnpublishedPredecessor reports whether an earlier message of the aggregate
+	// is pending, parked or failed, in which case msg has to wait
+	HasUnpublishedPredecessor(ctx context.Context, msg domain.OutboxMessage) (bool, error)
+	// ListHeldMessages returns the pending messages of the aggregate after the sequence number, in sequence
+	ListHeldMessages(ctx context.Context, aggregateID uuid.UUID, after int64) ([]domain.OutboxMessage, error)
+}
+
+// OutboxAdminRepository defines the operator access to failed outbox messages.
+// Only messages in the FAILED state are listed, edited, requeued or discarded.
+type OutboxAdminRepository interface {
+	ListFailedMessages(ctx context.Context, filter domain.OutboxMessageFilter, limit int) ([]domain.OutboxMessage, error)
+	GetMessage(ctx context.Context, messageID uuid.UUID) (*domain.OutboxMessage, error)
+	// UpdateFailedMessagePayload replaces the payload, which was written with the given schema version
+	UpdateFailedMessagePayload(ctx context.Context, messageID uuid.UUID, payload []byte, schemaVersion int) error
+	// RequeueFailedMessages moves the matching messages back to PENDING and returns how many were requeued
+	RequeueFailedMessages(ctx context.Context, filter domain.OutboxMessageFilter) (int64, error)
+	// DiscardFailedMessages moves the matching messages to DISCARDED and returns how many were discarded
+	DiscardFailedMessages(ctx context.Context, filter domain.OutboxMessageFilter) (int64, error)
+}
+
+// OutboxRetentionRepository removes processed messages from the outbox table in batches
+type OutboxRetentionRepository interface {
+	// ArchiveProcessedMessages moves up to limit messages processed before the cutoff
+	// to the archive table and returns how many were moved
+	ArchiveProcessedMessages(ctx context.Context, before time.Time, limit int) (int64, error)
+	// DeleteProcessedMessages deletes up to limit messages processed before the cutoff
+	// and returns how many were deleted
+	DeleteProcessedMessages(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+// InboxRepository records the events handled by consumers, so redelivered events are skipped
+type InboxRepository interface {
+	// MarkProcessed records the event as processed by the consumer. It returns false when the
+	// consumer processed the event before, in which case its side effects must not be repeated.
+	MarkProcessed(ctx context.Context, eventID, consumerName, eventType string) (bool, error)
+}
+
+// InboxRetentionRepository removes the records of events processed long ago in batches
+type InboxRetentionRepository interface {
+	// DeleteProcessedEvents deletes up to limit events processed before the cutoff
+	// and returns how many were deleted
+	DeleteProcessedEvents(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+// OrderSagaRepository stores the progress of the saga of every order
+type OrderSagaRepository interface {
+	Create(ctx context.Context, saga *domain.OrderSaga) error
+	// GetForUpdate returns the saga of the order and locks it until the transaction ends.
+	// It returns domain.ErrOrderSagaNotFound if the order has no saga.
+	GetForUpdate(ctx context.Context, orderID uuid.UUID) (*domain.OrderSaga, error)
+	Update(ctx context.Context, saga *domain.OrderSaga) error
+}
+
+// RelayOffsetRepository persists how far the outbox relay has read a logical replication slot
+type RelayOffsetRepository interface {
+	// GetOffset returns the last saved WAL position of the slot, 0 if none was saved
+	GetOffset(ctx context.Context, slotName string) (uint64, error)
+	SaveOffset(ctx context.Context, slotName string, lsn uint64) error
 }
@@ -2,20 +2,22 @@ package ports
 
 import (
 	"context"
-	"database/sql"
 )
 
 // UnitOfWork defines the interface for managing transactions
 type UnitOfWork interface {
 	// Execute the function within the transaction
-	Execute(ctx context.Context, fn func(*sql.Tx) error) error
+	Execute(ctx context.Context, fn func(factory RepositoryFactory) error) error
 }
 
 // RepositoryFactory creates repositories for use within a transaction
 type RepositoryFactory interface {
-	// GetRepository returns a repository for the requested type
-	// The type parameter allows for compile-time type checking
-	GetRepository(ctx context.Context, requestID string) (interface{}, error)
+	// OrderRepository returns an order repository bound to the transaction
+	OrderRepository() OrderRepository
+	// OutboxRepository returns an outbox repository bound to the transaction
+	OutboxRepository() OutboxRepository
+	// InboxRepository returns an inbox repository bound to the transaction
+	InboxRepository() InboxRepository
+	// OrderSagaRepository returns an order saga repository bound to the transaction
+	OrderSagaRepository() OrderSagaRepository
 }
-
-// EventPublisher publishes events to external systems
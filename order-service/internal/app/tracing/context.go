@@ -0,0 +1,48 @@
+// Package tracing carries the IDs that tie events back to the request that caused them.
+package tracing
+
+import "context"
+
+// contextKey is unexported so only this package can set or read the values
+type contextKey int
+
+const (
+	traceIDKey contextKey = iota
+	correlationIDKey
+	causationIDKey
+)
+
+// WithTraceID returns a context carrying the distributed trace ID
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey, traceID)
+}
+
+// TraceID returns the trace ID of the context, empty if there is none
+func TraceID(ctx context.Context) string {
+	id, _ := ctx.Value(traceIDKey).(string)
+	return id
+}
+
+// WithCorrelationID returns a context carrying the ID shared by everything
+// that happens because of one request, across services
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey, correlationID)
+}
+
+// CorrelationID returns the correlation ID of the context, empty if there is none
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey).(string)
+	return id
+}
+
+// WithCausationID returns a context carrying the ID of the request or message
+// that directly caused the work done with the context
+func WithCausationID(ctx context.Context, causationID string) context.Context {
+	return context.WithValue(ctx, causationIDKey, causationID)
+}
+
+// CausationID returns the causation ID of the context, empty if there is none
+func CausationID(ctx context.Context) string {
+	id, _ := ctx.Value(causationIDKey).(string)
+	return id
+}
@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"order-service/internal/app/ports"
+	"order-service/internal/domain"
+	event "order-service/internal/events"
+
+	"github.com/google/uuid"
+)
+
+// OrderSagaUseCase implements the choreography saga of an order.
+// A pending order is confirmed once its products are reserved and its payment approved,
+// and fails as soon as either is refused. Steps that succeeded for an order that failed
+// or was cancelled are compensated with order.cancelled, so stock is released and payments
+// are refunded. The saga emits order.cancelled at most once.
+type OrderSagaUseCase struct{}
+
+// NewOrderSagaUseCase creates a new order saga use case
+func NewOrderSagaUseCase() *OrderSagaUseCase {
+	return &OrderSagaUseCase{}
+}
+
+// ProductsReserved implements ports.OrderSagaUseCase.
+func (uc *OrderSagaUseCase) ProductsReserved(ctx context.Context, factory ports.RepositoryFactory, orderID uuid.UUID) error {
+	return uc.succeed(ctx, factory, orderID, func(saga *domain.OrderSaga) {
+		saga.ProductsReserved = true
+	})
+}
+
+// PaymentApproved implements ports.OrderSagaUseCase.
+func (uc *OrderSagaUseCase) PaymentApproved(ctx context.Context, factory ports.RepositoryFactory, orderID uuid.UUID) error {
+	return uc.succeed(ctx, factory, orderID, func(saga *domain.OrderSaga) {
+		saga.PaymentApproved = true
+	})
+}
+
+// ProductsReservationFailed implements ports.OrderSagaUseCase.
+func (uc *OrderSagaUseCase) ProductsReservationFailed(ctx context.Context, factory ports.RepositoryFactory, orderID uuid.UUID, reason string) error {
+	return uc.fail(ctx, factory, orderID, "products could not be reserved: "+reason)
+}
+
+// PaymentDeclined implements ports.OrderSagaUseCase.
+func (uc *OrderSagaUseCase) PaymentDeclined(ctx context.Context, factory ports.RepositoryFactory, orderID uuid.UUID, reason string) error {
+	return uc.fail(ctx, factory, orderID, "payment declined: "+reason)
+}
+
+// succeed records a successful step. The order is confirmed when it was the last step
+// missing, and the step is compensated when the order ended without it.
+func (uc *OrderSagaUseCase) succeed(
+	ctx context.Context,
+	factory ports.RepositoryFactory,
+	orderID uuid.UUID,
+	record func(saga *domain.OrderSaga),
+) error {
+	order, saga, err := uc.load(ctx, factory, orderID)
+	if err != nil {
+		return err
+	}
+
+	record(saga)
+	switch {
+	case order.Status == domain.OrderStatusPending && saga.Completed():
+		err = uc.changeOrderStatus(ctx, factory, order, domain.OrderStatusConfirmed, "products reserved and payment approved")
+	case order.Status == domain.OrderStatusFailed || order.Status == domain.OrderStatusCancelled:
+		err = uc.compensate(ctx, factory, order, saga, order.Status)
+	}
+	if err != nil {
+		return err
+	}
+
+	return factory.OrderSagaRepository().Update(ctx, saga)
+}
+
+// fail fails a pending order and compensates the steps that succeeded before.
+// Orders that are no longer pending have already been decided.
+func (uc *OrderSagaUseCase) fail(ctx context.Context, factory ports.RepositoryFactory, orderID uuid.UUID, reason string) error {
+	order, saga, err := uc.load(ctx, factory, orderID)
+	if err != nil {
+		return err
+	}
+
+	if order.Status != domain.OrderStatusPending {
+		log.Printf("Ignoring failed saga step of order %s in status %s: %s", order.ID, order.Status, reason)
+		return nil
+	}
+
+	if err := uc.changeOrderStatus(ctx, factory, order, domain.OrderStatusFailed, reason); err != nil {
+		return err
+	}
+
+	if !saga.ProductsReserved && !saga.PaymentApproved {
+		return nil
+	}
+	if err := uc.compensate(ctx, factory, order, saga, domain.OrderStatusPending); err != nil {
+		return err
+	}
+	return factory.OrderSagaRepository().Update(ctx, saga)
+}
+
+// load returns the order and its saga, which stays locked until the transaction ends
+func (uc *OrderSagaUseCase) load(
+	ctx context.Context,
+	factory ports.RepositoryFactory,
+	orderID uuid.UUID,
+) (*domain.Order, *domain.OrderSaga, error) {
+	saga, err := factory.OrderSagaRepository().GetForUpdate(ctx, orderID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get saga of order %s: %w", orderID, err)
+	}
+
+	order, err := factory.OrderRepository().GetByIDForUpdate(ctx, orderID.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	order.SagaID = saga.ID
+
+	return order, saga, nil
+}
+
+// changeOrderStatus moves the order to the given status and emits order.status_changed
+func (uc *OrderSagaUseCase) changeOrderStatus(
+	ctx context.Context,
+	factory ports.RepositoryFactory,
+	order *domain.Order,
+	status domain.OrderStatus,
+	reason string,
+) error {
+	previousStatus := order.Status
+	if err := order.TransitionTo(status, reason); err != nil {
+		return err
+	}
+
+	if err := factory.OrderRepository().Update(ctx, order); err != nil {
+		return fmt.Errorf("failed to update order: %w", err)
+	}
+
+	return uc.writeOutboxEvent(ctx, factory, order.ID, event.OrderStatusChangedEventType, event.OrderStatusChangedEvent{
+		EventID:        uuid.New(),
+		OrderID:        order.ID,
+		PreviousStatus: previousStatus,
+		Status:         order.Status,
+		ChangedAt:      order.UpdatedAt,
+	})
+}
+
+// compensate emits order.cancelled for an order that will not be fulfilled, so the inventory
+// releases its reserved products and the payment service refunds its approved payment.
+// It marks the saga as compensated, the caller saves it.
+func (uc *OrderSagaUseCase) compensate(
+	ctx context.Context,
+	factory ports.RepositoryFactory,
+	order *domain.Order,
+	saga *domain.OrderSaga,
+	previousStatus domain.OrderStatus,
+) error {
+	// order.cancelled covers the whole order, so the steps succeeding later need nothing else
+	if saga.Compensated {
+		log.Printf("Saga %s of order %s is already compensated", saga.ID, order.ID)
+		return nil
+	}
+	saga.Compensated = true
+
+	log.Printf("Compensating saga %s of order %s, products reserved: %t, payment approved: %t",
+		saga.ID, order.ID, saga.ProductsReserved, saga.PaymentApproved)
+
+	return uc.writeOutboxEvent(ctx, factory, order.ID, event.OrderCancelledEventType, event.OrderCancelledEvent{
+		EventID:        uuid.New(),
+		SagaID:         saga.ID,
+		OrderID:        order.ID,
+		CustomerID:     order.CustomerID,
+		PreviousStatus: previousStatus,
+		Items:          order.Items,
+		CancelledAt:    order.UpdatedAt,
+	})
+}
+
+// writeOutboxEvent stores an event in the outbox within the current unit of work
+func (uc *OrderSagaUseCase) writeOutboxEvent(
+	ctx context.Context,
+	factory ports.RepositoryFactory,
+	aggregateID uuid.UUID,
+	eventType string,
+	payload interface{},
+) error {
+	if err := factory.OutboxRepository().CreateMessage(ctx, aggregateID, eventType, payload); err != nil {
+		return fmt.Errorf("failed to create outbox message: %w", err)
+	}
+
+	return nil
+}
+
+var _ ports.OrderSagaUseCase = (*OrderSagaUseCase)(nil)
@@ -0,0 +1,185 @@
+package usecase_test
+
+import (
+	"context"
+	"order-service/internal/app/usecase"
+	"order-service/internal/domain"
+	"order-service/internal/events"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOrderSaga(t *testing.T) {
+	sagaUseCase := usecase.NewOrderSagaUseCase()
+
+	testCases := []struct {
+		name             string
+		status           domain.OrderStatus
+		productsReserved bool
+		paymentApproved  bool
+		compensated      bool
+		step             func(ctx context.Context, uow *mockUnitOfWork, orderID uuid.UUID) error
+		expectedStatus   domain.OrderStatus
+		expectSagaUpdate bool
+		expectedEvents   []string
+	}{
+		{
+			name:   "Products reserved before the payment is approved",
+			status: domain.OrderStatusPending,
+			step: func(ctx context.Context, uow *mockUnitOfWork, orderID uuid.UUID) error {
+				return sagaUseCase.ProductsReserved(ctx, uow, orderID)
+			},
+			expectedStatus:   domain.OrderStatusPending,
+			expectSagaUpdate: true,
+		},
+		{
+			name:             "Payment approved after the products are reserved confirms the order",
+			status:           domain.OrderStatusPending,
+			productsReserved: true,
+			step: func(ctx context.Context, uow *mockUnitOfWork, orderID uuid.UUID) error {
+				return sagaUseCase.PaymentApproved(ctx, uow, orderID)
+			},
+			expectedStatus:   domain.OrderStatusConfirmed,
+			expectSagaUpdate: true,
+			expectedEvents:   []string{events.OrderStatusChangedEventType},
+		},
+		{
+			name:             "Payment declined after the products are reserved releases them",
+			status:           domain.OrderStatusPending,
+			productsReserved: true,
+			step: func(ctx context.Context, uow *mockUnitOfWork, orderID uuid.UUID) error {
+				return sagaUseCase.PaymentDeclined(ctx, uow, orderID, "insufficient funds")
+			},
+			expectedStatus:   domain.OrderStatusFailed,
+			expectSagaUpdate: true,
+			expectedEvents:   []string{events.OrderStatusChangedEventType, events.OrderCancelledEventType},
+		},
+		{
+			name:   "Reservation failed before any step succeeded",
+			status: domain.OrderStatusPending,
+			step: func(ctx context.Context, uow *mockUnitOfWork, orderID uuid.UUID) error {
+				return sagaUseCase.ProductsReservationFailed(ctx, uow, orderID, "out of stock")
+			},
+			expectedStatus: domain.OrderStatusFailed,
+			expectedEvents: []string{events.OrderStatusChangedEventType},
+		},
+		{
+			name:   "Payment approved for a failed order is compensated",
+			status: domain.OrderStatusFailed,
+			step: func(ctx context.Context, uow *mockUnitOfWork, orderID uuid.UUID) error {
+				return sagaUseCase.PaymentApproved(ctx, uow, orderID)
+			},
+			expectedStatus:   domain.OrderStatusFailed,
+			expectSagaUpdate: true,
+			expectedEvents:   []string{events.OrderCancelledEventType},
+		},
+		{
+			name:   "Products reserved for a cancelled order are released",
+			status: domain.OrderStatusCancelled,
+			step: func(ctx context.Context, uow *mockUnitOfWork, orderID uuid.UUID) error {
+				return sagaUseCase.ProductsReserved(ctx, uow, orderID)
+			},
+			expectedStatus:   domain.OrderStatusCancelled,
+			expectSagaUpdate: true,
+			expectedEvents:   []string{events.OrderCancelledEventType},
+		},
+		{
+			name:             "Payment approved for a compensated order is not compensated again",
+			status:           domain.OrderStatusFailed,
+			productsReserved: true,
+			compensated:      true,
+			step: func(ctx context.Context, uow *mockUnitOfWork, orderID uuid.UUID) error {
+				return sagaUseCase.PaymentApproved(ctx, uow, orderID)
+			},
+			expectedStatus:   domain.OrderStatusFailed,
+			expectSagaUpdate: true,
+		},
+		{
+			name:             "Payment declined for a failed order is ignored",
+			status:           domain.OrderStatusFailed,
+			productsReserved: true,
+			step: func(ctx context.Context, uow *mockUnitOfWork, orderID uuid.UUID) error {
+				return sagaUseCase.PaymentDeclined(ctx, uow, orderID, "card expired")
+			},
+			expectedStatus: domain.OrderStatusFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOrderRepo := new(mockOrderRepo)
+			mockOutboxRepo := new(mockOutboxRepo)
+			mockSagaRepo := new(mockOrderSagaRepo)
+			mockUoW := &mockUnitOfWork{mockOrderRepo: mockOrderRepo, mockOutboxRepo: mockOutboxRepo, mockOrderSagaRepo: mockSagaRepo}
+
+			order := newOrder(t, "customer-123", []domain.OrderItem{
+				{ID: uuid.New(), ProductID: "product-1", Quantity: 1, Price: usd("10.00")},
+			})
+			order.Status = tc.status
+			saga := domain.NewOrderSaga(order)
+			saga.ProductsReserved = tc.productsReserved
+			saga.PaymentApproved = tc.paymentApproved
+			saga.Compensated = tc.compensated
+
+			mockSagaRepo.On("GetForUpdate", mock.Anything, order.ID).Return(saga, nil)
+			mockOrderRepo.On("GetByIDForUpdate", mock.Anything, order.ID.String()).Return(order, nil)
+			if tc.expectSagaUpdate {
+				mockSagaRepo.On("Update", mock.Anything, saga).Return(nil)
+			}
+			if tc.expectedStatus != tc.status {
+				mockOrderRepo.On("Update", mock.Anything, order).Return(nil)
+			}
+			for _, eventType := range tc.expectedEvents {
+				mockOutboxRepo.On("CreateMessage", mock.Anything, order.ID, eventType, mock.Anything).Return(nil).Once()
+			}
+
+			err := tc.step(context.Background(), mockUoW, order.ID)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, order.Status)
+			assert.Equal(t, tc.compensated || slices.Contains(tc.expectedEvents, events.OrderCancelledEventType), saga.Compensated)
+			mockSagaRepo.AssertExpectations(t)
+			mockOrderRepo.AssertExpectations(t)
+			mockOutboxRepo.AssertExpectations(t)
+			mockOutboxRepo.AssertNumberOfCalls(t, "CreateMessage", len(tc.expectedEvents))
+			if !tc.expectSagaUpdate {
+				mockSagaRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestOrderSagaCompensationCarriesSagaID(t *testing.T) {
+	mockOrderRepo := new(mockOrderRepo)
+	mockOutboxRepo := new(mockOutboxRepo)
+	mockSagaRepo := new(mockOrderSagaRepo)
+	mockUoW := &mockUnitOfWork{mockOrderRepo: mockOrderRepo, mockOutboxRepo: mockOutboxRepo, mockOrderSagaRepo: mockSagaRepo}
+
+	order := newOrder(t, "customer-123", []domain.OrderItem{
+		{ID: uuid.New(), ProductID: "product-1", Quantity: 2, Price: usd("10.00")},
+	})
+	saga := domain.NewOrderSaga(order)
+	saga.PaymentApproved = true
+	// Orders are loaded without their saga ID
+	order.SagaID = uuid.Nil
+
+	mockSagaRepo.On("GetForUpdate", mock.Anything, order.ID).Return(saga, nil)
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, order.ID.String()).Return(order, nil)
+	mockOrderRepo.On("Update", mock.Anything, order).Return(nil)
+	mockSagaRepo.On("Update", mock.Anything, saga).Return(nil)
+	mockOutboxRepo.On("CreateMessage", mock.Anything, order.ID, events.OrderStatusChangedEventType, mock.Anything).Return(nil)
+	mockOutboxRepo.On("CreateMessage", mock.Anything, order.ID, events.OrderCancelledEventType, mock.MatchedBy(func(e events.OrderCancelledEvent) bool {
+		return e.SagaID == saga.ID && e.PreviousStatus == domain.OrderStatusPending && len(e.Items) == 1
+	})).Return(nil)
+
+	err := usecase.NewOrderSagaUseCase().ProductsReservationFailed(context.Background(), mockUoW, order.ID, "out of stock")
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OrderStatusFailed, order.Status)
+	assert.Equal(t, "products could not be reserved: out of stock", order.StatusHistory[len(order.StatusHistory)-1].Reason)
+	mockOutboxRepo.AssertExpectations(t)
+}
@@ -2,14 +2,11 @@ package usecase
 
 import (
 	"context"
-	"database/sql"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"order-service/internal/app/ports"
 	"order-service/internal/domain"
 	event "order-service/internal/events"
-	"order-service/internal/infrastructure/repository"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,54 +14,337 @@ import (
 
 // OrderUsecase implements the order business logic
 type OrderUseCase struct {
-	eventPublisher ports.EventPublisher
-	uow            ports.UnitOfWork
+	uow           ports.UnitOfWork
+	exchangeRates ports.ExchangeRateProvider
 }
 
-// AddOrderItem implements ports.OrderUseCase.
-func (uc *OrderUseCase) AddOrderItem(ctx context.Context, orderID string, productID string, quantity int32, price float64) error {
-	panic("unimplemented")
+// GetOrder implements ports.OrderUseCase.
+func (uc *OrderUseCase) GetOrder(ctx context.Context, id string) (*domain.Order, error) {
+	if id == "" {
+		return nil, domain.ErrInvalidOrderID
+	}
+
+	var order *domain.Order
+	err := uc.uow.Execute(ctx, func(factory ports.RepositoryFactory) error {
+		var err error
+		order, err = factory.OrderRepository().GetByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// ListOrders implements ports.OrderUseCase.
+// Pages are addressed by the cursor of the previous page. Without an explicit sort
+// the page continues in the sort of its cursor.
+func (uc *OrderUseCase) ListOrders(ctx context.Context, query domain.OrderListQuery) (*domain.OrderPage, error) {
+	if query.Limit <= 0 {
+		query.Limit = domain.DefaultOrderPageSize
+	}
+
+	if query.Limit > domain.MaxOrderPageSize {
+		query.Limit = domain.MaxOrderPageSize
+	}
+
+	if query.Sort.Field == "" {
+		query.Sort = domain.DefaultOrderSort
+		if query.After != nil {
+			query.Sort = query.After.Sort
+		}
+	}
+
+	if !query.Sort.Field.IsValid() {
+		return nil, domain.ErrInvalidOrderSort
+	}
+
+	// Totals in different currencies can not be compared, so they are only sorted within one
+	if query.Sort.Field == domain.OrderSortByTotalPrice && query.Filter.TotalCurrency() == "" {
+		return nil, fmt.Errorf("%w: sorting by total_price needs a currency filter", domain.ErrInvalidOrderSort)
+	}
+
+	if query.After != nil && query.After.Sort != query.Sort {
+		return nil, fmt.Errorf("%w: cursor belongs to a listing sorted by %s", domain.ErrInvalidCursor, query.After.Sort)
+	}
+
+	if err := query.Filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	var page *domain.OrderPage
+	err := uc.uow.Execute(ctx, func(factory ports.RepositoryFactory) error {
+		var err error
+		page, err = factory.OrderRepository().List(ctx, query)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// UpdateOrderStatus implements ports.OrderUseCase.
+func (uc *OrderUseCase) UpdateOrderStatus(ctx context.Context, id string, status domain.OrderStatus) error {
+	return uc.changeOrderStatus(ctx, id, status)
 }
 
 // CancelOrder implements ports.OrderUseCase.
 func (uc *OrderUseCase) CancelOrder(ctx context.Context, id string) error {
-	panic("unimplemented")
+	return uc.changeOrderStatus(ctx, id, domain.OrderStatusCancelled)
 }
 
-// GetOrder implements ports.OrderUseCase.
-func (uc *OrderUseCase) GetOrder(ctx context.Context, id string) (*domain.Order, error) {
-	panic("unimplemented")
+// changeOrderStatus moves an order to the given status, rejecting transitions
+// the order lifecycle does not allow. Cancellations emit order.cancelled,
+// every other change emits order.status_changed.
+func (uc *OrderUseCase) changeOrderStatus(ctx context.Context, id string, status domain.OrderStatus) error {
+	if id == "" {
+		return domain.ErrInvalidOrderID
+	}
+
+	return uc.uow.Execute(ctx, func(factory ports.RepositoryFactory) error {
+		// A cancellation compensates the saga, which is locked before the order
+		// like in the saga steps, so a step succeeding meanwhile waits for it
+		var saga *domain.OrderSaga
+		if status == domain.OrderStatusCancelled {
+			orderID, err := uuid.Parse(id)
+			if err != nil {
+				return domain.ErrInvalidOrderID
+			}
+
+			saga, err = factory.OrderSagaRepository().GetForUpdate(ctx, orderID)
+			if errors.Is(err, domain.ErrOrderSagaNotFound) {
+				// Every order has a saga, so the order does not exist
+				return domain.ErrOrderNotFound
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get saga of order %s: %w", orderID, err)
+			}
+		}
+
+		orderRepo := factory.OrderRepository()
+
+		order, err := orderRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		previousStatus := order.Status
+		if err := order.ChangeStatus(status); err != nil {
+			return err
+		}
+
+		if err := orderRepo.Update(ctx, order); err != nil {
+			return fmt.Errorf("failed to update order: %w", err)
+		}
+
+		if status == domain.OrderStatusCancelled {
+			return uc.compensate(ctx, factory, order, saga, previousStatus)
+		}
+
+		return uc.writeOutboxEvent(ctx, factory, order.ID, event.OrderStatusChangedEventType, event.OrderStatusChangedEvent{
+			EventID:        uuid.New(),
+			OrderID:        order.ID,
+			PreviousStatus: previousStatus,
+			Status:         order.Status,
+			ChangedAt:      order.UpdatedAt,
+		})
+	})
 }
 
-// ListOrders implements ports.OrderUseCase.
-func (uc *OrderUseCase) ListOrders(ctx context.Context, limit int, offset int) ([]*domain.Order, error) {
-	panic("unimplemented")
+// compensate emits order.cancelled for the saga of a cancelled order and marks the saga
+// as compensated, so steps of the saga succeeding later do not emit it a second time
+func (uc *OrderUseCase) compensate(
+	ctx context.Context,
+	factory ports.RepositoryFactory,
+	order *domain.Order,
+	saga *domain.OrderSaga,
+	previousStatus domain.OrderStatus,
+) error {
+	if saga.Compensated {
+		return nil
+	}
+	saga.Compensated = true
+
+	if err := factory.OrderSagaRepository().Update(ctx, saga); err != nil {
+		return fmt.Errorf("failed to update saga of order %s: %w", order.ID, err)
+	}
+
+	return uc.writeOutboxEvent(ctx, factory, order.ID, event.OrderCancelledEventType, event.OrderCancelledEvent{
+		EventID:        uuid.New(),
+		SagaID:         saga.ID,
+		OrderID:        order.ID,
+		CustomerID:     order.CustomerID,
+		PreviousStatus: previousStatus,
+		Items:          order.Items,
+		CancelledAt:    order.UpdatedAt,
+	})
+}
+
+// AddOrderItem implements ports.OrderUseCase.
+func (uc *OrderUseCase) AddOrderItem(ctx context.Context, orderID string, productID string, quantity int32, price domain.Money) error {
+	if orderID == "" {
+		return domain.ErrInvalidOrderID
+	}
+
+	if productID == "" {
+		return domain.ErrInvalidProductID
+	}
+
+	if quantity <= 0 {
+		return domain.ErrInvalidQuantity
+	}
+
+	if !price.IsPositive() {
+		return domain.ErrInvalidPrice
+	}
+
+	return uc.uow.Execute(ctx, func(factory ports.RepositoryFactory) error {
+		orderRepo := factory.OrderRepository()
+
+		order, err := orderRepo.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		if order.Status != domain.OrderStatusPending {
+			return domain.ErrOrderNotModifiable
+		}
+
+		// Items in a new currency extend the order's rate snapshot,
+		// rates already in the snapshot keep being used as they are
+		if _, ok := order.ExchangeRate(price.Currency()); !ok && price.Currency() != order.Currency {
+			rate, err := uc.exchangeRates.GetRate(ctx, price.Currency(), order.Currency)
+			if err != nil {
+				return fmt.Errorf("failed to get exchange rate: %w", err)
+			}
+
+			if err := order.AddExchangeRate(rate); err != nil {
+				return err
+			}
+		}
+
+		if err := order.AddItem(productID, quantity, price); err != nil {
+			return err
+		}
+
+		if err := orderRepo.Update(ctx, order); err != nil {
+			return fmt.Errorf("failed to update order: %w", err)
+		}
+
+		return uc.writeOutboxEvent(ctx, factory, order.ID, event.OrderItemAddedEventType, event.OrderItemAddedEvent{
+			EventID:    uuid.New(),
+			OrderID:    order.ID,
+			Item:       order.Items[len(order.Items)-1],
+			TotalPrice: order.TotalPrice,
+			AddedAt:    order.UpdatedAt,
+		})
+	})
 }
 
 // RemoveOrderItem implements ports.OrderUseCase.
 func (uc *OrderUseCase) RemoveOrderItem(ctx context.Context, orderID string, itemID string) error {
-	panic("unimplemented")
+	if orderID == "" {
+		return domain.ErrInvalidOrderID
+	}
+
+	itemUUID, err := uuid.Parse(itemID)
+	if err != nil {
+		return domain.ErrInvalidOrderItemID
+	}
+
+	return uc.uow.Execute(ctx, func(factory ports.RepositoryFactory) error {
+		orderRepo := factory.OrderRepository()
+
+		order, err := orderRepo.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		if order.Status != domain.OrderStatusPending {
+			return domain.ErrOrderNotModifiable
+		}
+
+		if err := order.RemoveItem(itemUUID); err != nil {
+			return err
+		}
+
+		if len(order.Items) == 0 {
+			return domain.ErrEmptyOrderItems
+		}
+
+		if err := orderRepo.Update(ctx, order); err != nil {
+			return fmt.Errorf("failed to update order: %w", err)
+		}
+
+		return uc.writeOutboxEvent(ctx, factory, order.ID, event.OrderItemRemovedEventType, event.OrderItemRemovedEvent{
+			EventID:    uuid.New(),
+			OrderID:    order.ID,
+			ItemID:     itemUUID,
+			TotalPrice: order.TotalPrice,
+			RemovedAt:  order.UpdatedAt,
+		})
+	})
 }
 
-// UpdateOrderStatus implements ports.OrderUseCase.
-func (uc *OrderUseCase) UpdateOrderStatus(ctx context.Context, id string, status domain.OrderStatus) error {
-	panic("unimplemented")
+// writeOutboxEvent stores an event in the outbox within the current unit of work
+func (uc *OrderUseCase) writeOutboxEvent(
+	ctx context.Context,
+	factory ports.RepositoryFactory,
+	aggregateID uuid.UUID,
+	eventType string,
+	payload interface{},
+) error {
+	if err := factory.OutboxRepository().CreateMessage(ctx, aggregateID, eventType, payload); err != nil {
+		return fmt.Errorf("failed to create outbox message: %w", err)
+	}
+
+	return nil
 }
 
 // NewOrderUseCase creates a new order use case
 func NewOrderUseCase(
 	uow ports.UnitOfWork,
-	eventPublisher ports.EventPublisher,
+	exchangeRates ports.ExchangeRateProvider,
 ) *OrderUseCase {
 	return &OrderUseCase{
-		uow:            uow,
-		eventPublisher: eventPublisher}
+		uow:           uow,
+		exchangeRates: exchangeRates}
+}
+
+// snapshotRates fetches the rates needed to convert the item prices into the order currency
+func (uc *OrderUseCase) snapshotRates(
+	ctx context.Context,
+	currency domain.Currency,
+	items []domain.OrderItem,
+) ([]domain.ExchangeRate, error) {
+	var rates []domain.ExchangeRate
+	seen := make(map[domain.Currency]bool)
+	for _, item := range items {
+		from := item.Price.Currency()
+		if from == currency || seen[from] {
+			continue
+		}
+		seen[from] = true
+
+		rate, err := uc.exchangeRates.GetRate(ctx, from, currency)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get exchange rate: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
 }
 
-// CreateOrder creates a new order with the given details
+// CreateOrder creates a new order with the given details.
+// If no currency is given the order uses the currency of its first item.
 func (uc *OrderUseCase) CreateOrder(
 	ctx context.Context,
 	customerID string,
+	currency domain.Currency,
 	items []domain.OrderItem,
 ) (*domain.Order, error) {
 	// Validate input
@@ -74,42 +354,56 @@ func (uc *OrderUseCase) CreateOrder(
 	if len(items) == 0 {
 		return nil, domain.ErrEmptyOrderItems
 	}
+	if currency == "" {
+		currency = items[0].Price.Currency()
+	}
+
+	// Snapshot the rates of all foreign item currencies
+	rates, err := uc.snapshotRates(ctx, currency, items)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create order entity
-	order := domain.NewOrder(customerID, items)
+	order, err := domain.NewOrder(customerID, currency, items, rates)
+	if err != nil {
+		return nil, err
+	}
 	order.Status = domain.OrderStatusPending
 	order.SagaID = uuid.New()
 
 	// Prepare order created event
 	orderCreatedEvent := event.OrderCreatedEvent{
-		EventID:    uuid.New(),
-		SageID:     order.SagaID,
-		OrderID:    order.ID,
-		CustomerID: order.CustomerID,
-		Items:      order.Items,
-		TotalPrice: order.TotalPrice,
-		CreatedAt:  time.Now(),
+		EventID:       uuid.New(),
+		SageID:        order.SagaID,
+		OrderID:       order.ID,
+		CustomerID:    order.CustomerID,
+		Items:         order.Items,
+		Currency:      order.Currency,
+		TotalPrice:    order.TotalPrice,
+		ExchangeRates: order.ExchangeRates,
+		CreatedAt:     time.Now(),
 	}
 
-	// Marshal event payload
-	eventPayload, err := json.Marshal(orderCreatedEvent)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal order created event: %w", err)
-	}
-
-	err = uc.uow.Execute(ctx, func(tx *sql.Tx) error {
+	err = uc.uow.Execute(ctx, func(factory ports.RepositoryFactory) error {
 		// Create order
-		orderRepo := repository.OrderRepositoryWithTx(tx)
-		outboxRepo := repository.NewOutboxRepositoryWithTx(tx)
+		orderRepo := factory.OrderRepository()
+		outboxRepo := factory.OutboxRepository()
 		if err := orderRepo.Create(ctx, order); err != nil {
 			return fmt.Errorf("failed to create order: %w", err)
 		}
 
+		// Start the saga the inventory and payment outcomes are recorded in
+		if err := factory.OrderSagaRepository().Create(ctx, domain.NewOrderSaga(order)); err != nil {
+			return err
+		}
+
 		if err = outboxRepo.CreateMessage(
 			ctx,
 			order.ID,
-			"order.created",
-			eventPayload,
+			event.OrderCreatedEventType,
+			// The event carries its schema version into the outbox row
+			orderCreatedEvent,
 		); err != nil {
 			return fmt.Errorf("failed to create outbox message: %w", err)
 		}
@@ -120,158 +414,6 @@ func (uc *OrderUseCase) CreateOrder(
 	if err != nil {
 		return nil, err
 	}
-	// After transaction is committed, publish event to broker
-	// This is done outside the transaction for "at-least-once" delivery semantics
-	// If publishing fails, the event is still in the outbox table and can be published later by an outbox processor
-	err = uc.publishEvent(ctx, "order.created", orderCreatedEvent)
-	if err != nil {
-		// Log the error but don't fail the operation
-		// The outbox pattern ensures events will be delivered eventually
-		log.Printf("warning: failed to publish order.created event: %v", err)
-	}
 
 	return order, nil
 }
-
-// publishEvent publishes an event to the message broker
-func (uc *OrderUseCase) publishEvent(ctx context.Context, topic string, event interface{}) error {
-	// Publish the event to the message broker
-	err := uc.eventPublisher.Publish(ctx, topic, event)
-	if err != nil {
-		return fmt.Errorf("failed to publish event to broker: %w", err)
-	}
-
-	return nil
-}
-
-// // publishOrderCreatedEvent publishes an event to notify other services
-// func (uc *OrderUseCase) publishOrderCreatedEvent(ctx context.Context, order *domain.Order) error {
-// 	event := event.OrderCreatedEvent{
-// 		EventID:    uuid.New(),
-// 		SageID:     order.SagaID,
-// 		OrderID:    order.ID,
-// 		CustomerID: order.CustomerID,
-// 		Items:      order.Items,
-// 		CreatedAt:  time.Now(),
-// 	}
-
-// 	return uc.eventPublisher.Publish(ctx, "order.created", event)
-// }
-
-// GetOrder retrieves an order by its ID
-// func (uc *OrderUseCase) GetOrder(ctx context.Context, id string) (*domain.Order, error) {
-// 	if id == "" {
-// 		return nil, domain.ErrInvalidOrderID
-// 	}
-
-// 	return uc.orderRepo.GetByID(ctx, id)
-// }
-
-// // UpdateOrderStatus updates the status of an order
-// func (uc *OrderUseCase) UpdateOrderStatus(ctx context.Context, id string, status domain.OrderStatus) error {
-// 	if id == "" {
-// 		return domain.ErrInvalidOrderID
-// 	}
-
-// 	order, err := uc.orderRepo.GetByID(ctx, id)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	order.ChangeStatus(status)
-
-// 	return uc.orderRepo.Update(ctx, order)
-// }
-
-// // AddOrderItem adds an item to an existing order
-// func (uc *OrderUseCase) AddOrderItem(
-// 	ctx context.Context,
-// 	orderID string,
-// 	productID string,
-// 	quantity int32,
-// 	price float64,
-// ) error {
-// 	if orderID == "" {
-// 		return domain.ErrInvalidOrderID
-// 	}
-
-// 	if productID == "" {
-// 		return domain.ErrInvalidProductID
-// 	}
-
-// 	if quantity <= 0 {
-// 		return domain.ErrInvalidQuantity
-// 	}
-
-// 	if price <= 0 {
-// 		return domain.ErrInvalidPrice
-// 	}
-
-// 	order, err := uc.orderRepo.GetByID(ctx, orderID)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	order.AddItem(productID, quantity, price)
-
-// 	return uc.orderRepo.Update(ctx, order)
-// }
-
-// // RemoveOrderItem removes an item from an order
-// func (uc *OrderUseCase) RemoveOrderItem(ctx context.Context, orderID string, itemID string) error {
-// 	if orderID == "" {
-// 		return domain.ErrInvalidOrderID
-// 	}
-
-// 	if itemID == "" {
-// 		return domain.ErrInvalidOrderID
-// 	}
-
-// 	order, err := uc.orderRepo.GetByID(ctx, orderID)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	itemUUID, err := uuid.Parse(itemID)
-// 	if err != nil {
-// 		return domain.ErrInvalidOrderID
-// 	}
-
-// 	if !order.RemoveItem(itemUUID) {
-// 		return domain.ErrOrderNotFound
-// 	}
-
-// 	if len(order.Items) == 0 {
-// 		return domain.ErrEmptyOrderItems
-// 	}
-
-// 	return uc.orderRepo.Update(ctx, order)
-// }
-
-// // CancelOrder cancels an order
-// func (uc *OrderUseCase) CancelOrder(ctx context.Context, id string) error {
-// 	if id == "" {
-// 		return domain.ErrInvalidOrderID
-// 	}
-
-// 	order, err := uc.orderRepo.GetByID(ctx, id)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	order.ChangeStatus(domain.OrderStatusCancelled)
-
-// 	return uc.orderRepo.Update(ctx, order)
-// }
-
-// func (uc *OrderUseCase) ListOrders(ctx context.Context, limit, offset int) ([]*domain.Order, error) {
-// 	if limit <= 0 {
-// 		limit = 10
-// 	}
-
-// 	if offset < 0 {
-// 		offset = 0
-// 	}
-
-// 	return uc.orderRepo.List(ctx, limit, offset)
-// }
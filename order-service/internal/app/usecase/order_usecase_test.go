@@ -2,16 +2,31 @@ package usecase_test
 
 import (
 	"context"
-	"database/sql"
+	"encoding/json"
+	"order-service/internal/app/ports"
 	"order-service/internal/app/usecase"
 	"order-service/internal/domain"
+	"order-service/internal/events"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+func usd(amount string) domain.Money {
+	return domain.MustParseMoney(amount, domain.CurrencyUSD)
+}
+
+func newOrder(t *testing.T, customerID string, items []domain.OrderItem) *domain.Order {
+	order, err := domain.NewOrder(customerID, domain.CurrencyUSD, items, nil)
+	if err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+	return order
+}
+
 // Mock repositories and UnitOfWork
 type mockOrderRepo struct {
 	mock.Mock
@@ -22,6 +37,40 @@ func (m *mockOrderRepo) Create(ctx context.Context, order *domain.Order) error {
 	return args.Error(0)
 }
 
+func (m *mockOrderRepo) GetByID(ctx context.Context, id string) (*domain.Order, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Order), args.Error(1)
+}
+
+func (m *mockOrderRepo) GetByIDForUpdate(ctx context.Context, id string) (*domain.Order, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Order), args.Error(1)
+}
+
+func (m *mockOrderRepo) Update(ctx context.Context, order *domain.Order) error {
+	args := m.Called(ctx, order)
+	return args.Error(0)
+}
+
+func (m *mockOrderRepo) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockOrderRepo) List(ctx context.Context, query domain.OrderListQuery) (*domain.OrderPage, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OrderPage), args.Error(1)
+}
+
 type mockOutboxRepo struct {
 	mock.Mock
 }
@@ -31,31 +80,123 @@ func (m *mockOutboxRepo) CreateMessage(ctx context.Context, aggregateID uuid.UUI
 	return args.Error(0)
 }
 
+func (m *mockOutboxRepo) ClaimPendingMessages(ctx context.Context, workerID string, limit int, lease time.Duration) ([]domain.OutboxMessage, error) {
+	args := m.Called(ctx, workerID, limit, lease)
+	return args.Get(0).([]domain.OutboxMessage), args.Error(1)
+}
+
+func (m *mockOutboxRepo) MarkMessageAsProcessed(ctx context.Context, messageID uuid.UUID) error {
+	args := m.Called(ctx, messageID)
+	return args.Error(0)
+}
+
+func (m *mockOutboxRepo) MarkMessageAsFailed(ctx context.Context, messageID uuid.UUID, reason string) error {
+	args := m.Called(ctx, messageID, reason)
+	return args.Error(0)
+}
+
+func (m *mockOutboxRepo) ScheduleRetry(ctx context.Context, messageID uuid.UUID, delay time.Duration, lastError string) error {
+	args := m.Called(ctx, messageID, delay, lastError)
+	return args.Error(0)
+}
+
+func (m *mockOutboxRepo) ClaimDeadLetters(ctx context.Context, workerID string, limit, maxAttempts int, lease time.Duration) ([]domain.OutboxMessage, error) {
+	args := m.Called(ctx, workerID, limit, maxAttempts, lease)
+	return args.Get(0).([]domain.OutboxMessage), args.Error(1)
+}
+
+func (m *mockOutboxRepo) MarkMessageAsDeadLettered(ctx context.Context, messageID uuid.UUID) error {
+	args := m.Called(ctx, messageID)
+	return args.Error(0)
+}
+
+func (m *mockOutboxRepo) RecordDeadLetterFailure(ctx context.Context, messageID uuid.UUID) error {
+	args := m.Called(ctx, messageID)
+	return args.Error(0)
+}
+
+func (m *mockOutboxRepo) ParkMessage(ctx context.Context, messageID uuid.UUID, reason string) error {
+	args := m.Called(ctx, messageID, reason)
+	return args.Error(0)
+}
+
+func (m *mockOutboxRepo) UnparkMessages(ctx context.Context, eventTypes []string) (int64, error) {
+	args := m.Called(ctx, eventTypes)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type mockOrderSagaRepo struct {
+	mock.Mock
+}
+
+func (m *mockOrderSagaRepo) Create(ctx context.Context, saga *domain.OrderSaga) error {
+	args := m.Called(ctx, saga)
+	return args.Error(0)
+}
+
+func (m *mockOrderSagaRepo) GetForUpdate(ctx context.Context, orderID uuid.UUID) (*domain.OrderSaga, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OrderSaga), args.Error(1)
+}
+
+func (m *mockOrderSagaRepo) Update(ctx context.Context, saga *domain.OrderSaga) error {
+	args := m.Called(ctx, saga)
+	return args.Error(0)
+}
+
 type mockUnitOfWork struct {
 	mock.Mock
-	mockOrderRepo  *mockOrderRepo
-	mockOutboxRepo *mockOutboxRepo
-	shouldFail     bool
-	failAt         string
+	mockOrderRepo     *mockOrderRepo
+	mockOutboxRepo    *mockOutboxRepo
+	mockOrderSagaRepo *mockOrderSagaRepo
+	shouldFail        bool
+	failAt            string
 }
 
-func (m *mockUnitOfWork) Execute(ctx context.Context, fn func(tx *sql.Tx) error) error {
+func (m *mockUnitOfWork) Execute(ctx context.Context, fn func(factory ports.RepositoryFactory) error) error {
 	args := m.Called(ctx)
 	if m.shouldFail {
 		return args.Error(0)
 	}
 
-	err := fn(nil) // Pass nil as tx since we're mocking
+	err := fn(m) // The mock doubles as the repository factory
 	return err
 }
 
-type mockEventPublisher struct {
+func (m *mockUnitOfWork) OrderRepository() ports.OrderRepository {
+	return m.mockOrderRepo
+}
+
+func (m *mockUnitOfWork) OutboxRepository() ports.OutboxRepository {
+	return m.mockOutboxRepo
+}
+
+func (m *mockUnitOfWork) InboxRepository() ports.InboxRepository {
+	return nil
+}
+
+func (m *mockUnitOfWork) OrderSagaRepository() ports.OrderSagaRepository {
+	return m.mockOrderSagaRepo
+}
+
+type mockExchangeRateProvider struct {
 	mock.Mock
 }
 
-func (m *mockEventPublisher) Publish(ctx context.Context, topic string, event interface{}) error {
-	args := m.Called(ctx, topic, event)
-	return args.Error(0)
+func (m *mockExchangeRateProvider) GetRate(ctx context.Context, from, to domain.Currency) (domain.ExchangeRate, error) {
+	args := m.Called(ctx, from, to)
+	return args.Get(0).(domain.ExchangeRate), args.Error(1)
+}
+
+func eurToUSD(t *testing.T) domain.ExchangeRate {
+	rate, err := domain.ParseExchangeRate(domain.CurrencyEUR, domain.CurrencyUSD, "1.10", time.Now())
+	if err != nil {
+		t.Fatalf("failed to create exchange rate: %v", err)
+	}
+	return rate
 }
 
 func TestCreateOrder(t *testing.T) {
@@ -63,25 +204,57 @@ func TestCreateOrder(t *testing.T) {
 	testCases := []struct {
 		name            string
 		customerID      string
+		currency        domain.Currency
 		items           []domain.OrderItem
-		setupMocks      func(*mockUnitOfWork, *mockOrderRepo, *mockOutboxRepo, *mockEventPublisher)
+		setupMocks      func(*mockUnitOfWork, *mockOrderRepo, *mockOutboxRepo, *mockExchangeRateProvider)
 		expectedError   bool
 		expectedErrType error
+		expectedTotal   domain.Money
 	}{
 		{
 			name:       "Success - Order creation successful",
 			customerID: "customer-123",
 			items: []domain.OrderItem{
-				{ID: uuid.New(), ProductID: "product-1", Quantity: 2, Price: 10.0},
-				{ID: uuid.New(), ProductID: "product-2", Quantity: 1, Price: 20.0},
+				{ID: uuid.New(), ProductID: "product-1", Quantity: 2, Price: usd("10.00")},
+				{ID: uuid.New(), ProductID: "product-2", Quantity: 1, Price: usd("20.00")},
+			},
+			setupMocks: func(muow *mockUnitOfWork, mor *mockOrderRepo, moutbox *mockOutboxRepo, mrp *mockExchangeRateProvider) {
+				muow.On("Execute", mock.Anything).Return(nil)
+				mor.On("Create", mock.Anything, mock.AnythingOfType("*domain.Order")).Return(nil)
+				moutbox.On("CreateMessage", mock.Anything, mock.AnythingOfType("uuid.UUID"), "order.created", mock.AnythingOfType("events.OrderCreatedEvent")).Return(nil)
+			},
+			expectedError: false,
+			expectedTotal: usd("40.00"),
+		},
+		{
+			name:       "Success - Foreign item converted into order currency",
+			customerID: "customer-123",
+			currency:   domain.CurrencyUSD,
+			items: []domain.OrderItem{
+				{ID: uuid.New(), ProductID: "product-1", Quantity: 2, Price: usd("10.00")},
+				{ID: uuid.New(), ProductID: "product-2", Quantity: 1, Price: domain.MustParseMoney("20.00", domain.CurrencyEUR)},
 			},
-			setupMocks: func(muow *mockUnitOfWork, mor *mockOrderRepo, moutbox *mockOutboxRepo, mep *mockEventPublisher) {
+			setupMocks: func(muow *mockUnitOfWork, mor *mockOrderRepo, moutbox *mockOutboxRepo, mrp *mockExchangeRateProvider) {
 				muow.On("Execute", mock.Anything).Return(nil)
+				mrp.On("GetRate", mock.Anything, domain.CurrencyEUR, domain.CurrencyUSD).Return(eurToUSD(t), nil).Once()
 				mor.On("Create", mock.Anything, mock.AnythingOfType("*domain.Order")).Return(nil)
-				moutbox.On("CreateMessage", mock.Anything, uuid.New(), "order.created", mock.AnythingOfType("[]uint8")).Return(nil)
-				mep.On("Publish", mock.Anything, "order.created", mock.AnythingOfType("*events.OrderCreatedEvent")).Return(nil)
+				moutbox.On("CreateMessage", mock.Anything, mock.AnythingOfType("uuid.UUID"), "order.created", mock.AnythingOfType("events.OrderCreatedEvent")).Return(nil)
 			},
 			expectedError: false,
+			expectedTotal: usd("42.00"),
+		},
+		{
+			name:       "Failure - Unknown exchange rate",
+			customerID: "customer-123",
+			currency:   domain.CurrencyUSD,
+			items: []domain.OrderItem{
+				{ID: uuid.New(), ProductID: "product-1", Quantity: 1, Price: domain.MustParseMoney("1000", domain.CurrencyJPY)},
+			},
+			setupMocks: func(muow *mockUnitOfWork, mor *mockOrderRepo, moutbox *mockOutboxRepo, mrp *mockExchangeRateProvider) {
+				mrp.On("GetRate", mock.Anything, domain.CurrencyJPY, domain.CurrencyUSD).Return(domain.ExchangeRate{}, domain.ErrExchangeRateNotFound)
+			},
+			expectedError:   true,
+			expectedErrType: domain.ErrExchangeRateNotFound,
 		},
 	}
 
@@ -91,24 +264,27 @@ func TestCreateOrder(t *testing.T) {
 			// Setup mocks
 			mockOrderRepo := new(mockOrderRepo)
 			mockOutboxRepo := new(mockOutboxRepo)
+			mockSagaRepo := new(mockOrderSagaRepo)
 			mockUoW := &mockUnitOfWork{
-				mockOrderRepo:  mockOrderRepo,
-				mockOutboxRepo: mockOutboxRepo,
+				mockOrderRepo:     mockOrderRepo,
+				mockOutboxRepo:    mockOutboxRepo,
+				mockOrderSagaRepo: mockSagaRepo,
 			}
-			mockPubliser := new(mockEventPublisher)
+			mockRates := new(mockExchangeRateProvider)
 
 			// Apply test case setup
 
-			tc.setupMocks(mockUoW, mockOrderRepo, mockOutboxRepo, mockPubliser)
+			tc.setupMocks(mockUoW, mockOrderRepo, mockOutboxRepo, mockRates)
+			mockSagaRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.OrderSaga")).Return(nil).Maybe()
 
 			// Create the use case
-			orderUseCase := usecase.NewOrderUseCase(mockUoW, mockPubliser)
+			orderUseCase := usecase.NewOrderUseCase(mockUoW, mockRates)
 
 			// Setup context
 			ctx := context.Background()
 
 			// Call method
-			order, err := orderUseCase.CreateOrder(ctx, tc.customerID, tc.items)
+			order, err := orderUseCase.CreateOrder(ctx, tc.customerID, tc.currency, tc.items)
 
 			// Check expectations
 			if tc.expectedError {
@@ -120,6 +296,453 @@ func TestCreateOrder(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 				assert.NotNil(t, order)
+				assert.Equal(t, tc.expectedTotal, order.TotalPrice)
+				mockRates.AssertExpectations(t)
+				// The saga of the order is started with the order
+				mockSagaRepo.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(saga *domain.OrderSaga) bool {
+					return saga.OrderID == order.ID && saga.ID == order.SagaID
+				}))
+			}
+		})
+	}
+}
+
+func TestCreateOrderOutboxPayloadDecodes(t *testing.T) {
+	mockOrderRepo := new(mockOrderRepo)
+	mockOutboxRepo := new(mockOutboxRepo)
+	mockSagaRepo := new(mockOrderSagaRepo)
+	mockUoW := &mockUnitOfWork{
+		mockOrderRepo:     mockOrderRepo,
+		mockOutboxRepo:    mockOutboxRepo,
+		mockOrderSagaRepo: mockSagaRepo,
+	}
+
+	var payload interface{}
+	mockUoW.On("Execute", mock.Anything).Return(nil)
+	mockOrderRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Order")).Return(nil)
+	mockSagaRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.OrderSaga")).Return(nil)
+	mockOutboxRepo.On("CreateMessage", mock.Anything, mock.AnythingOfType("uuid.UUID"), events.OrderCreatedEventType, mock.Anything).
+		Run(func(args mock.Arguments) { payload = args.Get(3) }).
+		Return(nil)
+
+	orderUseCase := usecase.NewOrderUseCase(mockUoW, new(mockExchangeRateProvider))
+	order, err := orderUseCase.CreateOrder(context.Background(), "customer-123", "", []domain.OrderItem{
+		{ID: uuid.New(), ProductID: "product-1", Quantity: 2, Price: usd("10.00")},
+	})
+	assert.NoError(t, err)
+
+	// Store the payload like the outbox repository does and publish it like the outbox worker
+	versioned, ok := payload.(ports.VersionedEvent)
+	if !assert.True(t, ok, "order.created has to carry its schema version") {
+		return
+	}
+	data, err := json.Marshal(payload)
+	assert.NoError(t, err)
+
+	def, err := events.NewDefaultRegistry().Lookup(events.OrderCreatedEventType)
+	assert.NoError(t, err)
+	decoded, err := def.Decode(data, versioned.SchemaVersion())
+	if assert.NoError(t, err) {
+		created := decoded.(*events.OrderCreatedEvent)
+		assert.Equal(t, order.ID, created.OrderID)
+		assert.Equal(t, order.TotalPrice, created.TotalPrice)
+	}
+}
+
+func TestUpdateOrderStatus(t *testing.T) {
+	testCases := []struct {
+		name            string
+		currentStatus   domain.OrderStatus
+		newStatus       domain.OrderStatus
+		expectUpdate    bool
+		expectedErrType error
+	}{
+		{
+			name:          "Success - Pending order confirmed",
+			currentStatus: domain.OrderStatusPending,
+			newStatus:     domain.OrderStatusConfirmed,
+			expectUpdate:  true,
+		},
+		{
+			name:            "Failure - Delivered order can not go back to pending",
+			currentStatus:   domain.OrderStatusDelivered,
+			newStatus:       domain.OrderStatusPending,
+			expectedErrType: domain.ErrInvalidStatusTransition,
+		},
+		{
+			name:            "Failure - Cancelled order can not be shipped",
+			currentStatus:   domain.OrderStatusCancelled,
+			newStatus:       domain.OrderStatusShipped,
+			expectedErrType: domain.ErrInvalidStatusTransition,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOrderRepo := new(mockOrderRepo)
+			mockOutboxRepo := new(mockOutboxRepo)
+			mockUoW := &mockUnitOfWork{mockOrderRepo: mockOrderRepo, mockOutboxRepo: mockOutboxRepo}
+			mockUoW.On("Execute", mock.Anything).Return(nil)
+
+			order := newOrder(t, "customer-123", []domain.OrderItem{
+				{ID: uuid.New(), ProductID: "product-1", Quantity: 1, Price: usd("10.00")},
+			})
+			order.Status = tc.currentStatus
+
+			mockOrderRepo.On("GetByIDForUpdate", mock.Anything, order.ID.String()).Return(order, nil)
+			if tc.expectUpdate {
+				mockOrderRepo.On("Update", mock.Anything, order).Return(nil)
+				mockOutboxRepo.On("CreateMessage", mock.Anything, order.ID, "order.status_changed", mock.AnythingOfType("events.OrderStatusChangedEvent")).Return(nil)
+			}
+
+			orderUseCase := usecase.NewOrderUseCase(mockUoW, new(mockExchangeRateProvider))
+			err := orderUseCase.UpdateOrderStatus(context.Background(), order.ID.String(), tc.newStatus)
+
+			if tc.expectedErrType != nil {
+				assert.ErrorIs(t, err, tc.expectedErrType)
+				assert.Equal(t, tc.currentStatus, order.Status)
+				mockOrderRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.newStatus, order.Status)
+			}
+			mockOrderRepo.AssertExpectations(t)
+			mockOutboxRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCancelOrder(t *testing.T) {
+	mockOrderRepo := new(mockOrderRepo)
+	mockOutboxRepo := new(mockOutboxRepo)
+	mockSagaRepo := new(mockOrderSagaRepo)
+	mockUoW := &mockUnitOfWork{mockOrderRepo: mockOrderRepo, mockOutboxRepo: mockOutboxRepo, mockOrderSagaRepo: mockSagaRepo}
+	mockUoW.On("Execute", mock.Anything).Return(nil)
+
+	order := newOrder(t, "customer-123", []domain.OrderItem{
+		{ID: uuid.New(), ProductID: "product-1", Quantity: 1, Price: usd("10.00")},
+	})
+	saga := domain.NewOrderSaga(order)
+	// Orders are loaded without their saga ID
+	order.SagaID = uuid.Nil
+
+	mockSagaRepo.On("GetForUpdate", mock.Anything, order.ID).Return(saga, nil)
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, order.ID.String()).Return(order, nil)
+	mockOrderRepo.On("Update", mock.Anything, order).Return(nil)
+	mockSagaRepo.On("Update", mock.Anything, saga).Return(nil)
+	mockOutboxRepo.On("CreateMessage", mock.Anything, order.ID, "order.cancelled", mock.MatchedBy(func(e events.OrderCancelledEvent) bool {
+		return e.SagaID == saga.ID && e.PreviousStatus == domain.OrderStatusPending
+	})).Return(nil)
+
+	orderUseCase := usecase.NewOrderUseCase(mockUoW, new(mockExchangeRateProvider))
+	err := orderUseCase.CancelOrder(context.Background(), order.ID.String())
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OrderStatusCancelled, order.Status)
+	assert.True(t, saga.Compensated)
+	mockSagaRepo.AssertExpectations(t)
+	mockOrderRepo.AssertExpectations(t)
+	mockOutboxRepo.AssertExpectations(t)
+}
+
+func TestCancelOrderThenLateStepSucceeds(t *testing.T) {
+	mockOrderRepo := new(mockOrderRepo)
+	mockOutboxRepo := new(mockOutboxRepo)
+	mockSagaRepo := new(mockOrderSagaRepo)
+	mockUoW := &mockUnitOfWork{mockOrderRepo: mockOrderRepo, mockOutboxRepo: mockOutboxRepo, mockOrderSagaRepo: mockSagaRepo}
+	mockUoW.On("Execute", mock.Anything).Return(nil)
+
+	order := newOrder(t, "customer-123", []domain.OrderItem{
+		{ID: uuid.New(), ProductID: "product-1", Quantity: 1, Price: usd("10.00")},
+	})
+	saga := domain.NewOrderSaga(order)
+	saga.ProductsReserved = true
+
+	mockSagaRepo.On("GetForUpdate", mock.Anything, order.ID).Return(saga, nil)
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, order.ID.String()).Return(order, nil)
+	mockOrderRepo.On("Update", mock.Anything, order).Return(nil)
+	mockSagaRepo.On("Update", mock.Anything, saga).Return(nil)
+	mockOutboxRepo.On("CreateMessage", mock.Anything, order.ID, "order.cancelled", mock.Anything).Return(nil)
+
+	orderUseCase := usecase.NewOrderUseCase(mockUoW, new(mockExchangeRateProvider))
+	err := orderUseCase.CancelOrder(context.Background(), order.ID.String())
+	assert.NoError(t, err)
+
+	// The payment is approved after the user cancelled, the cancellation already covers it
+	err = usecase.NewOrderSagaUseCase().PaymentApproved(context.Background(), mockUoW, order.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OrderStatusCancelled, order.Status)
+	assert.True(t, saga.PaymentApproved)
+	mockOutboxRepo.AssertNumberOfCalls(t, "CreateMessage", 1)
+	mockSagaRepo.AssertNumberOfCalls(t, "Update", 2)
+}
+
+func TestCancelShippedOrder(t *testing.T) {
+	mockOrderRepo := new(mockOrderRepo)
+	mockSagaRepo := new(mockOrderSagaRepo)
+	mockUoW := &mockUnitOfWork{mockOrderRepo: mockOrderRepo, mockOrderSagaRepo: mockSagaRepo}
+	mockUoW.On("Execute", mock.Anything).Return(nil)
+
+	order := newOrder(t, "customer-123", []domain.OrderItem{
+		{ID: uuid.New(), ProductID: "product-1", Quantity: 1, Price: usd("10.00")},
+	})
+	order.Status = domain.OrderStatusShipped
+
+	mockSagaRepo.On("GetForUpdate", mock.Anything, order.ID).Return(domain.NewOrderSaga(order), nil)
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, order.ID.String()).Return(order, nil)
+
+	orderUseCase := usecase.NewOrderUseCase(mockUoW, new(mockExchangeRateProvider))
+	err := orderUseCase.CancelOrder(context.Background(), order.ID.String())
+
+	assert.ErrorIs(t, err, domain.ErrInvalidStatusTransition)
+	assert.Equal(t, domain.OrderStatusShipped, order.Status)
+	mockSagaRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestAddOrderItem(t *testing.T) {
+	testCases := []struct {
+		name            string
+		status          domain.OrderStatus
+		productID       string
+		quantity        int32
+		price           domain.Money
+		expectedErrType error
+	}{
+		{
+			name:      "Success - Item added to pending order",
+			status:    domain.OrderStatusPending,
+			productID: "product-2",
+			quantity:  2,
+			price:     usd("5.00"),
+		},
+		{
+			name:      "Success - Foreign item added with a new rate",
+			status:    domain.OrderStatusPending,
+			productID: "product-2",
+			quantity:  1,
+			price:     domain.MustParseMoney("9.09", domain.CurrencyEUR),
+		},
+		{
+			name:            "Failure - Invalid quantity",
+			status:          domain.OrderStatusPending,
+			productID:       "product-2",
+			quantity:        0,
+			price:           usd("5.00"),
+			expectedErrType: domain.ErrInvalidQuantity,
+		},
+		{
+			name:            "Failure - Order already confirmed",
+			status:          domain.OrderStatusConfirmed,
+			productID:       "product-2",
+			quantity:        1,
+			price:           usd("5.00"),
+			expectedErrType: domain.ErrOrderNotModifiable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOrderRepo := new(mockOrderRepo)
+			mockOutboxRepo := new(mockOutboxRepo)
+			mockUoW := &mockUnitOfWork{mockOrderRepo: mockOrderRepo, mockOutboxRepo: mockOutboxRepo}
+			mockUoW.On("Execute", mock.Anything).Return(nil)
+
+			order := newOrder(t, "customer-123", []domain.OrderItem{
+				{ID: uuid.New(), ProductID: "product-1", Quantity: 1, Price: usd("10.00")},
+			})
+			order.Status = tc.status
+
+			mockOrderRepo.On("GetByIDForUpdate", mock.Anything, order.ID.String()).Return(order, nil)
+			mockOrderRepo.On("Update", mock.Anything, order).Return(nil)
+			mockOutboxRepo.On("CreateMessage", mock.Anything, order.ID, "order.item_added", mock.AnythingOfType("events.OrderItemAddedEvent")).Return(nil)
+
+			mockRates := new(mockExchangeRateProvider)
+			mockRates.On("GetRate", mock.Anything, domain.CurrencyEUR, domain.CurrencyUSD).Return(eurToUSD(t), nil)
+
+			orderUseCase := usecase.NewOrderUseCase(mockUoW, mockRates)
+			err := orderUseCase.AddOrderItem(context.Background(), order.ID.String(), tc.productID, tc.quantity, tc.price)
+
+			if tc.expectedErrType != nil {
+				assert.ErrorIs(t, err, tc.expectedErrType)
+				assert.Len(t, order.Items, 1)
+				mockOutboxRepo.AssertNotCalled(t, "CreateMessage", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, order.Items, 2)
+				assert.Equal(t, usd("20.00"), order.TotalPrice)
+				if tc.price.Currency() != order.Currency {
+					assert.Len(t, order.ExchangeRates, 1)
+				}
+				mockOutboxRepo.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestRemoveOrderItem(t *testing.T) {
+	keep := domain.OrderItem{ID: uuid.New(), ProductID: "product-1", Quantity: 1, Price: usd("10.00")}
+	remove := domain.OrderItem{ID: uuid.New(), ProductID: "product-2", Quantity: 2, Price: usd("5.00")}
+
+	testCases := []struct {
+		name            string
+		items           []domain.OrderItem
+		itemID          string
+		expectedErrType error
+	}{
+		{
+			name:   "Success - Item removed",
+			items:  []domain.OrderItem{keep, remove},
+			itemID: remove.ID.String(),
+		},
+		{
+			name:            "Failure - Unknown item",
+			items:           []domain.OrderItem{keep, remove},
+			itemID:          uuid.New().String(),
+			expectedErrType: domain.ErrOrderItemNotFound,
+		},
+		{
+			name:            "Failure - Invalid item ID",
+			items:           []domain.OrderItem{keep, remove},
+			itemID:          "not-a-uuid",
+			expectedErrType: domain.ErrInvalidOrderItemID,
+		},
+		{
+			name:            "Failure - Last item can not be removed",
+			items:           []domain.OrderItem{remove},
+			itemID:          remove.ID.String(),
+			expectedErrType: domain.ErrEmptyOrderItems,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOrderRepo := new(mockOrderRepo)
+			mockOutboxRepo := new(mockOutboxRepo)
+			mockUoW := &mockUnitOfWork{mockOrderRepo: mockOrderRepo, mockOutboxRepo: mockOutboxRepo}
+			mockUoW.On("Execute", mock.Anything).Return(nil)
+
+			order := newOrder(t, "customer-123", append([]domain.OrderItem{}, tc.items...))
+
+			mockOrderRepo.On("GetByIDForUpdate", mock.Anything, order.ID.String()).Return(order, nil)
+			mockOrderRepo.On("Update", mock.Anything, order).Return(nil)
+			mockOutboxRepo.On("CreateMessage", mock.Anything, order.ID, "order.item_removed", mock.AnythingOfType("events.OrderItemRemovedEvent")).Return(nil)
+
+			orderUseCase := usecase.NewOrderUseCase(mockUoW, new(mockExchangeRateProvider))
+			err := orderUseCase.RemoveOrderItem(context.Background(), order.ID.String(), tc.itemID)
+
+			if tc.expectedErrType != nil {
+				assert.ErrorIs(t, err, tc.expectedErrType)
+				mockOrderRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, order.Items, 1)
+				assert.Equal(t, usd("10.00"), order.TotalPrice)
+				mockOutboxRepo.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestGetOrder(t *testing.T) {
+	mockOrderRepo := new(mockOrderRepo)
+	mockUoW := &mockUnitOfWork{mockOrderRepo: mockOrderRepo}
+	mockUoW.On("Execute", mock.Anything).Return(nil)
+
+	id := uuid.New().String()
+	mockOrderRepo.On("GetByID", mock.Anything, id).Return(nil, domain.ErrOrderNotFound)
+
+	orderUseCase := usecase.NewOrderUseCase(mockUoW, new(mockExchangeRateProvider))
+	order, err := orderUseCase.GetOrder(context.Background(), id)
+
+	assert.ErrorIs(t, err, domain.ErrOrderNotFound)
+	assert.Nil(t, order)
+}
+
+func TestListOrders(t *testing.T) {
+	order := newOrder(t, "customer-123", []domain.OrderItem{
+		{ID: uuid.New(), ProductID: "product-1", Quantity: 1, Price: usd("10.00")},
+	})
+	byTotal := domain.OrderSort{Field: domain.OrderSortByTotalPrice}
+	cursor := domain.NewOrderCursor(byTotal, order)
+	inUSD := domain.OrderFilter{Currency: domain.CurrencyUSD}
+
+	testCases := []struct {
+		name            string
+		query           domain.OrderListQuery
+		expectedQuery   domain.OrderListQuery
+		expectedErrType error
+	}{
+		{
+			name:          "Success - Defaults applied",
+			query:         domain.OrderListQuery{},
+			expectedQuery: domain.OrderListQuery{Limit: domain.DefaultOrderPageSize, Sort: domain.DefaultOrderSort},
+		},
+		{
+			name:          "Success - Limit capped",
+			query:         domain.OrderListQuery{Limit: 1000, Sort: byTotal, Filter: inUSD},
+			expectedQuery: domain.OrderListQuery{Limit: domain.MaxOrderPageSize, Sort: byTotal, Filter: inUSD},
+		},
+		{
+			name:          "Success - Sort taken from cursor",
+			query:         domain.OrderListQuery{Limit: 5, After: &cursor, Filter: inUSD},
+			expectedQuery: domain.OrderListQuery{Limit: 5, Sort: byTotal, After: &cursor, Filter: inUSD},
+		},
+		{
+			name:            "Failure - Total price sorted across currencies",
+			query:           domain.OrderListQuery{Sort: byTotal},
+			expectedErrType: domain.ErrInvalidOrderSort,
+		},
+		{
+			name: "Failure - Total bound in another currency than the filter",
+			query: domain.OrderListQuery{
+				Filter: domain.OrderFilter{Currency: domain.CurrencyEUR, MinTotal: &[]domain.Money{usd("10.00")}[0]},
+			},
+			expectedErrType: domain.ErrCurrencyMismatch,
+		},
+		{
+			name:            "Failure - Cursor of another sort",
+			query:           domain.OrderListQuery{Sort: domain.DefaultOrderSort, After: &cursor},
+			expectedErrType: domain.ErrInvalidCursor,
+		},
+		{
+			name: "Failure - Invalid status filter",
+			query: domain.OrderListQuery{
+				Filter: domain.OrderFilter{Statuses: []domain.OrderStatus{"UNKNOWN"}},
+			},
+			expectedErrType: domain.ErrInvalidOrderStatus,
+		},
+		{
+			name: "Failure - Total bounds in different currencies",
+			query: domain.OrderListQuery{
+				Filter: domain.OrderFilter{
+					MinTotal: &[]domain.Money{usd("10.00")}[0],
+					MaxTotal: &[]domain.Money{domain.MustParseMoney("20.00", domain.CurrencyEUR)}[0],
+				},
+			},
+			expectedErrType: domain.ErrCurrencyMismatch,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOrderRepo := new(mockOrderRepo)
+			mockUoW := &mockUnitOfWork{mockOrderRepo: mockOrderRepo}
+			mockUoW.On("Execute", mock.Anything).Return(nil)
+
+			page := &domain.OrderPage{Orders: []*domain.Order{order}}
+			mockOrderRepo.On("List", mock.Anything, tc.expectedQuery).Return(page, nil)
+
+			orderUseCase := usecase.NewOrderUseCase(mockUoW, new(mockExchangeRateProvider))
+			result, err := orderUseCase.ListOrders(context.Background(), tc.query)
+
+			if tc.expectedErrType != nil {
+				assert.ErrorIs(t, err, tc.expectedErrType)
+				mockOrderRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, page, result)
+				mockOrderRepo.AssertExpectations(t)
 			}
 		})
 	}
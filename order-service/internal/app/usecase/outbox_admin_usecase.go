@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"order-service/internal/app/ports"
+	"order-service/internal/domain"
+	event "order-service/internal/events"
+
+	"github.com/google/uuid"
+)
+
+// OutboxAdminUseCase implements the operator tooling for failed outbox messages
+type OutboxAdminUseCase struct {
+	outboxRepo ports.OutboxAdminRepository
+	registry   *event.Registry
+}
+
+// NewOutboxAdminUseCase creates a new outbox admin use case.
+// Edited payloads are checked against the current schema of their event type in the registry.
+func NewOutboxAdminUseCase(outboxRepo ports.OutboxAdminRepository, registry *event.Registry) *OutboxAdminUseCase {
+	return &OutboxAdminUseCase{
+		outboxRepo: outboxRepo,
+		registry:   registry,
+	}
+}
+
+// ListFailedMessages implements ports.OutboxAdminUseCase.
+// The most recently failed messages come first.
+func (uc *OutboxAdminUseCase) ListFailedMessages(ctx context.Context, filter domain.OutboxMessageFilter, limit int) ([]domain.OutboxMessage, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = domain.DefaultOutboxPageSize
+	}
+
+	if limit > domain.MaxOutboxPageSize {
+		limit = domain.MaxOutboxPageSize
+	}
+
+	return uc.outboxRepo.ListFailedMessages(ctx, filter, limit)
+}
+
+// GetMessage implements ports.OutboxAdminUseCase.
+func (uc *OutboxAdminUseCase) GetMessage(ctx context.Context, id uuid.UUID) (*domain.OutboxMessage, error) {
+	return uc.outboxRepo.GetMessage(ctx, id)
+}
+
+// UpdatePayload implements ports.OutboxAdminUseCase.
+// Only failed messages can be edited, typically to fix a payload before requeueing it.
+// The new payload is written in the current schema version of the message's event type.
+func (uc *OutboxAdminUseCase) UpdatePayload(ctx context.Context, id uuid.UUID, payload []byte) error {
+	if !json.Valid(payload) {
+		return domain.ErrInvalidOutboxPayload
+	}
+
+	msg, err := uc.outboxRepo.GetMessage(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	def, err := uc.registry.Lookup(msg.EventType)
+	if err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrInvalidOutboxPayload, err)
+	}
+
+	if _, err := def.Decode(payload, def.Version); err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrInvalidOutboxPayload, err)
+	}
+
+	if err := uc.outboxRepo.UpdateFailedMessagePayload(ctx, id, payload, def.Version); err != nil {
+		return err
+	}
+
+	log.Printf("Updated payload of failed outbox message %s", id)
+	return nil
+}
+
+// Requeue implements ports.OutboxAdminUseCase.
+// The filter has to select something, requeueing every failed message takes an explicit time range.
+func (uc *OutboxAdminUseCase) Requeue(ctx context.Context, filter domain.OutboxMessageFilter) (int64, error) {
+	if err := validateBulkFilter(filter); err != nil {
+		return 0, err
+	}
+
+	requeued, err := uc.outboxRepo.RequeueFailedMessages(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("Requeued %d failed outbox messages", requeued)
+	return requeued, nil
+}
+
+// Discard implements ports.OutboxAdminUseCase.
+func (uc *OutboxAdminUseCase) Discard(ctx context.Context, filter domain.OutboxMessageFilter) (int64, error) {
+	if err := validateBulkFilter(filter); err != nil {
+		return 0, err
+	}
+
+	discarded, err := uc.outboxRepo.DiscardFailedMessages(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("Discarded %d failed outbox messages", discarded)
+	return discarded, nil
+}
+
+// validateBulkFilter guards requeue and discard against acting on every failed message by accident
+func validateBulkFilter(filter domain.OutboxMessageFilter) error {
+	if filter.IsEmpty() {
+		return fmt.Errorf("%w: select messages by id, event type or time range", domain.ErrInvalidOutboxFilter)
+	}
+	return filter.Validate()
+}
@@ -0,0 +1,138 @@
+package usecase_test
+
+import (
+	"context"
+	"order-service/internal/app/usecase"
+	"order-service/internal/domain"
+	"order-service/internal/events"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockOutboxAdminRepo struct {
+	mock.Mock
+}
+
+func (m *mockOutboxAdminRepo) ListFailedMessages(ctx context.Context, filter domain.OutboxMessageFilter, limit int) ([]domain.OutboxMessage, error) {
+	args := m.Called(ctx, filter, limit)
+	return args.Get(0).([]domain.OutboxMessage), args.Error(1)
+}
+
+func (m *mockOutboxAdminRepo) GetMessage(ctx context.Context, messageID uuid.UUID) (*domain.OutboxMessage, error) {
+	args := m.Called(ctx, messageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OutboxMessage), args.Error(1)
+}
+
+func (m *mockOutboxAdminRepo) UpdateFailedMessagePayload(ctx context.Context, messageID uuid.UUID, payload []byte, schemaVersion int) error {
+	args := m.Called(ctx, messageID, payload, schemaVersion)
+	return args.Error(0)
+}
+
+func (m *mockOutboxAdminRepo) RequeueFailedMessages(ctx context.Context, filter domain.OutboxMessageFilter) (int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockOutboxAdminRepo) DiscardFailedMessages(ctx context.Context, filter domain.OutboxMessageFilter) (int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestListFailedMessages(t *testing.T) {
+	repo := new(mockOutboxAdminRepo)
+	uc := usecase.NewOutboxAdminUseCase(repo, events.NewDefaultRegistry())
+	ctx := context.Background()
+
+	filter := domain.OutboxMessageFilter{EventType: "order.created"}
+	repo.On("ListFailedMessages", ctx, filter, domain.DefaultOutboxPageSize).Return([]domain.OutboxMessage{}, nil)
+	repo.On("ListFailedMessages", ctx, filter, domain.MaxOutboxPageSize).Return([]domain.OutboxMessage{}, nil)
+
+	_, err := uc.ListFailedMessages(ctx, filter, 0)
+	assert.NoError(t, err)
+
+	_, err = uc.ListFailedMessages(ctx, filter, 10000)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	_, err = uc.ListFailedMessages(ctx, domain.OutboxMessageFilter{FailedAfter: now, FailedBefore: now.Add(-time.Hour)}, 10)
+	assert.ErrorIs(t, err, domain.ErrInvalidOutboxFilter)
+
+	repo.AssertExpectations(t)
+}
+
+func TestUpdatePayload(t *testing.T) {
+	repo := new(mockOutboxAdminRepo)
+	uc := usecase.NewOutboxAdminUseCase(repo, events.NewDefaultRegistry())
+	ctx := context.Background()
+	id := uuid.New()
+
+	// The message was written with an older version, the fixed payload is in the current one
+	msg := &domain.OutboxMessage{ID: id, EventType: events.OrderCreatedEventType, SchemaVersion: 1, Status: domain.OutboxStatusFailed}
+	repo.On("GetMessage", ctx, id).Return(msg, nil)
+	repo.On("UpdateFailedMessagePayload", ctx, id, []byte(`{"OrderID":"3f1c2a9e-5d4b-4c8e-9a7f-2b6d1e0c4a53"}`), events.OrderCreatedEventVersion).Return(nil)
+
+	assert.NoError(t, uc.UpdatePayload(ctx, id, []byte(`{"OrderID":"3f1c2a9e-5d4b-4c8e-9a7f-2b6d1e0c4a53"}`)))
+	assert.ErrorIs(t, uc.UpdatePayload(ctx, id, []byte(`{"order_id":`)), domain.ErrInvalidOutboxPayload)
+	// Payloads are checked against the current schema of the event type
+	assert.ErrorIs(t, uc.UpdatePayload(ctx, id, []byte(`{"TotalPrice":12.5}`)), domain.ErrInvalidOutboxPayload)
+
+	repo.AssertNumberOfCalls(t, "UpdateFailedMessagePayload", 1)
+}
+
+func TestRequeueAndDiscard(t *testing.T) {
+	testCases := []struct {
+		name        string
+		filter      domain.OutboxMessageFilter
+		expectedErr error
+	}{
+		{
+			name:   "Success - By ID",
+			filter: domain.OutboxMessageFilter{IDs: []uuid.UUID{uuid.New()}},
+		},
+		{
+			name:   "Success - By time range",
+			filter: domain.OutboxMessageFilter{FailedAfter: time.Now().Add(-time.Hour)},
+		},
+		{
+			name:        "Failure - Empty filter",
+			filter:      domain.OutboxMessageFilter{},
+			expectedErr: domain.ErrInvalidOutboxFilter,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mockOutboxAdminRepo)
+			uc := usecase.NewOutboxAdminUseCase(repo, events.NewDefaultRegistry())
+			ctx := context.Background()
+
+			repo.On("RequeueFailedMessages", ctx, tc.filter).Return(int64(2), nil)
+			repo.On("DiscardFailedMessages", ctx, tc.filter).Return(int64(3), nil)
+
+			requeued, err := uc.Requeue(ctx, tc.filter)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				repo.AssertNotCalled(t, "RequeueFailedMessages", mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(2), requeued)
+			}
+
+			discarded, err := uc.Discard(ctx, tc.filter)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				repo.AssertNotCalled(t, "DiscardFailedMessages", mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(3), discarded)
+			}
+		})
+	}
+}
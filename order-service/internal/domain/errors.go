@@ -11,4 +11,9 @@ var (
 	ErrInvalidQuantity = errors.New("invalid quantity")
 	ErrInvalidPrice = errors.New("invalid price")
 	ErrEmptyOrderItems = errors.New("order must have at least one item")
+	ErrInvalidOrderItemID = errors.New("invalid order item ID")
+	ErrOrderItemNotFound = errors.New("order item not found")
+	ErrOrderNotModifiable = errors.New("order items can only be changed while the order is pending")
+	ErrInvalidOrderStatus = errors.New("invalid order status")
+	ErrInvalidStatusTransition = errors.New("invalid order status transition")
 )
@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"money"
+	"strings"
+	"time"
+)
+
+// Exchange rate errors
+var (
+	ErrExchangeRateNotFound = errors.New("exchange rate not found")
+	ErrInvalidExchangeRate  = errors.New("invalid exchange rate")
+)
+
+// exchangeRateScale is the number of decimal places an exchange rate is kept with.
+// Rates are rounded to this precision when created so that a persisted rate
+// converts amounts exactly like the one that was originally applied.
+const exchangeRateScale = 12
+
+// ExchangeRate is the price of one unit of From expressed in To
+type ExchangeRate struct {
+	From Currency
+	To   Currency
+	Rate *big.Rat
+	AsOf time.Time
+}
+
+// NewExchangeRate creates an exchange rate, rounding it to exchangeRateScale decimal places
+func NewExchangeRate(from, to Currency, rate *big.Rat, asOf time.Time) (ExchangeRate, error) {
+	if from == "" || to == "" {
+		return ExchangeRate{}, ErrInvalidCurrency
+	}
+
+	rounded := money.RoundHalfEven(rate, exchangeRateScale)
+	if rounded.Sign() <= 0 {
+		return ExchangeRate{}, fmt.Errorf("%w: %s to %s must be positive", ErrInvalidExchangeRate, from, to)
+	}
+
+	return ExchangeRate{From: from, To: to, Rate: rounded, AsOf: asOf}, nil
+}
+
+// ParseExchangeRate parses a decimal rate such as "1.0845"
+func ParseExchangeRate(from, to Currency, rate string, asOf time.Time) (ExchangeRate, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(rate))
+	if !ok {
+		return ExchangeRate{}, fmt.Errorf("%w: %q", ErrInvalidExchangeRate, rate)
+	}
+	return NewExchangeRate(from, to, r, asOf)
+}
+
+// Inverse returns the rate converting To back into From
+func (r ExchangeRate) Inverse() (ExchangeRate, error) {
+	if r.Rate == nil || r.Rate.Sign() <= 0 {
+		return ExchangeRate{}, ErrInvalidExchangeRate
+	}
+	return NewExchangeRate(r.To, r.From, new(big.Rat).Inv(r.Rate), r.AsOf)
+}
+
+// Decimal formats the rate as a decimal string without trailing zeros, e.g. "1.0845"
+func (r ExchangeRate) Decimal() string {
+	if r.Rate == nil {
+		return "0"
+	}
+	s := r.Rate.FloatString(exchangeRateScale)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// String formats the rate, e.g. "1 EUR = 1.0845 USD"
+func (r ExchangeRate) String() string {
+	return fmt.Sprintf("1 %s = %s %s", r.From, r.Decimal(), r.To)
+}
+
+// MarshalJSON encodes the rate with a decimal string so no precision is lost
+func (r ExchangeRate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		From string    `json:"from"`
+		To   string    `json:"to"`
+		Rate string    `json:"rate"`
+		AsOf time.Time `json:"as_of"`
+	}{
+		From: string(r.From),
+		To:   string(r.To),
+		Rate: r.Decimal(),
+		AsOf: r.AsOf,
+	})
+}
+
+// UnmarshalJSON decodes {"from": "EUR", "to": "USD", "rate": "1.0845", "as_of": "..."}
+func (r *ExchangeRate) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		From string          `json:"from"`
+		To   string          `json:"to"`
+		Rate json.RawMessage `json:"rate"`
+		AsOf time.Time       `json:"as_of"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	from, err := ParseCurrency(raw.From)
+	if err != nil {
+		return err
+	}
+
+	to, err := ParseCurrency(raw.To)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := ParseExchangeRate(from, to, strings.Trim(string(raw.Rate), `"`), raw.AsOf)
+	if err != nil {
+		return err
+	}
+
+	*r = parsed
+	return nil
+}
+
+// Convert converts an amount in From into To, rounding half to even
+func (r ExchangeRate) Convert(amount Money) (Money, error) {
+	if amount.Currency() != r.From {
+		return Money{}, fmt.Errorf("%w: cannot convert %s with a %s rate", ErrCurrencyMismatch, amount.Currency(), r.From)
+	}
+	if r.Rate == nil {
+		return Money{}, ErrInvalidExchangeRate
+	}
+
+	return amount.Convert(r.Rate, r.To)
+}
@@ -0,0 +1,130 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"order-service/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExchangeRateConvert(t *testing.T) {
+	asOf := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		amount   domain.Money
+		rate     string
+		to       domain.Currency
+		expected domain.Money
+	}{
+		{
+			name:     "EUR to USD",
+			amount:   domain.MustParseMoney("20.00", domain.CurrencyEUR),
+			rate:     "1.10",
+			to:       domain.CurrencyUSD,
+			expected: domain.MustParseMoney("22.00", domain.CurrencyUSD),
+		},
+		{
+			name:     "Rounds half to even",
+			amount:   domain.MustParseMoney("0.05", domain.CurrencyEUR),
+			rate:     "1.5",
+			to:       domain.CurrencyUSD,
+			expected: domain.MustParseMoney("0.08", domain.CurrencyUSD),
+		},
+		{
+			name:     "USD to JPY has no minor units",
+			amount:   domain.MustParseMoney("10.00", domain.CurrencyUSD),
+			rate:     "157.25",
+			to:       domain.CurrencyJPY,
+			expected: domain.MustParseMoney("1572", domain.CurrencyJPY),
+		},
+		{
+			name:     "JPY to USD",
+			amount:   domain.MustParseMoney("1000", domain.CurrencyJPY),
+			rate:     "0.0064",
+			to:       domain.CurrencyUSD,
+			expected: domain.MustParseMoney("6.40", domain.CurrencyUSD),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rate, err := domain.ParseExchangeRate(tc.amount.Currency(), tc.to, tc.rate, asOf)
+			assert.NoError(t, err)
+
+			converted, err := rate.Convert(tc.amount)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, converted)
+		})
+	}
+
+	rate, _ := domain.ParseExchangeRate(domain.CurrencyEUR, domain.CurrencyUSD, "1.10", asOf)
+	_, err := rate.Convert(domain.MustParseMoney("1.00", domain.CurrencyGBP))
+	assert.ErrorIs(t, err, domain.ErrCurrencyMismatch)
+}
+
+func TestParseExchangeRate(t *testing.T) {
+	_, err := domain.ParseExchangeRate(domain.CurrencyEUR, domain.CurrencyUSD, "abc", time.Now())
+	assert.ErrorIs(t, err, domain.ErrInvalidExchangeRate)
+
+	_, err = domain.ParseExchangeRate(domain.CurrencyEUR, domain.CurrencyUSD, "0", time.Now())
+	assert.ErrorIs(t, err, domain.ErrInvalidExchangeRate)
+
+	// Rates keep a fixed precision so a stored rate converts exactly like the original
+	rate, err := domain.ParseExchangeRate(domain.CurrencyEUR, domain.CurrencyUSD, "1.08450000000049", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0845", rate.Decimal())
+
+	inverse, err := rate.Inverse()
+	assert.NoError(t, err)
+	assert.Equal(t, domain.CurrencyUSD, inverse.From)
+	assert.Equal(t, "0.922083909636", inverse.Decimal())
+}
+
+func TestExchangeRateJSON(t *testing.T) {
+	asOf := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	rate, _ := domain.ParseExchangeRate(domain.CurrencyEUR, domain.CurrencyUSD, "1.0845", asOf)
+
+	data, err := json.Marshal(rate)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"from":"EUR","to":"USD","rate":"1.0845","as_of":"2025-01-01T00:00:00Z"}`, string(data))
+
+	var decoded domain.ExchangeRate
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, rate.Decimal(), decoded.Decimal())
+	assert.Equal(t, rate.From, decoded.From)
+	assert.True(t, rate.AsOf.Equal(decoded.AsOf))
+}
+
+func TestNewOrderWithExchangeRates(t *testing.T) {
+	rate, _ := domain.ParseExchangeRate(domain.CurrencyEUR, domain.CurrencyUSD, "1.10", time.Now())
+
+	order, err := domain.NewOrder("customer-123", domain.CurrencyUSD, []domain.OrderItem{
+		{ProductID: "product-1", Quantity: 2, Price: domain.MustParseMoney("10.00", domain.CurrencyUSD)},
+		{ProductID: "product-2", Quantity: 1, Price: domain.MustParseMoney("20.00", domain.CurrencyEUR)},
+	}, []domain.ExchangeRate{rate})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.MustParseMoney("42.00", domain.CurrencyUSD), order.TotalPrice)
+
+	// Later items reuse the snapshot rate
+	assert.NoError(t, order.AddItem("product-3", 1, domain.MustParseMoney("10.00", domain.CurrencyEUR)))
+	assert.Equal(t, domain.MustParseMoney("53.00", domain.CurrencyUSD), order.TotalPrice)
+
+	total, err := order.CalculateTotalPrice()
+	assert.NoError(t, err)
+	assert.Equal(t, order.TotalPrice, total)
+
+	// Rates must convert into the order currency
+	wrong, _ := domain.ParseExchangeRate(domain.CurrencyUSD, domain.CurrencyEUR, "0.9", time.Now())
+	assert.ErrorIs(t, order.AddExchangeRate(wrong), domain.ErrCurrencyMismatch)
+}
+
+func TestNewOrderWithoutExchangeRate(t *testing.T) {
+	_, err := domain.NewOrder("customer-123", domain.CurrencyUSD, []domain.OrderItem{
+		{ProductID: "product-1", Quantity: 1, Price: domain.MustParseMoney("10.00", domain.CurrencyUSD)},
+		{ProductID: "product-2", Quantity: 1, Price: domain.MustParseMoney("10.00", domain.CurrencyEUR)},
+	}, nil)
+	assert.ErrorIs(t, err, domain.ErrExchangeRateNotFound)
+}
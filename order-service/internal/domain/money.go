@@ -0,0 +1,51 @@
+package domain
+
+import "money"
+
+// Money is an exact monetary amount in the minor units of a currency, shared with the other services
+type Money = money.Money
+
+// Currency is an ISO 4217 currency code
+type Currency = money.Currency
+
+// Money errors
+var (
+	ErrCurrencyMismatch = money.ErrCurrencyMismatch
+	ErrInvalidCurrency  = money.ErrInvalidCurrency
+	ErrInvalidAmount    = money.ErrInvalidAmount
+	ErrMoneyOverflow    = money.ErrMoneyOverflow
+)
+
+// Commonly used currencies
+const (
+	CurrencyUSD = money.CurrencyUSD
+	CurrencyEUR = money.CurrencyEUR
+	CurrencyGBP = money.CurrencyGBP
+	CurrencyJPY = money.CurrencyJPY
+	CurrencyVND = money.CurrencyVND
+)
+
+// ParseCurrency validates and normalizes an ISO 4217 currency code
+func ParseCurrency(code string) (Currency, error) {
+	return money.ParseCurrency(code)
+}
+
+// NewMoney creates a Money value from an amount in minor units
+func NewMoney(minorUnits int64, currency Currency) Money {
+	return money.NewMoney(minorUnits, currency)
+}
+
+// Zero returns a zero amount in the given currency
+func Zero(currency Currency) Money {
+	return money.Zero(currency)
+}
+
+// ParseMoney parses a decimal string such as "12.34" into Money
+func ParseMoney(amount string, currency Currency) (Money, error) {
+	return money.ParseMoney(amount, currency)
+}
+
+// MustParseMoney is like ParseMoney but panics on error. Intended for constants and tests.
+func MustParseMoney(amount string, currency Currency) Money {
+	return money.MustParseMoney(amount, currency)
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,19 +21,27 @@ const (
 type Order struct {
 	ID         uuid.UUID
 	CustomerID string
+	Currency   Currency
 	Items      []OrderItem
-	TotalPrice float64
+	TotalPrice Money
 	Status     OrderStatus
 	SagaID     uuid.UUID
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
+
+	// StatusHistory holds every status transition the order went through
+	StatusHistory []StatusTransition
+
+	// ExchangeRates is the snapshot of rates used to convert item prices
+	// into the order currency, keyed by the item currency
+	ExchangeRates []ExchangeRate
 }
 
 type OrderItem struct {
 	ID        uuid.UUID
 	ProductID string
 	Quantity  int32
-	Price     float64
+	Price     Money
 }
 
 type OrderEvent struct {
@@ -44,36 +53,61 @@ type OrderEvent struct {
 	Data        interface{} `json:"data"`
 }
 
-// NewOrder creates a new order with the given details
-func NewOrder(customerID string, items []OrderItem) *Order {
+// NewOrder creates a new order with the given details.
+// Items priced in another currency than the order are converted with the given rates,
+// which are kept with the order so its total can always be recalculated the same way.
+func NewOrder(customerID string, currency Currency, items []OrderItem, rates []ExchangeRate) (*Order, error) {
 	orderID := uuid.New()
 	now := time.Now()
 
-	var totalPrice float64
-	for _, item := range items {
-		totalPrice += item.Price * float64(item.Quantity)
+	if currency == "" {
+		return nil, ErrInvalidCurrency
 	}
 
-	return &Order{
+	order := &Order{
 		ID:         orderID,
 		CustomerID: customerID,
+		Currency:   currency,
 		Status:     OrderStatusPending,
 		SagaID: uuid.New(),
-		TotalPrice: totalPrice,
 		Items:      items,
 		CreatedAt:  now,
 		UpdatedAt:  now,
 	}
+
+	for _, rate := range rates {
+		if err := order.AddExchangeRate(rate); err != nil {
+			return nil, err
+		}
+	}
+
+	totalPrice, err := order.CalculateTotalPrice()
+	if err != nil {
+		return nil, err
+	}
+	order.TotalPrice = totalPrice
+
+	return order, nil
 }
 
-// ChangeStatus updates the status of an order
-func (o *Order) ChangeStatus(status OrderStatus) {
-	o.Status = status
-	o.UpdatedAt = time.Now()
+// Subtotal returns the price of the item multiplied by its quantity
+func (i OrderItem) Subtotal() (Money, error) {
+	return i.Price.Multiply(int64(i.Quantity))
+}
+
+// ChangeStatus moves the order to the given status.
+// It returns a *StatusTransitionError if the order lifecycle does not allow the change.
+func (o *Order) ChangeStatus(status OrderStatus) error {
+	return o.TransitionTo(status, "")
+}
+
+// TransitionTo moves the order to the given status and records the reason in its history
+func (o *Order) TransitionTo(status OrderStatus, reason string) error {
+	return DefaultOrderStateMachine.Transition(o, status, reason)
 }
 
 // AddItem adds an item to the order and recalculates the total price
-func (o *Order) AddItem(productID string, quantity int32, price float64) {
+func (o *Order) AddItem(productID string, quantity int32, price Money) error {
 	item := OrderItem{
 		ID:        uuid.New(),
 		ProductID: productID,
@@ -81,30 +115,103 @@ func (o *Order) AddItem(productID string, quantity int32, price float64) {
 		Price:     price,
 	}
 
+	subtotal, err := o.itemTotal(item)
+	if err != nil {
+		return err
+	}
+
+	totalPrice, err := o.TotalPrice.Add(subtotal)
+	if err != nil {
+		return err
+	}
+
 	o.Items = append(o.Items, item)
-	o.TotalPrice += price * float64(quantity)
+	o.TotalPrice = totalPrice
 	o.UpdatedAt = time.Now()
+	return nil
 }
 
 // RemoveItem removes an item from the order by its ID
-func (o *Order) RemoveItem(itemID uuid.UUID) bool {
+func (o *Order) RemoveItem(itemID uuid.UUID) error {
 	for i, item := range o.Items {
 		if item.ID == itemID {
-			o.TotalPrice -= item.Price * float64(item.Quantity)
+			subtotal, err := o.itemTotal(item)
+			if err != nil {
+				return err
+			}
+
+			totalPrice, err := o.TotalPrice.Sub(subtotal)
+			if err != nil {
+				return err
+			}
+
+			o.TotalPrice = totalPrice
 			o.Items = append(o.Items[:i], o.Items[i+1:]...)
 			o.UpdatedAt = time.Now()
-			return true
+			return nil
+		}
+	}
+	return ErrOrderItemNotFound
+}
+
+
+// AddExchangeRate adds a rate into the order currency to the order's snapshot.
+// A rate that is already in the snapshot is never replaced.
+func (o *Order) AddExchangeRate(rate ExchangeRate) error {
+	if rate.To != o.Currency {
+		return fmt.Errorf("%w: rate %s does not convert into %s", ErrCurrencyMismatch, rate, o.Currency)
+	}
+
+	if _, ok := o.ExchangeRate(rate.From); ok {
+		return nil
+	}
+
+	o.ExchangeRates = append(o.ExchangeRates, rate)
+	return nil
+}
+
+// ExchangeRate returns the snapshot rate converting the given currency into the order currency
+func (o *Order) ExchangeRate(from Currency) (ExchangeRate, bool) {
+	for _, rate := range o.ExchangeRates {
+		if rate.From == from {
+			return rate, true
 		}
 	}
-	return false
+	return ExchangeRate{}, false
 }
 
+// itemTotal returns the subtotal of an item converted into the order currency
+func (o *Order) itemTotal(item OrderItem) (Money, error) {
+	subtotal, err := item.Subtotal()
+	if err != nil {
+		return Money{}, err
+	}
+
+	if subtotal.Currency() == o.Currency {
+		return subtotal, nil
+	}
+
+	rate, ok := o.ExchangeRate(subtotal.Currency())
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s to %s", ErrExchangeRateNotFound, subtotal.Currency(), o.Currency)
+	}
 
-// Calculate total order value
-func (o *Order) CalculateTotalPrice() float64 {
-	total := 0.0
+	return rate.Convert(subtotal)
+}
+
+// Calculate total order value in the order currency
+func (o *Order) CalculateTotalPrice() (Money, error) {
+	total := Zero(o.Currency)
 	for _, item := range o.Items {
-		total += item.Price * float64(item.Quantity)
+		subtotal, err := o.itemTotal(item)
+		if err != nil {
+			return Money{}, err
+		}
+
+		total, err = total.Add(subtotal)
+		if err != nil {
+			return Money{}, err
+		}
 	}
-	return total
+	return total, nil
 }
\ No newline at end of file
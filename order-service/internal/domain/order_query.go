@@ -0,0 +1,227 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Order listing errors
+var (
+	ErrInvalidCursor    = errors.New("invalid page cursor")
+	ErrInvalidOrderSort = errors.New("invalid order sort")
+	ErrInvalidFilter    = errors.New("invalid order filter")
+)
+
+const (
+	// DefaultOrderPageSize is used when a listing does not ask for a page size
+	DefaultOrderPageSize = 10
+	// MaxOrderPageSize caps the number of orders returned in one page
+	MaxOrderPageSize = 100
+)
+
+// OrderSortField is a column orders can be listed by
+type OrderSortField string
+
+const (
+	OrderSortByCreatedAt OrderSortField = "created_at"
+	// OrderSortByTotalPrice compares raw amounts, so it needs a filter on a single currency
+	OrderSortByTotalPrice OrderSortField = "total_price"
+)
+
+// OrderSort is the ordering of an order listing.
+// Orders with equal sort values are ordered by ID so every position is unique.
+type OrderSort struct {
+	Field      OrderSortField
+	Descending bool
+}
+
+// DefaultOrderSort lists the newest orders first
+var DefaultOrderSort = OrderSort{Field: OrderSortByCreatedAt, Descending: true}
+
+// ParseOrderSort parses a sort such as "created_at" or "-total_price".
+// A leading "-" sorts in descending order, an empty string gives DefaultOrderSort.
+func ParseOrderSort(sort string) (OrderSort, error) {
+	if sort == "" {
+		return DefaultOrderSort, nil
+	}
+
+	s := OrderSort{}
+	if strings.HasPrefix(sort, "-") {
+		s.Descending = true
+		sort = sort[1:]
+	}
+
+	s.Field = OrderSortField(strings.ToLower(sort))
+	if !s.Field.IsValid() {
+		return OrderSort{}, fmt.Errorf("%w: %q", ErrInvalidOrderSort, sort)
+	}
+
+	return s, nil
+}
+
+// IsValid reports whether orders can be sorted by the field
+func (f OrderSortField) IsValid() bool {
+	return f == OrderSortByCreatedAt || f == OrderSortByTotalPrice
+}
+
+// String formats the sort the way ParseOrderSort accepts it
+func (s OrderSort) String() string {
+	if s.Descending {
+		return "-" + string(s.Field)
+	}
+	return string(s.Field)
+}
+
+// OrderFilter restricts which orders are listed. Zero values do not filter.
+type OrderFilter struct {
+	CustomerID    string
+	Statuses      []OrderStatus
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// Currency only matches orders in the currency
+	Currency Currency
+	// MinTotal and MaxTotal only match orders in the currency of the bound
+	MinTotal *Money
+	MaxTotal *Money
+}
+
+// Validate checks that the filter is consistent
+func (f OrderFilter) Validate() error {
+	for _, status := range f.Statuses {
+		if !status.IsValid() {
+			return fmt.Errorf("%w: %q", ErrInvalidOrderStatus, status)
+		}
+	}
+
+	if !f.CreatedAfter.IsZero() && !f.CreatedBefore.IsZero() && f.CreatedBefore.Before(f.CreatedAfter) {
+		return fmt.Errorf("%w: created_before is before created_after", ErrInvalidFilter)
+	}
+
+	for _, bound := range []*Money{f.MinTotal, f.MaxTotal} {
+		if f.Currency != "" && bound != nil && bound.Currency() != f.Currency {
+			return fmt.Errorf("%w: total bound in %s, currency filter %s", ErrCurrencyMismatch, bound.Currency(), f.Currency)
+		}
+	}
+
+	if f.MinTotal != nil && f.MaxTotal != nil {
+		cmp, err := f.MinTotal.Cmp(*f.MaxTotal)
+		if err != nil {
+			return err
+		}
+		if cmp > 0 {
+			return fmt.Errorf("%w: min_total is greater than max_total", ErrInvalidFilter)
+		}
+	}
+
+	return nil
+}
+
+// TotalCurrency returns the single currency the listed orders are in, if any.
+// It is the currency filter, or else the currency the total bounds are expressed in.
+func (f OrderFilter) TotalCurrency() Currency {
+	if f.Currency != "" {
+		return f.Currency
+	}
+	if f.MinTotal != nil {
+		return f.MinTotal.Currency()
+	}
+	if f.MaxTotal != nil {
+		return f.MaxTotal.Currency()
+	}
+	return ""
+}
+
+// OrderListQuery describes one page of an order listing
+type OrderListQuery struct {
+	Filter OrderFilter
+	Sort   OrderSort
+	Limit  int
+	// After is the position the page starts after, nil for the first page
+	After *OrderCursor
+}
+
+// OrderPage is one page of an order listing
+type OrderPage struct {
+	Orders []*Order
+	// NextCursor is the opaque token of the next page, empty on the last page
+	NextCursor string
+}
+
+// OrderCursor is the position of an order within a sorted listing
+type OrderCursor struct {
+	Sort       OrderSort
+	CreatedAt  time.Time
+	TotalPrice string
+	ID         uuid.UUID
+}
+
+type cursorToken struct {
+	Sort  string    `json:"s"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// NewOrderCursor returns the position of the order in a listing with the given sort
+func NewOrderCursor(sort OrderSort, order *Order) OrderCursor {
+	return OrderCursor{
+		Sort:       sort,
+		CreatedAt:  order.CreatedAt,
+		TotalPrice: order.TotalPrice.Decimal(),
+		ID:         order.ID,
+	}
+}
+
+// Encode returns the cursor as an opaque URL-safe token
+func (c OrderCursor) Encode() string {
+	token := cursorToken{Sort: c.Sort.String(), ID: c.ID}
+	switch c.Sort.Field {
+	case OrderSortByCreatedAt:
+		token.Value = c.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case OrderSortByTotalPrice:
+		token.Value = c.TotalPrice
+	}
+
+	data, _ := json.Marshal(token)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeOrderCursor parses a token returned by OrderCursor.Encode
+func DecodeOrderCursor(encoded string) (OrderCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return OrderCursor{}, ErrInvalidCursor
+	}
+
+	var token cursorToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return OrderCursor{}, ErrInvalidCursor
+	}
+
+	sort, err := ParseOrderSort(token.Sort)
+	if err != nil || token.Sort == "" || token.ID == uuid.Nil {
+		return OrderCursor{}, ErrInvalidCursor
+	}
+
+	cursor := OrderCursor{Sort: sort, ID: token.ID}
+	switch sort.Field {
+	case OrderSortByCreatedAt:
+		cursor.CreatedAt, err = time.Parse(time.RFC3339Nano, token.Value)
+		if err != nil {
+			return OrderCursor{}, ErrInvalidCursor
+		}
+	case OrderSortByTotalPrice:
+		if _, ok := new(big.Rat).SetString(token.Value); !ok {
+			return OrderCursor{}, ErrInvalidCursor
+		}
+		cursor.TotalPrice = token.Value
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,66 @@
+package domain_test
+
+import (
+	"order-service/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOrderSort(t *testing.T) {
+	sort, err := domain.ParseOrderSort("")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.DefaultOrderSort, sort)
+
+	sort, err = domain.ParseOrderSort("-total_price")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OrderSort{Field: domain.OrderSortByTotalPrice, Descending: true}, sort)
+	assert.Equal(t, "-total_price", sort.String())
+
+	_, err = domain.ParseOrderSort("customer_id")
+	assert.ErrorIs(t, err, domain.ErrInvalidOrderSort)
+}
+
+func TestOrderCursor(t *testing.T) {
+	order := newTestOrder()
+	order.CreatedAt = time.Date(2025, 3, 1, 12, 30, 0, 123456000, time.UTC)
+
+	for _, sort := range []domain.OrderSort{
+		{Field: domain.OrderSortByCreatedAt, Descending: true},
+		{Field: domain.OrderSortByTotalPrice},
+	} {
+		cursor := domain.NewOrderCursor(sort, order)
+
+		decoded, err := domain.DecodeOrderCursor(cursor.Encode())
+		assert.NoError(t, err)
+		assert.Equal(t, sort, decoded.Sort)
+		assert.Equal(t, order.ID, decoded.ID)
+
+		switch sort.Field {
+		case domain.OrderSortByCreatedAt:
+			assert.True(t, order.CreatedAt.Equal(decoded.CreatedAt))
+		case domain.OrderSortByTotalPrice:
+			assert.Equal(t, "10.00", decoded.TotalPrice)
+		}
+	}
+
+	for _, token := range []string{"", "not base64!", "e30", "eyJzIjoiLWNyZWF0ZWRfYXQifQ"} {
+		_, err := domain.DecodeOrderCursor(token)
+		assert.ErrorIs(t, err, domain.ErrInvalidCursor, token)
+	}
+}
+
+func TestOrderFilterValidate(t *testing.T) {
+	min := domain.MustParseMoney("20.00", domain.CurrencyUSD)
+	max := domain.MustParseMoney("10.00", domain.CurrencyUSD)
+
+	assert.NoError(t, domain.OrderFilter{}.Validate())
+	assert.ErrorIs(t, domain.OrderFilter{MinTotal: &min, MaxTotal: &max}.Validate(), domain.ErrInvalidFilter)
+
+	now := time.Now()
+	filter := domain.OrderFilter{CreatedAfter: now, CreatedBefore: now.Add(-time.Hour)}
+	assert.ErrorIs(t, filter.Validate(), domain.ErrInvalidFilter)
+
+	assert.Equal(t, domain.CurrencyUSD, domain.OrderFilter{MaxTotal: &max}.TotalCurrency())
+}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrOrderSagaNotFound is returned for an order whose saga is not recorded
+var ErrOrderSagaNotFound = errors.New("order saga not found")
+
+// OrderSaga tracks the outcomes the inventory and the payment service reported for an order.
+// They may arrive in any order, the order is confirmed once both succeeded.
+type OrderSaga struct {
+	ID               uuid.UUID
+	OrderID          uuid.UUID
+	ProductsReserved bool
+	PaymentApproved  bool
+	// Compensated is set once order.cancelled was emitted for the saga. It is emitted only once,
+	// consumers release and refund everything they hold for the order when they receive it.
+	Compensated bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// NewOrderSaga starts the saga of the order under the order's saga ID
+func NewOrderSaga(order *Order) *OrderSaga {
+	now := time.Now()
+	return &OrderSaga{
+		ID:        order.SagaID,
+		OrderID:   order.ID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Completed reports whether the products of the order are reserved and its payment approved
+func (s *OrderSaga) Completed() bool {
+	return s.ProductsReserved && s.PaymentApproved
+}
@@ -0,0 +1,170 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StatusTransition records a single change of an order's status
+type StatusTransition struct {
+	ID        uuid.UUID
+	From      OrderStatus
+	To        OrderStatus
+	Reason    string
+	ChangedAt time.Time
+}
+
+// StatusTransitionError is returned when an order cannot move from one status to another.
+// It wraps ErrInvalidStatusTransition so callers can match it with errors.Is.
+type StatusTransitionError struct {
+	From   OrderStatus
+	To     OrderStatus
+	Reason string
+}
+
+// Error implements the error interface
+func (e *StatusTransitionError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("%s: %s -> %s: %s", ErrInvalidStatusTransition, e.From, e.To, e.Reason)
+	}
+	return fmt.Sprintf("%s: %s -> %s", ErrInvalidStatusTransition, e.From, e.To)
+}
+
+// Unwrap returns ErrInvalidStatusTransition
+func (e *StatusTransitionError) Unwrap() error {
+	return ErrInvalidStatusTransition
+}
+
+// TransitionGuard is consulted before a transition is applied.
+// Returning an error vetoes the transition.
+type TransitionGuard func(order *Order, from, to OrderStatus) error
+
+type transitionKey struct {
+	from OrderStatus
+	to   OrderStatus
+}
+
+// OrderStateMachine defines which status changes an order may go through
+// and the guards that have to pass for each of them
+type OrderStateMachine struct {
+	transitions map[OrderStatus][]OrderStatus
+	guards      map[transitionKey][]TransitionGuard
+}
+
+// DefaultOrderStateMachine is the state machine used by Order.ChangeStatus.
+// Guards should be registered on it during startup only.
+var DefaultOrderStateMachine = NewOrderStateMachine()
+
+// NewOrderStateMachine creates a state machine with the standard order lifecycle:
+//
+//	PENDING   -> CONFIRMED, FAILED, CANCELLED
+//	CONFIRMED -> SHIPPED, CANCELLED
+//	SHIPPED   -> DELIVERED
+//
+// DELIVERED, FAILED and CANCELLED are terminal.
+func NewOrderStateMachine() *OrderStateMachine {
+	m := &OrderStateMachine{
+		transitions: map[OrderStatus][]OrderStatus{
+			OrderStatusPending:   {OrderStatusConfirmed, OrderStatusFailed, OrderStatusCancelled},
+			OrderStatusConfirmed: {OrderStatusShipped, OrderStatusCancelled},
+			OrderStatusShipped:   {OrderStatusDelivered},
+		},
+		guards: make(map[transitionKey][]TransitionGuard),
+	}
+
+	// An order without items can never be confirmed
+	m.AddGuard(OrderStatusPending, OrderStatusConfirmed, func(order *Order, from, to OrderStatus) error {
+		if len(order.Items) == 0 {
+			return ErrEmptyOrderItems
+		}
+		return nil
+	})
+
+	return m
+}
+
+// AddGuard registers a guard for the given transition
+func (m *OrderStateMachine) AddGuard(from, to OrderStatus, guard TransitionGuard) {
+	key := transitionKey{from: from, to: to}
+	m.guards[key] = append(m.guards[key], guard)
+}
+
+// CanTransition reports whether the transition table allows moving from one status to another
+func (m *OrderStateMachine) CanTransition(from, to OrderStatus) bool {
+	for _, allowed := range m.transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedTransitions returns the statuses reachable from the given status
+func (m *OrderStateMachine) AllowedTransitions(from OrderStatus) []OrderStatus {
+	allowed := make([]OrderStatus, len(m.transitions[from]))
+	copy(allowed, m.transitions[from])
+	return allowed
+}
+
+// Transition moves the order to the given status if the transition is allowed
+// and all guards pass, and records it in the order's status history
+func (m *OrderStateMachine) Transition(order *Order, to OrderStatus, reason string) error {
+	from := order.Status
+	if !to.IsValid() {
+		return &StatusTransitionError{From: from, To: to, Reason: ErrInvalidOrderStatus.Error()}
+	}
+
+	if !m.CanTransition(from, to) {
+		return &StatusTransitionError{From: from, To: to}
+	}
+
+	for _, guard := range m.guards[transitionKey{from: from, to: to}] {
+		if err := guard(order, from, to); err != nil {
+			return &StatusTransitionError{From: from, To: to, Reason: err.Error()}
+		}
+	}
+
+	now := time.Now()
+	order.Status = to
+	order.UpdatedAt = now
+	order.StatusHistory = append(order.StatusHistory, StatusTransition{
+		ID:        uuid.New(),
+		From:      from,
+		To:        to,
+		Reason:    reason,
+		ChangedAt: now,
+	})
+
+	return nil
+}
+
+// IsValid reports whether the status is one of the known order statuses
+func (s OrderStatus) IsValid() bool {
+	switch s {
+	case OrderStatusPending,
+		OrderStatusConfirmed,
+		OrderStatusFailed,
+		OrderStatusShipped,
+		OrderStatusDelivered,
+		OrderStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// IsTerminal reports whether no further transitions are possible from the status
+func (s OrderStatus) IsTerminal() bool {
+	return s == OrderStatusDelivered || s == OrderStatusFailed || s == OrderStatusCancelled
+}
+
+// ParseOrderStatus converts a case-insensitive string into an OrderStatus
+func ParseOrderStatus(status string) (OrderStatus, error) {
+	s := OrderStatus(strings.ToUpper(status))
+	if !s.IsValid() {
+		return "", ErrInvalidOrderStatus
+	}
+	return s, nil
+}
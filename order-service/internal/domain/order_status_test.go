@@ -0,0 +1,109 @@
+package domain_test
+
+import (
+	"errors"
+	"order-service/internal/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestOrder() *domain.Order {
+	order, _ := domain.NewOrder("customer-123", domain.CurrencyUSD, []domain.OrderItem{
+		{ProductID: "product-1", Quantity: 1, Price: domain.MustParseMoney("10.00", domain.CurrencyUSD)},
+	}, nil)
+	return order
+}
+
+func TestOrderChangeStatus(t *testing.T) {
+	testCases := []struct {
+		name        string
+		path        []domain.OrderStatus
+		to          domain.OrderStatus
+		expectedErr bool
+	}{
+		{name: "Pending to confirmed", to: domain.OrderStatusConfirmed},
+		{name: "Pending to failed", to: domain.OrderStatusFailed},
+		{name: "Pending to cancelled", to: domain.OrderStatusCancelled},
+		{name: "Pending to shipped", to: domain.OrderStatusShipped, expectedErr: true},
+		{name: "Confirmed to shipped", path: []domain.OrderStatus{domain.OrderStatusConfirmed}, to: domain.OrderStatusShipped},
+		{name: "Confirmed to cancelled", path: []domain.OrderStatus{domain.OrderStatusConfirmed}, to: domain.OrderStatusCancelled},
+		{name: "Shipped to delivered", path: []domain.OrderStatus{domain.OrderStatusConfirmed, domain.OrderStatusShipped}, to: domain.OrderStatusDelivered},
+		{name: "Shipped to cancelled", path: []domain.OrderStatus{domain.OrderStatusConfirmed, domain.OrderStatusShipped}, to: domain.OrderStatusCancelled, expectedErr: true},
+		{name: "Delivered to pending", path: []domain.OrderStatus{domain.OrderStatusConfirmed, domain.OrderStatusShipped, domain.OrderStatusDelivered}, to: domain.OrderStatusPending, expectedErr: true},
+		{name: "Cancelled to shipped", path: []domain.OrderStatus{domain.OrderStatusCancelled}, to: domain.OrderStatusShipped, expectedErr: true},
+		{name: "Unknown status", to: domain.OrderStatus("LOST"), expectedErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			order := newTestOrder()
+			for _, status := range tc.path {
+				assert.NoError(t, order.ChangeStatus(status))
+			}
+			from := order.Status
+
+			err := order.ChangeStatus(tc.to)
+
+			if tc.expectedErr {
+				assert.ErrorIs(t, err, domain.ErrInvalidStatusTransition)
+				var transitionErr *domain.StatusTransitionError
+				assert.True(t, errors.As(err, &transitionErr))
+				assert.Equal(t, from, transitionErr.From)
+				assert.Equal(t, tc.to, transitionErr.To)
+				assert.Equal(t, from, order.Status)
+				assert.Len(t, order.StatusHistory, len(tc.path))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.to, order.Status)
+				assert.Len(t, order.StatusHistory, len(tc.path)+1)
+			}
+		})
+	}
+}
+
+func TestOrderTransitionHistory(t *testing.T) {
+	order := newTestOrder()
+
+	assert.NoError(t, order.TransitionTo(domain.OrderStatusConfirmed, "payment approved"))
+	assert.NoError(t, order.TransitionTo(domain.OrderStatusCancelled, "customer request"))
+
+	assert.Len(t, order.StatusHistory, 2)
+	assert.Equal(t, domain.OrderStatusPending, order.StatusHistory[0].From)
+	assert.Equal(t, domain.OrderStatusConfirmed, order.StatusHistory[0].To)
+	assert.Equal(t, "payment approved", order.StatusHistory[0].Reason)
+	assert.Equal(t, domain.OrderStatusConfirmed, order.StatusHistory[1].From)
+	assert.Equal(t, domain.OrderStatusCancelled, order.StatusHistory[1].To)
+	assert.Equal(t, "customer request", order.StatusHistory[1].Reason)
+}
+
+func TestOrderStateMachineGuards(t *testing.T) {
+	machine := domain.NewOrderStateMachine()
+	errNotPaid := errors.New("order not paid")
+	machine.AddGuard(domain.OrderStatusConfirmed, domain.OrderStatusShipped, func(order *domain.Order, from, to domain.OrderStatus) error {
+		return errNotPaid
+	})
+
+	order := newTestOrder()
+	assert.NoError(t, machine.Transition(order, domain.OrderStatusConfirmed, ""))
+
+	err := machine.Transition(order, domain.OrderStatusShipped, "")
+	assert.ErrorIs(t, err, domain.ErrInvalidStatusTransition)
+	assert.Contains(t, err.Error(), errNotPaid.Error())
+	assert.Equal(t, domain.OrderStatusConfirmed, order.Status)
+
+	// Orders without items can not be confirmed
+	empty, _ := domain.NewOrder("customer-123", domain.CurrencyUSD, nil, nil)
+	err = machine.Transition(empty, domain.OrderStatusConfirmed, "")
+	assert.ErrorIs(t, err, domain.ErrInvalidStatusTransition)
+	assert.Equal(t, domain.OrderStatusPending, empty.Status)
+}
+
+func TestParseOrderStatus(t *testing.T) {
+	status, err := domain.ParseOrderStatus("shipped")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OrderStatusShipped, status)
+
+	_, err = domain.ParseOrderStatus("lost")
+	assert.ErrorIs(t, err, domain.ErrInvalidOrderStatus)
+}
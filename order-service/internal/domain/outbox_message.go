@@ -1,27 +1,87 @@
 package domain
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Outbox administration errors
+var (
+	ErrOutboxMessageNotFound  = errors.New("outbox message not found")
+	ErrOutboxMessageNotFailed = errors.New("outbox message is not failed")
+	ErrInvalidOutboxPayload   = errors.New("outbox payload must be a JSON document")
+	ErrInvalidOutboxFilter    = errors.New("invalid outbox filter")
+)
+
 type OutboxStatus string
 
 const (
 	OutboxStatusPending   OutboxStatus = "PENDING"
 	OutboxStatusProcessed OutboxStatus = "PROCESSED"
 	OutboxStatusFailed    OutboxStatus = "FAILED"
+	// OutboxStatusDiscarded marks failed messages an operator gave up on
+	OutboxStatusDiscarded OutboxStatus = "DISCARDED"
+	// OutboxStatusParked marks messages of an event type the service cannot publish.
+	// They return to PENDING once a worker that knows the type starts.
+	OutboxStatusParked OutboxStatus = "PARKED"
+)
+
+const (
+	// DefaultOutboxPageSize is used when a listing of failed messages does not ask for a page size
+	DefaultOutboxPageSize = 50
+	// MaxOutboxPageSize caps the number of failed messages returned at once
+	MaxOutboxPageSize = 500
 )
 
 // OutputMessage represents a message in the outbox queue
 type OutboxMessage struct {
-	ID           uuid.UUID
-	AggregateID  uuid.UUID
+	ID          uuid.UUID
+	AggregateID uuid.UUID
+	// SequenceNumber orders the messages of one aggregate, starting at 1
+	SequenceNumber int64
+	EventType      string
+	Payload        []byte
+	Status         OutboxStatus
+	AttemptCount   int
+	CreatedAt      time.Time
+	ProcessedAt    time.Time
+	// LockedBy is the worker holding the message until LockedUntil
+	LockedBy    string
+	LockedUntil time.Time
+	// NextAttemptAt is when a failed message is retried, zero if it is due right away
+	NextAttemptAt time.Time
+	// LastError is the error of the latest failed attempt
+	LastError string
+	// FailedAt is when the message ran out of attempts
+	FailedAt time.Time
+	// DeadLetteredAt is when the failed message was forwarded to the dead-letter topic
+	DeadLetteredAt time.Time
+	// DeadLetterAttempts counts the failed attempts to forward it there
+	DeadLetterAttempts int
+	// SchemaVersion is the version of its event type the payload was written with
+	SchemaVersion int
+}
+
+// OutboxMessageFilter selects failed outbox messages. Zero values do not filter.
+type OutboxMessageFilter struct {
+	IDs          []uuid.UUID
 	EventType    string
-	Payload      []byte
-	Status       OutboxStatus
-	AttemptCount int
-	CreatedAt    time.Time
-	ProcessedAt  time.Time
+	FailedAfter  time.Time
+	FailedBefore time.Time
+}
+
+// IsEmpty reports whether the filter matches every failed message
+func (f OutboxMessageFilter) IsEmpty() bool {
+	return len(f.IDs) == 0 && f.EventType == "" && f.FailedAfter.IsZero() && f.FailedBefore.IsZero()
+}
+
+// Validate checks that the filter is consistent
+func (f OutboxMessageFilter) Validate() error {
+	if !f.FailedAfter.IsZero() && !f.FailedBefore.IsZero() && f.FailedBefore.Before(f.FailedAfter) {
+		return fmt.Errorf("%w: failed_before is before failed_after", ErrInvalidOutboxFilter)
+	}
+	return nil
 }
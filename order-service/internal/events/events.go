@@ -7,12 +7,102 @@ import (
 	"github.com/google/uuid"
 )
 
+// Event types written to the outbox
+const (
+	OrderCreatedEventType       = "order.created"
+	OrderStatusChangedEventType = "order.status_changed"
+	OrderItemAddedEventType     = "order.item_added"
+	OrderItemRemovedEventType   = "order.item_removed"
+	OrderCancelledEventType     = "order.cancelled"
+)
+
 type OrderCreatedEvent struct {
+	EventID       uuid.UUID
+	SageID        uuid.UUID
+	OrderID       uuid.UUID
+	CustomerID    string
+	Items         []domain.OrderItem
+	Currency      domain.Currency
+	TotalPrice    domain.Money
+	ExchangeRates []domain.ExchangeRate
+	CreatedAt     time.Time
+}
+
+type OrderStatusChangedEvent struct {
+	EventID        uuid.UUID
+	OrderID        uuid.UUID
+	PreviousStatus domain.OrderStatus
+	Status         domain.OrderStatus
+	ChangedAt      time.Time
+}
+
+type OrderItemAddedEvent struct {
+	EventID    uuid.UUID
+	OrderID    uuid.UUID
+	Item       domain.OrderItem
+	TotalPrice domain.Money
+	AddedAt    time.Time
+}
+
+type OrderItemRemovedEvent struct {
 	EventID    uuid.UUID
-	SageID     uuid.UUID
 	OrderID    uuid.UUID
-	CustomerID string
-	Items      []domain.OrderItem
-	TotalPrice float64
-	CreatedAt  time.Time
+	ItemID     uuid.UUID
+	TotalPrice domain.Money
+	RemovedAt  time.Time
 }
+
+type OrderCancelledEvent struct {
+	EventID        uuid.UUID
+	SagaID         uuid.UUID
+	OrderID        uuid.UUID
+	CustomerID     string
+	PreviousStatus domain.OrderStatus
+	Items          []domain.OrderItem
+	CancelledAt    time.Time
+}
+
+// AggregateID implements ports.AggregateEvent.
+func (e OrderCreatedEvent) AggregateID() uuid.UUID { return e.OrderID }
+
+// AggregateID implements ports.AggregateEvent.
+func (e OrderStatusChangedEvent) AggregateID() uuid.UUID { return e.OrderID }
+
+// AggregateID implements ports.AggregateEvent.
+func (e OrderItemAddedEvent) AggregateID() uuid.UUID { return e.OrderID }
+
+// AggregateID implements ports.AggregateEvent.
+func (e OrderItemRemovedEvent) AggregateID() uuid.UUID { return e.OrderID }
+
+// AggregateID implements ports.AggregateEvent.
+func (e OrderCancelledEvent) AggregateID() uuid.UUID { return e.OrderID }
+
+// EventType implements ports.TypedEvent.
+func (OrderCreatedEvent) EventType() string { return OrderCreatedEventType }
+
+// EventType implements ports.TypedEvent.
+func (OrderStatusChangedEvent) EventType() string { return OrderStatusChangedEventType }
+
+// EventType implements ports.TypedEvent.
+func (OrderItemAddedEvent) EventType() string { return OrderItemAddedEventType }
+
+// EventType implements ports.TypedEvent.
+func (OrderItemRemovedEvent) EventType() string { return OrderItemRemovedEventType }
+
+// EventType implements ports.TypedEvent.
+func (OrderCancelledEvent) EventType() string { return OrderCancelledEventType }
+
+// SchemaVersion implements ports.VersionedEvent.
+func (OrderCreatedEvent) SchemaVersion() int { return OrderCreatedEventVersion }
+
+// SchemaVersion implements ports.VersionedEvent.
+func (OrderStatusChangedEvent) SchemaVersion() int { return OrderStatusChangedEventVersion }
+
+// SchemaVersion implements ports.VersionedEvent.
+func (OrderItemAddedEvent) SchemaVersion() int { return OrderItemAddedEventVersion }
+
+// SchemaVersion implements ports.VersionedEvent.
+func (OrderItemRemovedEvent) SchemaVersion() int { return OrderItemRemovedEventVersion }
+
+// SchemaVersion implements ports.VersionedEvent.
+func (OrderCancelledEvent) SchemaVersion() int { return OrderCancelledEventVersion }
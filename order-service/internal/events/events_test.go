@@ -0,0 +1,42 @@
+package events_test
+
+import (
+	"order-service/internal/app/ports"
+	"order-service/internal/events"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderEventsAreKeyedByOrder(t *testing.T) {
+	orderID := uuid.New()
+
+	for _, event := range []interface{}{
+		events.OrderCreatedEvent{OrderID: orderID},
+		events.OrderStatusChangedEvent{OrderID: orderID},
+		events.OrderItemAddedEvent{OrderID: orderID},
+		events.OrderItemRemovedEvent{OrderID: orderID},
+		&events.OrderCancelledEvent{OrderID: orderID},
+	} {
+		aggregate, ok := event.(ports.AggregateEvent)
+		if assert.True(t, ok, "%T is not an aggregate event", event) {
+			assert.Equal(t, orderID, aggregate.AggregateID())
+		}
+	}
+}
+
+func TestOrderEventsKnowTheirType(t *testing.T) {
+	for eventType, event := range map[string]interface{}{
+		events.OrderCreatedEventType:       events.OrderCreatedEvent{},
+		events.OrderStatusChangedEventType: events.OrderStatusChangedEvent{},
+		events.OrderItemAddedEventType:     events.OrderItemAddedEvent{},
+		events.OrderItemRemovedEventType:   events.OrderItemRemovedEvent{},
+		events.OrderCancelledEventType:     &events.OrderCancelledEvent{},
+	} {
+		typed, ok := event.(ports.TypedEvent)
+		if assert.True(t, ok, "%T is not a typed event", event) {
+			assert.Equal(t, eventType, typed.EventType())
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package events
+
+// CloudEvents extension attributes added to published events
+const (
+	// ExtensionSchemaVersion is the schema version of the event data
+	ExtensionSchemaVersion = "schemaversion"
+	// ExtensionSequence is the position of the event among the events of its aggregate
+	ExtensionSequence = "sequence"
+	// ExtensionCorrelationID is shared by all events caused by one request
+	ExtensionCorrelationID = "correlationid"
+	// ExtensionCausationID is the ID of the request or message that caused the event
+	ExtensionCausationID = "causationid"
+	ExtensionTraceID     = "traceid"
+)
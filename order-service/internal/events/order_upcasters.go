@@ -0,0 +1,116 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"order-service/internal/domain"
+)
+
+// Schema versions of the order event payloads. A payload change bumps the version of its
+// event type and adds an upcaster from the previous version to the event's definition.
+const (
+	// OrderCreatedEventVersion 2 replaced float prices with money amounts,
+	// version 3 added the order currency and the exchange rate snapshot
+	OrderCreatedEventVersion       = 3
+	OrderStatusChangedEventVersion = 1
+	// OrderItemAddedEventVersion 2 replaced float prices with money amounts
+	OrderItemAddedEventVersion = 2
+	// OrderItemRemovedEventVersion 2 replaced float prices with money amounts
+	OrderItemRemovedEventVersion = 2
+	// OrderCancelledEventVersion 2 replaced float item prices with money amounts
+	OrderCancelledEventVersion = 2
+)
+
+// legacyCurrency is the currency of the float prices of version 1 payloads,
+// the service only sold in US dollars back then
+const legacyCurrency = domain.CurrencyUSD
+
+// upcastOrderCreatedV1 turns the float prices of the order and its items into money amounts
+func upcastOrderCreatedV1(payload map[string]interface{}) (map[string]interface{}, error) {
+	if err := upcastPrice(payload, "TotalPrice"); err != nil {
+		return nil, err
+	}
+	if err := upcastItemPrices(payload["Items"]); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// upcastOrderCreatedV2 adds the order currency, the currency of its total price.
+// Orders of version 2 were placed in their own currency and have no exchange rates.
+func upcastOrderCreatedV2(payload map[string]interface{}) (map[string]interface{}, error) {
+	currency := string(legacyCurrency)
+	if total, ok := payload["TotalPrice"].(map[string]interface{}); ok {
+		if code, ok := total["currency"].(string); ok && code != "" {
+			currency = code
+		}
+	}
+	payload["Currency"] = currency
+	payload["ExchangeRates"] = []interface{}{}
+	return payload, nil
+}
+
+// upcastOrderItemAddedV1 turns the float prices of the item and the new total into money amounts
+func upcastOrderItemAddedV1(payload map[string]interface{}) (map[string]interface{}, error) {
+	if err := upcastPrice(payload, "TotalPrice"); err != nil {
+		return nil, err
+	}
+	if item, ok := payload["Item"].(map[string]interface{}); ok {
+		if err := upcastPrice(item, "Price"); err != nil {
+			return nil, err
+		}
+	}
+	return payload, nil
+}
+
+// upcastOrderItemRemovedV1 turns the float total into a money amount
+func upcastOrderItemRemovedV1(payload map[string]interface{}) (map[string]interface{}, error) {
+	if err := upcastPrice(payload, "TotalPrice"); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// upcastOrderCancelledV1 turns the float prices of the cancelled items into money amounts
+func upcastOrderCancelledV1(payload map[string]interface{}) (map[string]interface{}, error) {
+	if err := upcastItemPrices(payload["Items"]); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+func upcastItemPrices(items interface{}) error {
+	list, _ := items.([]interface{})
+	for i, item := range list {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("item %d is not an object", i)
+		}
+		if err := upcastPrice(fields, "Price"); err != nil {
+			return fmt.Errorf("item %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// upcastPrice replaces a float price with the money amount it stood for, rounded to cents
+func upcastPrice(fields map[string]interface{}, name string) error {
+	value, ok := fields[name]
+	if !ok || value == nil {
+		return nil
+	}
+	number, ok := value.(json.Number)
+	if !ok {
+		return fmt.Errorf("%s is %T, not a number", name, value)
+	}
+
+	price, err := domain.ParseMoney(number.String(), legacyCurrency)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	fields[name] = map[string]interface{}{
+		"amount":   price.Decimal(),
+		"currency": string(price.Currency()),
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Registry errors
+var (
+	ErrUnknownEventType       = errors.New("unknown event type")
+	ErrDuplicateEventType     = errors.New("event type already registered")
+	ErrInvalidEventDefinition = errors.New("invalid event definition")
+	ErrUnknownSchemaVersion   = errors.New("unknown schema version")
+)
+
+// Definition describes how the outbox publishes one event type
+type Definition struct {
+	// Type is the event type stored with outbox messages, e.g. "order.created"
+	Type string
+	// Topic is the topic events of this type are published to
+	Topic string
+	// Version is the schema version of the payload
+	Version int
+	// Upcasters convert stored payloads of older versions to the current one.
+	// Upcasters[i] converts a payload of version i+1 to version i+2.
+	Upcasters []Upcaster
+	// New returns a pointer to an empty payload the stored JSON is decoded into
+	New func() interface{}
+	// Key returns the partition key of a decoded payload.
+	// Events with the same key are published to the same partition and keep their order.
+	Key func(payload interface{}) string
+}
+
+// Define builds the definition of an event type with payload type T
+func Define[T any](eventType, topic string, version int, key func(*T) string) Definition {
+	return Definition{
+		Type:    eventType,
+		Topic:   topic,
+		Version: version,
+		New:     func() interface{} { return new(T) },
+		Key:     func(payload interface{}) string { return key(payload.(*T)) },
+	}
+}
+
+// WithUpcasters returns the definition with the chain of upcasters from version 1
+func (d Definition) WithUpcasters(upcasters ...Upcaster) Definition {
+	d.Upcasters = upcasters
+	return d
+}
+
+// Decode unmarshals a stored payload of the schema version into the event's payload type,
+// upcasting it first when it was written with an older version.
+// Version 0 stands for the current version.
+func (d Definition) Decode(payload []byte, version int) (interface{}, error) {
+	payload, err := d.Upcast(payload, version)
+	if err != nil {
+		return nil, err
+	}
+
+	event := d.New()
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s event: %w", d.Type, err)
+	}
+	return event, nil
+}
+
+// Upcast converts a stored payload of the schema version to the current version.
+// Payloads of the current version are returned as they are.
+func (d Definition) Upcast(payload []byte, version int) ([]byte, error) {
+	if version == 0 || version == d.Version {
+		return payload, nil
+	}
+	if version < 0 || version > d.Version {
+		return nil, fmt.Errorf("%w: %s version %d, current version is %d", ErrUnknownSchemaVersion, d.Type, version, d.Version)
+	}
+	return upcast(payload, d.Upcasters[version-1:])
+}
+
+// Registry maps event types to their definitions. It is safe for concurrent use.
+type Registry struct {
+	mu          sync.RWMutex
+	definitions map[string]Definition
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{definitions: make(map[string]Definition)}
+}
+
+// Register adds an event type to the registry
+func (r *Registry) Register(def Definition) error {
+	if def.Type == "" || def.Topic == "" || def.Version < 1 || def.New == nil || def.Key == nil {
+		return fmt.Errorf("%w: %q", ErrInvalidEventDefinition, def.Type)
+	}
+	// Every older version needs an upcaster to the next one
+	if len(def.Upcasters) != def.Version-1 || slices.ContainsFunc(def.Upcasters, func(u Upcaster) bool { return u == nil }) {
+		return fmt.Errorf("%w: %q needs upcasters from each of its %d older versions", ErrInvalidEventDefinition, def.Type, def.Version-1)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.definitions[def.Type]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateEventType, def.Type)
+	}
+
+	r.definitions[def.Type] = def
+	return nil
+}
+
+// MustRegister is like Register but panics on an invalid or duplicate definition
+func (r *Registry) MustRegister(defs ...Definition) {
+	for _, def := range defs {
+		if err := r.Register(def); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Lookup returns the definition of an event type
+func (r *Registry) Lookup(eventType string) (Definition, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	def, ok := r.definitions[eventType]
+	if !ok {
+		return Definition{}, fmt.Errorf("%w: %q", ErrUnknownEventType, eventType)
+	}
+	return def, nil
+}
+
+// Types returns the registered event types
+func (r *Registry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]string, 0, len(r.definitions))
+	for eventType := range r.definitions {
+		types = append(types, eventType)
+	}
+	return types
+}
+
+// NewDefaultRegistry returns a registry with the order events.
+// Order events are keyed by order ID and published to the topic named after their type
+// until the registry is routed to configured topics.
+func NewDefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.MustRegister(
+		Define(OrderCreatedEventType, OrderCreatedEventType, OrderCreatedEventVersion,
+			func(e *OrderCreatedEvent) string { return e.OrderID.String() }).
+			WithUpcasters(upcastOrderCreatedV1, upcastOrderCreatedV2),
+		Define(OrderStatusChangedEventType, OrderStatusChangedEventType, OrderStatusChangedEventVersion,
+			func(e *OrderStatusChangedEvent) string { return e.OrderID.String() }),
+		Define(OrderItemAddedEventType, OrderItemAddedEventType, OrderItemAddedEventVersion,
+			func(e *OrderItemAddedEvent) string { return e.OrderID.String() }).
+			WithUpcasters(upcastOrderItemAddedV1),
+		Define(OrderItemRemovedEventType, OrderItemRemovedEventType, OrderItemRemovedEventVersion,
+			func(e *OrderItemRemovedEvent) string { return e.OrderID.String() }).
+			WithUpcasters(upcastOrderItemRemovedV1),
+		Define(OrderCancelledEventType, OrderCancelledEventType, OrderCancelledEventVersion,
+			func(e *OrderCancelledEvent) string { return e.OrderID.String() }).
+			WithUpcasters(upcastOrderCancelledV1),
+	)
+	return r
+}
@@ -0,0 +1,74 @@
+package events_test
+
+import (
+	"encoding/json"
+	"order-service/internal/events"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := events.NewRegistry()
+
+	type refundedEvent struct {
+		OrderID uuid.UUID
+		Amount  string
+	}
+
+	def := events.Define("order.refunded", "order-refunds", 2,
+		func(e *refundedEvent) string { return e.OrderID.String() })
+	assert.ErrorIs(t, registry.Register(def), events.ErrInvalidEventDefinition, "version 1 has no upcaster")
+
+	def = def.WithUpcasters(func(payload map[string]interface{}) (map[string]interface{}, error) {
+		return payload, nil
+	})
+	assert.NoError(t, registry.Register(def))
+	assert.ErrorIs(t, registry.Register(def), events.ErrDuplicateEventType)
+	assert.ErrorIs(t, registry.Register(events.Definition{Type: "order.paid"}), events.ErrInvalidEventDefinition)
+
+	found, err := registry.Lookup("order.refunded")
+	assert.NoError(t, err)
+	assert.Equal(t, "order-refunds", found.Topic)
+	assert.Equal(t, 2, found.Version)
+
+	orderID := uuid.New()
+	payload, _ := json.Marshal(refundedEvent{OrderID: orderID, Amount: "5.00"})
+
+	event, err := found.Decode(payload, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, &refundedEvent{OrderID: orderID, Amount: "5.00"}, event)
+	assert.Equal(t, orderID.String(), found.Key(event))
+
+	_, err = found.Decode([]byte(`{"OrderID":`), 2)
+	assert.Error(t, err)
+
+	_, err = registry.Lookup("order.unknown")
+	assert.ErrorIs(t, err, events.ErrUnknownEventType)
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	registry := events.NewDefaultRegistry()
+
+	orderID := uuid.New()
+	payload, _ := json.Marshal(events.OrderCancelledEvent{OrderID: orderID})
+
+	for _, eventType := range []string{
+		events.OrderCreatedEventType,
+		events.OrderStatusChangedEventType,
+		events.OrderItemAddedEventType,
+		events.OrderItemRemovedEventType,
+		events.OrderCancelledEventType,
+	} {
+		def, err := registry.Lookup(eventType)
+		assert.NoError(t, err, eventType)
+		assert.Equal(t, eventType, def.Topic)
+	}
+
+	def, _ := registry.Lookup(events.OrderCancelledEventType)
+	event, err := def.Decode(payload, events.OrderCancelledEventVersion)
+	assert.NoError(t, err)
+	assert.Equal(t, orderID.String(), def.Key(event))
+	assert.Len(t, registry.Types(), 5)
+}
@@ -0,0 +1,93 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrNoTopic is returned for event types without a destination topic
+var ErrNoTopic = errors.New("no topic for event type")
+
+// TopicRouter maps event types to the topics they are published to.
+// Every topic gets the prefix and suffix, so environments sharing a cluster stay apart.
+type TopicRouter struct {
+	routes map[string]string
+	prefix string
+	suffix string
+}
+
+// NewTopicRouter creates a router from event types to topic names. Empty names are ignored.
+func NewTopicRouter(routes map[string]string, prefix, suffix string) *TopicRouter {
+	r := &TopicRouter{routes: make(map[string]string, len(routes)), prefix: prefix, suffix: suffix}
+	for eventType, topic := range routes {
+		if topic != "" {
+			r.routes[eventType] = topic
+		}
+	}
+	return r
+}
+
+// Topic returns the full name of the topic events of the type are published to
+func (r *TopicRouter) Topic(eventType string) (string, error) {
+	topic, ok := r.routes[eventType]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrNoTopic, eventType)
+	}
+	return r.prefix + topic + r.suffix, nil
+}
+
+// Topics returns the full names of all destination topics, sorted and without duplicates
+func (r *TopicRouter) Topics() []string {
+	seen := make(map[string]bool, len(r.routes))
+	topics := make([]string, 0, len(r.routes))
+	for _, topic := range r.routes {
+		topic = r.prefix + topic + r.suffix
+		if !seen[topic] {
+			seen[topic] = true
+			topics = append(topics, topic)
+		}
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// Route sets the topic of every registered event type to the router's destination.
+// It fails without changing anything when an event type has no destination.
+func (r *Registry) Route(router *TopicRouter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	topics := make(map[string]string, len(r.definitions))
+	var missing []string
+	for eventType := range r.definitions {
+		topic, err := router.Topic(eventType)
+		if err != nil {
+			missing = append(missing, eventType)
+			continue
+		}
+		topics[eventType] = topic
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("%w: %s", ErrNoTopic, strings.Join(missing, ", "))
+	}
+
+	for eventType, topic := range topics {
+		def := r.definitions[eventType]
+		def.Topic = topic
+		r.definitions[eventType] = def
+	}
+	return nil
+}
+
+// Topic returns the topic events of the registered type are published to
+func (r *Registry) Topic(eventType string) (string, error) {
+	def, err := r.Lookup(eventType)
+	if err != nil {
+		return "", err
+	}
+	return def.Topic, nil
+}
@@ -0,0 +1,52 @@
+package events_test
+
+import (
+	"order-service/internal/events"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopicRouter(t *testing.T) {
+	router := events.NewTopicRouter(map[string]string{
+		events.OrderCreatedEventType:       "orders-created",
+		events.OrderStatusChangedEventType: "orders-updated",
+		events.OrderItemAddedEventType:     "orders-updated",
+		events.OrderCancelledEventType:     "",
+	}, "staging.", ".v1")
+
+	topic, err := router.Topic(events.OrderCreatedEventType)
+	require.NoError(t, err)
+	assert.Equal(t, "staging.orders-created.v1", topic)
+
+	// Empty topic names are no destination
+	_, err = router.Topic(events.OrderCancelledEventType)
+	assert.ErrorIs(t, err, events.ErrNoTopic)
+
+	assert.Equal(t, []string{"staging.orders-created.v1", "staging.orders-updated.v1"}, router.Topics())
+}
+
+func TestRegistryRoute(t *testing.T) {
+	registry := events.NewDefaultRegistry()
+
+	// Nothing changes unless every event type has a destination
+	partial := events.NewTopicRouter(map[string]string{events.OrderCreatedEventType: "orders-created"}, "", "")
+	err := registry.Route(partial)
+	assert.ErrorIs(t, err, events.ErrNoTopic)
+	assert.ErrorContains(t, err, events.OrderCancelledEventType)
+
+	topic, err := registry.Topic(events.OrderCreatedEventType)
+	require.NoError(t, err)
+	assert.Equal(t, events.OrderCreatedEventType, topic)
+
+	routes := make(map[string]string)
+	for _, eventType := range registry.Types() {
+		routes[eventType] = "orders"
+	}
+	require.NoError(t, registry.Route(events.NewTopicRouter(routes, "dev-", "")))
+
+	def, err := registry.Lookup(events.OrderItemRemovedEventType)
+	require.NoError(t, err)
+	assert.Equal(t, "dev-orders", def.Topic)
+}
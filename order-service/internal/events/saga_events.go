@@ -0,0 +1,34 @@
+package events
+
+import "github.com/google/uuid"
+
+// Event types consumed from the inventory and the payment service, named as in order-management.
+// ProductsReservationFailed is the outcome of a reservation the inventory could not make.
+const (
+	ProductsReservedEventType          = "ProductsReserved"
+	ProductsReservationFailedEventType = "ProductsReservationFailed"
+	PaymentApprovedEventType           = "PaymentApproved"
+	PaymentDeclinedEventType           = "PaymentDeclined"
+)
+
+// ProductsReservedEvent reports that the inventory reserved the items of an order
+type ProductsReservedEvent struct {
+	OrderID uuid.UUID `json:"order_id"`
+}
+
+// ProductsReservationFailedEvent reports that the inventory could not reserve the items of an order
+type ProductsReservationFailedEvent struct {
+	OrderID uuid.UUID `json:"order_id"`
+	Reason  string    `json:"reason"`
+}
+
+// PaymentApprovedEvent reports that the payment of an order was approved
+type PaymentApprovedEvent struct {
+	OrderID uuid.UUID `json:"order_id"`
+}
+
+// PaymentDeclinedEvent reports that the payment of an order was declined
+type PaymentDeclinedEvent struct {
+	OrderID uuid.UUID `json:"order_id"`
+	Reason  string    `json:"reason"`
+}
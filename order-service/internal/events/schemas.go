@@ -0,0 +1,39 @@
+package events
+
+import (
+	"embed"
+	"fmt"
+	"sort"
+)
+
+//go:embed schemas
+var schemaFiles embed.FS
+
+// schemaExtensions maps the serialization formats to the extensions of their schema files
+var schemaExtensions = map[string]string{
+	"avro":     ".avsc",
+	"protobuf": ".proto",
+	"json":     ".json",
+}
+
+// Schemas returns the schemas of the registered event types in a serialization format,
+// "avro", "protobuf" or "json", keyed by event type. A field renamed in an event has to be
+// renamed in its schemas too, where the schema registry rejects the change if it breaks consumers.
+func (r *Registry) Schemas(format string) (map[string]string, error) {
+	extension, ok := schemaExtensions[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown schema format %q", format)
+	}
+
+	types := r.Types()
+	sort.Strings(types)
+	schemas := make(map[string]string, len(types))
+	for _, eventType := range types {
+		schema, err := schemaFiles.ReadFile("schemas/" + eventType + extension)
+		if err != nil {
+			return nil, fmt.Errorf("no %s schema for %s event: %w", format, eventType, err)
+		}
+		schemas[eventType] = string(schema)
+	}
+	return schemas, nil
+}
@@ -0,0 +1,127 @@
+package events_test
+
+import (
+	"context"
+	"math/big"
+	"order-service/internal/domain"
+	"order-service/internal/events"
+	"schemaregistry"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleEvents() map[string]interface{} {
+	now := time.Date(2025, 3, 1, 12, 30, 0, 0, time.UTC)
+	item := domain.OrderItem{
+		ID:        uuid.New(),
+		ProductID: "product-1",
+		Quantity:  2,
+		Price:     domain.MustParseMoney("12.50", domain.CurrencyEUR),
+	}
+	rate, err := domain.NewExchangeRate(domain.CurrencyEUR, domain.CurrencyUSD, big.NewRat(10845, 10000), now)
+	if err != nil {
+		panic(err)
+	}
+
+	return map[string]interface{}{
+		events.OrderCreatedEventType: &events.OrderCreatedEvent{
+			EventID:       uuid.New(),
+			SageID:        uuid.New(),
+			OrderID:       uuid.New(),
+			CustomerID:    "customer-1",
+			Items:         []domain.OrderItem{item},
+			Currency:      domain.CurrencyUSD,
+			TotalPrice:    domain.MustParseMoney("27.11", domain.CurrencyUSD),
+			ExchangeRates: []domain.ExchangeRate{rate},
+			CreatedAt:     now,
+		},
+		events.OrderStatusChangedEventType: &events.OrderStatusChangedEvent{
+			EventID:        uuid.New(),
+			OrderID:        uuid.New(),
+			PreviousStatus: domain.OrderStatusPending,
+			Status:         domain.OrderStatusConfirmed,
+			ChangedAt:      now,
+		},
+		events.OrderItemAddedEventType: &events.OrderItemAddedEvent{
+			EventID:    uuid.New(),
+			OrderID:    uuid.New(),
+			Item:       item,
+			TotalPrice: domain.MustParseMoney("25.00", domain.CurrencyEUR),
+			AddedAt:    now,
+		},
+		events.OrderItemRemovedEventType: &events.OrderItemRemovedEvent{
+			EventID:    uuid.New(),
+			OrderID:    uuid.New(),
+			ItemID:     uuid.New(),
+			TotalPrice: domain.MustParseMoney("0.00", domain.CurrencyEUR),
+			RemovedAt:  now,
+		},
+		events.OrderCancelledEventType: &events.OrderCancelledEvent{
+			EventID:        uuid.New(),
+			SagaID:         uuid.New(),
+			OrderID:        uuid.New(),
+			CustomerID:     "customer-1",
+			PreviousStatus: domain.OrderStatusPending,
+			Items:          []domain.OrderItem{item},
+			CancelledAt:    now,
+		},
+	}
+}
+
+func TestEventSchemasDescribeTheEvents(t *testing.T) {
+	ctx := context.Background()
+	registry := events.NewDefaultRegistry()
+
+	for _, name := range []string{"avro", "protobuf", "json"} {
+		t.Run(name, func(t *testing.T) {
+			schemas, err := registry.Schemas(name)
+			require.NoError(t, err)
+			assert.Len(t, schemas, len(registry.Types()))
+
+			format, err := schemaregistry.ParseFormat(name)
+			require.NoError(t, err)
+			schemaRegistry := schemaregistry.NewMemoryRegistry(schemaregistry.CompatibilityBackward)
+			serializer, err := schemaregistry.NewSerializer(schemaRegistry, format, schemaregistry.TopicRecordNameStrategy, schemas)
+			require.NoError(t, err)
+			deserializer := schemaregistry.NewDeserializer(schemaRegistry)
+
+			for eventType, event := range sampleEvents() {
+				data, err := serializer.Serialize(ctx, "orders", eventType, event)
+				require.NoError(t, err, eventType)
+
+				def, err := registry.Lookup(eventType)
+				require.NoError(t, err)
+				decoded := def.New()
+				require.NoError(t, deserializer.Deserialize(ctx, data, decoded), eventType)
+				assert.Equal(t, event, decoded, eventType)
+			}
+		})
+	}
+}
+
+func TestEventSchemasRejectRenamedFields(t *testing.T) {
+	schemas, err := events.NewDefaultRegistry().Schemas("json")
+	require.NoError(t, err)
+	serializer, err := schemaregistry.NewSerializer(schemaregistry.NewMemoryRegistry(""), schemaregistry.JSONSchema,
+		schemaregistry.TopicRecordNameStrategy, schemas)
+	require.NoError(t, err)
+
+	created := sampleEvents()[events.OrderCreatedEventType].(*events.OrderCreatedEvent)
+	renamed := struct {
+		events.OrderCreatedEvent
+		SageID uuid.UUID `json:"-"`
+		SagaID uuid.UUID
+	}{OrderCreatedEvent: *created, SagaID: created.SageID}
+
+	_, err = serializer.Serialize(context.Background(), "orders", events.OrderCreatedEventType, renamed)
+	assert.Error(t, err)
+}
+
+func TestSchemasOfUnknownFormat(t *testing.T) {
+	_, err := events.NewDefaultRegistry().Schemas("xml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,37 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Upcaster converts the JSON object of a payload to the next schema version.
+// Numbers are json.Number, so amounts keep their precision.
+type Upcaster func(payload map[string]interface{}) (map[string]interface{}, error)
+
+// upcast runs the payload through the upcasters in order
+func upcast(payload []byte, upcasters []Upcaster) ([]byte, error) {
+	object, err := decodeObject(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, upcaster := range upcasters {
+		if object, err = upcaster(object); err != nil {
+			return nil, fmt.Errorf("failed to upcast payload: %w", err)
+		}
+	}
+	return json.Marshal(object)
+}
+
+func decodeObject(payload []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil {
+		return nil, fmt.Errorf("failed to decode payload: %w", err)
+	}
+	return object, nil
+}
@@ -0,0 +1,170 @@
+package events_test
+
+import (
+	"errors"
+	"fmt"
+	"order-service/internal/domain"
+	"order-service/internal/events"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	fixtureOrderID  = uuid.MustParse("9a8e5d2c-3b4f-4e6a-8c1d-7e2f1a0b9c03")
+	fixtureSagaID   = uuid.MustParse("0b7d4a3e-5c1f-4f57-8a59-3f0f9a2c7d02")
+	fixtureFirstID  = uuid.MustParse("c2d3e4f5-a6b7-4c8d-9e0f-1a2b3c4d5e04")
+	fixtureSecondID = uuid.MustParse("d3e4f5a6-b7c8-4d9e-8f1a-2b3c4d5e6f05")
+)
+
+func usd(amount string) domain.Money {
+	return domain.MustParseMoney(amount, domain.CurrencyUSD)
+}
+
+func fixtureTime(value string) time.Time {
+	t, _ := time.Parse(time.RFC3339, value)
+	return t
+}
+
+// decodeFixture decodes a historical payload from testdata with the default registry
+func decodeFixture(t *testing.T, eventType string, version int) interface{} {
+	t.Helper()
+
+	payload, err := os.ReadFile(filepath.Join("testdata", fmt.Sprintf("%s.v%d.json", eventType, version)))
+	require.NoError(t, err)
+
+	def, err := events.NewDefaultRegistry().Lookup(eventType)
+	require.NoError(t, err)
+
+	event, err := def.Decode(payload, version)
+	require.NoError(t, err)
+	return event
+}
+
+func TestUpcastOrderCreated(t *testing.T) {
+	expected := &events.OrderCreatedEvent{
+		EventID:    uuid.MustParse("6f1c1b52-0c55-4c4e-9d6a-2f4f3a9b1e01"),
+		SageID:     fixtureSagaID,
+		OrderID:    fixtureOrderID,
+		CustomerID: "customer-42",
+		Items: []domain.OrderItem{
+			{ID: fixtureFirstID, ProductID: "product-1", Quantity: 2, Price: usd("19.99")},
+			{ID: fixtureSecondID, ProductID: "product-2", Quantity: 1, Price: usd("0.10")},
+		},
+		Currency:      domain.CurrencyUSD,
+		TotalPrice:    usd("40.08"),
+		ExchangeRates: []domain.ExchangeRate{},
+		CreatedAt:     fixtureTime("2024-03-01T10:15:00Z"),
+	}
+
+	// Float prices are rounded to cents, the currency is taken from the total
+	for _, version := range []int{1, 2} {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			assert.Equal(t, expected, decodeFixture(t, events.OrderCreatedEventType, version))
+		})
+	}
+}
+
+func TestUpcastOrderItemEvents(t *testing.T) {
+	assert.Equal(t, &events.OrderItemAddedEvent{
+		EventID: uuid.MustParse("7a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c06"),
+		OrderID: fixtureOrderID,
+		Item: domain.OrderItem{
+			ID:        uuid.MustParse("e4f5a6b7-c8d9-4e0f-9a1b-3c4d5e6f7a07"),
+			ProductID: "product-3",
+			Quantity:  3,
+			Price:     usd("5.50"),
+		},
+		TotalPrice: usd("56.58"),
+		AddedAt:    fixtureTime("2024-03-01T10:20:00Z"),
+	}, decodeFixture(t, events.OrderItemAddedEventType, 1))
+
+	assert.Equal(t, &events.OrderItemRemovedEvent{
+		EventID:    uuid.MustParse("8b3c4d5e-6f7a-4b8c-9d0e-1f2a3b4c5d08"),
+		OrderID:    fixtureOrderID,
+		ItemID:     fixtureSecondID,
+		TotalPrice: usd("56.48"),
+		RemovedAt:  fixtureTime("2024-03-01T10:25:00Z"),
+	}, decodeFixture(t, events.OrderItemRemovedEventType, 1))
+}
+
+func TestUpcastOrderCancelled(t *testing.T) {
+	assert.Equal(t, &events.OrderCancelledEvent{
+		EventID:        uuid.MustParse("9c4d5e6f-7a8b-4c9d-8e0f-2a3b4c5d6e09"),
+		SagaID:         fixtureSagaID,
+		OrderID:        fixtureOrderID,
+		CustomerID:     "customer-42",
+		PreviousStatus: domain.OrderStatusPending,
+		Items: []domain.OrderItem{
+			{ID: fixtureFirstID, ProductID: "product-1", Quantity: 2, Price: usd("19.99")},
+		},
+		CancelledAt: fixtureTime("2024-03-01T10:30:00Z"),
+	}, decodeFixture(t, events.OrderCancelledEventType, 1))
+}
+
+func TestDecodeCurrentVersion(t *testing.T) {
+	assert.Equal(t, &events.OrderStatusChangedEvent{
+		EventID:        uuid.MustParse("ad5e6f7a-8b9c-4d0e-9f1a-3b4c5d6e7f10"),
+		OrderID:        fixtureOrderID,
+		PreviousStatus: domain.OrderStatusPending,
+		Status:         domain.OrderStatusConfirmed,
+		ChangedAt:      fixtureTime("2024-03-01T10:35:00Z"),
+	}, decodeFixture(t, events.OrderStatusChangedEventType, events.OrderStatusChangedEventVersion))
+}
+
+func TestUpcastChain(t *testing.T) {
+	type renamedEvent struct {
+		OrderID uuid.UUID
+		Amount  string
+		Reason  string
+	}
+
+	def := events.Define("order.refunded", "order-refunds", 3,
+		func(e *renamedEvent) string { return e.OrderID.String() }).
+		WithUpcasters(
+			// Version 2 renamed Total to Amount
+			func(payload map[string]interface{}) (map[string]interface{}, error) {
+				payload["Amount"] = payload["Total"]
+				delete(payload, "Total")
+				return payload, nil
+			},
+			// Version 3 requires a reason
+			func(payload map[string]interface{}) (map[string]interface{}, error) {
+				if _, ok := payload["Reason"]; !ok {
+					payload["Reason"] = "unknown"
+				}
+				return payload, nil
+			},
+		)
+	registry := events.NewRegistry()
+	require.NoError(t, registry.Register(def))
+
+	orderID := uuid.New()
+	event, err := def.Decode([]byte(`{"OrderID":"`+orderID.String()+`","Total":"5.00"}`), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, &renamedEvent{OrderID: orderID, Amount: "5.00", Reason: "unknown"}, event)
+
+	event, err = def.Decode([]byte(`{"OrderID":"`+orderID.String()+`","Amount":"5.00","Reason":"damaged"}`), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, &renamedEvent{OrderID: orderID, Amount: "5.00", Reason: "damaged"}, event)
+
+	// Payloads of versions this service does not know yet are rejected
+	_, err = def.Decode([]byte(`{}`), 4)
+	assert.ErrorIs(t, err, events.ErrUnknownSchemaVersion)
+
+	// A failing upcaster fails decoding
+	failing := def.WithUpcasters(def.Upcasters[0], func(map[string]interface{}) (map[string]interface{}, error) {
+		return nil, errors.New("no reason")
+	})
+	_, err = failing.Decode([]byte(`{"Total":"5.00"}`), 1)
+	assert.ErrorContains(t, err, "no reason")
+
+	// Every older version needs an upcaster
+	assert.ErrorIs(t, events.NewRegistry().Register(def.WithUpcasters(def.Upcasters[0])), events.ErrInvalidEventDefinition)
+	assert.ErrorIs(t, events.NewRegistry().Register(def.WithUpcasters(def.Upcasters[0], nil)), events.ErrInvalidEventDefinition)
+}
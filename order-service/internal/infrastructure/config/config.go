@@ -11,16 +11,22 @@ import (
 
 // Config represents the global application configuration
 type Config struct {
-	Server      ServerConfig
-	Database    DatabaseConfig
-	Kafka       KafkaConfig
-	Environment string
-	LogLevel    string
+	Server        ServerConfig
+	Database      DatabaseConfig
+	Kafka         KafkaConfig
+	ExchangeRates ExchangeRateConfig
+	Outbox        OutboxWorkerConfig
+	Inbox         InboxConfig
+	Environment   string
+	LogLevel      string
 }
 
 // ServerConfig holds server-related configuration
 type ServerConfig struct {
 	Port int
+	// AdminPort serves the operator endpoints, apart from the public API.
+	// It must not be reachable from outside, 0 disables the endpoints.
+	AdminPort int
 }
 
 // DatabaseConfig holds database-related configuration
@@ -35,12 +41,78 @@ type DatabaseConfig struct {
 	MigrationsPath string
 }
 
+// ExchangeRateConfig holds exchange rate configuration
+type ExchangeRateConfig struct {
+	// RatesFile is a JSON file with static exchange rates.
+	// Without it only single-currency orders can be created.
+	RatesFile string
+}
+
+// Ways of finding new outbox messages
+const (
+	// OutboxModePoll claims pending messages from the outbox table
+	OutboxModePoll = "poll"
+	// OutboxModeCDC streams inserted messages from a logical replication slot
+	OutboxModeCDC = "cdc"
+)
+
+// InboxConfig holds configuration of the inbox of processed events
+type InboxConfig struct {
+	// RetentionPeriod is how long processed events are remembered. It has to be longer than
+	// an event can be redelivered, including its retries. A period of zero keeps them forever.
+	RetentionPeriod    time.Duration
+	RetentionInterval  time.Duration
+	RetentionBatchSize int
+}
+
+// OutboxWorkerConfig holds configuration of the outbox worker
 type OutboxWorkerConfig struct {
+	// Mode is OutboxModePoll or OutboxModeCDC
+	Mode string
+
 	BatchSize       int
 	ProcessInterval time.Duration
 	MaxRetries      int
+	// DeadLetterQueue is the topic failed messages are forwarded to, empty to disable forwarding.
+	// Forwarding a message is attempted up to DeadLetterMaxRetries times.
 	DeadLetterQueue string
 	DeadLetterMaxRetries int
+
+	// Workers is the number of workers claiming and publishing messages concurrently
+	Workers int
+	// LeaseDuration is how long a claimed message stays reserved for its worker.
+	// It has to be longer than publishing a whole batch takes.
+	LeaseDuration time.Duration
+
+	// Failed messages are retried after an exponentially growing delay
+	// between RetryInitialBackoff and RetryMaxBackoff, randomized by RetryJitter
+	RetryInitialBackoff    time.Duration
+	RetryMaxBackoff        time.Duration
+	RetryBackoffMultiplier float64
+	RetryJitter            float64
+
+	// NotifyChannel is the Postgres channel new messages are announced on, empty to only poll.
+	// While the listener is connected, workers wake on notifications and poll every NotifyPollInterval.
+	NotifyChannel      string
+	NotifyPollInterval time.Duration
+
+	// In CDC mode the relay reads ReplicationSlot, restricted to the outbox table by Publication.
+	// Both are created on startup when missing. The position is reported to Postgres every RelayStatusInterval.
+	ReplicationSlot     string
+	Publication         string
+	RelayStatusInterval time.Duration
+
+	// Processed messages older than RetentionPeriod are removed every RetentionInterval,
+	// RetentionBatchSize rows at a time. They are moved to the archive table unless
+	// RetentionArchive is false. A zero RetentionPeriod keeps processed messages forever.
+	RetentionPeriod    time.Duration
+	RetentionInterval  time.Duration
+	RetentionBatchSize int
+	RetentionArchive   bool
+
+	// Transactional publishes each claimed batch in one Kafka transaction, so consumers
+	// reading committed messages never see part of a batch. It needs a transactional producer.
+	Transactional bool
 }
 
 // KafkaConfig represents the configuration for Kafka messaging
@@ -55,14 +127,33 @@ type KafkaConfig struct {
 	Consumer ConsumerConfig
 	Topics   TopicConfig
 	Security SecurityConfig
+
+	SchemaRegistry SchemaRegistryConfig
 }
 
 // ProducerConfig holds configuration specific to Kafka producers
 type ProducerConfig struct {
-	RetryMax       int
-	RetryBackoff   time.Duration
-	MessageTimeout time.Duration
-	RequiredAcks   string // "none", "leader", or "all"
+	RetryMax        int
+	RetryBackoff    time.Duration
+	MessageTimeout  time.Duration
+	RequiredAcks    string // "none", "leader", or "all"
+	// ConfirmDelivery makes publishing wait until the broker acknowledged the message
+	// and report its error. Without it publishing returns once the message is queued.
+	ConfirmDelivery bool
+	// Partitioner picks the partition of a message: "hash" hashes the key,
+	// "sticky" also hashes the key but keeps keyless messages on one partition per batch,
+	// "aggregate" hashes the event subject, the aggregate ID, and falls back to the key
+	Partitioner string
+	// Idempotent makes the broker drop duplicates of retried messages.
+	// It requires acknowledgements from all replicas.
+	Idempotent bool
+	// TransactionalID enables Kafka transactions, which imply idempotence. The host name
+	// is appended to it, so every instance uses its own ID. Empty disables transactions.
+	TransactionalID string
+	// Events are published as CloudEvents with EventSource as their source, in "binary"
+	// mode with the attributes in headers or in "structured" mode as JSON documents
+	EventSource string
+	EventMode   string
 }
 
 // ConsumerConfig holds configuration specific to Kafka consumers
@@ -74,6 +165,23 @@ type ConsumerConfig struct {
 	MaxWaitTime       time.Duration
 	MinFetchBytes     int
 	MaxFetchBytes     int
+
+	// Workers handle the events of each partition, the events of one key in order
+	Workers        int
+	CommitInterval time.Duration
+	// HandlerTimeout cancels the context of a handler that takes longer
+	HandlerTimeout time.Duration
+
+	// A failing handler is retried with a delay doubling up to RetryMaxBackoff
+	RetryInitialBackoff time.Duration
+	RetryMaxBackoff     time.Duration
+
+	// DeadLetterTopic receives the events that cannot be decoded or failed MaxAttempts times,
+	// after being retried from the retry topic of each of RetryDelays.
+	// Empty retries failing events in place instead, up to MaxAttempts times.
+	DeadLetterTopic string
+	RetryDelays     []time.Duration
+	MaxAttempts     int
 }
 
 // TopicConfig holds names of Kafka topics used by the application
@@ -82,6 +190,20 @@ type TopicConfig struct {
 	OrdersUpdated   string
 	OrdersCancelled string
 	OrderPayments   string
+
+	// Inventory and Payments are consumed, the other services publish the outcomes of the order saga on them
+	Inventory string
+	Payments  string
+
+	// Prefix and Suffix are added to every topic name, e.g. "staging." to keep environments apart
+	Prefix string
+	Suffix string
+
+	// AutoCreate creates missing topics on startup with Partitions partitions,
+	// each replicated ReplicationFactor times
+	AutoCreate        bool
+	Partitions        int32
+	ReplicationFactor int16
 }
 
 // SecurityConfig holds Kafka security configuration
@@ -93,6 +215,20 @@ type SecurityConfig struct {
 	SaslPassword  string
 }
 
+// SchemaRegistryConfig holds the schema registry event payloads are serialized with
+type SchemaRegistryConfig struct {
+	// URL of the registry, empty publishes plain JSON payloads without schemas
+	URL      string
+	Username string
+	Password string
+	Timeout  time.Duration
+	// Format is "avro", "protobuf" or "json" (JSON Schema)
+	Format string
+	// SubjectNameStrategy names the subjects schemas are registered under:
+	// "topic_record" (<topic>-<event type>), "record" (<event type>) or "topic" (<topic>-value)
+	SubjectNameStrategy string
+}
+
 // Load loads the entire application configuration
 func Load() (*Config, error) {
 	v := viper.New()
@@ -125,7 +261,8 @@ func Load() (*Config, error) {
 
 	// Build server configuration
 	config.Server = ServerConfig{
-		Port: v.GetInt("server.port"),
+		Port:      v.GetInt("server.port"),
+		AdminPort: v.GetInt("server.admin_port"),
 	}
 
 	// Build database configuration
@@ -144,6 +281,61 @@ func Load() (*Config, error) {
 		dbConfig.User, dbConfig.Password, dbConfig.Host, dbConfig.Port, dbConfig.Name, dbConfig.SSLMode)
 	config.Database = dbConfig
 
+	// Build exchange rate configuration
+	config.ExchangeRates = ExchangeRateConfig{
+		RatesFile: v.GetString("exchange_rates.file"),
+	}
+
+	// Build outbox worker configuration
+	processInterval, _ := time.ParseDuration(v.GetString("outbox.process_interval"))
+	leaseDuration, _ := time.ParseDuration(v.GetString("outbox.lease_duration"))
+	retryInitialBackoff, _ := time.ParseDuration(v.GetString("outbox.retry.initial_backoff"))
+	retryMaxBackoff, _ := time.ParseDuration(v.GetString("outbox.retry.max_backoff"))
+	notifyPollInterval, _ := time.ParseDuration(v.GetString("outbox.notify.poll_interval"))
+	relayStatusInterval, _ := time.ParseDuration(v.GetString("outbox.cdc.status_interval"))
+	retentionPeriod, _ := time.ParseDuration(v.GetString("outbox.retention.period"))
+	retentionInterval, _ := time.ParseDuration(v.GetString("outbox.retention.interval"))
+
+	config.Outbox = OutboxWorkerConfig{
+		Mode:                 v.GetString("outbox.mode"),
+		BatchSize:            v.GetInt("outbox.batch_size"),
+		ProcessInterval:      processInterval,
+		MaxRetries:           v.GetInt("outbox.max_retries"),
+		DeadLetterQueue:      v.GetString("outbox.dead_letter_queue"),
+		DeadLetterMaxRetries: v.GetInt("outbox.dead_letter_max_retries"),
+		Workers:              v.GetInt("outbox.workers"),
+		LeaseDuration:        leaseDuration,
+
+		RetryInitialBackoff:    retryInitialBackoff,
+		RetryMaxBackoff:        retryMaxBackoff,
+		RetryBackoffMultiplier: v.GetFloat64("outbox.retry.multiplier"),
+		RetryJitter:            v.GetFloat64("outbox.retry.jitter"),
+
+		NotifyChannel:      v.GetString("outbox.notify.channel"),
+		NotifyPollInterval: notifyPollInterval,
+
+		ReplicationSlot:     v.GetString("outbox.cdc.slot"),
+		Publication:         v.GetString("outbox.cdc.publication"),
+		RelayStatusInterval: relayStatusInterval,
+
+		RetentionPeriod:    retentionPeriod,
+		RetentionInterval:  retentionInterval,
+		RetentionBatchSize: v.GetInt("outbox.retention.batch_size"),
+		RetentionArchive:   v.GetBool("outbox.retention.archive"),
+
+		Transactional: v.GetBool("outbox.transactional"),
+	}
+
+	// Build inbox configuration
+	inboxRetentionPeriod, _ := time.ParseDuration(v.GetString("inbox.retention.period"))
+	inboxRetentionInterval, _ := time.ParseDuration(v.GetString("inbox.retention.interval"))
+
+	config.Inbox = InboxConfig{
+		RetentionPeriod:    inboxRetentionPeriod,
+		RetentionInterval:  inboxRetentionInterval,
+		RetentionBatchSize: v.GetInt("inbox.retention.batch_size"),
+	}
+
 	// Build Kafka configuration
 	connectionTimeout, _ := time.ParseDuration(v.GetString("kafka.connection_timeout"))
 	retryBackoff, _ := time.ParseDuration(v.GetString("kafka.producer.retry_backoff"))
@@ -151,16 +343,32 @@ func Load() (*Config, error) {
 	heartbeatInterval, _ := time.ParseDuration(v.GetString("kafka.consumer.heartbeat_interval"))
 	sessionTimeout, _ := time.ParseDuration(v.GetString("kafka.consumer.session_timeout"))
 	maxWaitTime, _ := time.ParseDuration(v.GetString("kafka.consumer.max_wait_time"))
+	commitInterval, _ := time.ParseDuration(v.GetString("kafka.consumer.commit_interval"))
+	handlerTimeout, _ := time.ParseDuration(v.GetString("kafka.consumer.handler_timeout"))
+	handlerRetryInitialBackoff, _ := time.ParseDuration(v.GetString("kafka.consumer.retry_initial_backoff"))
+	handlerRetryMaxBackoff, _ := time.ParseDuration(v.GetString("kafka.consumer.retry_max_backoff"))
+	var retryDelays []time.Duration
+	for _, delay := range v.GetStringSlice("kafka.consumer.retry_delays") {
+		if parsed, err := time.ParseDuration(delay); err == nil {
+			retryDelays = append(retryDelays, parsed)
+		}
+	}
 
 	config.Kafka = KafkaConfig{
 		Brokers:           strings.Split(v.GetString("kafka.brokers"), ","),
 		ConnectionTimeout: connectionTimeout,
 		
 		Producer: ProducerConfig{
-			RetryMax:       v.GetInt("kafka.producer.retry_max"),
-			RetryBackoff:   retryBackoff,
-			MessageTimeout: messageTimeout,
-			RequiredAcks:   v.GetString("kafka.producer.required_acks"),
+			RetryMax:        v.GetInt("kafka.producer.retry_max"),
+			RetryBackoff:    retryBackoff,
+			MessageTimeout:  messageTimeout,
+			RequiredAcks:    v.GetString("kafka.producer.required_acks"),
+			ConfirmDelivery: v.GetBool("kafka.producer.confirm_delivery"),
+			Partitioner:     v.GetString("kafka.producer.partitioner"),
+			Idempotent:      v.GetBool("kafka.producer.idempotent"),
+			TransactionalID: v.GetString("kafka.producer.transactional_id"),
+			EventSource:     v.GetString("kafka.producer.event_source"),
+			EventMode:       v.GetString("kafka.producer.event_mode"),
 		},
 		
 		Consumer: ConsumerConfig{
@@ -171,6 +379,17 @@ func Load() (*Config, error) {
 			MaxWaitTime:       maxWaitTime,
 			MinFetchBytes:     v.GetInt("kafka.consumer.min_fetch_bytes"),
 			MaxFetchBytes:     v.GetInt("kafka.consumer.max_fetch_bytes"),
+
+			Workers:        v.GetInt("kafka.consumer.workers"),
+			CommitInterval: commitInterval,
+			HandlerTimeout: handlerTimeout,
+
+			RetryInitialBackoff: handlerRetryInitialBackoff,
+			RetryMaxBackoff:     handlerRetryMaxBackoff,
+
+			DeadLetterTopic: v.GetString("kafka.consumer.dead_letter_topic"),
+			RetryDelays:     retryDelays,
+			MaxAttempts:     v.GetInt("kafka.consumer.max_attempts"),
 		},
 		
 		Topics: TopicConfig{
@@ -178,6 +397,16 @@ func Load() (*Config, error) {
 			OrdersUpdated:   v.GetString("kafka.topics.orders_updated"),
 			OrdersCancelled: v.GetString("kafka.topics.orders_cancelled"),
 			OrderPayments:   v.GetString("kafka.topics.order_payments"),
+
+			Inventory: v.GetString("kafka.topics.inventory"),
+			Payments:  v.GetString("kafka.topics.payments"),
+
+			Prefix: v.GetString("kafka.topics.prefix"),
+			Suffix: v.GetString("kafka.topics.suffix"),
+
+			AutoCreate:        v.GetBool("kafka.topics.auto_create"),
+			Partitions:        v.GetInt32("kafka.topics.partitions"),
+			ReplicationFactor: int16(v.GetInt("kafka.topics.replication_factor")),
 		},
 		
 		Security: SecurityConfig{
@@ -187,6 +416,15 @@ func Load() (*Config, error) {
 			SaslUsername:  v.GetString("kafka.security.sasl_username"),
 			SaslPassword:  v.GetString("kafka.security.sasl_password"),
 		},
+
+		SchemaRegistry: SchemaRegistryConfig{
+			URL:                 v.GetString("kafka.schema_registry.url"),
+			Username:            v.GetString("kafka.schema_registry.username"),
+			Password:            v.GetString("kafka.schema_registry.password"),
+			Timeout:             v.GetDuration("kafka.schema_registry.timeout"),
+			Format:              v.GetString("kafka.schema_registry.format"),
+			SubjectNameStrategy: v.GetString("kafka.schema_registry.subject_name_strategy"),
+		},
 	}
 
 	return config, nil
@@ -200,6 +438,7 @@ func setDefaultValues(v *viper.Viper) {
 	
 	// Server defaults
 	v.SetDefault("server.port", 8089)
+	v.SetDefault("server.admin_port", 8090)
 	
 	// Database defaults
 	v.SetDefault("db.host", "localhost")
@@ -210,6 +449,38 @@ func setDefaultValues(v *viper.Viper) {
 	v.SetDefault("db.sslmode", "disable")
 	v.SetDefault("migrations.path", "db/migrations")
 	
+	// Exchange rate defaults
+	v.SetDefault("exchange_rates.file", "")
+
+	// Outbox worker defaults
+	v.SetDefault("outbox.batch_size", 100)
+	v.SetDefault("outbox.process_interval", "5s")
+	v.SetDefault("outbox.max_retries", 3)
+	v.SetDefault("outbox.dead_letter_queue", "")
+	v.SetDefault("outbox.dead_letter_max_retries", 5)
+	v.SetDefault("outbox.workers", 4)
+	v.SetDefault("outbox.lease_duration", "30s")
+	v.SetDefault("outbox.retry.initial_backoff", "1s")
+	v.SetDefault("outbox.retry.max_backoff", "5m")
+	v.SetDefault("outbox.retry.multiplier", 2.0)
+	v.SetDefault("outbox.retry.jitter", 0.2)
+	v.SetDefault("outbox.notify.channel", "")
+	v.SetDefault("outbox.notify.poll_interval", "1m")
+	v.SetDefault("outbox.mode", OutboxModePoll)
+	v.SetDefault("outbox.cdc.slot", "order_service_outbox")
+	v.SetDefault("outbox.cdc.publication", "order_service_outbox")
+	v.SetDefault("outbox.cdc.status_interval", "10s")
+	v.SetDefault("outbox.retention.period", "0s")
+	v.SetDefault("outbox.retention.interval", "1h")
+	v.SetDefault("outbox.retention.batch_size", 1000)
+	v.SetDefault("outbox.retention.archive", true)
+	v.SetDefault("outbox.transactional", false)
+
+	// Inbox defaults
+	v.SetDefault("inbox.retention.period", "168h")
+	v.SetDefault("inbox.retention.interval", "1h")
+	v.SetDefault("inbox.retention.batch_size", 1000)
+
 	// Kafka defaults - basic
 	v.SetDefault("kafka.brokers", "localhost:9092")
 	v.SetDefault("kafka.connection_timeout", "10s")
@@ -219,6 +490,12 @@ func setDefaultValues(v *viper.Viper) {
 	v.SetDefault("kafka.producer.retry_backoff", "100ms")
 	v.SetDefault("kafka.producer.message_timeout", "5s")
 	v.SetDefault("kafka.producer.required_acks", "all")
+	v.SetDefault("kafka.producer.confirm_delivery", true)
+	v.SetDefault("kafka.producer.partitioner", "hash")
+	v.SetDefault("kafka.producer.idempotent", false)
+	v.SetDefault("kafka.producer.transactional_id", "")
+	v.SetDefault("kafka.producer.event_source", "/order-service")
+	v.SetDefault("kafka.producer.event_mode", "binary")
 	
 	// Kafka defaults - consumer
 	v.SetDefault("kafka.consumer.group_id", "order-service-group")
@@ -228,12 +505,27 @@ func setDefaultValues(v *viper.Viper) {
 	v.SetDefault("kafka.consumer.max_wait_time", "1s")
 	v.SetDefault("kafka.consumer.min_fetch_bytes", 1)
 	v.SetDefault("kafka.consumer.max_fetch_bytes", 1048576) // 1MB
+	v.SetDefault("kafka.consumer.workers", 4)
+	v.SetDefault("kafka.consumer.commit_interval", "1s")
+	v.SetDefault("kafka.consumer.handler_timeout", "30s")
+	v.SetDefault("kafka.consumer.retry_initial_backoff", "100ms")
+	v.SetDefault("kafka.consumer.retry_max_backoff", "30s")
+	v.SetDefault("kafka.consumer.dead_letter_topic", "order-service-group.dlq")
+	v.SetDefault("kafka.consumer.retry_delays", []string{"5s", "1m"})
+	v.SetDefault("kafka.consumer.max_attempts", 3)
 	
 	// Kafka defaults - topics
 	v.SetDefault("kafka.topics.orders_created", "orders-created")
 	v.SetDefault("kafka.topics.orders_updated", "orders-updated")
 	v.SetDefault("kafka.topics.orders_cancelled", "orders-cancelled")
 	v.SetDefault("kafka.topics.order_payments", "order-payments")
+	v.SetDefault("kafka.topics.inventory", "inventory")
+	v.SetDefault("kafka.topics.payments", "payments")
+	v.SetDefault("kafka.topics.prefix", "")
+	v.SetDefault("kafka.topics.suffix", "")
+	v.SetDefault("kafka.topics.auto_create", false)
+	v.SetDefault("kafka.topics.partitions", 3)
+	v.SetDefault("kafka.topics.replication_factor", 1)
 	
 	// Kafka defaults - security
 	v.SetDefault("kafka.security.enabled", false)
@@ -241,4 +533,12 @@ func setDefaultValues(v *viper.Viper) {
 	v.SetDefault("kafka.security.sasl_mechanism", "plain")
 	v.SetDefault("kafka.security.sasl_username", "")
 	v.SetDefault("kafka.security.sasl_password", "")
+
+	// Kafka defaults - schema registry
+	v.SetDefault("kafka.schema_registry.url", "")
+	v.SetDefault("kafka.schema_registry.username", "")
+	v.SetDefault("kafka.schema_registry.password", "")
+	v.SetDefault("kafka.schema_registry.timeout", "10s")
+	v.SetDefault("kafka.schema_registry.format", "json")
+	v.SetDefault("kafka.schema_registry.subject_name_strategy", "topic_record")
 }
\ No newline at end of file
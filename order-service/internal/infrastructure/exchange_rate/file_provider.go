@@ -0,0 +1,70 @@
+package exchangerate
+
+import (
+	"encoding/json"
+	"fmt"
+	"order-service/internal/domain"
+	"os"
+	"strings"
+	"time"
+)
+
+// rateFile is the layout of an exchange rate file:
+//
+//	{
+//	  "as_of": "2025-01-01T00:00:00Z",
+//	  "rates": [
+//	    {"from": "EUR", "to": "USD", "rate": "1.0845"}
+//	  ]
+//	}
+//
+// A rate without its own as_of uses the one of the file.
+type rateFile struct {
+	AsOf  time.Time `json:"as_of"`
+	Rates []struct {
+		From string          `json:"from"`
+		To   string          `json:"to"`
+		Rate json.RawMessage `json:"rate"`
+		AsOf *time.Time      `json:"as_of"`
+	} `json:"rates"`
+}
+
+// NewFileRateProvider creates a static provider from a JSON rate file
+func NewFileRateProvider(path string) (*StaticRateProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exchange rate file: %w", err)
+	}
+
+	var file rateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse exchange rate file %s: %w", path, err)
+	}
+
+	provider := NewStaticRateProvider()
+	for _, r := range file.Rates {
+		asOf := file.AsOf
+		if r.AsOf != nil {
+			asOf = *r.AsOf
+		}
+
+		from, err := domain.ParseCurrency(r.From)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate in %s: %w", path, err)
+		}
+
+		to, err := domain.ParseCurrency(r.To)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate in %s: %w", path, err)
+		}
+
+		rate, err := domain.ParseExchangeRate(from, to, strings.Trim(string(r.Rate), `"`), asOf)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate %s to %s in %s: %w", from, to, path, err)
+		}
+
+		provider.SetRate(rate)
+	}
+
+	return provider, nil
+}
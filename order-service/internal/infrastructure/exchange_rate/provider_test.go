@@ -0,0 +1,67 @@
+package exchangerate_test
+
+import (
+	"context"
+	"order-service/internal/domain"
+	exchangerate "order-service/internal/infrastructure/exchange_rate"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRates = `{
+  "as_of": "2025-01-01T00:00:00Z",
+  "rates": [
+    {"from": "EUR", "to": "USD", "rate": "1.25"},
+    {"from": "USD", "to": "JPY", "rate": 150, "as_of": "2025-01-02T00:00:00Z"}
+  ]
+}`
+
+func TestFileRateProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	if err := os.WriteFile(path, []byte(testRates), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := exchangerate.NewFileRateProvider(path)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	rate, err := provider.GetRate(ctx, domain.CurrencyEUR, domain.CurrencyUSD)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.25", rate.Decimal())
+	assert.Equal(t, 2025, rate.AsOf.Year())
+
+	// Inverse rates are derived when only the opposite direction is known
+	rate, err = provider.GetRate(ctx, domain.CurrencyUSD, domain.CurrencyEUR)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.8", rate.Decimal())
+
+	rate, err = provider.GetRate(ctx, domain.CurrencyUSD, domain.CurrencyJPY)
+	assert.NoError(t, err)
+	assert.Equal(t, "150", rate.Decimal())
+	assert.Equal(t, 2, rate.AsOf.Day())
+
+	rate, err = provider.GetRate(ctx, domain.CurrencyGBP, domain.CurrencyGBP)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", rate.Decimal())
+
+	_, err = provider.GetRate(ctx, domain.CurrencyGBP, domain.CurrencyUSD)
+	assert.ErrorIs(t, err, domain.ErrExchangeRateNotFound)
+}
+
+func TestFileRateProviderInvalidFile(t *testing.T) {
+	_, err := exchangerate.NewFileRateProvider(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "rates.json")
+	if err := os.WriteFile(path, []byte(`{"rates":[{"from":"EUR","to":"USD","rate":"-1"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = exchangerate.NewFileRateProvider(path)
+	assert.ErrorIs(t, err, domain.ErrInvalidExchangeRate)
+}
@@ -0,0 +1,66 @@
+package exchangerate
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"order-service/internal/app/ports"
+	"order-service/internal/domain"
+	"sync"
+	"time"
+)
+
+type currencyPair struct {
+	from domain.Currency
+	to   domain.Currency
+}
+
+// StaticRateProvider serves exchange rates from an in-memory table.
+// A rate is also used in the opposite direction when only its inverse is known.
+type StaticRateProvider struct {
+	mu    sync.RWMutex
+	rates map[currencyPair]domain.ExchangeRate
+}
+
+// NewStaticRateProvider creates a provider serving the given rates
+func NewStaticRateProvider(rates ...domain.ExchangeRate) *StaticRateProvider {
+	p := &StaticRateProvider{
+		rates: make(map[currencyPair]domain.ExchangeRate, len(rates)),
+	}
+
+	for _, rate := range rates {
+		p.SetRate(rate)
+	}
+
+	return p
+}
+
+// SetRate adds or replaces a rate in the table
+func (p *StaticRateProvider) SetRate(rate domain.ExchangeRate) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.rates[currencyPair{from: rate.From, to: rate.To}] = rate
+}
+
+// GetRate implements ports.ExchangeRateProvider
+func (p *StaticRateProvider) GetRate(ctx context.Context, from, to domain.Currency) (domain.ExchangeRate, error) {
+	if from == to {
+		return domain.NewExchangeRate(from, to, big.NewRat(1, 1), time.Now())
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if rate, ok := p.rates[currencyPair{from: from, to: to}]; ok {
+		return rate, nil
+	}
+
+	if rate, ok := p.rates[currencyPair{from: to, to: from}]; ok {
+		return rate.Inverse()
+	}
+
+	return domain.ExchangeRate{}, fmt.Errorf("%w: %s to %s", domain.ErrExchangeRateNotFound, from, to)
+}
+
+var _ ports.ExchangeRateProvider = (*StaticRateProvider)(nil)
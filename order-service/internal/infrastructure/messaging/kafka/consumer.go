@@ -0,0 +1,71 @@
+package kafka
+
+import (
+	"context"
+	"kafkaconsumer"
+	"order-service/internal/app/tracing"
+	"order-service/internal/events"
+	"order-service/internal/infrastructure/config"
+	"sync"
+)
+
+// consumerMetrics counts the events handled by the consumers of the service, published once
+// with expvar
+var consumerMetrics = sync.OnceValue(func() *kafkaconsumer.ExpvarMetrics {
+	return kafkaconsumer.NewExpvarMetrics("order_service.consumer")
+})
+
+// NewConsumer joins the consumer group of cfg.Consumer to consume the topics.
+// It connects with the client and security settings of the publisher, and every handler
+// recovers from panics, is logged, measured, traced and bounded by cfg.Consumer.HandlerTimeout.
+func NewConsumer(cfg config.KafkaConfig, topics []string) (*kafkaconsumer.Consumer, error) {
+	consumer, err := kafkaconsumer.New(newConsumerConfig(cfg, topics))
+	if err != nil {
+		return nil, err
+	}
+
+	consumer.Use(
+		kafkaconsumer.Recover(),
+		kafkaconsumer.Logging(),
+		kafkaconsumer.Metrics(consumerMetrics()),
+		kafkaconsumer.Tracing(consumerTracing),
+	)
+	if cfg.Consumer.HandlerTimeout > 0 {
+		consumer.Use(kafkaconsumer.Timeout(cfg.Consumer.HandlerTimeout))
+	}
+	return consumer, nil
+}
+
+func newConsumerConfig(cfg config.KafkaConfig, topics []string) kafkaconsumer.Config {
+	return kafkaconsumer.Config{
+		Brokers:             cfg.Brokers,
+		GroupID:             cfg.Consumer.GroupID,
+		Topics:              topics,
+		AutoOffsetReset:     cfg.Consumer.AutoOffsetReset,
+		HeartbeatInterval:   cfg.Consumer.HeartbeatInterval,
+		SessionTimeout:      cfg.Consumer.SessionTimeout,
+		MaxWaitTime:         cfg.Consumer.MaxWaitTime,
+		MinFetchBytes:       cfg.Consumer.MinFetchBytes,
+		MaxFetchBytes:       cfg.Consumer.MaxFetchBytes,
+		Workers:             cfg.Consumer.Workers,
+		CommitInterval:      cfg.Consumer.CommitInterval,
+		RetryInitialBackoff: cfg.Consumer.RetryInitialBackoff,
+		RetryMaxBackoff:     cfg.Consumer.RetryMaxBackoff,
+		DeadLetterTopic:     cfg.Consumer.DeadLetterTopic,
+		RetryDelays:         cfg.Consumer.RetryDelays,
+		MaxAttempts:         cfg.Consumer.MaxAttempts,
+		Sarama:              newSaramaConfig(cfg),
+	}
+}
+
+// consumerTracing puts the tracing IDs of a consumed event into the context of its handler,
+// so the events the handler publishes share its correlation ID and name it as their cause
+func consumerTracing(ctx context.Context, event kafkaconsumer.Event) context.Context {
+	if correlationID := event.Extension(events.ExtensionCorrelationID); correlationID != "" {
+		ctx = tracing.WithCorrelationID(ctx, correlationID)
+	}
+	if traceID := event.Extension(events.ExtensionTraceID); traceID != "" {
+		ctx = tracing.WithTraceID(ctx, traceID)
+	}
+	return tracing.WithCausationID(ctx, event.ID)
+}
@@ -0,0 +1,59 @@
+package kafka
+
+import (
+	"context"
+	"kafkaconsumer"
+	"order-service/internal/app/tracing"
+	"order-service/internal/events"
+	"order-service/internal/infrastructure/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConsumerConfig(t *testing.T) {
+	cfg := config.KafkaConfig{
+		Brokers:           []string{"kafka-1:9092", "kafka-2:9092"},
+		ConnectionTimeout: 5 * time.Second,
+		Consumer: config.ConsumerConfig{
+			GroupID:             "order-service-group",
+			AutoOffsetReset:     "earliest",
+			SessionTimeout:      45 * time.Second,
+			Workers:             4,
+			CommitInterval:      time.Second,
+			RetryInitialBackoff: 100 * time.Millisecond,
+			RetryMaxBackoff:     30 * time.Second,
+			DeadLetterTopic:     "order-service-group.dlq",
+			RetryDelays:         []time.Duration{5 * time.Second, time.Minute},
+			MaxAttempts:         3,
+		},
+	}
+
+	consumerConfig := newConsumerConfig(cfg, []string{"payments"})
+
+	assert.Equal(t, cfg.Brokers, consumerConfig.Brokers)
+	assert.Equal(t, "order-service-group", consumerConfig.GroupID)
+	assert.Equal(t, []string{"payments"}, consumerConfig.Topics)
+	assert.Equal(t, 45*time.Second, consumerConfig.SessionTimeout)
+	assert.Equal(t, 4, consumerConfig.Workers)
+	assert.Equal(t, 30*time.Second, consumerConfig.RetryMaxBackoff)
+	assert.Equal(t, "order-service-group.dlq", consumerConfig.DeadLetterTopic)
+	assert.Equal(t, []time.Duration{5 * time.Second, time.Minute}, consumerConfig.RetryDelays)
+	assert.Equal(t, 3, consumerConfig.MaxAttempts)
+	// The connection settings are shared with the publisher
+	assert.Equal(t, "order-service", consumerConfig.Sarama.ClientID)
+	assert.Equal(t, 5*time.Second, consumerConfig.Sarama.Net.DialTimeout)
+}
+
+func TestConsumerTracing(t *testing.T) {
+	event := kafkaconsumer.Event{ID: "event-1"}
+	event.SetExtension(events.ExtensionCorrelationID, "correlation-1")
+	event.SetExtension(events.ExtensionTraceID, "4bf92f3577b34da6a3ce929d0e0e4736")
+
+	ctx := consumerTracing(context.Background(), event)
+	assert.Equal(t, "correlation-1", tracing.CorrelationID(ctx))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tracing.TraceID(ctx))
+	// The consumed event causes what its handler does
+	assert.Equal(t, "event-1", tracing.CausationID(ctx))
+}
@@ -0,0 +1,30 @@
+package kafka
+
+import (
+	"context"
+	"kafkaconsumer"
+	"log"
+	"order-service/internal/app/ports"
+)
+
+// InboxHandler handles an event with the repositories of the transaction recording it
+type InboxHandler func(ctx context.Context, event kafkaconsumer.Event, factory ports.RepositoryFactory) error
+
+// Idempotent handles every event once for the consumer, however often it is delivered.
+// The event is recorded in the inbox in the same transaction as the side effects of the
+// handler, so either both are committed or neither is.
+func Idempotent(uow ports.UnitOfWork, consumerName string, handle InboxHandler) kafkaconsumer.Handler {
+	return kafkaconsumer.HandlerFunc(func(ctx context.Context, event kafkaconsumer.Event) error {
+		return uow.Execute(ctx, func(factory ports.RepositoryFactory) error {
+			first, err := factory.InboxRepository().MarkProcessed(ctx, event.ID, consumerName, event.Type)
+			if err != nil {
+				return err
+			}
+			if !first {
+				log.Printf("Skipping event %s, %s processed it before", event.ID, consumerName)
+				return nil
+			}
+			return handle(ctx, event, factory)
+		})
+	})
+}
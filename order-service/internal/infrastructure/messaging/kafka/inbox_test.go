@@ -0,0 +1,77 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"kafkaconsumer"
+	"order-service/internal/app/ports"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeInbox remembers the events recorded by committed transactions
+type fakeInbox struct {
+	committed map[string]bool
+	pending   map[string]bool
+}
+
+func (i *fakeInbox) MarkProcessed(ctx context.Context, eventID, consumerName, eventType string) (bool, error) {
+	key := consumerName + "/" + eventID
+	if i.committed[key] || i.pending[key] {
+		return false, nil
+	}
+	i.pending[key] = true
+	return true, nil
+}
+
+// fakeUnitOfWork commits the inbox records of a transaction unless it fails
+type fakeUnitOfWork struct {
+	inbox *fakeInbox
+}
+
+func (u *fakeUnitOfWork) Execute(ctx context.Context, fn func(factory ports.RepositoryFactory) error) error {
+	u.inbox.pending = make(map[string]bool)
+	if err := fn(u); err != nil {
+		return err
+	}
+	for key := range u.inbox.pending {
+		u.inbox.committed[key] = true
+	}
+	return nil
+}
+
+func (u *fakeUnitOfWork) OrderRepository() ports.OrderRepository         { return nil }
+func (u *fakeUnitOfWork) OutboxRepository() ports.OutboxRepository       { return nil }
+func (u *fakeUnitOfWork) InboxRepository() ports.InboxRepository         { return u.inbox }
+func (u *fakeUnitOfWork) OrderSagaRepository() ports.OrderSagaRepository { return nil }
+
+func TestIdempotentHandlesEventsOnce(t *testing.T) {
+	uow := &fakeUnitOfWork{inbox: &fakeInbox{committed: make(map[string]bool)}}
+
+	handled := 0
+	fail := true
+	handler := Idempotent(uow, "order-saga", func(ctx context.Context, event kafkaconsumer.Event, factory ports.RepositoryFactory) error {
+		if fail {
+			fail = false
+			return errors.New("database unavailable")
+		}
+		handled++
+		return nil
+	})
+
+	event := kafkaconsumer.Event{ID: "event-1", Type: "payment.approved"}
+	// The failed attempt is not recorded, so the redelivered event is handled
+	assert.Error(t, handler.Handle(context.Background(), event))
+	assert.NoError(t, handler.Handle(context.Background(), event))
+	assert.NoError(t, handler.Handle(context.Background(), event))
+	assert.Equal(t, 1, handled)
+
+	// Another consumer handles the event as well
+	other := Idempotent(uow, "order-projection", func(ctx context.Context, event kafkaconsumer.Event, factory ports.RepositoryFactory) error {
+		handled++
+		return nil
+	})
+	assert.NoError(t, other.Handle(context.Background(), event))
+	assert.Equal(t, 2, handled)
+}
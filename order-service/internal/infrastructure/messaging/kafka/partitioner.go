@@ -0,0 +1,104 @@
+package kafka
+
+import (
+	"cloudevents"
+	"fmt"
+	"math/rand"
+
+	"github.com/IBM/sarama"
+)
+
+// Partitioner names accepted by the producer configuration
+const (
+	PartitionerHash      = "hash"
+	PartitionerSticky    = "sticky"
+	PartitionerAggregate = "aggregate"
+)
+
+// headerSubject carries the subject of binary mode CloudEvents
+const headerSubject = cloudevents.HeaderPrefix + "subject"
+
+// stickyBatchSize is the number of keyless messages sent to a partition before moving on
+const stickyBatchSize = 100
+
+// newPartitioner returns the constructor of the named partitioner, hash when the name is empty
+func newPartitioner(name string) (sarama.PartitionerConstructor, error) {
+	switch name {
+	case "", PartitionerHash:
+		return sarama.NewHashPartitioner, nil
+	case PartitionerSticky:
+		return newStickyPartitioner, nil
+	case PartitionerAggregate:
+		return newAggregatePartitioner, nil
+	default:
+		return nil, fmt.Errorf("unknown partitioner %q", name)
+	}
+}
+
+// stickyPartitioner hashes keyed messages. Keyless messages stay on one partition for
+// stickyBatchSize messages, so they fill larger batches than when spread randomly.
+// Sarama creates a partitioner per topic and calls it from a single goroutine.
+type stickyPartitioner struct {
+	hash      sarama.Partitioner
+	partition int32
+	remaining int
+}
+
+func newStickyPartitioner(topic string) sarama.Partitioner {
+	return &stickyPartitioner{hash: sarama.NewHashPartitioner(topic)}
+}
+
+func (p *stickyPartitioner) Partition(message *sarama.ProducerMessage, numPartitions int32) (int32, error) {
+	if message.Key != nil {
+		return p.hash.Partition(message, numPartitions)
+	}
+
+	if p.remaining <= 0 || p.partition >= numPartitions {
+		p.partition = rand.Int31n(numPartitions)
+		p.remaining = stickyBatchSize
+	}
+	p.remaining--
+	return p.partition, nil
+}
+
+// RequiresConsistency is true so keyed messages keep their partition while the producer retries
+func (p *stickyPartitioner) RequiresConsistency() bool {
+	return true
+}
+
+// MessageRequiresConsistency lets keyless messages move to another partition
+// when the leader of theirs is unavailable
+func (p *stickyPartitioner) MessageRequiresConsistency(message *sarama.ProducerMessage) bool {
+	return message.Key != nil
+}
+
+// aggregatePartitioner hashes the subject of binary mode CloudEvents, which is the aggregate ID,
+// so all events of an aggregate share a partition even when they are keyed differently.
+// Messages without the header are hashed by key.
+type aggregatePartitioner struct {
+	hash sarama.Partitioner
+}
+
+func newAggregatePartitioner(topic string) sarama.Partitioner {
+	return &aggregatePartitioner{hash: sarama.NewHashPartitioner(topic)}
+}
+
+func (p *aggregatePartitioner) Partition(message *sarama.ProducerMessage, numPartitions int32) (int32, error) {
+	for _, header := range message.Headers {
+		if string(header.Key) == headerSubject && len(header.Value) > 0 {
+			byAggregate := *message
+			byAggregate.Key = sarama.ByteEncoder(header.Value)
+			return p.hash.Partition(&byAggregate, numPartitions)
+		}
+	}
+	return p.hash.Partition(message, numPartitions)
+}
+
+func (p *aggregatePartitioner) RequiresConsistency() bool {
+	return true
+}
+
+var (
+	_ sarama.DynamicConsistencyPartitioner = (*stickyPartitioner)(nil)
+	_ sarama.Partitioner                   = (*aggregatePartitioner)(nil)
+)
@@ -0,0 +1,59 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func partitionOf(t *testing.T, partitioner sarama.Partitioner, msg *sarama.ProducerMessage) int32 {
+	partition, err := partitioner.Partition(msg, 12)
+	require.NoError(t, err)
+	return partition
+}
+
+func TestNewPartitionerRejectsUnknownName(t *testing.T) {
+	for _, name := range []string{"", PartitionerHash, PartitionerSticky, PartitionerAggregate} {
+		_, err := newPartitioner(name)
+		assert.NoError(t, err, name)
+	}
+
+	_, err := newPartitioner("round-robin")
+	assert.Error(t, err)
+}
+
+func TestStickyPartitionerKeepsKeylessMessagesTogether(t *testing.T) {
+	partitioner := newStickyPartitioner("orders")
+	hash := sarama.NewHashPartitioner("orders")
+
+	first := partitionOf(t, partitioner, &sarama.ProducerMessage{})
+	for i := 1; i < stickyBatchSize; i++ {
+		assert.Equal(t, first, partitionOf(t, partitioner, &sarama.ProducerMessage{}))
+	}
+
+	// Keyed messages are hashed as usual
+	keyed := &sarama.ProducerMessage{Key: sarama.StringEncoder("order-1")}
+	assert.Equal(t, partitionOf(t, hash, keyed), partitionOf(t, partitioner, keyed))
+}
+
+func TestAggregatePartitionerHashesAggregateID(t *testing.T) {
+	partitioner := newAggregatePartitioner("orders")
+	hash := sarama.NewHashPartitioner("orders")
+
+	byAggregate := partitionOf(t, hash, &sarama.ProducerMessage{Key: sarama.StringEncoder("order-1")})
+	for _, key := range []string{"a", "b", "c", "d"} {
+		msg := &sarama.ProducerMessage{
+			Key:     sarama.StringEncoder(key),
+			Headers: []sarama.RecordHeader{{Key: []byte(headerSubject), Value: []byte("order-1")}},
+		}
+		assert.Equal(t, byAggregate, partitionOf(t, partitioner, msg))
+		// The message itself keeps its key
+		assert.Equal(t, sarama.StringEncoder(key), msg.Key)
+	}
+
+	// Without the header the key is hashed
+	keyed := &sarama.ProducerMessage{Key: sarama.StringEncoder("order-2")}
+	assert.Equal(t, partitionOf(t, hash, keyed), partitionOf(t, partitioner, keyed))
+}
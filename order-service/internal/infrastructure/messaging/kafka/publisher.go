@@ -1,11 +1,19 @@
 package kafka
 
 import (
+	"cloudevents"
 	"context"
-	"encoding/json"
 	"fmt"
+	"log"
 	"order-service/internal/app/ports"
+	"order-service/internal/app/tracing"
+	"order-service/internal/domain"
+	"order-service/internal/events"
 	"order-service/internal/infrastructure/config"
+	"os"
+	"schemaregistry"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/IBM/sarama"
@@ -35,19 +43,41 @@ import (
 // 	}
 // }
 
-// EventPublisher implements the ports.EventPublisher interface using Sarama
+// EventPublisher implements the ports.EventPublisher interface using Sarama.
+// With ConfirmDelivery every publish waits for the broker's acknowledgement of its message,
+// otherwise it returns once the message is queued and delivery errors are only logged.
+//
+// With a transactional producer every publish runs in a Kafka transaction, and PublishBatch
+// publishes all of its envelopes in a single one.
 type EventPublisher struct {
 	producer sarama.AsyncProducer
 	config   config.KafkaConfig
+	confirm  bool
+	// source and mode describe the CloudEvents the events are published as
+	source string
+	mode   cloudevents.Mode
+	// topics names the original topic of dead letters, which default to their event type
+	topics ports.TopicRouter
+	// serializer encodes payloads with their registered schemas, they are plain JSON without one
+	serializer *schemaregistry.Serializer
+
+	// txnMu serializes transactions, a producer has at most one open transaction
+	txnMu sync.Mutex
 }
 
+// defaultEventSource is the CloudEvents source of published events unless configured otherwise
+const defaultEventSource = "/order-service"
+
+// delivery receives the outcome of a confirmed message. It travels with the
+// message as its Metadata, so every response is routed back to its publish call.
+type delivery chan error
+
 // NewEventPublisher creates a new EventPublisher with configuration
 func NewEventPublisher(cfg config.KafkaConfig) (*EventPublisher, error) {
 	// Create Sarama configuration
-	saramaConfig := sarama.NewConfig()
+	saramaConfig := newSaramaConfig(cfg)
 
 	// Apply configuration from our unified config
-	saramaConfig.ClientID = "order-service" // You may want to add this to your config
 	saramaConfig.Producer.Retry.Max = cfg.Producer.RetryMax
 
 	// Map our RequiredAcks string to Sarama's RequiredAcks type
@@ -68,9 +98,50 @@ func NewEventPublisher(cfg config.KafkaConfig) (*EventPublisher, error) {
 	saramaConfig.Producer.Retry.Backoff = cfg.Producer.RetryBackoff
 	saramaConfig.Producer.Timeout = cfg.Producer.MessageTimeout
 
-	// Default flush settings (you might want to add these to your config)
-	saramaConfig.Producer.Flush.Frequency = 500 * time.Millisecond
-	saramaConfig.Producer.Flush.Messages = 1000
+	// Idempotence needs acknowledgements from all replicas and one request in flight per broker
+	if cfg.Producer.Idempotent || cfg.Producer.TransactionalID != "" {
+		saramaConfig.Producer.Idempotent = true
+		saramaConfig.Producer.RequiredAcks = sarama.WaitForAll
+		saramaConfig.Net.MaxOpenRequests = 1
+		if saramaConfig.Producer.Retry.Max < 1 {
+			saramaConfig.Producer.Retry.Max = 1
+		}
+	}
+	if cfg.Producer.TransactionalID != "" {
+		saramaConfig.Producer.Transaction.ID = instanceTransactionalID(cfg.Producer.TransactionalID)
+	}
+
+	// Default flush settings (you might want to add these to your config).
+	// Confirmed and transactional messages are sent right away, batching would delay every publish call.
+	if !cfg.Producer.ConfirmDelivery && cfg.Producer.TransactionalID == "" {
+		saramaConfig.Producer.Flush.Frequency = 500 * time.Millisecond
+		saramaConfig.Producer.Flush.Messages = 1000
+	}
+
+	if _, err := cloudevents.ParseMode(cfg.Producer.EventMode); err != nil {
+		return nil, err
+	}
+
+	partitioner, err := newPartitioner(cfg.Producer.Partitioner)
+	if err != nil {
+		return nil, err
+	}
+	saramaConfig.Producer.Partitioner = partitioner
+
+	// Create a new async producer
+	producer, err := sarama.NewAsyncProducer(cfg.Brokers, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
+	}
+
+	// Return the EventPublisher instance with the configured producer
+	return newEventPublisher(producer, cfg), nil
+}
+
+// newSaramaConfig returns the client settings shared by the producer and the admin client
+func newSaramaConfig(cfg config.KafkaConfig) *sarama.Config {
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.ClientID = "order-service" // You may want to add this to your config
 
 	// Connection timeout
 	saramaConfig.Net.DialTimeout = cfg.ConnectionTimeout
@@ -93,70 +164,341 @@ func NewEventPublisher(cfg config.KafkaConfig) (*EventPublisher, error) {
 		}
 	}
 
-	// Create a new async producer
-	producer, err := sarama.NewAsyncProducer(cfg.Brokers, saramaConfig)
+	return saramaConfig
+}
+
+// instanceTransactionalID appends the host name to the configured transactional ID.
+// A second producer with the same ID fences off the first, so instances must not share one.
+func instanceTransactionalID(prefix string) string {
+	hostname, err := os.Hostname()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
+		return prefix
 	}
+	return prefix + "-" + hostname
+}
 
-	// Start a goroutine to handle success and error responses
+// newEventPublisher wraps the producer and starts handling its responses.
+// The producer has to return successes as well as errors.
+func newEventPublisher(producer sarama.AsyncProducer, cfg config.KafkaConfig) *EventPublisher {
+	// Hand the responses back to the waiting publish calls. Both loops end when the producer is closed.
 	go func() {
-		for {
-			select {
-			case success := <-producer.Successes():
-				// You might want to log this or emit metrics
-				_ = success
-			case err := <-producer.Errors():
-				// Handle errors (ideally with proper error handling/logging)
-				fmt.Printf("Failed to publish message: %v\n", err)
+		for success := range producer.Successes() {
+			if result, ok := success.Metadata.(delivery); ok {
+				result <- nil
 			}
 		}
 	}()
+	go func() {
+		for err := range producer.Errors() {
+			if result, ok := err.Msg.Metadata.(delivery); ok {
+				result <- err.Err
+				continue
+			}
+			log.Printf("Failed to publish message to %s: %v", err.Msg.Topic, err.Err)
+		}
+	}()
+
+	// An unknown mode is rejected by NewEventPublisher
+	mode, _ := cloudevents.ParseMode(cfg.Producer.EventMode)
+	source := cfg.Producer.EventSource
+	if source == "" {
+		source = defaultEventSource
+	}
 
-	// Return the EventPublisher instance with the configured producer
 	return &EventPublisher{
 		producer: producer,
 		config:   cfg,
-	}, nil
+		confirm:  cfg.Producer.ConfirmDelivery,
+		source:   source,
+		mode:     mode,
+	}
+}
 
+// UseTopicRouter resolves the topic outbox messages were published to for dead-letter headers
+func (p *EventPublisher) UseTopicRouter(topics ports.TopicRouter) {
+	p.topics = topics
 }
 
-// Publish publishes an event to the specified Kafka topic
+// UseSerializer serializes event payloads with their schemas in the schema registry.
+// Publishing fails for events without a schema or whose schema breaks the compatibility
+// of its subject.
+func (p *EventPublisher) UseSerializer(serializer *schemaregistry.Serializer) {
+	p.serializer = serializer
+}
+
+// Publish publishes an event to the specified Kafka topic.
+// Aggregate events are keyed by their aggregate ID so they land on one partition in order.
 func (p *EventPublisher) Publish(ctx context.Context, topic string, event interface{}) error {
-	// Marshal the event payload into JSON
-	payload, err := json.Marshal(event)
+	envelope := ports.Envelope{Topic: topic, Event: event}
+	if aggregate, ok := event.(ports.AggregateEvent); ok {
+		envelope.AggregateID = aggregate.AggregateID().String()
+		envelope.Key = envelope.AggregateID
+	}
+	if typed, ok := event.(ports.TypedEvent); ok {
+		envelope.EventType = typed.EventType()
+	}
+	if versioned, ok := event.(ports.VersionedEvent); ok {
+		envelope.SchemaVersion = versioned.SchemaVersion()
+	}
+	return p.PublishEnvelope(ctx, envelope)
+}
+
+// PublishEnvelope publishes the event of the envelope to its topic as a CloudEvent.
+// A non-empty key selects the partition.
+func (p *EventPublisher) PublishEnvelope(ctx context.Context, envelope ports.Envelope) error {
+	msg, err := p.newMessage(ctx, envelope)
+	if err != nil {
+		return err
+	}
+
+	// Send the message
+	return p.send(ctx, msg)
+}
+
+// PublishBatch publishes the envelopes in order. With a transactional producer they are
+// published in one transaction, otherwise one after the other until the first error,
+// which is returned as a *ports.BatchError telling how many envelopes were published.
+func (p *EventPublisher) PublishBatch(ctx context.Context, envelopes []ports.Envelope) error {
+	msgs := make([]*sarama.ProducerMessage, 0, len(envelopes))
+	for _, envelope := range envelopes {
+		msg, err := p.newMessage(ctx, envelope)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+	}
+
+	if p.producer.IsTransactional() {
+		return p.sendTransaction(ctx, msgs)
+	}
+
+	for i, msg := range msgs {
+		if err := p.send(ctx, msg); err != nil {
+			return &ports.BatchError{Index: i, Err: err}
+		}
+	}
+	return nil
+}
+
+// newMessage builds the Kafka message of an envelope. Its event is published as a CloudEvent
+// of the envelope's type, or of the topic name when the envelope has no type.
+// IDs missing from the envelope are taken from the context.
+func (p *EventPublisher) newMessage(ctx context.Context, envelope ports.Envelope) (*sarama.ProducerMessage, error) {
+	event, err := p.newEvent(ctx, firstNonEmpty(envelope.EventType, envelope.Topic), envelope)
 	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
+		return nil, err
+	}
+	if envelope.ID != "" {
+		event.ID = envelope.ID
+	}
+	if envelope.ContentType != "" {
+		event.DataContentType = envelope.ContentType
+	}
+	event.Subject = envelope.AggregateID
+
+	for name, value := range envelope.Extensions {
+		event.SetExtension(name, value)
+	}
+	if envelope.SchemaVersion > 0 {
+		event.SetExtension(events.ExtensionSchemaVersion, strconv.Itoa(envelope.SchemaVersion))
 	}
+	event.SetExtension(events.ExtensionCorrelationID, firstNonEmpty(envelope.CorrelationID, tracing.CorrelationID(ctx)))
+	event.SetExtension(events.ExtensionCausationID, firstNonEmpty(envelope.CausationID, tracing.CausationID(ctx)))
+	event.SetExtension(events.ExtensionTraceID, tracing.TraceID(ctx))
 
 	// Create a new Kafka message
 	msg := &sarama.ProducerMessage{
-		Topic:     topic,
-		Value:     sarama.ByteEncoder(payload),
+		Topic:     envelope.Topic,
 		Timestamp: time.Now(),
 	}
+	if envelope.Key != "" {
+		msg.Key = sarama.StringEncoder(envelope.Key)
+	}
+
+	if err := cloudevents.WriteMessage(msg, event, p.mode); err != nil {
+		return nil, fmt.Errorf("failed to encode %s event: %w", event.Type, err)
+	}
+	return msg, nil
+}
+
+// newEvent creates the CloudEvent carrying the envelope's event, serialized with its schema
+// when a schema registry is used and as JSON otherwise
+func (p *EventPublisher) newEvent(ctx context.Context, eventType string, envelope ports.Envelope) (cloudevents.Event, error) {
+	if p.serializer == nil {
+		return cloudevents.New(p.source, eventType, envelope.Event)
+	}
 
-	// Add any headers from context if needed
-	// This could include tracing IDs or other metadata
-	traceID := ctx.Value("traceID")
-	if traceID != nil {
-		if tracIDstr, ok := traceID.(string); ok {
-			msg.Headers = append(msg.Headers, sarama.RecordHeader{
-				Key:   []byte("trace-id"),
-				Value: []byte(tracIDstr),
-			})
+	data, err := p.serializer.Serialize(ctx, envelope.Topic, eventType, envelope.Event)
+	if err != nil {
+		return cloudevents.Event{}, fmt.Errorf("failed to serialize %s event: %w", eventType, err)
+	}
+	event, err := cloudevents.New(p.source, eventType, nil)
+	if err != nil {
+		return cloudevents.Event{}, err
+	}
+	event.Data = data
+	event.DataContentType = p.serializer.ContentType()
+	return event, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
 		}
 	}
+	return ""
+}
+
+// send hands the message to the producer and, when delivery is confirmed,
+// waits for the broker's response to it
+func (p *EventPublisher) send(ctx context.Context, msg *sarama.ProducerMessage) error {
+	// A transactional producer only accepts messages inside a transaction
+	if p.producer.IsTransactional() {
+		return p.sendTransaction(ctx, []*sarama.ProducerMessage{msg})
+	}
+
+	var result delivery
+	if p.confirm {
+		// Buffered so the response is never blocked by a caller that gave up
+		result = make(delivery, 1)
+		msg.Metadata = result
+	}
 
-	// Send the message
 	select {
 	case p.producer.Input() <- msg:
 		// Message accepted
-		return nil
 	case <-ctx.Done():
 		// Context cancelled
 		return ctx.Err()
 	}
+
+	if result == nil {
+		return nil
+	}
+
+	select {
+	case err := <-result:
+		if err != nil {
+			return fmt.Errorf("kafka did not accept message for %s: %w", msg.Topic, err)
+		}
+		return nil
+	case <-ctx.Done():
+		// The message may still be delivered, the outbox publishes it again at worst
+		return ctx.Err()
+	}
+}
+
+// sendTransaction sends the messages in one transaction. The transaction is committed once
+// the broker acknowledged every message and aborted when any of them failed.
+func (p *EventPublisher) sendTransaction(ctx context.Context, msgs []*sarama.ProducerMessage) error {
+	p.txnMu.Lock()
+	defer p.txnMu.Unlock()
+
+	if err := p.producer.BeginTxn(); err != nil {
+		return fmt.Errorf("failed to begin kafka transaction: %w", err)
+	}
+
+	if err := p.sendAll(ctx, msgs); err != nil {
+		p.abortTransaction()
+		return err
+	}
+
+	if err := p.producer.CommitTxn(); err != nil {
+		if p.producer.TxnStatus()&sarama.ProducerTxnFlagAbortableError != 0 {
+			p.abortTransaction()
+		}
+		return fmt.Errorf("failed to commit kafka transaction: %w", err)
+	}
+	return nil
+}
+
+// sendAll hands all messages to the producer and waits for the broker's response to each
+func (p *EventPublisher) sendAll(ctx context.Context, msgs []*sarama.ProducerMessage) error {
+	results := make([]delivery, len(msgs))
+	for i, msg := range msgs {
+		// Buffered so the response is never blocked by a caller that gave up
+		results[i] = make(delivery, 1)
+		msg.Metadata = results[i]
+
+		select {
+		case p.producer.Input() <- msg:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	for i, result := range results {
+		select {
+		case err := <-result:
+			if err != nil {
+				return fmt.Errorf("kafka did not accept message for %s: %w", msgs[i].Topic, err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// abortTransaction aborts the open transaction, its messages are never seen by
+// consumers reading committed messages
+func (p *EventPublisher) abortTransaction() {
+	if err := p.producer.AbortTxn(); err != nil {
+		log.Printf("Failed to abort kafka transaction: %v", err)
+	}
+}
+
+// Headers describing why a message ended up on the dead-letter topic
+const (
+	HeaderDeadLetterOriginalTopic = "dlq-original-topic"
+	HeaderDeadLetterEventType     = "dlq-event-type"
+	HeaderDeadLetterMessageID     = "dlq-message-id"
+	HeaderDeadLetterAggregateID   = "dlq-aggregate-id"
+	HeaderDeadLetterAttempts      = "dlq-attempts"
+	HeaderDeadLetterError         = "dlq-error"
+	HeaderDeadLetterCreatedAt     = "dlq-created-at"
+	HeaderDeadLetterFailedAt      = "dlq-failed-at"
+	// HeaderDeadLetterSchemaVersion is the schema version the payload was written with
+	HeaderDeadLetterSchemaVersion = "dlq-schema-version"
+)
+
+// PublishDeadLetter forwards the original payload of a failed outbox message to the
+// dead-letter topic, keyed by its aggregate and annotated with diagnostic headers
+func (p *EventPublisher) PublishDeadLetter(ctx context.Context, topic string, msg domain.OutboxMessage) error {
+	header := func(key, value string) sarama.RecordHeader {
+		return sarama.RecordHeader{Key: []byte(key), Value: []byte(value)}
+	}
+
+	originalTopic := msg.EventType
+	if p.topics != nil {
+		if topic, err := p.topics.Topic(msg.EventType); err == nil {
+			originalTopic = topic
+		}
+	}
+
+	dlqMsg := &sarama.ProducerMessage{
+		Topic:     topic,
+		Key:       sarama.StringEncoder(msg.AggregateID.String()),
+		Value:     sarama.ByteEncoder(msg.Payload),
+		Timestamp: time.Now(),
+		Headers: []sarama.RecordHeader{
+			header(HeaderDeadLetterOriginalTopic, originalTopic),
+			header(HeaderDeadLetterEventType, msg.EventType),
+			header(HeaderDeadLetterMessageID, msg.ID.String()),
+			header(HeaderDeadLetterAggregateID, msg.AggregateID.String()),
+			header(HeaderDeadLetterAttempts, strconv.Itoa(msg.AttemptCount)),
+			header(HeaderDeadLetterError, msg.LastError),
+			header(HeaderDeadLetterCreatedAt, msg.CreatedAt.UTC().Format(time.RFC3339Nano)),
+			header(HeaderDeadLetterFailedAt, msg.FailedAt.UTC().Format(time.RFC3339Nano)),
+		},
+	}
+	// The payload is forwarded as stored, consumers need its version to read it
+	if msg.SchemaVersion > 0 {
+		dlqMsg.Headers = append(dlqMsg.Headers, header(HeaderDeadLetterSchemaVersion, strconv.Itoa(msg.SchemaVersion)))
+	}
+
+	return p.send(ctx, dlqMsg)
 }
 
 // Close closes the Kafka producer
@@ -164,4 +506,8 @@ func (p *EventPublisher) Close() error {
 	return p.producer.Close()
 }
 
-var _ ports.EventPublisher = (*EventPublisher)(nil)
+var (
+	_ ports.EnvelopePublisher   = (*EventPublisher)(nil)
+	_ ports.BatchPublisher      = (*EventPublisher)(nil)
+	_ ports.DeadLetterPublisher = (*EventPublisher)(nil)
+)
@@ -0,0 +1,313 @@
+package kafka
+
+import (
+	"cloudevents"
+	"context"
+	"order-service/internal/app/ports"
+	"order-service/internal/app/tracing"
+	"order-service/internal/domain"
+	"order-service/internal/events"
+	"order-service/internal/infrastructure/config"
+	"schemaregistry"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEvent struct {
+	OrderID uuid.UUID
+}
+
+func (e testEvent) AggregateID() uuid.UUID { return e.OrderID }
+
+func newTestPublisher(t *testing.T, confirm bool) (*EventPublisher, *mocks.AsyncProducer) {
+	saramaConfig := mocks.NewTestConfig()
+	saramaConfig.Producer.Return.Successes = true
+	producer := mocks.NewAsyncProducer(t, saramaConfig)
+
+	cfg := config.KafkaConfig{Producer: config.ProducerConfig{ConfirmDelivery: confirm}}
+	publisher := newEventPublisher(producer, cfg)
+	t.Cleanup(func() { publisher.Close() })
+	return publisher, producer
+}
+
+func TestPublishConfirmsDelivery(t *testing.T) {
+	publisher, producer := newTestPublisher(t, true)
+	event := testEvent{OrderID: uuid.New()}
+
+	producer.ExpectInputWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		key, err := msg.Key.Encode()
+		require.NoError(t, err)
+		assert.Equal(t, event.OrderID.String(), string(key))
+		return nil
+	})
+
+	assert.NoError(t, publisher.Publish(context.Background(), "order.created", event))
+}
+
+func TestPublishReturnsBrokerError(t *testing.T) {
+	publisher, producer := newTestPublisher(t, true)
+
+	producer.ExpectInputAndFail(sarama.ErrNotEnoughReplicas)
+	err := publisher.PublishEnvelope(context.Background(), ports.Envelope{Topic: "order.created", Key: "key", Event: testEvent{}})
+	assert.ErrorIs(t, err, sarama.ErrNotEnoughReplicas)
+
+	// Each call gets the response to its own message
+	producer.ExpectInputAndSucceed()
+	assert.NoError(t, publisher.Publish(context.Background(), "order.created", testEvent{}))
+}
+
+func TestPublishWithoutConfirmationIgnoresBrokerError(t *testing.T) {
+	publisher, producer := newTestPublisher(t, false)
+
+	producer.ExpectInputAndFail(sarama.ErrNotEnoughReplicas)
+	assert.NoError(t, publisher.Publish(context.Background(), "order.created", testEvent{}))
+}
+
+func TestPublishStopsWaitingWhenCancelled(t *testing.T) {
+	publisher := newEventPublisher(blockingProducer{mocks.NewAsyncProducer(t, mocks.NewTestConfig())}, config.KafkaConfig{
+		Producer: config.ProducerConfig{ConfirmDelivery: true},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, publisher.Publish(ctx, "order.created", testEvent{}), context.Canceled)
+}
+
+// blockingProducer never accepts messages
+type blockingProducer struct {
+	*mocks.AsyncProducer
+}
+
+func (blockingProducer) Input() chan<- *sarama.ProducerMessage {
+	return make(chan *sarama.ProducerMessage)
+}
+
+// consumed turns a produced message into the message a consumer would receive
+func consumed(t *testing.T, msg *sarama.ProducerMessage) *sarama.ConsumerMessage {
+	value, err := msg.Value.Encode()
+	require.NoError(t, err)
+
+	consumerMsg := &sarama.ConsumerMessage{Topic: msg.Topic, Value: value}
+	if msg.Key != nil {
+		consumerMsg.Key, err = msg.Key.Encode()
+		require.NoError(t, err)
+	}
+	for i := range msg.Headers {
+		consumerMsg.Headers = append(consumerMsg.Headers, &msg.Headers[i])
+	}
+	return consumerMsg
+}
+
+func TestPublishEnvelopeWritesCloudEvent(t *testing.T) {
+	for _, mode := range []string{"binary", "structured"} {
+		t.Run(mode, func(t *testing.T) {
+			saramaConfig := mocks.NewTestConfig()
+			saramaConfig.Producer.Return.Successes = true
+			producer := mocks.NewAsyncProducer(t, saramaConfig)
+			publisher := newEventPublisher(producer, config.KafkaConfig{Producer: config.ProducerConfig{
+				ConfirmDelivery: true,
+				EventMode:       mode,
+			}})
+
+			ctx := tracing.WithTraceID(context.Background(), "trace-1")
+			ctx = tracing.WithCorrelationID(ctx, "correlation-1")
+			ctx = tracing.WithCausationID(ctx, "request-1")
+
+			orderID := uuid.New()
+			producer.ExpectInputWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+				event, err := cloudevents.ReadMessage(consumed(t, msg))
+				require.NoError(t, err)
+
+				assert.Equal(t, "message-2", event.ID)
+				assert.Equal(t, defaultEventSource, event.Source)
+				assert.Equal(t, "order.created", event.Type)
+				assert.Equal(t, "order-1", event.Subject)
+				assert.Equal(t, cloudevents.ContentTypeJSON, event.DataContentType)
+				assert.Equal(t, map[string]string{
+					events.ExtensionSchemaVersion: "2",
+					events.ExtensionCorrelationID: "correlation-1",
+					events.ExtensionCausationID:   "message-1",
+					events.ExtensionTraceID:       "trace-1",
+					events.ExtensionSequence:      "4",
+				}, event.Extensions)
+
+				var data testEvent
+				require.NoError(t, event.DataAs(&data))
+				assert.Equal(t, orderID, data.OrderID)
+				return nil
+			})
+
+			err := publisher.PublishEnvelope(ctx, ports.Envelope{
+				ID:            "message-2",
+				Topic:         "order.created",
+				Key:           "order-1",
+				AggregateID:   "order-1",
+				EventType:     "order.created",
+				SchemaVersion: 2,
+				// The envelope's IDs take precedence over the context's
+				CausationID: "message-1",
+				Extensions:  map[string]string{events.ExtensionSequence: "4"},
+				Event:       testEvent{OrderID: orderID},
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// transactionRecorder records how transactions of the producer end
+type transactionRecorder struct {
+	*mocks.AsyncProducer
+	commits, aborts int
+}
+
+func (r *transactionRecorder) CommitTxn() error {
+	r.commits++
+	return r.AsyncProducer.CommitTxn()
+}
+
+func (r *transactionRecorder) AbortTxn() error {
+	r.aborts++
+	return r.AsyncProducer.AbortTxn()
+}
+
+func newTransactionalPublisher(t *testing.T) (*EventPublisher, *transactionRecorder) {
+	saramaConfig := mocks.NewTestConfig()
+	saramaConfig.Producer.Return.Successes = true
+	saramaConfig.Version = sarama.DefaultVersion
+	saramaConfig.Producer.Idempotent = true
+	saramaConfig.Producer.RequiredAcks = sarama.WaitForAll
+	saramaConfig.Producer.Transaction.ID = "order-service-test"
+	saramaConfig.Net.MaxOpenRequests = 1
+	producer := &transactionRecorder{AsyncProducer: mocks.NewAsyncProducer(t, saramaConfig)}
+
+	publisher := newEventPublisher(producer, config.KafkaConfig{})
+	t.Cleanup(func() { publisher.Close() })
+	return publisher, producer
+}
+
+func TestPublishBatchCommitsTransaction(t *testing.T) {
+	publisher, producer := newTransactionalPublisher(t)
+
+	producer.ExpectInputAndSucceed()
+	producer.ExpectInputAndSucceed()
+	err := publisher.PublishBatch(context.Background(), []ports.Envelope{
+		{Topic: "order.created", Event: testEvent{}},
+		{Topic: "order.confirmed", Event: testEvent{}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, producer.commits)
+	assert.Zero(t, producer.aborts)
+
+	// Single events get a transaction of their own
+	producer.ExpectInputAndSucceed()
+	assert.NoError(t, publisher.Publish(context.Background(), "order.created", testEvent{}))
+	assert.Equal(t, 2, producer.commits)
+}
+
+func TestPublishBatchAbortsTransactionOnError(t *testing.T) {
+	publisher, producer := newTransactionalPublisher(t)
+
+	producer.ExpectInputAndSucceed()
+	producer.ExpectInputAndFail(sarama.ErrNotEnoughReplicas)
+	err := publisher.PublishBatch(context.Background(), []ports.Envelope{
+		{Topic: "order.created", Event: testEvent{}},
+		{Topic: "order.confirmed", Event: testEvent{}},
+	})
+	assert.ErrorIs(t, err, sarama.ErrNotEnoughReplicas)
+	assert.Zero(t, producer.commits)
+	assert.Equal(t, 1, producer.aborts)
+}
+
+func TestPublishBatchReportsPublishedEnvelopes(t *testing.T) {
+	publisher, producer := newTestPublisher(t, true)
+
+	producer.ExpectInputAndSucceed()
+	producer.ExpectInputAndFail(sarama.ErrNotEnoughReplicas)
+	err := publisher.PublishBatch(context.Background(), []ports.Envelope{
+		{Topic: "order.created", Event: testEvent{}},
+		{Topic: "order.confirmed", Event: testEvent{}},
+		{Topic: "order.shipped", Event: testEvent{}},
+	})
+
+	// Without a transaction the first envelope stays published, the last one is not sent
+	var batchErr *ports.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 1, batchErr.Index)
+	assert.ErrorIs(t, err, sarama.ErrNotEnoughReplicas)
+}
+
+func TestPublishDeadLetterCarriesSchemaVersion(t *testing.T) {
+	publisher, producer := newTestPublisher(t, true)
+	msg := domain.OutboxMessage{
+		ID:            uuid.New(),
+		AggregateID:   uuid.New(),
+		EventType:     "order.created",
+		Payload:       []byte(`{"TotalPrice":12.5}`),
+		SchemaVersion: 1,
+	}
+
+	producer.ExpectInputWithMessageCheckerFunctionAndSucceed(func(produced *sarama.ProducerMessage) error {
+		value, err := produced.Value.Encode()
+		require.NoError(t, err)
+		assert.JSONEq(t, string(msg.Payload), string(value))
+
+		headers := make(map[string]string)
+		for _, header := range produced.Headers {
+			headers[string(header.Key)] = string(header.Value)
+		}
+		assert.Equal(t, "1", headers[HeaderDeadLetterSchemaVersion])
+		assert.Equal(t, msg.ID.String(), headers[HeaderDeadLetterMessageID])
+		return nil
+	})
+
+	assert.NoError(t, publisher.PublishDeadLetter(context.Background(), "order-service.dlq", msg))
+}
+
+func TestPublishEnvelopeSerializesWithSchema(t *testing.T) {
+	publisher, producer := newTestPublisher(t, true)
+	registry := schemaregistry.NewMemoryRegistry(schemaregistry.CompatibilityBackward)
+	serializer, err := schemaregistry.NewSerializer(registry, schemaregistry.JSONSchema, schemaregistry.TopicRecordNameStrategy,
+		map[string]string{"order.created": `{
+			"type": "object",
+			"properties": {"OrderID": {"type": "string"}},
+			"required": ["OrderID"]
+		}`})
+	require.NoError(t, err)
+	publisher.UseSerializer(serializer)
+
+	orderID := uuid.New()
+	producer.ExpectInputWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		event, err := cloudevents.ReadMessage(consumed(t, msg))
+		require.NoError(t, err)
+		assert.Equal(t, serializer.ContentType(), event.DataContentType)
+
+		var data testEvent
+		require.NoError(t, schemaregistry.NewDeserializer(registry).Deserialize(context.Background(), event.Data, &data))
+		assert.Equal(t, orderID, data.OrderID)
+		return nil
+	})
+
+	err = publisher.PublishEnvelope(context.Background(), ports.Envelope{
+		Topic:     "orders-created",
+		EventType: "order.created",
+		Event:     testEvent{OrderID: orderID},
+	})
+	require.NoError(t, err)
+
+	latest, err := registry.LatestSchema(context.Background(), "orders-created-order.created")
+	require.NoError(t, err)
+	assert.Equal(t, schemaregistry.TypeJSON, latest.Type)
+
+	// Events without a schema are not published
+	err = publisher.PublishEnvelope(context.Background(), ports.Envelope{
+		Topic:     "orders-cancelled",
+		EventType: "order.cancelled",
+		Event:     testEvent{OrderID: orderID},
+	})
+	assert.ErrorIs(t, err, schemaregistry.ErrNoSchema)
+}
@@ -0,0 +1,32 @@
+package kafka
+
+import (
+	"order-service/internal/events"
+	"order-service/internal/infrastructure/config"
+	"schemaregistry"
+)
+
+// NewSerializer creates the serializer of event payloads for the configured schema registry,
+// with the schemas of the registered events in the configured format
+func NewSerializer(cfg config.SchemaRegistryConfig, registry *events.Registry) (*schemaregistry.Serializer, error) {
+	format, err := schemaregistry.ParseFormat(cfg.Format)
+	if err != nil {
+		return nil, err
+	}
+	subjectName, err := schemaregistry.ParseSubjectNameStrategy(cfg.SubjectNameStrategy)
+	if err != nil {
+		return nil, err
+	}
+	schemas, err := registry.Schemas(cfg.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	client := schemaregistry.NewClient(schemaregistry.ClientConfig{
+		URL:      cfg.URL,
+		Username: cfg.Username,
+		Password: cfg.Password,
+		Timeout:  cfg.Timeout,
+	})
+	return schemaregistry.NewSerializer(client, format, subjectName, schemas)
+}
@@ -0,0 +1,71 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"order-service/internal/events"
+	"order-service/internal/infrastructure/config"
+
+	"github.com/IBM/sarama"
+)
+
+// NewTopicRouter routes the order events to the configured topics.
+// Status changes and item changes share the orders-updated topic.
+func NewTopicRouter(cfg config.TopicConfig) *events.TopicRouter {
+	return events.NewTopicRouter(map[string]string{
+		events.OrderCreatedEventType:       cfg.OrdersCreated,
+		events.OrderStatusChangedEventType: cfg.OrdersUpdated,
+		events.OrderItemAddedEventType:     cfg.OrdersUpdated,
+		events.OrderItemRemovedEventType:   cfg.OrdersUpdated,
+		events.OrderCancelledEventType:     cfg.OrdersCancelled,
+	}, cfg.Prefix, cfg.Suffix)
+}
+
+// SagaTopics returns the topics the inventory and the payment service publish
+// the outcomes of the order saga on
+func SagaTopics(cfg config.TopicConfig) []string {
+	return []string{
+		cfg.Prefix + cfg.Inventory + cfg.Suffix,
+		cfg.Prefix + cfg.Payments + cfg.Suffix,
+	}
+}
+
+// EnsureTopics creates the topics that do not exist yet, with the partitions
+// and replication factor of cfg.Topics. Existing topics are left as they are.
+func EnsureTopics(cfg config.KafkaConfig, topics []string) error {
+	admin, err := sarama.NewClusterAdmin(cfg.Brokers, newSaramaConfig(cfg))
+	if err != nil {
+		return fmt.Errorf("failed to create Kafka admin client: %w", err)
+	}
+	defer admin.Close()
+
+	return ensureTopics(admin, topics, cfg.Topics.Partitions, cfg.Topics.ReplicationFactor)
+}
+
+func ensureTopics(admin sarama.ClusterAdmin, topics []string, partitions int32, replicationFactor int16) error {
+	existing, err := admin.ListTopics()
+	if err != nil {
+		return fmt.Errorf("failed to list topics: %w", err)
+	}
+
+	for _, topic := range topics {
+		if _, ok := existing[topic]; ok {
+			continue
+		}
+
+		err := admin.CreateTopic(topic, &sarama.TopicDetail{
+			NumPartitions:     partitions,
+			ReplicationFactor: replicationFactor,
+		}, false)
+		if errors.Is(err, sarama.ErrTopicAlreadyExists) {
+			// Another instance created it in the meantime
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create topic %s: %w", topic, err)
+		}
+		log.Printf("Created topic %s with %d partitions", topic, partitions)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package kafka
+
+import (
+	"order-service/internal/events"
+	"order-service/internal/infrastructure/config"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAdmin knows a set of topics and records the ones created
+type fakeAdmin struct {
+	sarama.ClusterAdmin
+	topics  map[string]sarama.TopicDetail
+	created []string
+}
+
+func (a *fakeAdmin) ListTopics() (map[string]sarama.TopicDetail, error) {
+	return a.topics, nil
+}
+
+func (a *fakeAdmin) CreateTopic(topic string, detail *sarama.TopicDetail, validateOnly bool) error {
+	if _, ok := a.topics[topic]; ok {
+		return sarama.ErrTopicAlreadyExists
+	}
+	a.topics[topic] = *detail
+	a.created = append(a.created, topic)
+	return nil
+}
+
+func TestTopicRouterCoversDefaultRegistry(t *testing.T) {
+	router := NewTopicRouter(config.TopicConfig{
+		OrdersCreated:   "orders-created",
+		OrdersUpdated:   "orders-updated",
+		OrdersCancelled: "orders-cancelled",
+		Prefix:          "test.",
+	})
+
+	registry := events.NewDefaultRegistry()
+	require.NoError(t, registry.Route(router))
+
+	topic, err := registry.Topic(events.OrderItemAddedEventType)
+	require.NoError(t, err)
+	assert.Equal(t, "test.orders-updated", topic)
+}
+
+func TestEnsureTopicsCreatesMissingTopics(t *testing.T) {
+	admin := &fakeAdmin{topics: map[string]sarama.TopicDetail{"orders-created": {NumPartitions: 12}}}
+
+	err := ensureTopics(admin, []string{"orders-created", "orders-updated"}, 3, 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"orders-updated"}, admin.created)
+	assert.Equal(t, sarama.TopicDetail{NumPartitions: 3, ReplicationFactor: 2}, admin.topics["orders-updated"])
+	// Existing topics are left as they are
+	assert.Equal(t, int32(12), admin.topics["orders-created"].NumPartitions)
+}
+
+func TestSagaTopics(t *testing.T) {
+	topics := SagaTopics(config.TopicConfig{Inventory: "inventory", Payments: "payments", Prefix: "test."})
+	assert.Equal(t, []string{"test.inventory", "test.payments"}, topics)
+}
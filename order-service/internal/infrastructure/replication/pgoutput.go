@@ -0,0 +1,240 @@
+package replication
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// pgoutput message types, see "Logical Replication Message Formats" in the Postgres docs
+const (
+	beginByteID    = 'B'
+	commitByteID   = 'C'
+	relationByteID = 'R'
+	insertByteID   = 'I'
+	updateByteID   = 'U'
+)
+
+// Begin starts the changes of a transaction
+type Begin struct {
+	// FinalLSN is the LSN of the commit record
+	FinalLSN   LSN
+	CommitTime time.Time
+	XID        uint32
+}
+
+// Commit ends the changes of a transaction
+type Commit struct {
+	CommitLSN LSN
+	// EndLSN is the position to resume from once the transaction is handled
+	EndLSN     LSN
+	CommitTime time.Time
+}
+
+// Relation describes a table before its first change in a session
+type Relation struct {
+	ID        uint32
+	Namespace string
+	Name      string
+	Columns   []string
+}
+
+// Insert is a row inserted into a table of the publication
+type Insert struct {
+	Relation Relation
+	// Values holds the text representation of the columns, NULL columns are left out
+	Values map[string]string
+}
+
+// Update is a row of a table of the publication after an update
+type Update struct {
+	Relation Relation
+	// Values holds the text representation of the new columns. NULL columns and
+	// TOAST values that did not change are left out.
+	Values map[string]string
+}
+
+// Decoder decodes pgoutput messages. It remembers the relations the server
+// announced so inserts can be mapped to column names.
+type Decoder struct {
+	relations map[uint32]Relation
+}
+
+// NewDecoder creates a decoder for one replication session
+func NewDecoder() *Decoder {
+	return &Decoder{relations: make(map[uint32]Relation)}
+}
+
+// Decode returns a Begin, Commit, Relation, Insert or Update. Other messages,
+// such as deletes and type descriptions, decode to nil.
+func (d *Decoder) Decode(data []byte) (interface{}, error) {
+	if len(data) == 0 {
+		return nil, ErrMalformedMessage
+	}
+
+	r := &reader{data: data[1:]}
+	switch data[0] {
+	case beginByteID:
+		msg := Begin{
+			FinalLSN:   LSN(r.uint64()),
+			CommitTime: fromPostgresTime(int64(r.uint64())),
+			XID:        r.uint32(),
+		}
+		return msg, r.err("begin")
+	case commitByteID:
+		r.byte() // flags, currently unused
+		msg := Commit{
+			CommitLSN:  LSN(r.uint64()),
+			EndLSN:     LSN(r.uint64()),
+			CommitTime: fromPostgresTime(int64(r.uint64())),
+		}
+		return msg, r.err("commit")
+	case relationByteID:
+		rel := Relation{
+			ID:        r.uint32(),
+			Namespace: r.string(),
+			Name:      r.string(),
+		}
+		r.byte() // replica identity
+		n := int(r.uint16())
+		for i := 0; i < n && !r.failed; i++ {
+			r.byte() // flags
+			rel.Columns = append(rel.Columns, r.string())
+			r.uint32() // type OID
+			r.uint32() // type modifier
+		}
+		if err := r.err("relation"); err != nil {
+			return nil, err
+		}
+		d.relations[rel.ID] = rel
+		return rel, nil
+	case insertByteID:
+		id := r.uint32()
+		rel, ok := d.relations[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: insert into unknown relation %d", ErrMalformedMessage, id)
+		}
+		if kind := r.byte(); kind != 'N' {
+			return nil, fmt.Errorf("%w: unexpected tuple kind %q", ErrMalformedMessage, kind)
+		}
+		values, err := d.decodeTuple(r, rel)
+		if err != nil {
+			return nil, err
+		}
+		return Insert{Relation: rel, Values: values}, nil
+	case updateByteID:
+		id := r.uint32()
+		rel, ok := d.relations[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: update of unknown relation %d", ErrMalformedMessage, id)
+		}
+		kind := r.byte()
+		if kind == 'K' || kind == 'O' {
+			// The old key or row, sent when the replica identity changed
+			if _, err := d.decodeTuple(r, rel); err != nil {
+				return nil, err
+			}
+			kind = r.byte()
+		}
+		if kind != 'N' {
+			return nil, fmt.Errorf("%w: unexpected tuple kind %q", ErrMalformedMessage, kind)
+		}
+		values, err := d.decodeTuple(r, rel)
+		if err != nil {
+			return nil, err
+		}
+		return Update{Relation: rel, Values: values}, nil
+	default:
+		return nil, nil
+	}
+}
+
+func (d *Decoder) decodeTuple(r *reader, rel Relation) (map[string]string, error) {
+	n := int(r.uint16())
+	if n > len(rel.Columns) {
+		return nil, fmt.Errorf("%w: tuple has %d columns, %s.%s has %d",
+			ErrMalformedMessage, n, rel.Namespace, rel.Name, len(rel.Columns))
+	}
+
+	values := make(map[string]string, n)
+	for i := 0; i < n && !r.failed; i++ {
+		switch kind := r.byte(); kind {
+		case 'n', 'u':
+			// NULL, or a TOAST value the update did not change
+		case 't':
+			size := int(r.uint32())
+			values[rel.Columns[i]] = string(r.bytes(size))
+		default:
+			return nil, fmt.Errorf("%w: unexpected column kind %q", ErrMalformedMessage, kind)
+		}
+	}
+
+	return values, r.err("tuple")
+}
+
+// reader reads big-endian values and remembers running past the end
+type reader struct {
+	data   []byte
+	failed bool
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.failed || n < 0 || len(r.data) < n {
+		r.failed = true
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *reader) byte() byte {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) uint16() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) uint32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *reader) uint64() uint64 {
+	if b := r.bytes(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+// string reads a NUL-terminated string
+func (r *reader) string() string {
+	if r.failed {
+		return ""
+	}
+	end := bytes.IndexByte(r.data, 0)
+	if end < 0 {
+		r.failed = true
+		return ""
+	}
+	s := string(r.data[:end])
+	r.data = r.data[end+1:]
+	return s
+}
+
+func (r *reader) err(message string) error {
+	if r.failed {
+		return fmt.Errorf("%w: short %s message", ErrMalformedMessage, message)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package replication_test
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"order-service/internal/infrastructure/replication"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// message builds a protocol message from big-endian integers, strings and byte slices
+type message []byte
+
+func (m message) byte(b byte) message     { return append(m, b) }
+func (m message) uint16(v uint16) message { return binary.BigEndian.AppendUint16(m, v) }
+func (m message) uint32(v uint32) message { return binary.BigEndian.AppendUint32(m, v) }
+func (m message) uint64(v uint64) message { return binary.BigEndian.AppendUint64(m, v) }
+func (m message) string(s string) message { return append(append(m, s...), 0) }
+func (m message) text(s string) message   { return append(m.byte('t').uint32(uint32(len(s))), s...) }
+
+// micros is the protocol timestamp of 2024-01-01, in microseconds since 2000-01-01
+const micros = 757382400000000
+
+func TestParseLSN(t *testing.T) {
+	lsn, err := replication.ParseLSN("16/B374D848")
+	require.NoError(t, err)
+	assert.Equal(t, replication.LSN(0x16B374D848), lsn)
+	assert.Equal(t, "16/B374D848", lsn.String())
+	assert.Equal(t, "0/0", replication.LSN(0).String())
+
+	for _, invalid := range []string{"", "16", "x/1", "1/100000000"} {
+		_, err := replication.ParseLSN(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestParseCopyData(t *testing.T) {
+	payload := []byte("change")
+	msg, err := replication.ParseCopyData(append(message{}.byte('w').uint64(10).uint64(20).uint64(micros), payload...))
+	require.NoError(t, err)
+	xlog, ok := msg.(replication.XLogData)
+	require.True(t, ok)
+	assert.Equal(t, replication.LSN(10), xlog.WALStart)
+	assert.Equal(t, replication.LSN(20), xlog.ServerWALEnd)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), xlog.ServerTime)
+	assert.Equal(t, payload, xlog.Data)
+
+	msg, err = replication.ParseCopyData(message{}.byte('k').uint64(30).uint64(micros).byte(1))
+	require.NoError(t, err)
+	assert.Equal(t, replication.PrimaryKeepalive{
+		ServerWALEnd:   30,
+		ServerTime:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		ReplyRequested: true,
+	}, msg)
+
+	_, err = replication.ParseCopyData(message{}.byte('k').uint64(30))
+	assert.ErrorIs(t, err, replication.ErrMalformedMessage)
+	_, err = replication.ParseCopyData(message{}.byte('x'))
+	assert.ErrorIs(t, err, replication.ErrMalformedMessage)
+}
+
+func TestDecoderDecodesTransaction(t *testing.T) {
+	decoder := replication.NewDecoder()
+
+	msg, err := decoder.Decode(message{}.byte('B').uint64(0x200).uint64(micros).uint32(42))
+	require.NoError(t, err)
+	assert.Equal(t, replication.Begin{
+		FinalLSN:   0x200,
+		CommitTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		XID:        42,
+	}, msg)
+
+	relation := message{}.byte('R').uint32(16384).string("public").string("outbox_messages").byte('d').uint16(3)
+	for _, column := range []string{"id", "event_type", "processed_at"} {
+		relation = relation.byte(0).string(column).uint32(25).uint32(0xFFFFFFFF)
+	}
+	msg, err = decoder.Decode(relation)
+	require.NoError(t, err)
+	assert.Equal(t, replication.Relation{
+		ID:        16384,
+		Namespace: "public",
+		Name:      "outbox_messages",
+		Columns:   []string{"id", "event_type", "processed_at"},
+	}, msg)
+
+	insert := message{}.byte('I').uint32(16384).byte('N').uint16(3).
+		text("8b0c3c3e-0f4b-4a52-9a4b-6a43a8a0f0f1").text("order.created").byte('n')
+	msg, err = decoder.Decode(insert)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"id":         "8b0c3c3e-0f4b-4a52-9a4b-6a43a8a0f0f1",
+		"event_type": "order.created",
+	}, msg.(replication.Insert).Values)
+
+	msg, err = decoder.Decode(message{}.byte('C').byte(0).uint64(0x200).uint64(0x230).uint64(micros))
+	require.NoError(t, err)
+	assert.Equal(t, replication.Commit{
+		CommitLSN:  0x200,
+		EndLSN:     0x230,
+		CommitTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, msg)
+
+	// The old key is skipped and an unchanged TOAST value is left out
+	update := message{}.byte('U').uint32(16384).
+		byte('K').uint16(3).text("8b0c3c3e-0f4b-4a52-9a4b-6a43a8a0f0f1").byte('n').byte('n').
+		byte('N').uint16(3).text("8b0c3c3e-0f4b-4a52-9a4b-6a43a8a0f0f1").byte('u').text("2024-01-01")
+	msg, err = decoder.Decode(update)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"id":           "8b0c3c3e-0f4b-4a52-9a4b-6a43a8a0f0f1",
+		"processed_at": "2024-01-01",
+	}, msg.(replication.Update).Values)
+
+	// Deletes are not relayed
+	msg, err = decoder.Decode(message{}.byte('D').uint32(16384))
+	require.NoError(t, err)
+	assert.Nil(t, msg)
+}
+
+func TestDecoderRejectsMalformedMessages(t *testing.T) {
+	decoder := replication.NewDecoder()
+
+	// Inserts need the relation to be announced first
+	_, err := decoder.Decode(message{}.byte('I').uint32(1).byte('N').uint16(0))
+	assert.ErrorIs(t, err, replication.ErrMalformedMessage)
+
+	_, err = decoder.Decode(message{}.byte('U').uint32(1).byte('N').uint16(0))
+	assert.ErrorIs(t, err, replication.ErrMalformedMessage)
+
+	_, err = decoder.Decode(message{}.byte('B').uint64(1))
+	assert.ErrorIs(t, err, replication.ErrMalformedMessage)
+
+	_, err = decoder.Decode(message{}.byte('R').uint32(1).string("public"))
+	assert.ErrorIs(t, err, replication.ErrMalformedMessage)
+
+	_, err = decoder.Decode(nil)
+	assert.ErrorIs(t, err, replication.ErrMalformedMessage)
+}
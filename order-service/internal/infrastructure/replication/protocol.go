@@ -0,0 +1,117 @@
+package replication
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrMalformedMessage is returned for replication messages that cannot be decoded
+var ErrMalformedMessage = errors.New("malformed replication message")
+
+// LSN is a position in the Postgres write-ahead log
+type LSN uint64
+
+// ParseLSN parses an LSN in the "16/B374D848" form Postgres prints
+func ParseLSN(s string) (LSN, error) {
+	hi, lo, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid LSN %q", s)
+	}
+
+	upper, err := strconv.ParseUint(hi, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LSN %q", s)
+	}
+	lower, err := strconv.ParseUint(lo, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LSN %q", s)
+	}
+
+	return LSN(upper<<32 | lower), nil
+}
+
+// String formats the LSN the way Postgres prints it
+func (l LSN) String() string {
+	return fmt.Sprintf("%X/%X", uint32(l>>32), uint32(l))
+}
+
+// Replication message types sent by the server inside CopyData
+const (
+	xLogDataByteID            = 'w'
+	primaryKeepaliveByteID    = 'k'
+	standbyStatusUpdateByteID = 'r'
+)
+
+// XLogData carries a chunk of WAL, for logical replication one pgoutput message
+type XLogData struct {
+	WALStart     LSN
+	ServerWALEnd LSN
+	ServerTime   time.Time
+	Data         []byte
+}
+
+// PrimaryKeepalive is sent by the server to check the connection
+type PrimaryKeepalive struct {
+	ServerWALEnd LSN
+	ServerTime   time.Time
+	// ReplyRequested asks for a standby status update right away
+	ReplyRequested bool
+}
+
+// postgresEpoch is the origin of timestamps in the replication protocol
+var postgresEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func fromPostgresTime(micros int64) time.Time {
+	return postgresEpoch.Add(time.Duration(micros) * time.Microsecond)
+}
+
+func toPostgresTime(t time.Time) int64 {
+	return t.Sub(postgresEpoch).Microseconds()
+}
+
+// ParseCopyData decodes a message of the replication stream into
+// an XLogData or PrimaryKeepalive
+func ParseCopyData(data []byte) (interface{}, error) {
+	if len(data) == 0 {
+		return nil, ErrMalformedMessage
+	}
+
+	switch data[0] {
+	case xLogDataByteID:
+		if len(data) < 25 {
+			return nil, fmt.Errorf("%w: short XLogData", ErrMalformedMessage)
+		}
+		return XLogData{
+			WALStart:     LSN(binary.BigEndian.Uint64(data[1:])),
+			ServerWALEnd: LSN(binary.BigEndian.Uint64(data[9:])),
+			ServerTime:   fromPostgresTime(int64(binary.BigEndian.Uint64(data[17:]))),
+			Data:         data[25:],
+		}, nil
+	case primaryKeepaliveByteID:
+		if len(data) < 18 {
+			return nil, fmt.Errorf("%w: short keepalive", ErrMalformedMessage)
+		}
+		return PrimaryKeepalive{
+			ServerWALEnd:   LSN(binary.BigEndian.Uint64(data[1:])),
+			ServerTime:     fromPostgresTime(int64(binary.BigEndian.Uint64(data[9:]))),
+			ReplyRequested: data[17] == 1,
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown message type %q", ErrMalformedMessage, data[0])
+	}
+}
+
+// standbyStatus encodes a standby status update reporting the WAL up to flushed as processed
+func standbyStatus(flushed LSN, now time.Time) []byte {
+	data := make([]byte, 34)
+	data[0] = standbyStatusUpdateByteID
+	binary.BigEndian.PutUint64(data[1:], uint64(flushed))
+	binary.BigEndian.PutUint64(data[9:], uint64(flushed))
+	binary.BigEndian.PutUint64(data[17:], uint64(flushed))
+	binary.BigEndian.PutUint64(data[25:], uint64(toPostgresTime(now)))
+	return data
+}
@@ -0,0 +1,171 @@
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+)
+
+// ErrInvalidName is returned for slot, publication and table names that are not plain identifiers
+var ErrInvalidName = errors.New("invalid replication object name")
+
+// identifierPattern matches the names accepted for replication slots, which
+// are limited to lower case letters, digits and underscores
+var identifierPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// Stream is a logical replication connection to Postgres
+type Stream struct {
+	conn *pgconn.PgConn
+}
+
+// Connect opens a replication connection to the database at databaseURL
+func Connect(ctx context.Context, databaseURL string) (*Stream, error) {
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database URL: %w", err)
+	}
+	query := u.Query()
+	query.Set("replication", "database")
+	u.RawQuery = query.Encode()
+
+	conn, err := pgconn.Connect(ctx, u.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replication connection: %w", err)
+	}
+
+	return &Stream{conn: conn}, nil
+}
+
+// Close closes the connection, which also stops streaming
+func (s *Stream) Close(ctx context.Context) error {
+	return s.conn.Close(ctx)
+}
+
+// EnsurePublication creates a publication of the inserts into table unless it exists
+func (s *Stream) EnsurePublication(ctx context.Context, name, table string) error {
+	if err := validateNames(name, table); err != nil {
+		return err
+	}
+
+	exists, err := s.exists(ctx, fmt.Sprintf("SELECT 1 FROM pg_publication WHERE pubname = '%s'", name))
+	if err != nil || exists {
+		return err
+	}
+
+	// Only inserts are relayed, updates of the message status are not of interest
+	_, err = s.conn.Exec(ctx, fmt.Sprintf("CREATE PUBLICATION %s FOR TABLE %s WITH (publish = 'insert')", name, table)).ReadAll()
+	if err != nil {
+		return fmt.Errorf("failed to create publication %s: %w", name, err)
+	}
+	return nil
+}
+
+// EnsureSlot creates a logical replication slot using pgoutput unless it exists.
+// The slot keeps WAL on the server until the changes are confirmed with SendStatus.
+func (s *Stream) EnsureSlot(ctx context.Context, name string) error {
+	if err := validateNames(name); err != nil {
+		return err
+	}
+
+	exists, err := s.exists(ctx, fmt.Sprintf("SELECT 1 FROM pg_replication_slots WHERE slot_name = '%s'", name))
+	if err != nil || exists {
+		return err
+	}
+
+	_, err = s.conn.Exec(ctx, fmt.Sprintf("SELECT pg_create_logical_replication_slot('%s', 'pgoutput')", name)).ReadAll()
+	if err != nil {
+		return fmt.Errorf("failed to create replication slot %s: %w", name, err)
+	}
+	return nil
+}
+
+func (s *Stream) exists(ctx context.Context, query string) (bool, error) {
+	results, err := s.conn.Exec(ctx, query).ReadAll()
+	if err != nil {
+		return false, err
+	}
+	return len(results) > 0 && len(results[0].Rows) > 0, nil
+}
+
+// Start streams the changes of the slot from the LSN on, decoded with pgoutput
+// and restricted to the tables of the publication
+func (s *Stream) Start(ctx context.Context, slot, publication string, start LSN) error {
+	if err := validateNames(slot, publication); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("START_REPLICATION SLOT %s LOGICAL %s (proto_version '1', publication_names '%s')",
+		slot, start, publication)
+
+	s.conn.Frontend().Send(&pgproto3.Query{String: query})
+	if err := s.conn.Frontend().Flush(); err != nil {
+		return fmt.Errorf("failed to send START_REPLICATION: %w", err)
+	}
+
+	for {
+		msg, err := s.conn.ReceiveMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to start replication: %w", err)
+		}
+
+		switch msg := msg.(type) {
+		case *pgproto3.CopyBothResponse:
+			// The server streams from here on
+			return nil
+		case *pgproto3.ErrorResponse:
+			return pgconn.ErrorResponseToPgError(msg)
+		case *pgproto3.NoticeResponse:
+			// Informational, keep waiting for the stream
+		default:
+			return fmt.Errorf("unexpected message %T while starting replication", msg)
+		}
+	}
+}
+
+// Receive waits up to timeout for the next XLogData or PrimaryKeepalive.
+// It returns nil without an error when nothing arrived in time.
+func (s *Stream) Receive(ctx context.Context, timeout time.Duration) (interface{}, error) {
+	receiveCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	msg, err := s.conn.ReceiveMessage(receiveCtx)
+	if err != nil {
+		if pgconn.Timeout(err) && ctx.Err() == nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	switch msg := msg.(type) {
+	case *pgproto3.CopyData:
+		return ParseCopyData(msg.Data)
+	case *pgproto3.ErrorResponse:
+		return nil, pgconn.ErrorResponseToPgError(msg)
+	case *pgproto3.CopyDone:
+		return nil, errors.New("server ended replication")
+	default:
+		return nil, fmt.Errorf("unexpected message %T during replication", msg)
+	}
+}
+
+// SendStatus reports the WAL up to flushed as processed. Postgres keeps the WAL
+// after the reported position, so it is only advanced once changes are handled.
+func (s *Stream) SendStatus(flushed LSN) error {
+	s.conn.Frontend().Send(&pgproto3.CopyData{Data: standbyStatus(flushed, time.Now())})
+	return s.conn.Frontend().Flush()
+}
+
+func validateNames(names ...string) error {
+	for _, name := range names {
+		if !identifierPattern.MatchString(name) {
+			return fmt.Errorf("%w: %q", ErrInvalidName, name)
+		}
+	}
+	return nil
+}
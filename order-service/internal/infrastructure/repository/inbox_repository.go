@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"order-service/internal/app/ports"
+	"order-service/internal/infrastructure/sqlc"
+	"time"
+)
+
+// InboxRepository implements the ports.InboxRepository interface
+type InboxRepository struct {
+	queries *sqlc.Queries
+}
+
+func NewInboxRepositoryWithTx(tx *sql.Tx) ports.InboxRepository {
+	return &InboxRepository{
+		queries: sqlc.New(tx),
+	}
+}
+
+// NewInboxRetentionRepository returns the inbox repository for the retention job
+func NewInboxRetentionRepository(db *sql.DB) ports.InboxRetentionRepository {
+	return &InboxRepository{
+		queries: sqlc.New(db),
+	}
+}
+
+// MarkProcessed implements ports.InboxRepository. A second transaction recording the same
+// event waits for the first one, and only records it when the first one rolled back.
+func (r *InboxRepository) MarkProcessed(ctx context.Context, eventID, consumerName, eventType string) (bool, error) {
+	inserted, err := r.queries.CreateProcessedEvent(ctx, sqlc.CreateProcessedEventParams{
+		EventID:      eventID,
+		ConsumerName: consumerName,
+		EventType:    eventType,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to record processed event: %w", err)
+	}
+	return inserted > 0, nil
+}
+
+// DeleteProcessedEvents implements ports.InboxRetentionRepository.
+func (r *InboxRepository) DeleteProcessedEvents(ctx context.Context, before time.Time, limit int) (int64, error) {
+	return r.queries.DeleteProcessedEvents(ctx, sqlc.DeleteProcessedEventsParams{
+		Cutoff:    before,
+		BatchSize: int32(limit),
+	})
+}
@@ -0,0 +1,69 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"order-service/internal/infrastructure/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// markProcessed records an event in a transaction that is committed, or rolled back
+func markProcessed(t *testing.T, db *sql.DB, eventID, consumerName string, commit bool) bool {
+	t.Helper()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	first, err := repository.NewInboxRepositoryWithTx(tx).MarkProcessed(context.Background(), eventID, consumerName, "payment.approved")
+	require.NoError(t, err)
+
+	if commit {
+		require.NoError(t, tx.Commit())
+	} else {
+		require.NoError(t, tx.Rollback())
+	}
+	return first
+}
+
+func TestMarkEventProcessed(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec("TRUNCATE processed_events")
+	require.NoError(t, err)
+
+	assert.True(t, markProcessed(t, db, "event-1", "order-saga", true))
+	assert.False(t, markProcessed(t, db, "event-1", "order-saga", true), "a redelivered event was processed before")
+	// Every consumer processes the event once
+	assert.True(t, markProcessed(t, db, "event-1", "order-projection", true))
+
+	// An event whose side effects rolled back is processed again
+	assert.True(t, markProcessed(t, db, "event-2", "order-saga", false))
+	assert.True(t, markProcessed(t, db, "event-2", "order-saga", true))
+}
+
+func TestDeleteProcessedEvents(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec("TRUNCATE processed_events")
+	require.NoError(t, err)
+
+	for _, eventID := range []string{"event-1", "event-2", "event-3"} {
+		markProcessed(t, db, eventID, "order-saga", true)
+	}
+	_, err = db.Exec("UPDATE processed_events SET processed_at = NOW() - INTERVAL '8 days' WHERE event_id <> 'event-3'")
+	require.NoError(t, err)
+
+	repo := repository.NewInboxRetentionRepository(db)
+	deleted, err := repo.DeleteProcessedEvents(context.Background(), time.Now().Add(-7*24*time.Hour), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	deleted, err = repo.DeleteProcessedEvents(context.Background(), time.Now().Add(-7*24*time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	// The recent event is still recognised
+	assert.False(t, markProcessed(t, db, "event-3", "order-saga", true))
+}
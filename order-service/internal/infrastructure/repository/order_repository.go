@@ -5,9 +5,9 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"order-service/internal/app/ports"
 	"order-service/internal/domain"
 	"order-service/internal/infrastructure/sqlc"
-	"strconv"
 
 	"github.com/google/uuid"
 )
@@ -19,13 +19,13 @@ type OrderRepository struct {
 }
 
 // NewOrderRepository creates a new order repository
-func NewOrderRepository(db *sql.DB) domain.OrderRepository {
+func NewOrderRepository(db *sql.DB) ports.OrderRepository {
 	return &OrderRepository{
 		queries: sqlc.New(db),
 	}
 }
 
-func OrderRepositoryWithTx(tx *sql.Tx) domain.OrderRepository {
+func OrderRepositoryWithTx(tx *sql.Tx) ports.OrderRepository {
 	return &OrderRepository{
 		tx:      tx,
 		queries: sqlc.New(tx),
@@ -44,7 +44,8 @@ func (r *OrderRepository) Create(ctx context.Context, order *domain.Order) error
 		ID:         order.ID,
 		CustomerID: order.CustomerID,
 		Status:     string(order.Status),
-		TotalPrice: fmt.Sprintf("%.2f", order.TotalPrice),
+		TotalPrice: order.TotalPrice.Decimal(),
+		Currency:   string(order.Currency),
 		CreatedAt:  order.CreatedAt,
 		UpdatedAt:  order.UpdatedAt,
 	})
@@ -60,7 +61,8 @@ func (r *OrderRepository) Create(ctx context.Context, order *domain.Order) error
 			OrderID:   order.ID,
 			ProductID: item.ProductID,
 			Quantity:  item.Quantity,
-			Price:     fmt.Sprintf("%.2f", item.Price),
+			Price:     item.Price.Decimal(),
+			Currency:  string(item.Price.Currency()),
 		})
 
 		if err != nil {
@@ -68,18 +70,33 @@ func (r *OrderRepository) Create(ctx context.Context, order *domain.Order) error
 		}
 	}
 
-	return nil
+	if err := r.saveExchangeRates(ctx, order); err != nil {
+		return err
+	}
+
+	return r.saveStatusHistory(ctx, order)
 }
 
 // GetByID retrieves an order by its ID
 func (r *OrderRepository) GetByID(ctx context.Context, id string) (*domain.Order, error) {
+	return r.get(ctx, id, r.queries.GetOrder)
+}
+
+// GetByIDForUpdate retrieves an order by its ID and locks it until the transaction ends
+func (r *OrderRepository) GetByIDForUpdate(ctx context.Context, id string) (*domain.Order, error) {
+	return r.get(ctx, id, r.queries.GetOrderForUpdate)
+}
+
+// get retrieves an order with its items, exchange rates and status history,
+// reading the order row with the given query
+func (r *OrderRepository) get(ctx context.Context, id string, getOrder func(context.Context, uuid.UUID) (sqlc.Order, error)) (*domain.Order, error) {
 	// Validate UUID
 	uuid, err := uuid.Parse(id)
 	if err != nil {
 		return nil, domain.ErrInvalidOrderID
 	}
 
-	orderRow, err := r.queries.GetOrder(ctx, uuid)
+	orderRow, err := getOrder(ctx, uuid)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, domain.ErrOrderNotFound
@@ -95,31 +112,36 @@ func (r *OrderRepository) GetByID(ctx context.Context, id string) (*domain.Order
 	}
 
 	// Map to domain model
-	totalPriceFloat, err := strconv.ParseFloat(orderRow.TotalPrice, 64)
+	order, err := toDomainOrder(orderRow)
 	if err != nil {
 		return nil, err
 	}
 
-	order := &domain.Order{
-		ID:         orderRow.ID,
-		CustomerID: orderRow.CustomerID,
-		Status:     domain.OrderStatus(orderRow.Status),
-		TotalPrice: totalPriceFloat,
-		CreatedAt:  orderRow.CreatedAt,
-		UpdatedAt:  orderRow.UpdatedAt,
-		Items:      make([]domain.OrderItem, 0, len(items)),
-	}
-
 	for _, item := range items {
-		totalPriceFloat, err = strconv.ParseFloat(orderRow.TotalPrice, 64)
+		orderItem, err := toDomainOrderItem(item)
 		if err != nil {
 			return nil, err
 		}
-		order.Items = append(order.Items, domain.OrderItem{
-			ID:        item.ID,
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-			Price:     totalPriceFloat,
+		order.Items = append(order.Items, orderItem)
+	}
+
+	if err := r.loadExchangeRates(ctx, order); err != nil {
+		return nil, err
+	}
+
+	// Get status history
+	history, err := r.queries.GetOrderStatusHistory(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, h := range history {
+		order.StatusHistory = append(order.StatusHistory, domain.StatusTransition{
+			ID:        h.ID,
+			From:      domain.OrderStatus(h.FromStatus),
+			To:        domain.OrderStatus(h.ToStatus),
+			Reason:    h.Reason,
+			ChangedAt: h.ChangedAt,
 		})
 	}
 
@@ -133,7 +155,7 @@ func (r *OrderRepository) Update(ctx context.Context, order *domain.Order) error
 	// Update order
 	err := r.queries.UpdateOrder(ctx, sqlc.UpdateOrderParams{
 		Status:     string(order.Status),
-		TotalPrice: fmt.Sprintf("%.2f", order.TotalPrice),
+		TotalPrice: order.TotalPrice.Decimal(),
 		UpdatedAt:  order.UpdatedAt,
 		ID:         order.ID,
 	})
@@ -158,7 +180,69 @@ func (r *OrderRepository) Update(ctx context.Context, order *domain.Order) error
 			OrderID:   order.ID,
 			ProductID: item.ProductID,
 			Quantity:  item.Quantity,
-			Price:     fmt.Sprintf("%.2f", item.Price),
+			Price:     item.Price.Decimal(),
+			Currency:  string(item.Price.Currency()),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := r.saveExchangeRates(ctx, order); err != nil {
+		return err
+	}
+
+	return r.saveStatusHistory(ctx, order)
+}
+
+// saveExchangeRates persists the order's exchange rate snapshot.
+// Rates that were already stored are never overwritten.
+func (r *OrderRepository) saveExchangeRates(ctx context.Context, order *domain.Order) error {
+	for _, rate := range order.ExchangeRates {
+		err := r.queries.CreateOrderExchangeRate(ctx, sqlc.CreateOrderExchangeRateParams{
+			OrderID:      order.ID,
+			FromCurrency: string(rate.From),
+			ToCurrency:   string(rate.To),
+			Rate:         rate.Decimal(),
+			AsOf:         rate.AsOf,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadExchangeRates loads the exchange rate snapshot of an order
+func (r *OrderRepository) loadExchangeRates(ctx context.Context, order *domain.Order) error {
+	rows, err := r.queries.GetOrderExchangeRates(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		rate, err := toDomainExchangeRate(row)
+		if err != nil {
+			return err
+		}
+		order.ExchangeRates = append(order.ExchangeRates, rate)
+	}
+
+	return nil
+}
+
+// saveStatusHistory persists the order's status transitions.
+// Transitions that were already stored are skipped.
+func (r *OrderRepository) saveStatusHistory(ctx context.Context, order *domain.Order) error {
+	for _, t := range order.StatusHistory {
+		err := r.queries.CreateOrderStatusTransition(ctx, sqlc.CreateOrderStatusTransitionParams{
+			ID:         t.ID,
+			OrderID:    order.ID,
+			FromStatus: string(t.From),
+			ToStatus:   string(t.To),
+			Reason:     t.Reason,
+			ChangedAt:  t.ChangedAt,
 		})
 		if err != nil {
 			return err
@@ -187,56 +271,185 @@ func (r *OrderRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-// List retrieves a paginated list of orders
-func (r *OrderRepository) List(ctx context.Context, limit, offfset int) ([]*domain.Order, error) {
-	// Get orders
-	orderRows, err := r.queries.ListOrders(ctx, sqlc.ListOrdersParams{
-		Limit:  int32(limit),
-		Offset: int32(offfset),
-	})
-
+// List retrieves one page of orders using keyset pagination.
+// Items and exchange rates of the whole page are loaded with one query each.
+func (r *OrderRepository) List(ctx context.Context, query domain.OrderListQuery) (*domain.OrderPage, error) {
+	// Fetch one extra row to find out whether there is a next page
+	orderRows, err := r.listOrderRows(ctx, query, query.Limit+1)
 	if err != nil {
 		return nil, err
 	}
 
+	hasMore := len(orderRows) > query.Limit
+	if hasMore {
+		orderRows = orderRows[:query.Limit]
+	}
+
+	page := &domain.OrderPage{Orders: make([]*domain.Order, 0, len(orderRows))}
+
 	// Map to domain model
-	orders := make([]*domain.Order, 0, len(orderRows))
+	ids := make([]uuid.UUID, 0, len(orderRows))
+	byID := make(map[uuid.UUID]*domain.Order, len(orderRows))
 	for _, row := range orderRows {
-		totalPrice, err := strconv.ParseFloat(row.TotalPrice, 64)
+		order, err := toDomainOrder(row)
 		if err != nil {
 			return nil, err
 		}
 
-		order := &domain.Order{
-			ID:         row.ID,
-			CustomerID: row.CustomerID,
-			Status:     domain.OrderStatus(row.Status),
-			TotalPrice: totalPrice,
-			CreatedAt:  row.CreatedAt,
-			UpdatedAt:  row.UpdatedAt,
-			Items:      []domain.OrderItem{},
+		ids = append(ids, order.ID)
+		byID[order.ID] = order
+		page.Orders = append(page.Orders, order)
+	}
+
+	if len(ids) == 0 {
+		return page, nil
+	}
+
+	// Get items of all orders in the page
+	items, err := r.queries.GetOrderItemsByOrderIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		orderItem, err := toDomainOrderItem(item)
+		if err != nil {
+			return nil, err
 		}
+		order := byID[item.OrderID]
+		order.Items = append(order.Items, orderItem)
+	}
 
-		// Get items of this order
-		items, err := r.queries.GetOrderItems(ctx, row.ID)
+	rates, err := r.queries.GetOrderExchangeRatesByOrderIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rates {
+		rate, err := toDomainExchangeRate(row)
 		if err != nil {
-			continue
+			return nil, err
 		}
+		order := byID[row.OrderID]
+		order.ExchangeRates = append(order.ExchangeRates, rate)
+	}
 
-		for _, item := range items {
-			price, err := strconv.ParseFloat(item.Price, 64)
-			if err != nil {
-				return nil, err
-			}
-			order.Items = append(order.Items, domain.OrderItem{
-				ID:        item.ID,
-				ProductID: item.ProductID,
-				Quantity:  item.Quantity,
-				Price:     price,
-			})
+	if hasMore {
+		last := page.Orders[len(page.Orders)-1]
+		page.NextCursor = domain.NewOrderCursor(query.Sort, last).Encode()
+	}
+
+	return page, nil
+}
+
+// listOrderRows runs the listing query matching the sort of the query
+func (r *OrderRepository) listOrderRows(ctx context.Context, query domain.OrderListQuery, pageSize int) ([]sqlc.Order, error) {
+	filter := query.Filter
+
+	statuses := make([]string, 0, len(filter.Statuses))
+	for _, status := range filter.Statuses {
+		statuses = append(statuses, string(status))
+	}
+
+	var minTotal, maxTotal sql.NullString
+	if filter.MinTotal != nil {
+		minTotal = sql.NullString{String: filter.MinTotal.Decimal(), Valid: true}
+	}
+	if filter.MaxTotal != nil {
+		maxTotal = sql.NullString{String: filter.MaxTotal.Decimal(), Valid: true}
+	}
+
+	customerID := sql.NullString{String: filter.CustomerID, Valid: filter.CustomerID != ""}
+	createdAfter := sql.NullTime{Time: filter.CreatedAfter.UTC(), Valid: !filter.CreatedAfter.IsZero()}
+	createdBefore := sql.NullTime{Time: filter.CreatedBefore.UTC(), Valid: !filter.CreatedBefore.IsZero()}
+	currency := sql.NullString{String: string(filter.TotalCurrency()), Valid: filter.TotalCurrency() != ""}
+
+	var afterID uuid.NullUUID
+	var afterCreatedAt sql.NullTime
+	var afterTotalPrice sql.NullString
+	if query.After != nil {
+		afterID = uuid.NullUUID{UUID: query.After.ID, Valid: true}
+		afterCreatedAt = sql.NullTime{Time: query.After.CreatedAt.UTC(), Valid: true}
+		afterTotalPrice = sql.NullString{String: query.After.TotalPrice, Valid: true}
+	}
+
+	// The ascending and descending queries share their parameters
+	if query.Sort.Field == domain.OrderSortByTotalPrice {
+		params := sqlc.ListOrdersByTotalPriceDescParams{
+			CustomerID:      customerID,
+			Statuses:        statuses,
+			CreatedAfter:    createdAfter,
+			CreatedBefore:   createdBefore,
+			Currency:        currency,
+			MinTotal:        minTotal,
+			MaxTotal:        maxTotal,
+			AfterTotalPrice: afterTotalPrice,
+			AfterID:         afterID,
+			PageSize:        int32(pageSize),
+		}
+		if query.Sort.Descending {
+			return r.queries.ListOrdersByTotalPriceDesc(ctx, params)
 		}
-		orders = append(orders, order)
+		return r.queries.ListOrdersByTotalPriceAsc(ctx, sqlc.ListOrdersByTotalPriceAscParams(params))
+	}
+
+	params := sqlc.ListOrdersByCreatedAtDescParams{
+		CustomerID:     customerID,
+		Statuses:       statuses,
+		CreatedAfter:   createdAfter,
+		CreatedBefore:  createdBefore,
+		Currency:       currency,
+		MinTotal:       minTotal,
+		MaxTotal:       maxTotal,
+		AfterCreatedAt: afterCreatedAt,
+		AfterID:        afterID,
+		PageSize:       int32(pageSize),
+	}
+	if query.Sort.Descending {
+		return r.queries.ListOrdersByCreatedAtDesc(ctx, params)
+	}
+	return r.queries.ListOrdersByCreatedAtAsc(ctx, sqlc.ListOrdersByCreatedAtAscParams(params))
+}
+
+// toDomainOrder maps an order row to the domain model
+func toDomainOrder(row sqlc.Order) (*domain.Order, error) {
+	totalPrice, err := domain.ParseMoney(row.TotalPrice, domain.Currency(row.Currency))
+	if err != nil {
+		return nil, fmt.Errorf("invalid total price of order %s: %w", row.ID, err)
 	}
 
-	return orders, nil
+	return &domain.Order{
+		ID:         row.ID,
+		CustomerID: row.CustomerID,
+		Currency:   domain.Currency(row.Currency),
+		Status:     domain.OrderStatus(row.Status),
+		TotalPrice: totalPrice,
+		CreatedAt:  row.CreatedAt,
+		UpdatedAt:  row.UpdatedAt,
+		Items:      []domain.OrderItem{},
+	}, nil
+}
+
+// toDomainOrderItem maps an order item row to the domain model
+func toDomainOrderItem(row sqlc.OrderItem) (domain.OrderItem, error) {
+	price, err := domain.ParseMoney(row.Price, domain.Currency(row.Currency))
+	if err != nil {
+		return domain.OrderItem{}, fmt.Errorf("invalid price of order item %s: %w", row.ID, err)
+	}
+
+	return domain.OrderItem{
+		ID:        row.ID,
+		ProductID: row.ProductID,
+		Quantity:  row.Quantity,
+		Price:     price,
+	}, nil
+}
+
+// toDomainExchangeRate maps an exchange rate row to the domain model
+func toDomainExchangeRate(row sqlc.OrderExchangeRate) (domain.ExchangeRate, error) {
+	rate, err := domain.ParseExchangeRate(domain.Currency(row.FromCurrency), domain.Currency(row.ToCurrency), row.Rate, row.AsOf)
+	if err != nil {
+		return domain.ExchangeRate{}, fmt.Errorf("invalid exchange rate of order %s: %w", row.OrderID, err)
+	}
+	return rate, nil
 }
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"order-service/internal/app/ports"
+	"order-service/internal/domain"
+	"order-service/internal/infrastructure/sqlc"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderSagaRepository implements the ports.OrderSagaRepository interface
+type OrderSagaRepository struct {
+	queries *sqlc.Queries
+}
+
+func NewOrderSagaRepositoryWithTx(tx *sql.Tx) ports.OrderSagaRepository {
+	return &OrderSagaRepository{
+		queries: sqlc.New(tx),
+	}
+}
+
+// Create implements ports.OrderSagaRepository.
+func (r *OrderSagaRepository) Create(ctx context.Context, saga *domain.OrderSaga) error {
+	err := r.queries.CreateOrderSaga(ctx, sqlc.CreateOrderSagaParams{
+		OrderID:          saga.OrderID,
+		SagaID:           saga.ID,
+		ProductsReserved: saga.ProductsReserved,
+		PaymentApproved:  saga.PaymentApproved,
+		Compensated:      saga.Compensated,
+		CreatedAt:        saga.CreatedAt,
+		UpdatedAt:        saga.UpdatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create order saga: %w", err)
+	}
+	return nil
+}
+
+// GetForUpdate implements ports.OrderSagaRepository.
+func (r *OrderSagaRepository) GetForUpdate(ctx context.Context, orderID uuid.UUID) (*domain.OrderSaga, error) {
+	row, err := r.queries.GetOrderSagaForUpdate(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrOrderSagaNotFound
+		}
+		return nil, err
+	}
+
+	return &domain.OrderSaga{
+		ID:               row.SagaID,
+		OrderID:          row.OrderID,
+		ProductsReserved: row.ProductsReserved,
+		PaymentApproved:  row.PaymentApproved,
+		Compensated:      row.Compensated,
+		CreatedAt:        row.CreatedAt,
+		UpdatedAt:        row.UpdatedAt,
+	}, nil
+}
+
+// Update implements ports.OrderSagaRepository.
+func (r *OrderSagaRepository) Update(ctx context.Context, saga *domain.OrderSaga) error {
+	saga.UpdatedAt = time.Now()
+	err := r.queries.UpdateOrderSaga(ctx, sqlc.UpdateOrderSagaParams{
+		OrderID:          saga.OrderID,
+		ProductsReserved: saga.ProductsReserved,
+		PaymentApproved:  saga.PaymentApproved,
+		Compensated:      saga.Compensated,
+		UpdatedAt:        saga.UpdatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update order saga: %w", err)
+	}
+	return nil
+}
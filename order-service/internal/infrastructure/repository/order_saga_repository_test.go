@@ -0,0 +1,55 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"order-service/internal/domain"
+	"order-service/internal/infrastructure/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderSagaRepository(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	order, err := domain.NewOrder("customer-123", domain.CurrencyUSD, []domain.OrderItem{
+		{ID: uuid.New(), ProductID: "product-1", Quantity: 1, Price: domain.MustParseMoney("10.00", domain.CurrencyUSD)},
+	}, nil)
+	require.NoError(t, err)
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, repository.OrderRepositoryWithTx(tx).Create(ctx, order))
+	require.NoError(t, repository.NewOrderSagaRepositoryWithTx(tx).Create(ctx, domain.NewOrderSaga(order)))
+	require.NoError(t, tx.Commit())
+
+	tx, err = db.Begin()
+	require.NoError(t, err)
+	repo := repository.NewOrderSagaRepositoryWithTx(tx)
+	saga, err := repo.GetForUpdate(ctx, order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, order.SagaID, saga.ID)
+	assert.False(t, saga.ProductsReserved)
+
+	saga.ProductsReserved = true
+	saga.Compensated = true
+	require.NoError(t, repo.Update(ctx, saga))
+	require.NoError(t, tx.Commit())
+
+	tx, err = db.Begin()
+	require.NoError(t, err)
+	defer tx.Rollback()
+	repo = repository.NewOrderSagaRepositoryWithTx(tx)
+	saga, err = repo.GetForUpdate(ctx, order.ID)
+	require.NoError(t, err)
+	assert.True(t, saga.ProductsReserved)
+	assert.True(t, saga.Compensated)
+	assert.False(t, saga.Completed())
+
+	_, err = repo.GetForUpdate(ctx, uuid.New())
+	assert.ErrorIs(t, err, domain.ErrOrderSagaNotFound)
+}
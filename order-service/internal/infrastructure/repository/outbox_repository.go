@@ -4,9 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"order-service/internal/app/ports"
 	"order-service/internal/domain"
 	"order-service/internal/infrastructure/sqlc"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,66 +18,253 @@ import (
 // OutboxRepository implements the ports.OutboxRepository interface
 type OutboxRepository struct {
 	queries *sqlc.Queries
+	// notifyChannel is the channel new messages are announced on, empty to not announce them
+	notifyChannel string
 }
 
-func NewOutboxRepository(db *sql.DB) domain.OutboxRepository {
+func NewOutboxRepository(db *sql.DB) ports.OutboxRepository {
 	return &OutboxRepository{
 		queries: sqlc.New(db),
 	}
 }
 
-func NewOutboxRepositoryWithTx(tx *sql.Tx) domain.OutboxRepository {
+func NewOutboxRepositoryWithTx(tx *sql.Tx) ports.OutboxRepository {
 	return &OutboxRepository{
 		queries: sqlc.New(tx),
 	}
 }
 
+// NewNotifyingOutboxRepositoryWithTx returns an outbox repository that announces every new
+// message on the Postgres notification channel. The notification is delivered when tx commits.
+func NewNotifyingOutboxRepositoryWithTx(tx *sql.Tx, channel string) ports.OutboxRepository {
+	return &OutboxRepository{
+		queries:       sqlc.New(tx),
+		notifyChannel: channel,
+	}
+}
+
+// NewOutboxRetentionRepository returns the outbox repository for the retention job
+func NewOutboxRetentionRepository(db *sql.DB) ports.OutboxRetentionRepository {
+	return &OutboxRepository{
+		queries: sqlc.New(db),
+	}
+}
+
+// NewOutboxAdminRepository returns the outbox repository for operator tooling
+func NewOutboxAdminRepository(db *sql.DB) ports.OutboxAdminRepository {
+	return &OutboxRepository{
+		queries: sqlc.New(db),
+	}
+}
+
+// NewOutboxRelayRepository returns the outbox repository for the CDC relay
+func NewOutboxRelayRepository(db *sql.DB) ports.OutboxRelayRepository {
+	return &OutboxRepository{
+		queries: sqlc.New(db),
+	}
+}
+
 // CreateMessage implements ports.OutboxRepository.
 func (o *OutboxRepository) CreateMessage(ctx context.Context, aggregateID uuid.UUID, messageType string, payload interface{}) error {
-	// Marshal the payload into JSON
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %w", err)
+	// Marshal the payload into JSON unless it is already encoded
+	var jsonData []byte
+	switch p := payload.(type) {
+	case []byte:
+		jsonData = p
+	case json.RawMessage:
+		jsonData = p
+	default:
+		var err error
+		jsonData, err = json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal payload: %w", err)
+		}
+	}
+
+	// Payloads are upcast on read from the version they were written with
+	schemaVersion := 1
+	if versioned, ok := payload.(ports.VersionedEvent); ok {
+		schemaVersion = versioned.SchemaVersion()
 	}
 
 	params := sqlc.CreateOutboxMessageParams{
-		ID:          uuid.New(),
-		AggregateID: aggregateID,
-		EventType:   messageType,
-		Payload:     jsonData, // Assuming this is already a json.RawMessage or similar
-		CreatedAt:   time.Now(),
-		Status:      string(domain.OutboxStatusPending),
+		ID:            uuid.New(),
+		AggregateID:   aggregateID,
+		EventType:     messageType,
+		Payload:       jsonData,
+		MessageID:     uuid.New(),
+		CreatedAt:     time.Now(),
+		Status:        string(domain.OutboxStatusPending),
+		SchemaVersion: int32(schemaVersion),
+	}
+
+	if err := o.queries.CreateOutboxMessage(ctx, params); err != nil {
+		return err
+	}
+
+	if o.notifyChannel != "" {
+		return o.queries.NotifyOutboxMessage(ctx, sqlc.NotifyOutboxMessageParams{
+			Channel: o.notifyChannel,
+			Payload: params.ID.String(),
+		})
+	}
+
+	return nil
+}
+
+// ArchiveProcessedMessages implements ports.OutboxRetentionRepository.
+func (o *OutboxRepository) ArchiveProcessedMessages(ctx context.Context, before time.Time, limit int) (int64, error) {
+	// The archive is partitioned by month, the months of the batch need a partition first
+	if err := o.queries.CreateOutboxArchivePartitions(ctx, before); err != nil {
+		return 0, fmt.Errorf("failed to create archive partitions: %w", err)
 	}
 
-	return o.queries.CreateOutboxMessage(ctx, params)
+	return o.queries.ArchiveProcessedOutboxMessages(ctx, sqlc.ArchiveProcessedOutboxMessagesParams{
+		Cutoff:    before,
+		BatchSize: int32(limit),
+	})
 }
 
-// GetPendingMessages implements ports.OutboxRepository.
-func (o *OutboxRepository) GetPendingMessages(ctx context.Context, limit int) ([]domain.OutboxMessage, error) {
-	messages, err := o.queries.GetPendingOutboxMessages(ctx, int32(limit))
+// ClaimPendingMessages implements ports.OutboxRepository.
+func (o *OutboxRepository) ClaimPendingMessages(ctx context.Context, workerID string, limit int, lease time.Duration) ([]domain.OutboxMessage, error) {
+	messages, err := o.queries.ClaimOutboxMessages(ctx, sqlc.ClaimOutboxMessagesParams{
+		LockedBy:     sql.NullString{String: workerID, Valid: true},
+		LeaseSeconds: lease.Seconds(),
+		BatchSize:    int32(limit),
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	result := make([]domain.OutboxMessage, len(messages))
 	for i, msg := range messages {
-		result[i] = domain.OutboxMessage{
-			ID:          msg.ID,
-			AggregateID: msg.AggregateID,
-			EventType:   msg.EventType,
-			Payload:     msg.Payload,
-			CreatedAt:   msg.CreatedAt,
-			ProcessedAt: msg.ProcessedAt.Time,
-			Status:      domain.OutboxStatus(msg.Status),
+		result[i] = toDomainOutboxMessage(msg)
+	}
+
+	// Rows come back in no particular order from UPDATE ... RETURNING
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	return result, nil
+}
+
+// ClaimDeadLetters implements ports.OutboxRepository.
+func (o *OutboxRepository) ClaimDeadLetters(ctx context.Context, workerID string, limit, maxAttempts int, lease time.Duration) ([]domain.OutboxMessage, error) {
+	messages, err := o.queries.ClaimDeadLetterOutboxMessages(ctx, sqlc.ClaimDeadLetterOutboxMessagesParams{
+		LockedBy:     sql.NullString{String: workerID, Valid: true},
+		LeaseSeconds: lease.Seconds(),
+		MaxAttempts:  int32(maxAttempts),
+		BatchSize:    int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]domain.OutboxMessage, len(messages))
+	for i, msg := range messages {
+		result[i] = toDomainOutboxMessage(msg)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FailedAt.Before(result[j].FailedAt)
+	})
+
+	return result, nil
+}
+
+// DeleteProcessedMessages implements ports.OutboxRetentionRepository.
+func (o *OutboxRepository) DeleteProcessedMessages(ctx context.Context, before time.Time, limit int) (int64, error) {
+	return o.queries.DeleteProcessedOutboxMessages(ctx, sqlc.DeleteProcessedOutboxMessagesParams{
+		Cutoff:    before,
+		BatchSize: int32(limit),
+	})
+}
+
+// DiscardFailedMessages implements ports.OutboxAdminRepository.
+func (o *OutboxRepository) DiscardFailedMessages(ctx context.Context, filter domain.OutboxMessageFilter) (int64, error) {
+	ids, eventType, failedAfter, failedBefore := outboxFilterParams(filter)
+	return o.queries.DiscardFailedOutboxMessages(ctx, sqlc.DiscardFailedOutboxMessagesParams{
+		Ids:          ids,
+		EventType:    eventType,
+		FailedAfter:  failedAfter,
+		FailedBefore: failedBefore,
+	})
+}
+
+// GetMessage implements ports.OutboxAdminRepository.
+func (o *OutboxRepository) GetMessage(ctx context.Context, messageID uuid.UUID) (*domain.OutboxMessage, error) {
+	row, err := o.queries.GetOutboxMessageByID(ctx, messageID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrOutboxMessageNotFound
 		}
+		return nil, err
+	}
+
+	msg := toDomainOutboxMessage(row)
+	return &msg, nil
+}
+
+// HasUnpublishedPredecessor implements ports.OutboxRelayRepository.
+func (o *OutboxRepository) HasUnpublishedPredecessor(ctx context.Context, msg domain.OutboxMessage) (bool, error) {
+	return o.queries.HasUnpublishedOutboxPredecessor(ctx, sqlc.HasUnpublishedOutboxPredecessorParams{
+		AggregateID:    msg.AggregateID,
+		SequenceNumber: msg.SequenceNumber,
+	})
+}
+
+// ListFailedMessages implements ports.OutboxAdminRepository.
+func (o *OutboxRepository) ListFailedMessages(ctx context.Context, filter domain.OutboxMessageFilter, limit int) ([]domain.OutboxMessage, error) {
+	ids, eventType, failedAfter, failedBefore := outboxFilterParams(filter)
+	rows, err := o.queries.ListFailedOutboxMessages(ctx, sqlc.ListFailedOutboxMessagesParams{
+		Ids:          ids,
+		EventType:    eventType,
+		FailedAfter:  failedAfter,
+		FailedBefore: failedBefore,
+		PageSize:     int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]domain.OutboxMessage, len(rows))
+	for i, row := range rows {
+		result[i] = toDomainOutboxMessage(row)
+	}
+
+	return result, nil
+}
+
+// ListHeldMessages implements ports.OutboxRelayRepository.
+func (o *OutboxRepository) ListHeldMessages(ctx context.Context, aggregateID uuid.UUID, after int64) ([]domain.OutboxMessage, error) {
+	rows, err := o.queries.ListHeldOutboxMessages(ctx, sqlc.ListHeldOutboxMessagesParams{
+		AggregateID:    aggregateID,
+		SequenceNumber: after,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]domain.OutboxMessage, len(rows))
+	for i, row := range rows {
+		result[i] = toDomainOutboxMessage(row)
 	}
 
 	return result, nil
 }
 
+// MarkMessageAsDeadLettered implements ports.OutboxRepository.
+func (o *OutboxRepository) MarkMessageAsDeadLettered(ctx context.Context, messageID uuid.UUID) error {
+	return o.queries.MarkOutboxMessageDeadLettered(ctx, messageID)
+}
+
 // MarkMessageAsFailed implements ports.OutboxRepository.
 func (o *OutboxRepository) MarkMessageAsFailed(ctx context.Context, messageID uuid.UUID, reason string) error {
-	panic("unimplemented")
+	return o.queries.MarkOutboxMessageFailed(ctx, sqlc.MarkOutboxMessageFailedParams{
+		ID:           messageID,
+		ErrorMessage: sql.NullString{String: reason, Valid: reason != ""},
+	})
 }
 
 // MarkMessageAsProcessed implements ports.OutboxRepository.
@@ -85,7 +275,102 @@ func (o *OutboxRepository) MarkMessageAsProcessed(ctx context.Context, messageID
 	})
 }
 
-// IncrementAttempt implements ports.OutboxRepository.
-func (o *OutboxRepository) IncrementAttempt(ctx context.Context, messageID uuid.UUID) error {
-	return o.queries.IncrementAttempt(ctx, messageID)
+// ParkMessage implements ports.OutboxRepository.
+func (o *OutboxRepository) ParkMessage(ctx context.Context, messageID uuid.UUID, reason string) error {
+	return o.queries.ParkOutboxMessage(ctx, sqlc.ParkOutboxMessageParams{
+		ID:           messageID,
+		ErrorMessage: sql.NullString{String: reason, Valid: reason != ""},
+	})
+}
+
+// RecordDeadLetterFailure implements ports.OutboxRepository.
+func (o *OutboxRepository) RecordDeadLetterFailure(ctx context.Context, messageID uuid.UUID) error {
+	return o.queries.RecordOutboxDeadLetterFailure(ctx, messageID)
+}
+
+// RequeueFailedMessages implements ports.OutboxAdminRepository.
+func (o *OutboxRepository) RequeueFailedMessages(ctx context.Context, filter domain.OutboxMessageFilter) (int64, error) {
+	ids, eventType, failedAfter, failedBefore := outboxFilterParams(filter)
+	return o.queries.RequeueFailedOutboxMessages(ctx, sqlc.RequeueFailedOutboxMessagesParams{
+		Ids:          ids,
+		EventType:    eventType,
+		FailedAfter:  failedAfter,
+		FailedBefore: failedBefore,
+	})
+}
+
+// ScheduleRetry implements ports.OutboxRepository.
+func (o *OutboxRepository) ScheduleRetry(ctx context.Context, messageID uuid.UUID, delay time.Duration, lastError string) error {
+	return o.queries.ScheduleOutboxRetry(ctx, sqlc.ScheduleOutboxRetryParams{
+		ErrorMessage: sql.NullString{String: lastError, Valid: lastError != ""},
+		DelaySeconds: delay.Seconds(),
+		ID:           messageID,
+	})
+}
+
+// UnparkMessages implements ports.OutboxRepository.
+func (o *OutboxRepository) UnparkMessages(ctx context.Context, eventTypes []string) (int64, error) {
+	if len(eventTypes) == 0 {
+		return 0, nil
+	}
+	return o.queries.UnparkOutboxMessages(ctx, eventTypes)
+}
+
+// UpdateFailedMessagePayload implements ports.OutboxAdminRepository.
+func (o *OutboxRepository) UpdateFailedMessagePayload(ctx context.Context, messageID uuid.UUID, payload []byte, schemaVersion int) error {
+	updated, err := o.queries.UpdateFailedOutboxMessagePayload(ctx, sqlc.UpdateFailedOutboxMessagePayloadParams{
+		ID:            messageID,
+		Payload:       payload,
+		SchemaVersion: int32(schemaVersion),
+	})
+	if err != nil {
+		return err
+	}
+
+	if updated == 0 {
+		// Tell a missing message apart from one that is not failed
+		if _, err := o.GetMessage(ctx, messageID); err != nil {
+			return err
+		}
+		return domain.ErrOutboxMessageNotFailed
+	}
+
+	return nil
+}
+
+// outboxFilterParams maps a filter of failed messages to query parameters
+func outboxFilterParams(filter domain.OutboxMessageFilter) ([]uuid.UUID, sql.NullString, sql.NullTime, sql.NullTime) {
+	ids := filter.IDs
+	if ids == nil {
+		ids = []uuid.UUID{}
+	}
+
+	return ids,
+		sql.NullString{String: filter.EventType, Valid: filter.EventType != ""},
+		sql.NullTime{Time: filter.FailedAfter.UTC(), Valid: !filter.FailedAfter.IsZero()},
+		sql.NullTime{Time: filter.FailedBefore.UTC(), Valid: !filter.FailedBefore.IsZero()}
+}
+
+// toDomainOutboxMessage maps an outbox row to the domain model
+func toDomainOutboxMessage(msg sqlc.OutboxMessage) domain.OutboxMessage {
+	return domain.OutboxMessage{
+		ID:            msg.ID,
+		AggregateID:   msg.AggregateID,
+		EventType:     msg.EventType,
+		Payload:       msg.Payload,
+		AttemptCount:  int(msg.AttemptCount),
+		CreatedAt:     msg.CreatedAt,
+		ProcessedAt:   msg.ProcessedAt.Time,
+		Status:        domain.OutboxStatus(msg.Status),
+		LockedBy:      msg.LockedBy.String,
+		LockedUntil:   msg.LockedUntil.Time,
+		NextAttemptAt: msg.NextAttemptAt.Time,
+		LastError:     msg.ErrorMessage.String,
+
+		FailedAt:           msg.FailedAt.Time,
+		DeadLetteredAt:     msg.DeadLetteredAt.Time,
+		DeadLetterAttempts: int(msg.DeadLetterAttempts),
+		SequenceNumber:     msg.SequenceNumber,
+		SchemaVersion:      int(msg.SchemaVersion),
+	}
 }
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"order-service/internal/app/ports"
+	"order-service/internal/infrastructure/sqlc"
+)
+
+// RelayOffsetRepository implements the ports.RelayOffsetRepository interface
+type RelayOffsetRepository struct {
+	queries *sqlc.Queries
+}
+
+func NewRelayOffsetRepository(db *sql.DB) ports.RelayOffsetRepository {
+	return &RelayOffsetRepository{
+		queries: sqlc.New(db),
+	}
+}
+
+// GetOffset implements ports.RelayOffsetRepository.
+func (r *RelayOffsetRepository) GetOffset(ctx context.Context, slotName string) (uint64, error) {
+	lsn, err := r.queries.GetOutboxRelayOffset(ctx, slotName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get relay offset: %w", err)
+	}
+	return uint64(lsn), nil
+}
+
+// SaveOffset implements ports.RelayOffsetRepository.
+func (r *RelayOffsetRepository) SaveOffset(ctx context.Context, slotName string, lsn uint64) error {
+	err := r.queries.SaveOutboxRelayOffset(ctx, sqlc.SaveOutboxRelayOffsetParams{
+		SlotName: slotName,
+		Lsn:      int64(lsn),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save relay offset: %w", err)
+	}
+	return nil
+}
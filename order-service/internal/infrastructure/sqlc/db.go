@@ -24,15 +24,39 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.archiveProcessedOutboxMessagesStmt, err = db.PrepareContext(ctx, archiveProcessedOutboxMessages); err != nil {
+		return nil, fmt.Errorf("error preparing query ArchiveProcessedOutboxMessages: %w", err)
+	}
+	if q.claimDeadLetterOutboxMessagesStmt, err = db.PrepareContext(ctx, claimDeadLetterOutboxMessages); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimDeadLetterOutboxMessages: %w", err)
+	}
+	if q.claimOutboxMessagesStmt, err = db.PrepareContext(ctx, claimOutboxMessages); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimOutboxMessages: %w", err)
+	}
 	if q.createOrderStmt, err = db.PrepareContext(ctx, createOrder); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateOrder: %w", err)
 	}
+	if q.createOrderExchangeRateStmt, err = db.PrepareContext(ctx, createOrderExchangeRate); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateOrderExchangeRate: %w", err)
+	}
 	if q.createOrderItemStmt, err = db.PrepareContext(ctx, createOrderItem); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateOrderItem: %w", err)
 	}
+	if q.createOrderSagaStmt, err = db.PrepareContext(ctx, createOrderSaga); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateOrderSaga: %w", err)
+	}
+	if q.createOrderStatusTransitionStmt, err = db.PrepareContext(ctx, createOrderStatusTransition); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateOrderStatusTransition: %w", err)
+	}
+	if q.createOutboxArchivePartitionsStmt, err = db.PrepareContext(ctx, createOutboxArchivePartitions); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateOutboxArchivePartitions: %w", err)
+	}
 	if q.createOutboxMessageStmt, err = db.PrepareContext(ctx, createOutboxMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateOutboxMessage: %w", err)
 	}
+	if q.createProcessedEventStmt, err = db.PrepareContext(ctx, createProcessedEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateProcessedEvent: %w", err)
+	}
 	if q.deleteOrderStmt, err = db.PrepareContext(ctx, deleteOrder); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteOrder: %w", err)
 	}
@@ -45,23 +69,71 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.deleteOutboxMessageStmt, err = db.PrepareContext(ctx, deleteOutboxMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteOutboxMessage: %w", err)
 	}
+	if q.deleteProcessedEventsStmt, err = db.PrepareContext(ctx, deleteProcessedEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteProcessedEvents: %w", err)
+	}
+	if q.deleteProcessedOutboxMessagesStmt, err = db.PrepareContext(ctx, deleteProcessedOutboxMessages); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteProcessedOutboxMessages: %w", err)
+	}
+	if q.discardFailedOutboxMessagesStmt, err = db.PrepareContext(ctx, discardFailedOutboxMessages); err != nil {
+		return nil, fmt.Errorf("error preparing query DiscardFailedOutboxMessages: %w", err)
+	}
 	if q.getOrderStmt, err = db.PrepareContext(ctx, getOrder); err != nil {
 		return nil, fmt.Errorf("error preparing query GetOrder: %w", err)
 	}
+	if q.getOrderExchangeRatesStmt, err = db.PrepareContext(ctx, getOrderExchangeRates); err != nil {
+		return nil, fmt.Errorf("error preparing query GetOrderExchangeRates: %w", err)
+	}
+	if q.getOrderExchangeRatesByOrderIDsStmt, err = db.PrepareContext(ctx, getOrderExchangeRatesByOrderIDs); err != nil {
+		return nil, fmt.Errorf("error preparing query GetOrderExchangeRatesByOrderIDs: %w", err)
+	}
+	if q.getOrderForUpdateStmt, err = db.PrepareContext(ctx, getOrderForUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetOrderForUpdate: %w", err)
+	}
 	if q.getOrderItemsStmt, err = db.PrepareContext(ctx, getOrderItems); err != nil {
 		return nil, fmt.Errorf("error preparing query GetOrderItems: %w", err)
 	}
+	if q.getOrderItemsByOrderIDsStmt, err = db.PrepareContext(ctx, getOrderItemsByOrderIDs); err != nil {
+		return nil, fmt.Errorf("error preparing query GetOrderItemsByOrderIDs: %w", err)
+	}
+	if q.getOrderSagaForUpdateStmt, err = db.PrepareContext(ctx, getOrderSagaForUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetOrderSagaForUpdate: %w", err)
+	}
+	if q.getOrderStatusHistoryStmt, err = db.PrepareContext(ctx, getOrderStatusHistory); err != nil {
+		return nil, fmt.Errorf("error preparing query GetOrderStatusHistory: %w", err)
+	}
 	if q.getOutboxMessageByIDStmt, err = db.PrepareContext(ctx, getOutboxMessageByID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetOutboxMessageByID: %w", err)
 	}
+	if q.getOutboxRelayOffsetStmt, err = db.PrepareContext(ctx, getOutboxRelayOffset); err != nil {
+		return nil, fmt.Errorf("error preparing query GetOutboxRelayOffset: %w", err)
+	}
 	if q.getPendingOutboxMessagesStmt, err = db.PrepareContext(ctx, getPendingOutboxMessages); err != nil {
 		return nil, fmt.Errorf("error preparing query GetPendingOutboxMessages: %w", err)
 	}
-	if q.incrementAttemptStmt, err = db.PrepareContext(ctx, incrementAttempt); err != nil {
-		return nil, fmt.Errorf("error preparing query IncrementAttempt: %w", err)
+	if q.hasUnpublishedOutboxPredecessorStmt, err = db.PrepareContext(ctx, hasUnpublishedOutboxPredecessor); err != nil {
+		return nil, fmt.Errorf("error preparing query HasUnpublishedOutboxPredecessor: %w", err)
+	}
+	if q.listFailedOutboxMessagesStmt, err = db.PrepareContext(ctx, listFailedOutboxMessages); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFailedOutboxMessages: %w", err)
 	}
-	if q.listOrdersStmt, err = db.PrepareContext(ctx, listOrders); err != nil {
-		return nil, fmt.Errorf("error preparing query ListOrders: %w", err)
+	if q.listHeldOutboxMessagesStmt, err = db.PrepareContext(ctx, listHeldOutboxMessages); err != nil {
+		return nil, fmt.Errorf("error preparing query ListHeldOutboxMessages: %w", err)
+	}
+	if q.listOrdersByCreatedAtAscStmt, err = db.PrepareContext(ctx, listOrdersByCreatedAtAsc); err != nil {
+		return nil, fmt.Errorf("error preparing query ListOrdersByCreatedAtAsc: %w", err)
+	}
+	if q.listOrdersByCreatedAtDescStmt, err = db.PrepareContext(ctx, listOrdersByCreatedAtDesc); err != nil {
+		return nil, fmt.Errorf("error preparing query ListOrdersByCreatedAtDesc: %w", err)
+	}
+	if q.listOrdersByTotalPriceAscStmt, err = db.PrepareContext(ctx, listOrdersByTotalPriceAsc); err != nil {
+		return nil, fmt.Errorf("error preparing query ListOrdersByTotalPriceAsc: %w", err)
+	}
+	if q.listOrdersByTotalPriceDescStmt, err = db.PrepareContext(ctx, listOrdersByTotalPriceDesc); err != nil {
+		return nil, fmt.Errorf("error preparing query ListOrdersByTotalPriceDesc: %w", err)
+	}
+	if q.markOutboxMessageDeadLetteredStmt, err = db.PrepareContext(ctx, markOutboxMessageDeadLettered); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkOutboxMessageDeadLettered: %w", err)
 	}
 	if q.markOutboxMessageFailedStmt, err = db.PrepareContext(ctx, markOutboxMessageFailed); err != nil {
 		return nil, fmt.Errorf("error preparing query MarkOutboxMessageFailed: %w", err)
@@ -69,29 +141,96 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.markOutboxMessageProcessedStmt, err = db.PrepareContext(ctx, markOutboxMessageProcessed); err != nil {
 		return nil, fmt.Errorf("error preparing query MarkOutboxMessageProcessed: %w", err)
 	}
+	if q.notifyOutboxMessageStmt, err = db.PrepareContext(ctx, notifyOutboxMessage); err != nil {
+		return nil, fmt.Errorf("error preparing query NotifyOutboxMessage: %w", err)
+	}
+	if q.parkOutboxMessageStmt, err = db.PrepareContext(ctx, parkOutboxMessage); err != nil {
+		return nil, fmt.Errorf("error preparing query ParkOutboxMessage: %w", err)
+	}
+	if q.recordOutboxDeadLetterFailureStmt, err = db.PrepareContext(ctx, recordOutboxDeadLetterFailure); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordOutboxDeadLetterFailure: %w", err)
+	}
+	if q.requeueFailedOutboxMessagesStmt, err = db.PrepareContext(ctx, requeueFailedOutboxMessages); err != nil {
+		return nil, fmt.Errorf("error preparing query RequeueFailedOutboxMessages: %w", err)
+	}
+	if q.saveOutboxRelayOffsetStmt, err = db.PrepareContext(ctx, saveOutboxRelayOffset); err != nil {
+		return nil, fmt.Errorf("error preparing query SaveOutboxRelayOffset: %w", err)
+	}
+	if q.scheduleOutboxRetryStmt, err = db.PrepareContext(ctx, scheduleOutboxRetry); err != nil {
+		return nil, fmt.Errorf("error preparing query ScheduleOutboxRetry: %w", err)
+	}
+	if q.unparkOutboxMessagesStmt, err = db.PrepareContext(ctx, unparkOutboxMessages); err != nil {
+		return nil, fmt.Errorf("error preparing query UnparkOutboxMessages: %w", err)
+	}
+	if q.updateFailedOutboxMessagePayloadStmt, err = db.PrepareContext(ctx, updateFailedOutboxMessagePayload); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateFailedOutboxMessagePayload: %w", err)
+	}
 	if q.updateOrderStmt, err = db.PrepareContext(ctx, updateOrder); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateOrder: %w", err)
 	}
+	if q.updateOrderSagaStmt, err = db.PrepareContext(ctx, updateOrderSaga); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateOrderSaga: %w", err)
+	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
+	if q.archiveProcessedOutboxMessagesStmt != nil {
+		if cerr := q.archiveProcessedOutboxMessagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing archiveProcessedOutboxMessagesStmt: %w", cerr)
+		}
+	}
+	if q.claimDeadLetterOutboxMessagesStmt != nil {
+		if cerr := q.claimDeadLetterOutboxMessagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimDeadLetterOutboxMessagesStmt: %w", cerr)
+		}
+	}
+	if q.claimOutboxMessagesStmt != nil {
+		if cerr := q.claimOutboxMessagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimOutboxMessagesStmt: %w", cerr)
+		}
+	}
 	if q.createOrderStmt != nil {
 		if cerr := q.createOrderStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createOrderStmt: %w", cerr)
 		}
 	}
+	if q.createOrderExchangeRateStmt != nil {
+		if cerr := q.createOrderExchangeRateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createOrderExchangeRateStmt: %w", cerr)
+		}
+	}
 	if q.createOrderItemStmt != nil {
 		if cerr := q.createOrderItemStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createOrderItemStmt: %w", cerr)
 		}
 	}
+	if q.createOrderSagaStmt != nil {
+		if cerr := q.createOrderSagaStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createOrderSagaStmt: %w", cerr)
+		}
+	}
+	if q.createOrderStatusTransitionStmt != nil {
+		if cerr := q.createOrderStatusTransitionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createOrderStatusTransitionStmt: %w", cerr)
+		}
+	}
+	if q.createOutboxArchivePartitionsStmt != nil {
+		if cerr := q.createOutboxArchivePartitionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createOutboxArchivePartitionsStmt: %w", cerr)
+		}
+	}
 	if q.createOutboxMessageStmt != nil {
 		if cerr := q.createOutboxMessageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createOutboxMessageStmt: %w", cerr)
 		}
 	}
+	if q.createProcessedEventStmt != nil {
+		if cerr := q.createProcessedEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createProcessedEventStmt: %w", cerr)
+		}
+	}
 	if q.deleteOrderStmt != nil {
 		if cerr := q.deleteOrderStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteOrderStmt: %w", cerr)
@@ -112,34 +251,114 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteOutboxMessageStmt: %w", cerr)
 		}
 	}
+	if q.deleteProcessedEventsStmt != nil {
+		if cerr := q.deleteProcessedEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteProcessedEventsStmt: %w", cerr)
+		}
+	}
+	if q.deleteProcessedOutboxMessagesStmt != nil {
+		if cerr := q.deleteProcessedOutboxMessagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteProcessedOutboxMessagesStmt: %w", cerr)
+		}
+	}
+	if q.discardFailedOutboxMessagesStmt != nil {
+		if cerr := q.discardFailedOutboxMessagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing discardFailedOutboxMessagesStmt: %w", cerr)
+		}
+	}
 	if q.getOrderStmt != nil {
 		if cerr := q.getOrderStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getOrderStmt: %w", cerr)
 		}
 	}
+	if q.getOrderExchangeRatesStmt != nil {
+		if cerr := q.getOrderExchangeRatesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getOrderExchangeRatesStmt: %w", cerr)
+		}
+	}
+	if q.getOrderExchangeRatesByOrderIDsStmt != nil {
+		if cerr := q.getOrderExchangeRatesByOrderIDsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getOrderExchangeRatesByOrderIDsStmt: %w", cerr)
+		}
+	}
+	if q.getOrderForUpdateStmt != nil {
+		if cerr := q.getOrderForUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getOrderForUpdateStmt: %w", cerr)
+		}
+	}
 	if q.getOrderItemsStmt != nil {
 		if cerr := q.getOrderItemsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getOrderItemsStmt: %w", cerr)
 		}
 	}
+	if q.getOrderItemsByOrderIDsStmt != nil {
+		if cerr := q.getOrderItemsByOrderIDsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getOrderItemsByOrderIDsStmt: %w", cerr)
+		}
+	}
+	if q.getOrderSagaForUpdateStmt != nil {
+		if cerr := q.getOrderSagaForUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getOrderSagaForUpdateStmt: %w", cerr)
+		}
+	}
+	if q.getOrderStatusHistoryStmt != nil {
+		if cerr := q.getOrderStatusHistoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getOrderStatusHistoryStmt: %w", cerr)
+		}
+	}
 	if q.getOutboxMessageByIDStmt != nil {
 		if cerr := q.getOutboxMessageByIDStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getOutboxMessageByIDStmt: %w", cerr)
 		}
 	}
+	if q.getOutboxRelayOffsetStmt != nil {
+		if cerr := q.getOutboxRelayOffsetStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getOutboxRelayOffsetStmt: %w", cerr)
+		}
+	}
 	if q.getPendingOutboxMessagesStmt != nil {
 		if cerr := q.getPendingOutboxMessagesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getPendingOutboxMessagesStmt: %w", cerr)
 		}
 	}
-	if q.incrementAttemptStmt != nil {
-		if cerr := q.incrementAttemptStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing incrementAttemptStmt: %w", cerr)
+	if q.hasUnpublishedOutboxPredecessorStmt != nil {
+		if cerr := q.hasUnpublishedOutboxPredecessorStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing hasUnpublishedOutboxPredecessorStmt: %w", cerr)
+		}
+	}
+	if q.listFailedOutboxMessagesStmt != nil {
+		if cerr := q.listFailedOutboxMessagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFailedOutboxMessagesStmt: %w", cerr)
+		}
+	}
+	if q.listHeldOutboxMessagesStmt != nil {
+		if cerr := q.listHeldOutboxMessagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listHeldOutboxMessagesStmt: %w", cerr)
+		}
+	}
+	if q.listOrdersByCreatedAtAscStmt != nil {
+		if cerr := q.listOrdersByCreatedAtAscStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listOrdersByCreatedAtAscStmt: %w", cerr)
 		}
 	}
-	if q.listOrdersStmt != nil {
-		if cerr := q.listOrdersStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing listOrdersStmt: %w", cerr)
+	if q.listOrdersByCreatedAtDescStmt != nil {
+		if cerr := q.listOrdersByCreatedAtDescStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listOrdersByCreatedAtDescStmt: %w", cerr)
+		}
+	}
+	if q.listOrdersByTotalPriceAscStmt != nil {
+		if cerr := q.listOrdersByTotalPriceAscStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listOrdersByTotalPriceAscStmt: %w", cerr)
+		}
+	}
+	if q.listOrdersByTotalPriceDescStmt != nil {
+		if cerr := q.listOrdersByTotalPriceDescStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listOrdersByTotalPriceDescStmt: %w", cerr)
+		}
+	}
+	if q.markOutboxMessageDeadLetteredStmt != nil {
+		if cerr := q.markOutboxMessageDeadLetteredStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markOutboxMessageDeadLetteredStmt: %w", cerr)
 		}
 	}
 	if q.markOutboxMessageFailedStmt != nil {
@@ -152,11 +371,56 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing markOutboxMessageProcessedStmt: %w", cerr)
 		}
 	}
+	if q.notifyOutboxMessageStmt != nil {
+		if cerr := q.notifyOutboxMessageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing notifyOutboxMessageStmt: %w", cerr)
+		}
+	}
+	if q.parkOutboxMessageStmt != nil {
+		if cerr := q.parkOutboxMessageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing parkOutboxMessageStmt: %w", cerr)
+		}
+	}
+	if q.recordOutboxDeadLetterFailureStmt != nil {
+		if cerr := q.recordOutboxDeadLetterFailureStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordOutboxDeadLetterFailureStmt: %w", cerr)
+		}
+	}
+	if q.requeueFailedOutboxMessagesStmt != nil {
+		if cerr := q.requeueFailedOutboxMessagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing requeueFailedOutboxMessagesStmt: %w", cerr)
+		}
+	}
+	if q.saveOutboxRelayOffsetStmt != nil {
+		if cerr := q.saveOutboxRelayOffsetStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing saveOutboxRelayOffsetStmt: %w", cerr)
+		}
+	}
+	if q.scheduleOutboxRetryStmt != nil {
+		if cerr := q.scheduleOutboxRetryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing scheduleOutboxRetryStmt: %w", cerr)
+		}
+	}
+	if q.unparkOutboxMessagesStmt != nil {
+		if cerr := q.unparkOutboxMessagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing unparkOutboxMessagesStmt: %w", cerr)
+		}
+	}
+	if q.updateFailedOutboxMessagePayloadStmt != nil {
+		if cerr := q.updateFailedOutboxMessagePayloadStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateFailedOutboxMessagePayloadStmt: %w", cerr)
+		}
+	}
 	if q.updateOrderStmt != nil {
 		if cerr := q.updateOrderStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateOrderStmt: %w", cerr)
 		}
 	}
+	if q.updateOrderSagaStmt != nil {
+		if cerr := q.updateOrderSagaStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateOrderSagaStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -194,45 +458,111 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                             DBTX
-	tx                             *sql.Tx
-	createOrderStmt                *sql.Stmt
-	createOrderItemStmt            *sql.Stmt
-	createOutboxMessageStmt        *sql.Stmt
-	deleteOrderStmt                *sql.Stmt
-	deleteOrderItemStmt            *sql.Stmt
-	deleteOrderItemsStmt           *sql.Stmt
-	deleteOutboxMessageStmt        *sql.Stmt
-	getOrderStmt                   *sql.Stmt
-	getOrderItemsStmt              *sql.Stmt
-	getOutboxMessageByIDStmt       *sql.Stmt
-	getPendingOutboxMessagesStmt   *sql.Stmt
-	incrementAttemptStmt           *sql.Stmt
-	listOrdersStmt                 *sql.Stmt
-	markOutboxMessageFailedStmt    *sql.Stmt
-	markOutboxMessageProcessedStmt *sql.Stmt
-	updateOrderStmt                *sql.Stmt
+	db                                   DBTX
+	tx                                   *sql.Tx
+	archiveProcessedOutboxMessagesStmt   *sql.Stmt
+	claimDeadLetterOutboxMessagesStmt    *sql.Stmt
+	claimOutboxMessagesStmt              *sql.Stmt
+	createOrderStmt                      *sql.Stmt
+	createOrderExchangeRateStmt          *sql.Stmt
+	createOrderItemStmt                  *sql.Stmt
+	createOrderSagaStmt                  *sql.Stmt
+	createOrderStatusTransitionStmt      *sql.Stmt
+	createOutboxArchivePartitionsStmt    *sql.Stmt
+	createOutboxMessageStmt              *sql.Stmt
+	createProcessedEventStmt             *sql.Stmt
+	deleteOrderStmt                      *sql.Stmt
+	deleteOrderItemStmt                  *sql.Stmt
+	deleteOrderItemsStmt                 *sql.Stmt
+	deleteOutboxMessageStmt              *sql.Stmt
+	deleteProcessedEventsStmt            *sql.Stmt
+	deleteProcessedOutboxMessagesStmt    *sql.Stmt
+	discardFailedOutboxMessagesStmt      *sql.Stmt
+	getOrderStmt                         *sql.Stmt
+	getOrderExchangeRatesStmt            *sql.Stmt
+	getOrderExchangeRatesByOrderIDsStmt  *sql.Stmt
+	getOrderForUpdateStmt                *sql.Stmt
+	getOrderItemsStmt                    *sql.Stmt
+	getOrderItemsByOrderIDsStmt          *sql.Stmt
+	getOrderSagaForUpdateStmt            *sql.Stmt
+	getOrderStatusHistoryStmt            *sql.Stmt
+	getOutboxMessageByIDStmt             *sql.Stmt
+	getOutboxRelayOffsetStmt             *sql.Stmt
+	getPendingOutboxMessagesStmt         *sql.Stmt
+	hasUnpublishedOutboxPredecessorStmt  *sql.Stmt
+	listFailedOutboxMessagesStmt         *sql.Stmt
+	listHeldOutboxMessagesStmt           *sql.Stmt
+	listOrdersByCreatedAtAscStmt         *sql.Stmt
+	listOrdersByCreatedAtDescStmt        *sql.Stmt
+	listOrdersByTotalPriceAscStmt        *sql.Stmt
+	listOrdersByTotalPriceDescStmt       *sql.Stmt
+	markOutboxMessageDeadLetteredStmt    *sql.Stmt
+	markOutboxMessageFailedStmt          *sql.Stmt
+	markOutboxMessageProcessedStmt       *sql.Stmt
+	notifyOutboxMessageStmt              *sql.Stmt
+	parkOutboxMessageStmt                *sql.Stmt
+	recordOutboxDeadLetterFailureStmt    *sql.Stmt
+	requeueFailedOutboxMessagesStmt      *sql.Stmt
+	saveOutboxRelayOffsetStmt            *sql.Stmt
+	scheduleOutboxRetryStmt              *sql.Stmt
+	unparkOutboxMessagesStmt             *sql.Stmt
+	updateFailedOutboxMessagePayloadStmt *sql.Stmt
+	updateOrderStmt                      *sql.Stmt
+	updateOrderSagaStmt                  *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                             tx,
-		tx:                             tx,
-		createOrderStmt:                q.createOrderStmt,
-		createOrderItemStmt:            q.createOrderItemStmt,
-		createOutboxMessageStmt:        q.createOutboxMessageStmt,
-		deleteOrderStmt:                q.deleteOrderStmt,
-		deleteOrderItemStmt:            q.deleteOrderItemStmt,
-		deleteOrderItemsStmt:           q.deleteOrderItemsStmt,
-		deleteOutboxMessageStmt:        q.deleteOutboxMessageStmt,
-		getOrderStmt:                   q.getOrderStmt,
-		getOrderItemsStmt:              q.getOrderItemsStmt,
-		getOutboxMessageByIDStmt:       q.getOutboxMessageByIDStmt,
-		getPendingOutboxMessagesStmt:   q.getPendingOutboxMessagesStmt,
-		incrementAttemptStmt:           q.incrementAttemptStmt,
-		listOrdersStmt:                 q.listOrdersStmt,
-		markOutboxMessageFailedStmt:    q.markOutboxMessageFailedStmt,
-		markOutboxMessageProcessedStmt: q.markOutboxMessageProcessedStmt,
-		updateOrderStmt:                q.updateOrderStmt,
+		db:                                   tx,
+		tx:                                   tx,
+		archiveProcessedOutboxMessagesStmt:   q.archiveProcessedOutboxMessagesStmt,
+		claimDeadLetterOutboxMessagesStmt:    q.claimDeadLetterOutboxMessagesStmt,
+		claimOutboxMessagesStmt:              q.claimOutboxMessagesStmt,
+		createOrderStmt:                      q.createOrderStmt,
+		createOrderExchangeRateStmt:          q.createOrderExchangeRateStmt,
+		createOrderItemStmt:                  q.createOrderItemStmt,
+		createOrderSagaStmt:                  q.createOrderSagaStmt,
+		createOrderStatusTransitionStmt:      q.createOrderStatusTransitionStmt,
+		createOutboxArchivePartitionsStmt:    q.createOutboxArchivePartitionsStmt,
+		createOutboxMessageStmt:              q.createOutboxMessageStmt,
+		createProcessedEventStmt:             q.createProcessedEventStmt,
+		deleteOrderStmt:                      q.deleteOrderStmt,
+		deleteOrderItemStmt:                  q.deleteOrderItemStmt,
+		deleteOrderItemsStmt:                 q.deleteOrderItemsStmt,
+		deleteOutboxMessageStmt:              q.deleteOutboxMessageStmt,
+		deleteProcessedEventsStmt:            q.deleteProcessedEventsStmt,
+		deleteProcessedOutboxMessagesStmt:    q.deleteProcessedOutboxMessagesStmt,
+		discardFailedOutboxMessagesStmt:      q.discardFailedOutboxMessagesStmt,
+		getOrderStmt:                         q.getOrderStmt,
+		getOrderExchangeRatesStmt:            q.getOrderExchangeRatesStmt,
+		getOrderExchangeRatesByOrderIDsStmt:  q.getOrderExchangeRatesByOrderIDsStmt,
+		getOrderForUpdateStmt:                q.getOrderForUpdateStmt,
+		getOrderItemsStmt:                    q.getOrderItemsStmt,
+		getOrderItemsByOrderIDsStmt:          q.getOrderItemsByOrderIDsStmt,
+		getOrderSagaForUpdateStmt:            q.getOrderSagaForUpdateStmt,
+		getOrderStatusHistoryStmt:            q.getOrderStatusHistoryStmt,
+		getOutboxMessageByIDStmt:             q.getOutboxMessageByIDStmt,
+		getOutboxRelayOffsetStmt:             q.getOutboxRelayOffsetStmt,
+		getPendingOutboxMessagesStmt:         q.getPendingOutboxMessagesStmt,
+		hasUnpublishedOutboxPredecessorStmt:  q.hasUnpublishedOutboxPredecessorStmt,
+		listFailedOutboxMessagesStmt:         q.listFailedOutboxMessagesStmt,
+		listHeldOutboxMessagesStmt:           q.listHeldOutboxMessagesStmt,
+		listOrdersByCreatedAtAscStmt:         q.listOrdersByCreatedAtAscStmt,
+		listOrdersByCreatedAtDescStmt:        q.listOrdersByCreatedAtDescStmt,
+		listOrdersByTotalPriceAscStmt:        q.listOrdersByTotalPriceAscStmt,
+		listOrdersByTotalPriceDescStmt:       q.listOrdersByTotalPriceDescStmt,
+		markOutboxMessageDeadLetteredStmt:    q.markOutboxMessageDeadLetteredStmt,
+		markOutboxMessageFailedStmt:          q.markOutboxMessageFailedStmt,
+		markOutboxMessageProcessedStmt:       q.markOutboxMessageProcessedStmt,
+		notifyOutboxMessageStmt:              q.notifyOutboxMessageStmt,
+		parkOutboxMessageStmt:                q.parkOutboxMessageStmt,
+		recordOutboxDeadLetterFailureStmt:    q.recordOutboxDeadLetterFailureStmt,
+		requeueFailedOutboxMessagesStmt:      q.requeueFailedOutboxMessagesStmt,
+		saveOutboxRelayOffsetStmt:            q.saveOutboxRelayOffsetStmt,
+		scheduleOutboxRetryStmt:              q.scheduleOutboxRetryStmt,
+		unparkOutboxMessagesStmt:             q.unparkOutboxMessagesStmt,
+		updateFailedOutboxMessagePayloadStmt: q.updateFailedOutboxMessagePayloadStmt,
+		updateOrderStmt:                      q.updateOrderStmt,
+		updateOrderSagaStmt:                  q.updateOrderSagaStmt,
 	}
 }
@@ -19,6 +19,15 @@ type Order struct {
 	TotalPrice string    `json:"total_price"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
+	Currency   string    `json:"currency"`
+}
+
+type OrderExchangeRate struct {
+	OrderID      uuid.UUID `json:"order_id"`
+	FromCurrency string    `json:"from_currency"`
+	ToCurrency   string    `json:"to_currency"`
+	Rate         string    `json:"rate"`
+	AsOf         time.Time `json:"as_of"`
 }
 
 type OrderItem struct {
@@ -27,17 +36,73 @@ type OrderItem struct {
 	ProductID string    `json:"product_id"`
 	Quantity  int32     `json:"quantity"`
 	Price     string    `json:"price"`
+	Currency  string    `json:"currency"`
+}
+
+type OrderSaga struct {
+	OrderID          uuid.UUID `json:"order_id"`
+	SagaID           uuid.UUID `json:"saga_id"`
+	ProductsReserved bool      `json:"products_reserved"`
+	PaymentApproved  bool      `json:"payment_approved"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+	Compensated      bool      `json:"compensated"`
+}
+
+type OrderStatusHistory struct {
+	ID         uuid.UUID `json:"id"`
+	OrderID    uuid.UUID `json:"order_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     string    `json:"reason"`
+	ChangedAt  time.Time `json:"changed_at"`
 }
 
 type OutboxMessage struct {
-	ID           uuid.UUID       `json:"id"`
-	AggregateID  uuid.UUID       `json:"aggregate_id"`
-	EventType    string          `json:"event_type"`
-	Payload      json.RawMessage `json:"payload"`
-	MessageID    uuid.UUID       `json:"message_id"`
-	CreatedAt    time.Time       `json:"created_at"`
-	ProcessedAt  sql.NullTime    `json:"processed_at"`
-	AttemptCount int32           `json:"attempt_count"`
-	Status       string          `json:"status"`
-	ErrorMessage sql.NullString  `json:"error_message"`
+	ID                 uuid.UUID       `json:"id"`
+	AggregateID        uuid.UUID       `json:"aggregate_id"`
+	EventType          string          `json:"event_type"`
+	Payload            json.RawMessage `json:"payload"`
+	MessageID          uuid.UUID       `json:"message_id"`
+	CreatedAt          time.Time       `json:"created_at"`
+	ProcessedAt        sql.NullTime    `json:"processed_at"`
+	AttemptCount       int32           `json:"attempt_count"`
+	Status             string          `json:"status"`
+	ErrorMessage       sql.NullString  `json:"error_message"`
+	LockedBy           sql.NullString  `json:"locked_by"`
+	LockedUntil        sql.NullTime    `json:"locked_until"`
+	NextAttemptAt      sql.NullTime    `json:"next_attempt_at"`
+	FailedAt           sql.NullTime    `json:"failed_at"`
+	DeadLetteredAt     sql.NullTime    `json:"dead_lettered_at"`
+	DeadLetterAttempts int32           `json:"dead_letter_attempts"`
+	SequenceNumber     int64           `json:"sequence_number"`
+	SchemaVersion      int32           `json:"schema_version"`
+}
+
+type OutboxMessagesArchive struct {
+	ID             uuid.UUID       `json:"id"`
+	AggregateID    uuid.UUID       `json:"aggregate_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	MessageID      uuid.UUID       `json:"message_id"`
+	CreatedAt      time.Time       `json:"created_at"`
+	ProcessedAt    time.Time       `json:"processed_at"`
+	AttemptCount   int32           `json:"attempt_count"`
+	ErrorMessage   sql.NullString  `json:"error_message"`
+	ArchivedAt     time.Time       `json:"archived_at"`
+	SequenceNumber sql.NullInt64   `json:"sequence_number"`
+	SchemaVersion  sql.NullInt32   `json:"schema_version"`
+}
+
+type OutboxRelayOffset struct {
+	SlotName  string    `json:"slot_name"`
+	Lsn       int64     `json:"lsn"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ProcessedEvent struct {
+	EventID      string    `json:"event_id"`
+	ConsumerName string    `json:"consumer_name"`
+	EventType    string    `json:"event_type"`
+	ProcessedAt  time.Time `json:"processed_at"`
 }
@@ -7,16 +7,18 @@ package sqlc
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createOrder = `-- name: CreateOrder :exec
 INSERT INTO orders (
-    id, customer_id, status, total_price, created_at, updated_at
+    id, customer_id, status, total_price, currency, created_at, updated_at
 ) VALUES (
-    $1, $2, $3, $4, $5, $6
+    $1, $2, $3, $4, $5, $6, $7
 )
 `
 
@@ -25,6 +27,7 @@ type CreateOrderParams struct {
 	CustomerID string    `json:"customer_id"`
 	Status     string    `json:"status"`
 	TotalPrice string    `json:"total_price"`
+	Currency   string    `json:"currency"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 }
@@ -36,6 +39,7 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) error
 		arg.CustomerID,
 		arg.Status,
 		arg.TotalPrice,
+		arg.Currency,
 		arg.CreatedAt,
 		arg.UpdatedAt,
 	)
@@ -44,9 +48,9 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) error
 
 const createOrderItem = `-- name: CreateOrderItem :exec
 INSERT INTO order_items (
-    id, order_id, product_id, quantity, price
+    id, order_id, product_id, quantity, price, currency
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6
 )
 `
 
@@ -56,6 +60,7 @@ type CreateOrderItemParams struct {
 	ProductID string    `json:"product_id"`
 	Quantity  int32     `json:"quantity"`
 	Price     string    `json:"price"`
+	Currency  string    `json:"currency"`
 }
 
 func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams) error {
@@ -65,6 +70,7 @@ func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams
 		arg.ProductID,
 		arg.Quantity,
 		arg.Price,
+		arg.Currency,
 	)
 	return err
 }
@@ -105,7 +111,7 @@ func (q *Queries) DeleteOrderItems(ctx context.Context, orderID uuid.UUID) error
 }
 
 const getOrder = `-- name: GetOrder :one
-SELECT id, customer_id, status, total_price, created_at, updated_at FROM orders
+SELECT id, customer_id, status, total_price, created_at, updated_at, currency FROM orders
 WHERE id = $1
 `
 
@@ -119,12 +125,35 @@ func (q *Queries) GetOrder(ctx context.Context, id uuid.UUID) (Order, error) {
 		&i.TotalPrice,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Currency,
+	)
+	return i, err
+}
+
+const getOrderForUpdate = `-- name: GetOrderForUpdate :one
+SELECT id, customer_id, status, total_price, created_at, updated_at, currency FROM orders
+WHERE id = $1
+FOR UPDATE
+`
+
+// Locks the order, so changes of the same order are applied one after the other
+func (q *Queries) GetOrderForUpdate(ctx context.Context, id uuid.UUID) (Order, error) {
+	row := q.queryRow(ctx, q.getOrderForUpdateStmt, getOrderForUpdate, id)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.CustomerID,
+		&i.Status,
+		&i.TotalPrice,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Currency,
 	)
 	return i, err
 }
 
 const getOrderItems = `-- name: GetOrderItems :many
-SELECT id, order_id, product_id, quantity, price FROM order_items
+SELECT id, order_id, product_id, quantity, price, currency FROM order_items
 WHERE order_id = $1
 `
 
@@ -143,6 +172,112 @@ func (q *Queries) GetOrderItems(ctx context.Context, orderID uuid.UUID) ([]Order
 			&i.ProductID,
 			&i.Quantity,
 			&i.Price,
+			&i.Currency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrderItemsByOrderIDs = `-- name: GetOrderItemsByOrderIDs :many
+SELECT id, order_id, product_id, quantity, price, currency FROM order_items
+WHERE order_id = ANY($1::uuid[])
+ORDER BY order_id
+`
+
+func (q *Queries) GetOrderItemsByOrderIDs(ctx context.Context, orderIds []uuid.UUID) ([]OrderItem, error) {
+	rows, err := q.query(ctx, q.getOrderItemsByOrderIDsStmt, getOrderItemsByOrderIDs, pq.Array(orderIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OrderItem{}
+	for rows.Next() {
+		var i OrderItem
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.ProductID,
+			&i.Quantity,
+			&i.Price,
+			&i.Currency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrdersByCreatedAtAsc = `-- name: ListOrdersByCreatedAtAsc :many
+SELECT id, customer_id, status, total_price, created_at, updated_at, currency FROM orders
+WHERE ($1::text IS NULL OR customer_id = $1)
+  AND (cardinality($2::text[]) = 0 OR status = ANY($2::text[]))
+  AND ($3::timestamp IS NULL OR created_at >= $3)
+  AND ($4::timestamp IS NULL OR created_at < $4)
+  AND ($5::text IS NULL OR currency = $5)
+  AND ($6::numeric IS NULL OR total_price >= $6)
+  AND ($7::numeric IS NULL OR total_price <= $7)
+  AND ($8::timestamp IS NULL OR (created_at, id) > ($8, $9::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT $10
+`
+
+type ListOrdersByCreatedAtAscParams struct {
+	CustomerID     sql.NullString `json:"customer_id"`
+	Statuses       []string       `json:"statuses"`
+	CreatedAfter   sql.NullTime   `json:"created_after"`
+	CreatedBefore  sql.NullTime   `json:"created_before"`
+	Currency       sql.NullString `json:"currency"`
+	MinTotal       sql.NullString `json:"min_total"`
+	MaxTotal       sql.NullString `json:"max_total"`
+	AfterCreatedAt sql.NullTime   `json:"after_created_at"`
+	AfterID        uuid.NullUUID  `json:"after_id"`
+	PageSize       int32          `json:"page_size"`
+}
+
+func (q *Queries) ListOrdersByCreatedAtAsc(ctx context.Context, arg ListOrdersByCreatedAtAscParams) ([]Order, error) {
+	rows, err := q.query(ctx, q.listOrdersByCreatedAtAscStmt, listOrdersByCreatedAtAsc,
+		arg.CustomerID,
+		pq.Array(arg.Statuses),
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.Currency,
+		arg.MinTotal,
+		arg.MaxTotal,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.CustomerID,
+			&i.Status,
+			&i.TotalPrice,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Currency,
 		); err != nil {
 			return nil, err
 		}
@@ -157,19 +292,184 @@ func (q *Queries) GetOrderItems(ctx context.Context, orderID uuid.UUID) ([]Order
 	return items, nil
 }
 
-const listOrders = `-- name: ListOrders :many
-SELECT id, customer_id, status, total_price, created_at, updated_at FROM orders
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2
+const listOrdersByCreatedAtDesc = `-- name: ListOrdersByCreatedAtDesc :many
+SELECT id, customer_id, status, total_price, created_at, updated_at, currency FROM orders
+WHERE ($1::text IS NULL OR customer_id = $1)
+  AND (cardinality($2::text[]) = 0 OR status = ANY($2::text[]))
+  AND ($3::timestamp IS NULL OR created_at >= $3)
+  AND ($4::timestamp IS NULL OR created_at < $4)
+  AND ($5::text IS NULL OR currency = $5)
+  AND ($6::numeric IS NULL OR total_price >= $6)
+  AND ($7::numeric IS NULL OR total_price <= $7)
+  AND ($8::timestamp IS NULL OR (created_at, id) < ($8, $9::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $10
 `
 
-type ListOrdersParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+type ListOrdersByCreatedAtDescParams struct {
+	CustomerID     sql.NullString `json:"customer_id"`
+	Statuses       []string       `json:"statuses"`
+	CreatedAfter   sql.NullTime   `json:"created_after"`
+	CreatedBefore  sql.NullTime   `json:"created_before"`
+	Currency       sql.NullString `json:"currency"`
+	MinTotal       sql.NullString `json:"min_total"`
+	MaxTotal       sql.NullString `json:"max_total"`
+	AfterCreatedAt sql.NullTime   `json:"after_created_at"`
+	AfterID        uuid.NullUUID  `json:"after_id"`
+	PageSize       int32          `json:"page_size"`
+}
+
+func (q *Queries) ListOrdersByCreatedAtDesc(ctx context.Context, arg ListOrdersByCreatedAtDescParams) ([]Order, error) {
+	rows, err := q.query(ctx, q.listOrdersByCreatedAtDescStmt, listOrdersByCreatedAtDesc,
+		arg.CustomerID,
+		pq.Array(arg.Statuses),
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.Currency,
+		arg.MinTotal,
+		arg.MaxTotal,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.CustomerID,
+			&i.Status,
+			&i.TotalPrice,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Currency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (q *Queries) ListOrders(ctx context.Context, arg ListOrdersParams) ([]Order, error) {
-	rows, err := q.query(ctx, q.listOrdersStmt, listOrders, arg.Limit, arg.Offset)
+const listOrdersByTotalPriceAsc = `-- name: ListOrdersByTotalPriceAsc :many
+SELECT id, customer_id, status, total_price, created_at, updated_at, currency FROM orders
+WHERE ($1::text IS NULL OR customer_id = $1)
+  AND (cardinality($2::text[]) = 0 OR status = ANY($2::text[]))
+  AND ($3::timestamp IS NULL OR created_at >= $3)
+  AND ($4::timestamp IS NULL OR created_at < $4)
+  AND ($5::text IS NULL OR currency = $5)
+  AND ($6::numeric IS NULL OR total_price >= $6)
+  AND ($7::numeric IS NULL OR total_price <= $7)
+  AND ($8::numeric IS NULL OR (total_price, id) > ($8, $9::uuid))
+ORDER BY total_price ASC, id ASC
+LIMIT $10
+`
+
+type ListOrdersByTotalPriceAscParams struct {
+	CustomerID      sql.NullString `json:"customer_id"`
+	Statuses        []string       `json:"statuses"`
+	CreatedAfter    sql.NullTime   `json:"created_after"`
+	CreatedBefore   sql.NullTime   `json:"created_before"`
+	Currency        sql.NullString `json:"currency"`
+	MinTotal        sql.NullString `json:"min_total"`
+	MaxTotal        sql.NullString `json:"max_total"`
+	AfterTotalPrice sql.NullString `json:"after_total_price"`
+	AfterID         uuid.NullUUID  `json:"after_id"`
+	PageSize        int32          `json:"page_size"`
+}
+
+func (q *Queries) ListOrdersByTotalPriceAsc(ctx context.Context, arg ListOrdersByTotalPriceAscParams) ([]Order, error) {
+	rows, err := q.query(ctx, q.listOrdersByTotalPriceAscStmt, listOrdersByTotalPriceAsc,
+		arg.CustomerID,
+		pq.Array(arg.Statuses),
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.Currency,
+		arg.MinTotal,
+		arg.MaxTotal,
+		arg.AfterTotalPrice,
+		arg.AfterID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.CustomerID,
+			&i.Status,
+			&i.TotalPrice,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Currency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrdersByTotalPriceDesc = `-- name: ListOrdersByTotalPriceDesc :many
+SELECT id, customer_id, status, total_price, created_at, updated_at, currency FROM orders
+WHERE ($1::text IS NULL OR customer_id = $1)
+  AND (cardinality($2::text[]) = 0 OR status = ANY($2::text[]))
+  AND ($3::timestamp IS NULL OR created_at >= $3)
+  AND ($4::timestamp IS NULL OR created_at < $4)
+  AND ($5::text IS NULL OR currency = $5)
+  AND ($6::numeric IS NULL OR total_price >= $6)
+  AND ($7::numeric IS NULL OR total_price <= $7)
+  AND ($8::numeric IS NULL OR (total_price, id) < ($8, $9::uuid))
+ORDER BY total_price DESC, id DESC
+LIMIT $10
+`
+
+type ListOrdersByTotalPriceDescParams struct {
+	CustomerID      sql.NullString `json:"customer_id"`
+	Statuses        []string       `json:"statuses"`
+	CreatedAfter    sql.NullTime   `json:"created_after"`
+	CreatedBefore   sql.NullTime   `json:"created_before"`
+	Currency        sql.NullString `json:"currency"`
+	MinTotal        sql.NullString `json:"min_total"`
+	MaxTotal        sql.NullString `json:"max_total"`
+	AfterTotalPrice sql.NullString `json:"after_total_price"`
+	AfterID         uuid.NullUUID  `json:"after_id"`
+	PageSize        int32          `json:"page_size"`
+}
+
+func (q *Queries) ListOrdersByTotalPriceDesc(ctx context.Context, arg ListOrdersByTotalPriceDescParams) ([]Order, error) {
+	rows, err := q.query(ctx, q.listOrdersByTotalPriceDescStmt, listOrdersByTotalPriceDesc,
+		arg.CustomerID,
+		pq.Array(arg.Statuses),
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.Currency,
+		arg.MinTotal,
+		arg.MaxTotal,
+		arg.AfterTotalPrice,
+		arg.AfterID,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -184,6 +484,7 @@ func (q *Queries) ListOrders(ctx context.Context, arg ListOrdersParams) ([]Order
 			&i.TotalPrice,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Currency,
 		); err != nil {
 			return nil, err
 		}
@@ -12,38 +12,252 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const archiveProcessedOutboxMessages = `-- name: ArchiveProcessedOutboxMessages :execrows
+WITH archived AS (
+    DELETE FROM outbox_messages
+    WHERE id IN (
+        SELECT id FROM outbox_messages
+        WHERE status = 'PROCESSED'
+          AND processed_at < $1::timestamp
+        ORDER BY processed_at
+        LIMIT $2
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING id, aggregate_id, event_type, payload, message_id, created_at, processed_at, attempt_count, error_message, sequence_number, schema_version
+)
+INSERT INTO outbox_messages_archive (
+    id, aggregate_id, event_type, payload, message_id, created_at, processed_at, attempt_count, error_message, sequence_number, schema_version
+)
+SELECT id, aggregate_id, event_type, payload, message_id, created_at, processed_at, attempt_count, error_message, sequence_number, schema_version
+FROM archived
+`
+
+type ArchiveProcessedOutboxMessagesParams struct {
+	Cutoff    time.Time `json:"cutoff"`
+	BatchSize int32     `json:"batch_size"`
+}
+
+// Moves a batch of messages processed before the cutoff to the archive
+func (q *Queries) ArchiveProcessedOutboxMessages(ctx context.Context, arg ArchiveProcessedOutboxMessagesParams) (int64, error) {
+	result, err := q.exec(ctx, q.archiveProcessedOutboxMessagesStmt, archiveProcessedOutboxMessages, arg.Cutoff, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const claimDeadLetterOutboxMessages = `-- name: ClaimDeadLetterOutboxMessages :many
+UPDATE outbox_messages
+SET locked_by = $1,
+    locked_until = NOW() + make_interval(secs => $2::double precision)
+WHERE id IN (
+    SELECT id
+    FROM outbox_messages
+    WHERE status = 'FAILED'
+      AND dead_lettered_at IS NULL
+      AND dead_letter_attempts < $3
+      AND (locked_until IS NULL OR locked_until < NOW())
+    ORDER BY failed_at ASC
+    LIMIT $4
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, aggregate_id, event_type, payload, message_id, created_at, processed_at, attempt_count, status, error_message, locked_by, locked_until, next_attempt_at, failed_at, dead_lettered_at, dead_letter_attempts, sequence_number, schema_version
+`
+
+type ClaimDeadLetterOutboxMessagesParams struct {
+	LockedBy     sql.NullString `json:"locked_by"`
+	LeaseSeconds float64        `json:"lease_seconds"`
+	MaxAttempts  int32          `json:"max_attempts"`
+	BatchSize    int32          `json:"batch_size"`
+}
+
+// Claims failed messages that still have to be forwarded to the dead-letter topic
+func (q *Queries) ClaimDeadLetterOutboxMessages(ctx context.Context, arg ClaimDeadLetterOutboxMessagesParams) ([]OutboxMessage, error) {
+	rows, err := q.query(ctx, q.claimDeadLetterOutboxMessagesStmt, claimDeadLetterOutboxMessages,
+		arg.LockedBy,
+		arg.LeaseSeconds,
+		arg.MaxAttempts,
+		arg.BatchSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OutboxMessage{}
+	for rows.Next() {
+		var i OutboxMessage
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateID,
+			&i.EventType,
+			&i.Payload,
+			&i.MessageID,
+			&i.CreatedAt,
+			&i.ProcessedAt,
+			&i.AttemptCount,
+			&i.Status,
+			&i.ErrorMessage,
+			&i.LockedBy,
+			&i.LockedUntil,
+			&i.NextAttemptAt,
+			&i.FailedAt,
+			&i.DeadLetteredAt,
+			&i.DeadLetterAttempts,
+			&i.SequenceNumber,
+			&i.SchemaVersion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimOutboxMessages = `-- name: ClaimOutboxMessages :many
+UPDATE outbox_messages
+SET locked_by = $1,
+    locked_until = NOW() + make_interval(secs => $2::double precision)
+WHERE id IN (
+    SELECT id
+    FROM outbox_messages candidate
+    WHERE status = 'PENDING'
+      AND (locked_until IS NULL OR locked_until < NOW())
+      AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
+      AND NOT EXISTS (
+          SELECT 1
+          FROM outbox_messages earlier
+          WHERE earlier.aggregate_id = candidate.aggregate_id
+            AND earlier.sequence_number < candidate.sequence_number
+            AND earlier.status IN ('PENDING', 'PARKED', 'FAILED')
+      )
+    ORDER BY created_at ASC
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, aggregate_id, event_type, payload, message_id, created_at, processed_at, attempt_count, status, error_message, locked_by, locked_until, next_attempt_at, failed_at, dead_lettered_at, dead_letter_attempts, sequence_number, schema_version
+`
+
+type ClaimOutboxMessagesParams struct {
+	LockedBy     sql.NullString `json:"locked_by"`
+	LeaseSeconds float64        `json:"lease_seconds"`
+	BatchSize    int32          `json:"batch_size"`
+}
+
+// Claims a batch of pending messages for one worker. Rows locked by another
+// transaction are skipped and claimed rows stay leased until locked_until, so
+// concurrent workers never receive the same message while its lease is valid.
+// A message is held back while an earlier message of its aggregate is pending, parked
+// or failed, so the messages of one aggregate are published in sequence.
+func (q *Queries) ClaimOutboxMessages(ctx context.Context, arg ClaimOutboxMessagesParams) ([]OutboxMessage, error) {
+	rows, err := q.query(ctx, q.claimOutboxMessagesStmt, claimOutboxMessages, arg.LockedBy, arg.LeaseSeconds, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OutboxMessage{}
+	for rows.Next() {
+		var i OutboxMessage
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateID,
+			&i.EventType,
+			&i.Payload,
+			&i.MessageID,
+			&i.CreatedAt,
+			&i.ProcessedAt,
+			&i.AttemptCount,
+			&i.Status,
+			&i.ErrorMessage,
+			&i.LockedBy,
+			&i.LockedUntil,
+			&i.NextAttemptAt,
+			&i.FailedAt,
+			&i.DeadLetteredAt,
+			&i.DeadLetterAttempts,
+			&i.SequenceNumber,
+			&i.SchemaVersion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createOutboxArchivePartitions = `-- name: CreateOutboxArchivePartitions :exec
+SELECT create_outbox_archive_partitions(
+    (SELECT MIN(processed_at) FROM outbox_messages WHERE status = 'PROCESSED' AND processed_at < $1::timestamp),
+    $1::timestamp
+)
+`
+
+// Creates the archive partitions for the messages processed before the cutoff
+func (q *Queries) CreateOutboxArchivePartitions(ctx context.Context, cutoff time.Time) error {
+	_, err := q.exec(ctx, q.createOutboxArchivePartitionsStmt, createOutboxArchivePartitions, cutoff)
+	return err
+}
+
 const createOutboxMessage = `-- name: CreateOutboxMessage :exec
+WITH next_sequence AS (
+    INSERT INTO outbox_aggregate_sequences (aggregate_id, last_sequence)
+    VALUES ($2, 1)
+    ON CONFLICT (aggregate_id) DO UPDATE
+    SET last_sequence = outbox_aggregate_sequences.last_sequence + 1
+    RETURNING last_sequence
+)
 INSERT INTO outbox_messages (
     id,
     aggregate_id,
     event_type,
     payload,
+    message_id,
     created_at,
-    status
-) VALUES (
-    $1, $2, $3, $4, $5, $6
+    status,
+    schema_version,
+    sequence_number
 )
+SELECT $1::uuid, $2::uuid, $3::text, $4::jsonb, $5::uuid, $6::timestamp, $7::text, $8::int, last_sequence
+FROM next_sequence
 `
 
 type CreateOutboxMessageParams struct {
-	ID          uuid.UUID       `json:"id"`
-	AggregateID uuid.UUID       `json:"aggregate_id"`
-	EventType   string          `json:"event_type"`
-	Payload     json.RawMessage `json:"payload"`
-	CreatedAt   time.Time       `json:"created_at"`
-	Status      string          `json:"status"`
+	ID            uuid.UUID       `json:"id"`
+	AggregateID   uuid.UUID       `json:"aggregate_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	MessageID     uuid.UUID       `json:"message_id"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Status        string          `json:"status"`
+	SchemaVersion int32           `json:"schema_version"`
 }
 
+// Numbers the message after the previous message of its aggregate. The counter row
+// stays locked until the transaction ends, so concurrent writers get consecutive numbers.
 func (q *Queries) CreateOutboxMessage(ctx context.Context, arg CreateOutboxMessageParams) error {
 	_, err := q.exec(ctx, q.createOutboxMessageStmt, createOutboxMessage,
 		arg.ID,
 		arg.AggregateID,
 		arg.EventType,
 		arg.Payload,
+		arg.MessageID,
 		arg.CreatedAt,
 		arg.Status,
+		arg.SchemaVersion,
 	)
 	return err
 }
@@ -58,8 +272,67 @@ func (q *Queries) DeleteOutboxMessage(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deleteProcessedOutboxMessages = `-- name: DeleteProcessedOutboxMessages :execrows
+DELETE FROM outbox_messages
+WHERE id IN (
+    SELECT id FROM outbox_messages
+    WHERE status = 'PROCESSED'
+      AND processed_at < $1::timestamp
+    ORDER BY processed_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+`
+
+type DeleteProcessedOutboxMessagesParams struct {
+	Cutoff    time.Time `json:"cutoff"`
+	BatchSize int32     `json:"batch_size"`
+}
+
+// Deletes a batch of messages processed before the cutoff without archiving them
+func (q *Queries) DeleteProcessedOutboxMessages(ctx context.Context, arg DeleteProcessedOutboxMessagesParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteProcessedOutboxMessagesStmt, deleteProcessedOutboxMessages, arg.Cutoff, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const discardFailedOutboxMessages = `-- name: DiscardFailedOutboxMessages :execrows
+UPDATE outbox_messages
+SET status = 'DISCARDED',
+    locked_by = NULL,
+    locked_until = NULL
+WHERE status = 'FAILED'
+  AND (cardinality($1::uuid[]) = 0 OR id = ANY($1::uuid[]))
+  AND ($2::text IS NULL OR event_type = $2)
+  AND ($3::timestamp IS NULL OR failed_at >= $3)
+  AND ($4::timestamp IS NULL OR failed_at < $4)
+`
+
+type DiscardFailedOutboxMessagesParams struct {
+	Ids          []uuid.UUID    `json:"ids"`
+	EventType    sql.NullString `json:"event_type"`
+	FailedAfter  sql.NullTime   `json:"failed_after"`
+	FailedBefore sql.NullTime   `json:"failed_before"`
+}
+
+// Gives up on failed messages, they stay in the table for reference
+func (q *Queries) DiscardFailedOutboxMessages(ctx context.Context, arg DiscardFailedOutboxMessagesParams) (int64, error) {
+	result, err := q.exec(ctx, q.discardFailedOutboxMessagesStmt, discardFailedOutboxMessages,
+		pq.Array(arg.Ids),
+		arg.EventType,
+		arg.FailedAfter,
+		arg.FailedBefore,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getOutboxMessageByID = `-- name: GetOutboxMessageByID :one
-SELECT id, aggregate_id, event_type, payload, message_id, created_at, processed_at, attempt_count, status, error_message
+SELECT id, aggregate_id, event_type, payload, message_id, created_at, processed_at, attempt_count, status, error_message, locked_by, locked_until, next_attempt_at, failed_at, dead_lettered_at, dead_letter_attempts, sequence_number, schema_version
 FROM outbox_messages
 WHERE id = $1
 `
@@ -78,14 +351,23 @@ func (q *Queries) GetOutboxMessageByID(ctx context.Context, id uuid.UUID) (Outbo
 		&i.AttemptCount,
 		&i.Status,
 		&i.ErrorMessage,
+		&i.LockedBy,
+		&i.LockedUntil,
+		&i.NextAttemptAt,
+		&i.FailedAt,
+		&i.DeadLetteredAt,
+		&i.DeadLetterAttempts,
+		&i.SequenceNumber,
+		&i.SchemaVersion,
 	)
 	return i, err
 }
 
 const getPendingOutboxMessages = `-- name: GetPendingOutboxMessages :many
-SELECT id, aggregate_id, event_type, payload, message_id, created_at, processed_at, attempt_count, status, error_message
+SELECT id, aggregate_id, event_type, payload, message_id, created_at, processed_at, attempt_count, status, error_message, locked_by, locked_until, next_attempt_at, failed_at, dead_lettered_at, dead_letter_attempts, sequence_number, schema_version
 FROM outbox_messages
-WHERE status = 'pending'
+WHERE status = 'PENDING'
+  AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
 ORDER BY created_at ASC
 LIMIT $1
 `
@@ -110,6 +392,162 @@ func (q *Queries) GetPendingOutboxMessages(ctx context.Context, limit int32) ([]
 			&i.AttemptCount,
 			&i.Status,
 			&i.ErrorMessage,
+			&i.LockedBy,
+			&i.LockedUntil,
+			&i.NextAttemptAt,
+			&i.FailedAt,
+			&i.DeadLetteredAt,
+			&i.DeadLetterAttempts,
+			&i.SequenceNumber,
+			&i.SchemaVersion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hasUnpublishedOutboxPredecessor = `-- name: HasUnpublishedOutboxPredecessor :one
+SELECT EXISTS (
+    SELECT 1
+    FROM outbox_messages
+    WHERE aggregate_id = $1
+      AND sequence_number < $2
+      AND status IN ('PENDING', 'PARKED', 'FAILED')
+)
+`
+
+type HasUnpublishedOutboxPredecessorParams struct {
+	AggregateID    uuid.UUID `json:"aggregate_id"`
+	SequenceNumber int64     `json:"sequence_number"`
+}
+
+// Reports whether an earlier message of the aggregate is still pending, parked or failed
+func (q *Queries) HasUnpublishedOutboxPredecessor(ctx context.Context, arg HasUnpublishedOutboxPredecessorParams) (bool, error) {
+	row := q.queryRow(ctx, q.hasUnpublishedOutboxPredecessorStmt, hasUnpublishedOutboxPredecessor, arg.AggregateID, arg.SequenceNumber)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listFailedOutboxMessages = `-- name: ListFailedOutboxMessages :many
+SELECT id, aggregate_id, event_type, payload, message_id, created_at, processed_at, attempt_count, status, error_message, locked_by, locked_until, next_attempt_at, failed_at, dead_lettered_at, dead_letter_attempts, sequence_number, schema_version
+FROM outbox_messages
+WHERE status = 'FAILED'
+  AND (cardinality($1::uuid[]) = 0 OR id = ANY($1::uuid[]))
+  AND ($2::text IS NULL OR event_type = $2)
+  AND ($3::timestamp IS NULL OR failed_at >= $3)
+  AND ($4::timestamp IS NULL OR failed_at < $4)
+ORDER BY failed_at DESC, id DESC
+LIMIT $5
+`
+
+type ListFailedOutboxMessagesParams struct {
+	Ids          []uuid.UUID    `json:"ids"`
+	EventType    sql.NullString `json:"event_type"`
+	FailedAfter  sql.NullTime   `json:"failed_after"`
+	FailedBefore sql.NullTime   `json:"failed_before"`
+	PageSize     int32          `json:"page_size"`
+}
+
+func (q *Queries) ListFailedOutboxMessages(ctx context.Context, arg ListFailedOutboxMessagesParams) ([]OutboxMessage, error) {
+	rows, err := q.query(ctx, q.listFailedOutboxMessagesStmt, listFailedOutboxMessages,
+		pq.Array(arg.Ids),
+		arg.EventType,
+		arg.FailedAfter,
+		arg.FailedBefore,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OutboxMessage{}
+	for rows.Next() {
+		var i OutboxMessage
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateID,
+			&i.EventType,
+			&i.Payload,
+			&i.MessageID,
+			&i.CreatedAt,
+			&i.ProcessedAt,
+			&i.AttemptCount,
+			&i.Status,
+			&i.ErrorMessage,
+			&i.LockedBy,
+			&i.LockedUntil,
+			&i.NextAttemptAt,
+			&i.FailedAt,
+			&i.DeadLetteredAt,
+			&i.DeadLetterAttempts,
+			&i.SequenceNumber,
+			&i.SchemaVersion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHeldOutboxMessages = `-- name: ListHeldOutboxMessages :many
+SELECT id, aggregate_id, event_type, payload, message_id, created_at, processed_at, attempt_count, status, error_message, locked_by, locked_until, next_attempt_at, failed_at, dead_lettered_at, dead_letter_attempts, sequence_number, schema_version
+FROM outbox_messages
+WHERE aggregate_id = $1
+  AND sequence_number > $2
+  AND status = 'PENDING'
+ORDER BY sequence_number ASC
+`
+
+type ListHeldOutboxMessagesParams struct {
+	AggregateID    uuid.UUID `json:"aggregate_id"`
+	SequenceNumber int64     `json:"sequence_number"`
+}
+
+// Lists the pending messages of the aggregate after the sequence number, in sequence
+func (q *Queries) ListHeldOutboxMessages(ctx context.Context, arg ListHeldOutboxMessagesParams) ([]OutboxMessage, error) {
+	rows, err := q.query(ctx, q.listHeldOutboxMessagesStmt, listHeldOutboxMessages, arg.AggregateID, arg.SequenceNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OutboxMessage{}
+	for rows.Next() {
+		var i OutboxMessage
+		if err := rows.Scan(
+			&i.ID,
+			&i.AggregateID,
+			&i.EventType,
+			&i.Payload,
+			&i.MessageID,
+			&i.CreatedAt,
+			&i.ProcessedAt,
+			&i.AttemptCount,
+			&i.Status,
+			&i.ErrorMessage,
+			&i.LockedBy,
+			&i.LockedUntil,
+			&i.NextAttemptAt,
+			&i.FailedAt,
+			&i.DeadLetteredAt,
+			&i.DeadLetterAttempts,
+			&i.SequenceNumber,
+			&i.SchemaVersion,
 		); err != nil {
 			return nil, err
 		}
@@ -124,22 +562,28 @@ func (q *Queries) GetPendingOutboxMessages(ctx context.Context, limit int32) ([]
 	return items, nil
 }
 
-const incrementAttempt = `-- name: IncrementAttempt :exec
+const markOutboxMessageDeadLettered = `-- name: MarkOutboxMessageDeadLettered :exec
 UPDATE outbox_messages
-SET attempt_count = attempt_count + 1
+SET dead_lettered_at = NOW(),
+    locked_by = NULL,
+    locked_until = NULL
 WHERE id = $1
 `
 
-func (q *Queries) IncrementAttempt(ctx context.Context, id uuid.UUID) error {
-	_, err := q.exec(ctx, q.incrementAttemptStmt, incrementAttempt, id)
+func (q *Queries) MarkOutboxMessageDeadLettered(ctx context.Context, id uuid.UUID) error {
+	_, err := q.exec(ctx, q.markOutboxMessageDeadLetteredStmt, markOutboxMessageDeadLettered, id)
 	return err
 }
 
 const markOutboxMessageFailed = `-- name: MarkOutboxMessageFailed :exec
 UPDATE outbox_messages
-SET status = 'failed',
+SET status = 'FAILED',
     attempt_count = attempt_count + 1,
-    error_message = $2
+    error_message = $2,
+    failed_at = NOW(),
+    next_attempt_at = NULL,
+    locked_by = NULL,
+    locked_until = NULL
 WHERE id = $1
 `
 
@@ -155,8 +599,10 @@ func (q *Queries) MarkOutboxMessageFailed(ctx context.Context, arg MarkOutboxMes
 
 const markOutboxMessageProcessed = `-- name: MarkOutboxMessageProcessed :exec
 UPDATE outbox_messages
-SET status = 'processed',
-    processed_at = $2
+SET status = 'PROCESSED',
+    processed_at = $2,
+    locked_by = NULL,
+    locked_until = NULL
 WHERE id = $1
 `
 
@@ -169,3 +615,149 @@ func (q *Queries) MarkOutboxMessageProcessed(ctx context.Context, arg MarkOutbox
 	_, err := q.exec(ctx, q.markOutboxMessageProcessedStmt, markOutboxMessageProcessed, arg.ID, arg.ProcessedAt)
 	return err
 }
+
+const notifyOutboxMessage = `-- name: NotifyOutboxMessage :exec
+SELECT pg_notify($1::text, $2::text)
+`
+
+type NotifyOutboxMessageParams struct {
+	Channel string `json:"channel"`
+	Payload string `json:"payload"`
+}
+
+// Wakes listening workers once the surrounding transaction commits
+func (q *Queries) NotifyOutboxMessage(ctx context.Context, arg NotifyOutboxMessageParams) error {
+	_, err := q.exec(ctx, q.notifyOutboxMessageStmt, notifyOutboxMessage, arg.Channel, arg.Payload)
+	return err
+}
+
+const parkOutboxMessage = `-- name: ParkOutboxMessage :exec
+UPDATE outbox_messages
+SET status = 'PARKED',
+    error_message = $2,
+    locked_by = NULL,
+    locked_until = NULL
+WHERE id = $1
+`
+
+type ParkOutboxMessageParams struct {
+	ID           uuid.UUID      `json:"id"`
+	ErrorMessage sql.NullString `json:"error_message"`
+}
+
+// Sets aside a message of an event type this service cannot publish
+func (q *Queries) ParkOutboxMessage(ctx context.Context, arg ParkOutboxMessageParams) error {
+	_, err := q.exec(ctx, q.parkOutboxMessageStmt, parkOutboxMessage, arg.ID, arg.ErrorMessage)
+	return err
+}
+
+const recordOutboxDeadLetterFailure = `-- name: RecordOutboxDeadLetterFailure :exec
+UPDATE outbox_messages
+SET dead_letter_attempts = dead_letter_attempts + 1,
+    locked_by = NULL,
+    locked_until = NULL
+WHERE id = $1
+`
+
+func (q *Queries) RecordOutboxDeadLetterFailure(ctx context.Context, id uuid.UUID) error {
+	_, err := q.exec(ctx, q.recordOutboxDeadLetterFailureStmt, recordOutboxDeadLetterFailure, id)
+	return err
+}
+
+const requeueFailedOutboxMessages = `-- name: RequeueFailedOutboxMessages :execrows
+UPDATE outbox_messages
+SET status = 'PENDING',
+    attempt_count = 0,
+    next_attempt_at = NULL,
+    failed_at = NULL,
+    dead_lettered_at = NULL,
+    dead_letter_attempts = 0,
+    locked_by = NULL,
+    locked_until = NULL
+WHERE status = 'FAILED'
+  AND (cardinality($1::uuid[]) = 0 OR id = ANY($1::uuid[]))
+  AND ($2::text IS NULL OR event_type = $2)
+  AND ($3::timestamp IS NULL OR failed_at >= $3)
+  AND ($4::timestamp IS NULL OR failed_at < $4)
+`
+
+type RequeueFailedOutboxMessagesParams struct {
+	Ids          []uuid.UUID    `json:"ids"`
+	EventType    sql.NullString `json:"event_type"`
+	FailedAfter  sql.NullTime   `json:"failed_after"`
+	FailedBefore sql.NullTime   `json:"failed_before"`
+}
+
+// Moves failed messages back to PENDING with a fresh set of attempts
+func (q *Queries) RequeueFailedOutboxMessages(ctx context.Context, arg RequeueFailedOutboxMessagesParams) (int64, error) {
+	result, err := q.exec(ctx, q.requeueFailedOutboxMessagesStmt, requeueFailedOutboxMessages,
+		pq.Array(arg.Ids),
+		arg.EventType,
+		arg.FailedAfter,
+		arg.FailedBefore,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const scheduleOutboxRetry = `-- name: ScheduleOutboxRetry :exec
+UPDATE outbox_messages
+SET attempt_count = attempt_count + 1,
+    error_message = $1,
+    next_attempt_at = NOW() + make_interval(secs => $2::double precision),
+    locked_by = NULL,
+    locked_until = NULL
+WHERE id = $3
+`
+
+type ScheduleOutboxRetryParams struct {
+	ErrorMessage sql.NullString `json:"error_message"`
+	DelaySeconds float64        `json:"delay_seconds"`
+	ID           uuid.UUID      `json:"id"`
+}
+
+// Records a failed attempt and releases the message until the retry is due
+func (q *Queries) ScheduleOutboxRetry(ctx context.Context, arg ScheduleOutboxRetryParams) error {
+	_, err := q.exec(ctx, q.scheduleOutboxRetryStmt, scheduleOutboxRetry, arg.ErrorMessage, arg.DelaySeconds, arg.ID)
+	return err
+}
+
+const unparkOutboxMessages = `-- name: UnparkOutboxMessages :execrows
+UPDATE outbox_messages
+SET status = 'PENDING'
+WHERE status = 'PARKED'
+  AND event_type = ANY($1::text[])
+`
+
+// Returns parked messages of event types that can be published again
+func (q *Queries) UnparkOutboxMessages(ctx context.Context, eventTypes []string) (int64, error) {
+	result, err := q.exec(ctx, q.unparkOutboxMessagesStmt, unparkOutboxMessages, pq.Array(eventTypes))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateFailedOutboxMessagePayload = `-- name: UpdateFailedOutboxMessagePayload :execrows
+UPDATE outbox_messages
+SET payload = $2,
+    schema_version = $3
+WHERE id = $1 AND status = 'FAILED'
+`
+
+type UpdateFailedOutboxMessagePayloadParams struct {
+	ID            uuid.UUID       `json:"id"`
+	Payload       json.RawMessage `json:"payload"`
+	SchemaVersion int32           `json:"schema_version"`
+}
+
+// The new payload is written with the schema version given, the current one of its event type
+func (q *Queries) UpdateFailedOutboxMessagePayload(ctx context.Context, arg UpdateFailedOutboxMessagePayloadParams) (int64, error) {
+	result, err := q.exec(ctx, q.updateFailedOutboxMessagePayloadStmt, updateFailedOutboxMessagePayload, arg.ID, arg.Payload, arg.SchemaVersion)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -6,28 +6,85 @@ package sqlc
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Querier interface {
+	// Moves a batch of messages processed before the cutoff to the archive
+	ArchiveProcessedOutboxMessages(ctx context.Context, arg ArchiveProcessedOutboxMessagesParams) (int64, error)
+	// Claims failed messages that still have to be forwarded to the dead-letter topic
+	ClaimDeadLetterOutboxMessages(ctx context.Context, arg ClaimDeadLetterOutboxMessagesParams) ([]OutboxMessage, error)
+	// Claims a batch of pending messages for one worker. Rows locked by another
+	// transaction are skipped and claimed rows stay leased until locked_until, so
+	// concurrent workers never receive the same message while its lease is valid.
+	// A message is held back while an earlier message of its aggregate is pending, parked
+	// or failed, so the messages of one aggregate are published in sequence.
+	ClaimOutboxMessages(ctx context.Context, arg ClaimOutboxMessagesParams) ([]OutboxMessage, error)
 	// db/queries.sql
 	CreateOrder(ctx context.Context, arg CreateOrderParams) error
+	CreateOrderExchangeRate(ctx context.Context, arg CreateOrderExchangeRateParams) error
 	CreateOrderItem(ctx context.Context, arg CreateOrderItemParams) error
+	CreateOrderSaga(ctx context.Context, arg CreateOrderSagaParams) error
+	CreateOrderStatusTransition(ctx context.Context, arg CreateOrderStatusTransitionParams) error
+	// Creates the archive partitions for the messages processed before the cutoff
+	CreateOutboxArchivePartitions(ctx context.Context, cutoff time.Time) error
+	// Numbers the message after the previous message of its aggregate. The counter row
+	// stays locked until the transaction ends, so concurrent writers get consecutive numbers.
 	CreateOutboxMessage(ctx context.Context, arg CreateOutboxMessageParams) error
+	// Records the event as processed by the consumer, no row is inserted when it was before
+	CreateProcessedEvent(ctx context.Context, arg CreateProcessedEventParams) (int64, error)
 	DeleteOrder(ctx context.Context, id uuid.UUID) error
 	DeleteOrderItem(ctx context.Context, arg DeleteOrderItemParams) error
 	DeleteOrderItems(ctx context.Context, orderID uuid.UUID) error
 	DeleteOutboxMessage(ctx context.Context, id uuid.UUID) error
+	// Deletes a batch of events processed before the cutoff
+	DeleteProcessedEvents(ctx context.Context, arg DeleteProcessedEventsParams) (int64, error)
+	// Deletes a batch of messages processed before the cutoff without archiving them
+	DeleteProcessedOutboxMessages(ctx context.Context, arg DeleteProcessedOutboxMessagesParams) (int64, error)
+	// Gives up on failed messages, they stay in the table for reference
+	DiscardFailedOutboxMessages(ctx context.Context, arg DiscardFailedOutboxMessagesParams) (int64, error)
 	GetOrder(ctx context.Context, id uuid.UUID) (Order, error)
+	GetOrderExchangeRates(ctx context.Context, orderID uuid.UUID) ([]OrderExchangeRate, error)
+	GetOrderExchangeRatesByOrderIDs(ctx context.Context, orderIds []uuid.UUID) ([]OrderExchangeRate, error)
+	// Locks the order, so changes of the same order are applied one after the other
+	GetOrderForUpdate(ctx context.Context, id uuid.UUID) (Order, error)
 	GetOrderItems(ctx context.Context, orderID uuid.UUID) ([]OrderItem, error)
+	GetOrderItemsByOrderIDs(ctx context.Context, orderIds []uuid.UUID) ([]OrderItem, error)
+	// Locks the saga, so outcomes of the same order are applied one after the other
+	GetOrderSagaForUpdate(ctx context.Context, orderID uuid.UUID) (OrderSaga, error)
+	GetOrderStatusHistory(ctx context.Context, orderID uuid.UUID) ([]OrderStatusHistory, error)
 	GetOutboxMessageByID(ctx context.Context, id uuid.UUID) (OutboxMessage, error)
+	GetOutboxRelayOffset(ctx context.Context, slotName string) (int64, error)
 	GetPendingOutboxMessages(ctx context.Context, limit int32) ([]OutboxMessage, error)
-	IncrementAttempt(ctx context.Context, id uuid.UUID) error
-	ListOrders(ctx context.Context, arg ListOrdersParams) ([]Order, error)
+	// Reports whether an earlier message of the aggregate is still pending, parked or failed
+	HasUnpublishedOutboxPredecessor(ctx context.Context, arg HasUnpublishedOutboxPredecessorParams) (bool, error)
+	ListFailedOutboxMessages(ctx context.Context, arg ListFailedOutboxMessagesParams) ([]OutboxMessage, error)
+	// Lists the pending messages of the aggregate after the sequence number, in sequence
+	ListHeldOutboxMessages(ctx context.Context, arg ListHeldOutboxMessagesParams) ([]OutboxMessage, error)
+	ListOrdersByCreatedAtAsc(ctx context.Context, arg ListOrdersByCreatedAtAscParams) ([]Order, error)
+	ListOrdersByCreatedAtDesc(ctx context.Context, arg ListOrdersByCreatedAtDescParams) ([]Order, error)
+	ListOrdersByTotalPriceAsc(ctx context.Context, arg ListOrdersByTotalPriceAscParams) ([]Order, error)
+	ListOrdersByTotalPriceDesc(ctx context.Context, arg ListOrdersByTotalPriceDescParams) ([]Order, error)
+	MarkOutboxMessageDeadLettered(ctx context.Context, id uuid.UUID) error
 	MarkOutboxMessageFailed(ctx context.Context, arg MarkOutboxMessageFailedParams) error
 	MarkOutboxMessageProcessed(ctx context.Context, arg MarkOutboxMessageProcessedParams) error
+	// Wakes listening workers once the surrounding transaction commits
+	NotifyOutboxMessage(ctx context.Context, arg NotifyOutboxMessageParams) error
+	// Sets aside a message of an event type this service cannot publish
+	ParkOutboxMessage(ctx context.Context, arg ParkOutboxMessageParams) error
+	RecordOutboxDeadLetterFailure(ctx context.Context, id uuid.UUID) error
+	// Moves failed messages back to PENDING with a fresh set of attempts
+	RequeueFailedOutboxMessages(ctx context.Context, arg RequeueFailedOutboxMessagesParams) (int64, error)
+	SaveOutboxRelayOffset(ctx context.Context, arg SaveOutboxRelayOffsetParams) error
+	// Records a failed attempt and releases the message until the retry is due
+	ScheduleOutboxRetry(ctx context.Context, arg ScheduleOutboxRetryParams) error
+	// Returns parked messages of event types that can be published again
+	UnparkOutboxMessages(ctx context.Context, eventTypes []string) (int64, error)
+	UpdateFailedOutboxMessagePayload(ctx context.Context, arg UpdateFailedOutboxMessagePayloadParams) (int64, error)
 	UpdateOrder(ctx context.Context, arg UpdateOrderParams) error
+	UpdateOrderSaga(ctx context.Context, arg UpdateOrderSagaParams) error
 }
 
 var _ Querier = (*Queries)(nil)
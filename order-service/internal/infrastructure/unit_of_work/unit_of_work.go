@@ -6,17 +6,26 @@ import (
 	"fmt"
 	"log"
 	"order-service/internal/app/ports"
+	"order-service/internal/infrastructure/repository"
 )
 
 type SQLUnitOfWork struct {
-	db                 *sql.DB
-	repositoryCreators map[string]func(*sql.Tx) interface{}
+	db            *sql.DB
+	notifyChannel string
 }
 
 func NewSQLUnitOfWork(db *sql.DB) *SQLUnitOfWork {
 	return &SQLUnitOfWork{
-		db:                 db,
-		repositoryCreators: make(map[string]func(*sql.Tx) interface{}),
+		db: db,
+	}
+}
+
+// NewNotifyingSQLUnitOfWork creates a unit of work whose outbox messages are announced
+// on the Postgres notification channel when the transaction commits
+func NewNotifyingSQLUnitOfWork(db *sql.DB, channel string) *SQLUnitOfWork {
+	return &SQLUnitOfWork{
+		db:            db,
+		notifyChannel: channel,
 	}
 }
 
@@ -31,17 +40,16 @@ func (uow *SQLUnitOfWork) Execute(ctx context.Context, fn func(factory ports.Rep
 	// Ensure transaction is eventually rolled back or committed
 	defer func() {
 		if tx != nil {
-			err = tx.Rollback()
-			if err != nil {
-				log.Printf("rollback error: %v", err)
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("rollback error: %v", rbErr)
 			}
 		}
 	}()
 
-	factory := &SQL
+	factory := &SQLRepositoryFactory{tx: tx, notifyChannel: uow.notifyChannel}
 
 	// Execute the function within the transaction
-	if err = fn(f); err != nil {
+	if err = fn(factory); err != nil {
 		return err
 	}
 
@@ -56,7 +64,33 @@ func (uow *SQLUnitOfWork) Execute(ctx context.Context, fn func(factory ports.Rep
 
 }
 
+// SQLRepositoryFactory creates repositories that share a single transaction
 type SQLRepositoryFactory struct {
-	tx                 *sql.Tx
-	repositoryCreators map[string]func(*sql.Tx) interface{}
+	tx            *sql.Tx
+	notifyChannel string
+}
+
+// OrderRepository implements ports.RepositoryFactory.
+func (f *SQLRepositoryFactory) OrderRepository() ports.OrderRepository {
+	return repository.OrderRepositoryWithTx(f.tx)
+}
+
+// OutboxRepository implements ports.RepositoryFactory.
+func (f *SQLRepositoryFactory) OutboxRepository() ports.OutboxRepository {
+	if f.notifyChannel != "" {
+		return repository.NewNotifyingOutboxRepositoryWithTx(f.tx, f.notifyChannel)
+	}
+	return repository.NewOutboxRepositoryWithTx(f.tx)
+}
+
+// InboxRepository implements ports.RepositoryFactory.
+func (f *SQLRepositoryFactory) InboxRepository() ports.InboxRepository {
+	return repository.NewInboxRepositoryWithTx(f.tx)
 }
+
+// OrderSagaRepository implements ports.RepositoryFactory.
+func (f *SQLRepositoryFactory) OrderSagaRepository() ports.OrderSagaRepository {
+	return repository.NewOrderSagaRepositoryWithTx(f.tx)
+}
+
+var _ ports.UnitOfWork = (*SQLUnitOfWork)(nil)
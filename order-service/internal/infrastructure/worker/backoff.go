@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff computes exponentially growing retry delays with random jitter
+type Backoff struct {
+	// Initial is the delay before the first retry
+	Initial time.Duration
+	// Max caps the delay of later retries
+	Max time.Duration
+	// Multiplier is the growth factor between consecutive retries
+	Multiplier float64
+	// Jitter is the fraction of the delay that is randomized, between 0 and 1.
+	// With 0.2 a delay of 10s becomes anything between 8s and 12s.
+	Jitter float64
+}
+
+// DefaultBackoff is used for settings that are not configured
+var DefaultBackoff = Backoff{
+	Initial:    time.Second,
+	Max:        5 * time.Minute,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// withDefaults fills in unset fields from DefaultBackoff
+func (b Backoff) withDefaults() Backoff {
+	if b.Initial <= 0 {
+		b.Initial = DefaultBackoff.Initial
+	}
+	if b.Max <= 0 {
+		b.Max = DefaultBackoff.Max
+	}
+	if b.Max < b.Initial {
+		b.Max = b.Initial
+	}
+	if b.Multiplier < 1 {
+		b.Multiplier = DefaultBackoff.Multiplier
+	}
+	if b.Jitter < 0 || b.Jitter > 1 {
+		b.Jitter = DefaultBackoff.Jitter
+	}
+	return b
+}
+
+// Delay returns the delay before the given retry, starting at 1 for the first retry
+func (b Backoff) Delay(retry int) time.Duration {
+	if retry < 1 {
+		retry = 1
+	}
+
+	delay := float64(b.Initial) * math.Pow(b.Multiplier, float64(retry-1))
+	if delay > float64(b.Max) || math.IsInf(delay, 0) {
+		delay = float64(b.Max)
+	}
+
+	// Spread retries of messages that failed together, e.g. during a broker outage
+	if b.Jitter > 0 {
+		delay += delay * b.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(delay)
+}
@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"order-service/internal/app/ports"
+	"order-service/internal/infrastructure/config"
+	"time"
+)
+
+// InboxRetentionJob forgets the events processed by consumers once they are older than
+// the retention period, when they can no longer be redelivered. Events are deleted in
+// bounded batches so no statement holds locks for long.
+type InboxRetentionJob struct {
+	repo      ports.InboxRetentionRepository
+	period    time.Duration
+	interval  time.Duration
+	batchSize int
+}
+
+// NewInboxRetentionJob creates a retention job. It does nothing when cfg.RetentionPeriod is zero.
+func NewInboxRetentionJob(repo ports.InboxRetentionRepository, cfg config.InboxConfig) *InboxRetentionJob {
+	if cfg.RetentionInterval <= 0 {
+		cfg.RetentionInterval = time.Hour
+	}
+	if cfg.RetentionBatchSize <= 0 {
+		cfg.RetentionBatchSize = 1000
+	}
+
+	return &InboxRetentionJob{
+		repo:      repo,
+		period:    cfg.RetentionPeriod,
+		interval:  cfg.RetentionInterval,
+		batchSize: cfg.RetentionBatchSize,
+	}
+}
+
+// Start deletes old events right away and then every interval until the context is cancelled
+func (j *InboxRetentionJob) Start(ctx context.Context) error {
+	if j.period <= 0 {
+		log.Println("Inbox retention disabled, processed events are kept")
+		return nil
+	}
+
+	log.Printf("Starting inbox retention job, deleting events processed more than %s ago", j.period)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := j.RunOnce(ctx)
+		if err != nil {
+			log.Printf("Error deleting processed inbox events: %v", err)
+		}
+		if report.Deleted > 0 {
+			log.Printf("Inbox retention: deleted %d events processed before %s in %d batches",
+				report.Deleted, report.Cutoff.Format(time.RFC3339), report.Batches)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Inbox retention job stopping due to context cancellation")
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce deletes the events processed before the retention period, batch by batch,
+// until none are left. The report counts what was deleted, also when an error stopped the run.
+func (j *InboxRetentionJob) RunOnce(ctx context.Context) (RetentionReport, error) {
+	report := RetentionReport{Cutoff: time.Now().Add(-j.period)}
+
+	for ctx.Err() == nil {
+		deleted, err := j.repo.DeleteProcessedEvents(ctx, report.Cutoff, j.batchSize)
+		report.Deleted += deleted
+		if err != nil {
+			return report, fmt.Errorf("failed to delete processed events: %w", err)
+		}
+
+		if deleted > 0 {
+			report.Batches++
+		}
+
+		// A partial batch means no old events are left
+		if deleted < int64(j.batchSize) {
+			break
+		}
+	}
+
+	return report, ctx.Err()
+}
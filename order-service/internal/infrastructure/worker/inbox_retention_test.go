@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"order-service/internal/infrastructure/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockInboxRetentionRepo struct {
+	mock.Mock
+}
+
+func (m *mockInboxRetentionRepo) DeleteProcessedEvents(ctx context.Context, before time.Time, limit int) (int64, error) {
+	args := m.Called(ctx, before, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestInboxRetentionDeletesInBatches(t *testing.T) {
+	repo := new(mockInboxRetentionRepo)
+	job := NewInboxRetentionJob(repo, config.InboxConfig{
+		RetentionPeriod:    7 * 24 * time.Hour,
+		RetentionBatchSize: 100,
+	})
+
+	// Every batch uses the same cutoff, one retention period ago
+	cutoff := mock.MatchedBy(func(before time.Time) bool {
+		age := time.Since(before)
+		return age >= 7*24*time.Hour && age < 7*24*time.Hour+time.Hour
+	})
+	repo.On("DeleteProcessedEvents", mock.Anything, cutoff, 100).Return(int64(100), nil).Once()
+	repo.On("DeleteProcessedEvents", mock.Anything, cutoff, 100).Return(int64(7), nil).Once()
+
+	report, err := job.RunOnce(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(107), report.Deleted)
+	assert.Equal(t, 2, report.Batches)
+	repo.AssertExpectations(t)
+}
+
+func TestInboxRetentionStopsOnError(t *testing.T) {
+	repo := new(mockInboxRetentionRepo)
+	job := NewInboxRetentionJob(repo, config.InboxConfig{RetentionPeriod: time.Hour, RetentionBatchSize: 10})
+
+	repo.On("DeleteProcessedEvents", mock.Anything, mock.Anything, 10).Return(int64(10), nil).Once()
+	repo.On("DeleteProcessedEvents", mock.Anything, mock.Anything, 10).Return(int64(0), errors.New("connection reset")).Once()
+
+	report, err := job.RunOnce(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, int64(10), report.Deleted)
+	repo.AssertExpectations(t)
+}
+
+func TestInboxRetentionDisabled(t *testing.T) {
+	repo := new(mockInboxRetentionRepo)
+	job := NewInboxRetentionJob(repo, config.InboxConfig{})
+
+	assert.NoError(t, job.Start(context.Background()))
+	repo.AssertNotCalled(t, "DeleteProcessedEvents", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,113 @@
+package worker
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Notifier wakes the outbox processor when new messages are written
+type Notifier interface {
+	// Notifications receives a value whenever new messages may be pending
+	Notifications() <-chan struct{}
+	// Connected reports whether notifications are currently delivered.
+	// While they are not, the processor polls at its regular interval.
+	Connected() bool
+}
+
+// PostgresNotifier listens for outbox notifications sent with pg_notify.
+// The listener reconnects on its own, after reconnecting it sends a notification
+// because messages may have been written while it was disconnected.
+type PostgresNotifier struct {
+	listener      *pq.Listener
+	notifications chan struct{}
+	connected     atomic.Bool
+	done          chan struct{}
+	closeOnce     sync.Once
+}
+
+// pingInterval is how often the listener connection is checked while it is idle
+const pingInterval = 90 * time.Second
+
+// NewPostgresNotifier starts listening on the channel of the database at dbURL
+func NewPostgresNotifier(dbURL, channel string) (*PostgresNotifier, error) {
+	n := &PostgresNotifier{
+		// One pending wake-up is enough, workers claim everything that is due
+		notifications: make(chan struct{}, 1),
+		done:          make(chan struct{}),
+	}
+
+	n.listener = pq.NewListener(dbURL, time.Second, time.Minute, n.handleEvent)
+	if err := n.listener.Listen(channel); err != nil {
+		n.listener.Close()
+		return nil, fmt.Errorf("failed to listen on %s: %w", channel, err)
+	}
+
+	go n.run()
+	return n, nil
+}
+
+// Notifications implements Notifier.
+func (n *PostgresNotifier) Notifications() <-chan struct{} {
+	return n.notifications
+}
+
+// Connected implements Notifier.
+func (n *PostgresNotifier) Connected() bool {
+	return n.connected.Load()
+}
+
+// Close stops listening
+func (n *PostgresNotifier) Close() error {
+	var err error
+	n.closeOnce.Do(func() {
+		close(n.done)
+		err = n.listener.Close()
+	})
+	return err
+}
+
+// run forwards notifications until the notifier is closed
+func (n *PostgresNotifier) run() {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.done:
+			return
+		case <-n.listener.Notify:
+			// A nil notification after a reconnect wakes the workers as well
+			n.wake()
+		case <-ticker.C:
+			// Detect dead connections that would otherwise go unnoticed
+			go n.listener.Ping()
+		}
+	}
+}
+
+// handleEvent tracks the state of the listener connection
+func (n *PostgresNotifier) handleEvent(event pq.ListenerEventType, err error) {
+	switch event {
+	case pq.ListenerEventConnected, pq.ListenerEventReconnected:
+		n.connected.Store(true)
+	case pq.ListenerEventDisconnected, pq.ListenerEventConnectionAttemptFailed:
+		if n.connected.Swap(false) {
+			log.Printf("Outbox listener disconnected, falling back to polling: %v", err)
+			// Let the workers pick up the shorter polling interval right away
+			n.wake()
+		}
+	}
+}
+
+func (n *PostgresNotifier) wake() {
+	select {
+	case n.notifications <- struct{}{}:
+	default:
+		// A wake-up is already pending
+	}
+}
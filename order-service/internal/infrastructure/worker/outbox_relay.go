@@ -0,0 +1,393 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"order-service/internal/app/ports"
+	"order-service/internal/domain"
+	"order-service/internal/events"
+	"order-service/internal/infrastructure/config"
+	"order-service/internal/infrastructure/replication"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// outboxTable is the table the relay streams the changes of
+const outboxTable = "outbox_messages"
+
+// replicationStream is the part of a replication connection the relay uses
+type replicationStream interface {
+	Receive(ctx context.Context, timeout time.Duration) (interface{}, error)
+	SendStatus(flushed replication.LSN) error
+	Close(ctx context.Context) error
+}
+
+// OutboxRelay publishes outbox messages as they are committed, by consuming a
+// Postgres logical replication slot instead of polling the outbox table.
+//
+// The messages of a transaction are published once its commit arrives. The end of the
+// transaction is then saved as the relay offset and confirmed to Postgres, so a restarted
+// relay resumes right after the last published transaction. Messages are published at
+// least once: a crash between publishing and saving the offset publishes them again.
+//
+// A message that cannot be published is retried in place up to MaxRetries times and then
+// marked as failed. Messages of unknown event types are parked, like OutboxProcessor does.
+// Messages that return to PENDING, because an operator requeued them or they were unparked,
+// are published when their update is committed. Like ClaimPendingMessages, the relay holds a
+// message back while an earlier message of its aggregate is unpublished, and publishes it
+// once that message is.
+type OutboxRelay struct {
+	outboxRepo     ports.OutboxRelayRepository
+	offsets        ports.RelayOffsetRepository
+	eventPublisher ports.EnvelopePublisher
+	registry       *events.Registry
+	slot           string
+	maxRetries     int
+	statusInterval time.Duration
+	backoff        Backoff
+
+	// connect opens a stream of the slot starting at the LSN
+	connect func(ctx context.Context, start replication.LSN) (replicationStream, error)
+}
+
+// NewOutboxRelay creates a relay reading cfg.ReplicationSlot of the database at databaseURL.
+// The server has to run with wal_level=logical.
+func NewOutboxRelay(
+	databaseURL string,
+	outboxRepo ports.OutboxRelayRepository,
+	offsets ports.RelayOffsetRepository,
+	eventPublisher ports.EnvelopePublisher,
+	registry *events.Registry,
+	cfg config.OutboxWorkerConfig,
+) *OutboxRelay {
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.RelayStatusInterval <= 0 {
+		cfg.RelayStatusInterval = 10 * time.Second
+	}
+
+	backoff := Backoff{
+		Initial:    cfg.RetryInitialBackoff,
+		Max:        cfg.RetryMaxBackoff,
+		Multiplier: cfg.RetryBackoffMultiplier,
+		Jitter:     cfg.RetryJitter,
+	}
+
+	slot, publication := cfg.ReplicationSlot, cfg.Publication
+	connect := func(ctx context.Context, start replication.LSN) (replicationStream, error) {
+		stream, err := replication.Connect(ctx, databaseURL)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := stream.EnsurePublication(ctx, publication, outboxTable); err != nil {
+			stream.Close(ctx)
+			return nil, err
+		}
+		if err := stream.EnsureSlot(ctx, slot); err != nil {
+			stream.Close(ctx)
+			return nil, err
+		}
+		if err := stream.Start(ctx, slot, publication, start); err != nil {
+			stream.Close(ctx)
+			return nil, err
+		}
+		return stream, nil
+	}
+
+	return &OutboxRelay{
+		outboxRepo:     outboxRepo,
+		offsets:        offsets,
+		eventPublisher: eventPublisher,
+		registry:       registry,
+		slot:           slot,
+		maxRetries:     cfg.MaxRetries,
+		statusInterval: cfg.RelayStatusInterval,
+		backoff:        backoff.withDefaults(),
+		connect:        connect,
+	}
+}
+
+// Start relays messages until the context is cancelled, reconnecting after errors
+func (r *OutboxRelay) Start(ctx context.Context) error {
+	log.Printf("Starting outbox relay on replication slot %s...", r.slot)
+
+	// Messages parked by a replica that did not know their type can be published now.
+	// The relay receives the updates of the unparked messages like any other change.
+	unparked, err := r.outboxRepo.UnparkMessages(ctx, r.registry.Types())
+	if err != nil {
+		log.Printf("Failed to unpark outbox messages: %v", err)
+	} else if unparked > 0 {
+		log.Printf("Unparked %d outbox messages", unparked)
+	}
+
+	failures := 0
+	for {
+		relayed, err := r.run(ctx)
+		if ctx.Err() != nil {
+			log.Println("Outbox relay stopping due to context cancellation")
+			return ctx.Err()
+		}
+
+		if relayed > 0 {
+			failures = 0
+		}
+		failures++
+
+		delay := r.backoff.Delay(failures)
+		log.Printf("Outbox relay disconnected, reconnecting in %s: %v", delay, err)
+		select {
+		case <-ctx.Done():
+			log.Println("Outbox relay stopping due to context cancellation")
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// pendingChange is an outbox change waiting for the commit of its transaction
+type pendingChange struct {
+	msg domain.OutboxMessage
+	// returned is set for a message updated back to PENDING, which is loaded again before publishing
+	returned bool
+}
+
+// run streams the slot from the saved offset until an error occurs.
+// It returns the number of relayed transactions.
+func (r *OutboxRelay) run(ctx context.Context) (int, error) {
+	offset, err := r.offsets.GetOffset(ctx, r.slot)
+	if err != nil {
+		return 0, err
+	}
+	flushed := replication.LSN(offset)
+
+	stream, err := r.connect(ctx, flushed)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start replication: %w", err)
+	}
+	defer stream.Close(context.Background())
+
+	log.Printf("Outbox relay streaming slot %s from %s", r.slot, flushed)
+
+	decoder := replication.NewDecoder()
+	var pending []pendingChange
+	inTransaction := false
+	relayed := 0
+	nextStatus := time.Now().Add(r.statusInterval)
+
+	for {
+		if !time.Now().Before(nextStatus) {
+			if err := stream.SendStatus(flushed); err != nil {
+				return relayed, fmt.Errorf("failed to send standby status: %w", err)
+			}
+			nextStatus = time.Now().Add(r.statusInterval)
+		}
+
+		msg, err := stream.Receive(ctx, time.Until(nextStatus))
+		if err != nil {
+			return relayed, err
+		}
+
+		switch msg := msg.(type) {
+		case replication.PrimaryKeepalive:
+			// Nothing is published between transactions, so the WAL sent so far is handled
+			if !inTransaction && msg.ServerWALEnd > flushed {
+				flushed = msg.ServerWALEnd
+			}
+			if msg.ReplyRequested {
+				nextStatus = time.Now()
+			}
+		case replication.XLogData:
+			change, err := decoder.Decode(msg.Data)
+			if err != nil {
+				return relayed, err
+			}
+
+			switch change := change.(type) {
+			case replication.Begin:
+				inTransaction = true
+				pending = pending[:0]
+			case replication.Insert:
+				if change.Relation.Name != outboxTable {
+					continue
+				}
+				// Fail the transaction instead of resuming after a message that was never published
+				outboxMsg, err := outboxMessageFromRow(change.Values)
+				if err != nil {
+					return relayed, fmt.Errorf("failed to decode outbox row: %w", err)
+				}
+				pending = append(pending, pendingChange{msg: outboxMsg})
+			case replication.Update:
+				// Only requeued and unparked messages are updated to PENDING
+				if change.Relation.Name != outboxTable || change.Values["status"] != string(domain.OutboxStatusPending) {
+					continue
+				}
+				id, err := uuid.Parse(change.Values["id"])
+				if err != nil {
+					return relayed, fmt.Errorf("failed to decode outbox row: invalid id: %w", err)
+				}
+				pending = append(pending, pendingChange{msg: domain.OutboxMessage{ID: id}, returned: true})
+			case replication.Commit:
+				for _, p := range pending {
+					var err error
+					if p.returned {
+						err = r.relayReturned(ctx, stream, flushed, p.msg.ID)
+					} else {
+						_, err = r.relay(ctx, stream, flushed, p.msg)
+					}
+					if err != nil {
+						return relayed, err
+					}
+				}
+
+				// Resume after this transaction from now on
+				if err := r.offsets.SaveOffset(ctx, r.slot, uint64(change.EndLSN)); err != nil {
+					return relayed, err
+				}
+				flushed = change.EndLSN
+				inTransaction = false
+				pending = pending[:0]
+				relayed++
+				nextStatus = time.Now()
+			}
+		}
+	}
+}
+
+// relay publishes the message unless an earlier message of its aggregate is unpublished.
+// It reports whether the message was handled, a held message stays PENDING.
+func (r *OutboxRelay) relay(ctx context.Context, stream replicationStream, flushed replication.LSN, msg domain.OutboxMessage) (bool, error) {
+	held, err := r.outboxRepo.HasUnpublishedPredecessor(ctx, msg)
+	if err != nil {
+		return false, fmt.Errorf("failed to check the predecessors of message %s: %w", msg.ID, err)
+	}
+	if held {
+		log.Printf("Holding back message %s until the earlier messages of aggregate %s are published", msg.ID, msg.AggregateID)
+		return false, nil
+	}
+
+	return true, r.relayMessage(ctx, stream, flushed, msg)
+}
+
+// relayReturned publishes a message that was updated back to PENDING, followed by
+// the messages of its aggregate that were held back behind it
+func (r *OutboxRelay) relayReturned(ctx context.Context, stream replicationStream, flushed replication.LSN, id uuid.UUID) error {
+	msg, err := r.outboxRepo.GetMessage(ctx, id)
+	if errors.Is(err, domain.ErrOutboxMessageNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load message %s: %w", id, err)
+	}
+	// A later change already published or parked it again
+	if msg.Status != domain.OutboxStatusPending {
+		return nil
+	}
+
+	if relayed, err := r.relay(ctx, stream, flushed, *msg); err != nil || !relayed {
+		return err
+	}
+
+	held, err := r.outboxRepo.ListHeldMessages(ctx, msg.AggregateID, msg.SequenceNumber)
+	if err != nil {
+		return fmt.Errorf("failed to list the messages held back behind %s: %w", id, err)
+	}
+	for _, heldMsg := range held {
+		if relayed, err := r.relay(ctx, stream, flushed, heldMsg); err != nil || !relayed {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// relayMessage publishes one message, retrying in place so messages keep their commit order.
+// While waiting for a retry the stream is kept alive by reporting the unchanged position.
+func (r *OutboxRelay) relayMessage(ctx context.Context, stream replicationStream, flushed replication.LSN, msg domain.OutboxMessage) error {
+	for attempt := 1; ; attempt++ {
+		err := publishMessage(ctx, r.registry, r.eventPublisher, msg)
+		if err == nil {
+			if err := r.outboxRepo.MarkMessageAsProcessed(ctx, msg.ID); err != nil {
+				log.Printf("Failed to mark message %s as processed: %v", msg.ID, err)
+			}
+			return nil
+		}
+
+		if unpublishable(err) {
+			// Keep the message until a worker that knows its type and version picks it up
+			log.Printf("Parking message %s: %v", msg.ID, err)
+			if parkErr := r.outboxRepo.ParkMessage(ctx, msg.ID, err.Error()); parkErr != nil {
+				log.Printf("Failed to park message %s: %v", msg.ID, parkErr)
+			}
+			return nil
+		}
+
+		if attempt >= r.maxRetries {
+			log.Printf("Message %s failed %d times, marking as failed: %v", msg.ID, attempt, err)
+			if markErr := r.outboxRepo.MarkMessageAsFailed(ctx, msg.ID, err.Error()); markErr != nil {
+				log.Printf("Failed to mark message %s as failed: %v", msg.ID, markErr)
+			}
+			return nil
+		}
+
+		delay := r.backoff.Delay(attempt)
+		log.Printf("Failed to relay message %s (attempt %d), retrying in %s: %v", msg.ID, attempt, delay, err)
+		if err := r.wait(ctx, stream, flushed, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// wait sleeps for the delay while sending standby status updates
+func (r *OutboxRelay) wait(ctx context.Context, stream replicationStream, flushed replication.LSN, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	ticker := time.NewTicker(r.statusInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		case <-ticker.C:
+			if err := stream.SendStatus(flushed); err != nil {
+				return fmt.Errorf("failed to send standby status: %w", err)
+			}
+		}
+	}
+}
+
+// outboxMessageFromRow builds a message from the text columns of an outbox_messages row
+func outboxMessageFromRow(values map[string]string) (domain.OutboxMessage, error) {
+	id, err := uuid.Parse(values["id"])
+	if err != nil {
+		return domain.OutboxMessage{}, fmt.Errorf("invalid id: %w", err)
+	}
+
+	aggregateID, err := uuid.Parse(values["aggregate_id"])
+	if err != nil {
+		return domain.OutboxMessage{}, fmt.Errorf("invalid aggregate_id of message %s: %w", id, err)
+	}
+
+	attempts, _ := strconv.Atoi(values["attempt_count"])
+	sequence, _ := strconv.ParseInt(values["sequence_number"], 10, 64)
+	version, _ := strconv.Atoi(values["schema_version"])
+
+	return domain.OutboxMessage{
+		ID:             id,
+		AggregateID:    aggregateID,
+		SequenceNumber: sequence,
+		EventType:      values["event_type"],
+		Payload:        []byte(values["payload"]),
+		Status:         domain.OutboxStatus(values["status"]),
+		AttemptCount:   attempts,
+		SchemaVersion:  version,
+	}, nil
+}
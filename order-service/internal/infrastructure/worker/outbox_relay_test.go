@@ -0,0 +1,319 @@
+package worker
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"order-service/internal/app/ports"
+	"order-service/internal/domain"
+	"order-service/internal/events"
+	"order-service/internal/infrastructure/config"
+	"order-service/internal/infrastructure/replication"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var errStreamEnded = errors.New("stream ended")
+
+// fakeStream replays messages and ends with errStreamEnded
+type fakeStream struct {
+	messages []interface{}
+	statuses []replication.LSN
+}
+
+func (s *fakeStream) Receive(ctx context.Context, timeout time.Duration) (interface{}, error) {
+	if len(s.messages) == 0 {
+		return nil, errStreamEnded
+	}
+	msg := s.messages[0]
+	s.messages = s.messages[1:]
+	return msg, nil
+}
+
+func (s *fakeStream) SendStatus(flushed replication.LSN) error {
+	s.statuses = append(s.statuses, flushed)
+	return nil
+}
+
+func (s *fakeStream) Close(ctx context.Context) error { return nil }
+
+type fakeRelayOffsets struct {
+	offsets map[string]uint64
+}
+
+func (o *fakeRelayOffsets) GetOffset(ctx context.Context, slotName string) (uint64, error) {
+	return o.offsets[slotName], nil
+}
+
+func (o *fakeRelayOffsets) SaveOffset(ctx context.Context, slotName string, lsn uint64) error {
+	o.offsets[slotName] = lsn
+	return nil
+}
+
+// pgoutput encodes the pgoutput messages of one transaction inserting the outbox rows
+func pgoutput(endLSN uint64, rows ...map[string]string) []interface{} {
+	return pgoutputChanges(endLSN, 'I', rows)
+}
+
+// pgoutputUpdates encodes the pgoutput messages of one transaction updating the outbox rows
+func pgoutputUpdates(endLSN uint64, rows ...map[string]string) []interface{} {
+	return pgoutputChanges(endLSN, 'U', rows)
+}
+
+func pgoutputChanges(endLSN uint64, kind byte, rows []map[string]string) []interface{} {
+	columns := []string{"id", "aggregate_id", "sequence_number", "event_type", "payload", "status", "schema_version"}
+
+	relation := append([]byte{'R'}, binary.BigEndian.AppendUint32(nil, 1)...)
+	relation = append(relation, "public\x00outbox_messages\x00d"...)
+	relation = binary.BigEndian.AppendUint16(relation, uint16(len(columns)))
+	for _, column := range columns {
+		relation = append(append(append(relation, 0), column...), 0)
+		relation = binary.BigEndian.AppendUint64(relation, 0)
+	}
+
+	begin := binary.BigEndian.AppendUint64([]byte{'B'}, endLSN)
+	begin = binary.BigEndian.AppendUint64(begin, 0)
+	begin = binary.BigEndian.AppendUint32(begin, 1)
+
+	messages := []interface{}{
+		replication.XLogData{Data: relation},
+		replication.XLogData{Data: begin},
+	}
+
+	for _, row := range rows {
+		change := append([]byte{kind}, binary.BigEndian.AppendUint32(nil, 1)...)
+		change = binary.BigEndian.AppendUint16(append(change, 'N'), uint16(len(columns)))
+		for _, column := range columns {
+			change = binary.BigEndian.AppendUint32(append(change, 't'), uint32(len(row[column])))
+			change = append(change, row[column]...)
+		}
+		messages = append(messages, replication.XLogData{Data: change})
+	}
+
+	commit := binary.BigEndian.AppendUint64([]byte{'C', 0}, endLSN)
+	commit = binary.BigEndian.AppendUint64(commit, endLSN)
+	commit = binary.BigEndian.AppendUint64(commit, 0)
+
+	return append(messages, replication.XLogData{Data: commit})
+}
+
+func newTestRelay(repo *mockOutboxRepo, publisher *mockEventPublisher, offsets *fakeRelayOffsets, stream *fakeStream) (*OutboxRelay, *replication.LSN) {
+	relay := NewOutboxRelay("", repo, offsets, publisher, events.NewDefaultRegistry(), config.OutboxWorkerConfig{
+		ReplicationSlot:     "outbox",
+		MaxRetries:          2,
+		RetryInitialBackoff: time.Millisecond,
+		RelayStatusInterval: time.Minute,
+	})
+
+	start := new(replication.LSN)
+	relay.connect = func(ctx context.Context, lsn replication.LSN) (replicationStream, error) {
+		*start = lsn
+		return stream, nil
+	}
+	return relay, start
+}
+
+func TestRelayPublishesCommittedTransactions(t *testing.T) {
+	orderID := uuid.New()
+	payload, _ := json.Marshal(events.OrderCreatedEvent{
+		OrderID:    orderID,
+		TotalPrice: domain.MustParseMoney("10.00", domain.CurrencyUSD),
+	})
+	created := map[string]string{
+		"id":              uuid.NewString(),
+		"aggregate_id":    orderID.String(),
+		"sequence_number": "7",
+		"event_type":      events.OrderCreatedEventType,
+		"payload":         string(payload),
+		"status":          string(domain.OutboxStatusPending),
+		"schema_version":  strconv.Itoa(events.OrderCreatedEventVersion),
+	}
+	unknown := map[string]string{
+		"id":           uuid.NewString(),
+		"aggregate_id": orderID.String(),
+		"event_type":   "order.archived",
+		"payload":      "{}",
+		"status":       string(domain.OutboxStatusPending),
+	}
+
+	repo := new(mockOutboxRepo)
+	publisher := new(mockEventPublisher)
+	offsets := &fakeRelayOffsets{offsets: map[string]uint64{"outbox": 0x100}}
+	stream := &fakeStream{messages: pgoutput(0x230, created, unknown)}
+	relay, start := newTestRelay(repo, publisher, offsets, stream)
+
+	envelope := ports.Envelope{
+		ID:            created["id"],
+		Topic:         events.OrderCreatedEventType,
+		Key:           orderID.String(),
+		AggregateID:   orderID.String(),
+		EventType:     events.OrderCreatedEventType,
+		SchemaVersion: events.OrderCreatedEventVersion,
+		Extensions: map[string]string{
+			events.ExtensionSequence: "7",
+		},
+	}
+	repo.On("HasUnpublishedPredecessor", mock.Anything, mock.Anything).Return(false, nil)
+	publisher.On("PublishEnvelope", mock.Anything, envelopeOf(envelope)).Return(nil)
+	repo.On("MarkMessageAsProcessed", mock.Anything, uuid.MustParse(created["id"])).Return(nil)
+	repo.On("ParkMessage", mock.Anything, uuid.MustParse(unknown["id"]), mock.Anything).Return(nil)
+
+	relayed, err := relay.run(context.Background())
+	assert.ErrorIs(t, err, errStreamEnded)
+	assert.Equal(t, 1, relayed)
+
+	// The relay resumed from the saved offset and saved the end of the transaction
+	assert.Equal(t, replication.LSN(0x100), *start)
+	assert.Equal(t, uint64(0x230), offsets.offsets["outbox"])
+	assert.Equal(t, []replication.LSN{0x230}, stream.statuses)
+	publisher.AssertExpectations(t)
+	repo.AssertExpectations(t)
+}
+
+func TestRelayWaitsForCommit(t *testing.T) {
+	repo := new(mockOutboxRepo)
+	publisher := new(mockEventPublisher)
+	offsets := &fakeRelayOffsets{offsets: map[string]uint64{}}
+
+	messages := pgoutput(0x230, map[string]string{
+		"id":           uuid.NewString(),
+		"aggregate_id": uuid.NewString(),
+		"event_type":   events.OrderCreatedEventType,
+		"payload":      "{}",
+	})
+	// The connection drops before the transaction is committed
+	stream := &fakeStream{messages: messages[:len(messages)-1]}
+	relay, _ := newTestRelay(repo, publisher, offsets, stream)
+
+	relayed, err := relay.run(context.Background())
+	assert.ErrorIs(t, err, errStreamEnded)
+	assert.Zero(t, relayed)
+
+	publisher.AssertNotCalled(t, "PublishEnvelope", mock.Anything, mock.Anything)
+	assert.Empty(t, offsets.offsets)
+	assert.Empty(t, stream.statuses)
+}
+
+func TestRelayFailsOnUndecodableRows(t *testing.T) {
+	repo := new(mockOutboxRepo)
+	publisher := new(mockEventPublisher)
+	offsets := &fakeRelayOffsets{offsets: map[string]uint64{}}
+
+	stream := &fakeStream{messages: pgoutput(0x230, map[string]string{
+		"id":           "not-a-uuid",
+		"aggregate_id": uuid.NewString(),
+		"event_type":   events.OrderCreatedEventType,
+		"payload":      "{}",
+	})}
+	relay, _ := newTestRelay(repo, publisher, offsets, stream)
+
+	relayed, err := relay.run(context.Background())
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, errStreamEnded)
+	assert.Zero(t, relayed)
+
+	// The transaction is streamed again after reconnecting
+	assert.Empty(t, offsets.offsets)
+	publisher.AssertNotCalled(t, "PublishEnvelope", mock.Anything, mock.Anything)
+}
+
+func TestRelayHoldsBackMessagesBehindUnpublishedPredecessors(t *testing.T) {
+	orderID := uuid.New()
+	held := map[string]string{
+		"id":              uuid.NewString(),
+		"aggregate_id":    orderID.String(),
+		"sequence_number": "2",
+		"event_type":      events.OrderCreatedEventType,
+		"payload":         "{}",
+		"status":          string(domain.OutboxStatusPending),
+	}
+
+	repo := new(mockOutboxRepo)
+	publisher := new(mockEventPublisher)
+	offsets := &fakeRelayOffsets{offsets: map[string]uint64{}}
+	relay, _ := newTestRelay(repo, publisher, offsets, &fakeStream{messages: pgoutput(0x230, held)})
+
+	repo.On("HasUnpublishedPredecessor", mock.Anything, mock.MatchedBy(func(msg domain.OutboxMessage) bool {
+		return msg.AggregateID == orderID && msg.SequenceNumber == 2
+	})).Return(true, nil)
+
+	relayed, err := relay.run(context.Background())
+	assert.ErrorIs(t, err, errStreamEnded)
+	assert.Equal(t, 1, relayed)
+
+	// The message stays PENDING until its predecessor is published
+	publisher.AssertNotCalled(t, "PublishEnvelope", mock.Anything, mock.Anything)
+	assert.Equal(t, uint64(0x230), offsets.offsets["outbox"])
+	repo.AssertExpectations(t)
+}
+
+func TestRelayPublishesRequeuedMessages(t *testing.T) {
+	orderID := uuid.New()
+	payload, _ := json.Marshal(events.OrderCreatedEvent{
+		OrderID:    orderID,
+		TotalPrice: domain.MustParseMoney("10.00", domain.CurrencyUSD),
+	})
+	requeued := domain.OutboxMessage{
+		ID:             uuid.New(),
+		AggregateID:    orderID,
+		SequenceNumber: 1,
+		EventType:      events.OrderCreatedEventType,
+		Payload:        payload,
+		Status:         domain.OutboxStatusPending,
+		SchemaVersion:  events.OrderCreatedEventVersion,
+	}
+	held := requeued
+	held.ID = uuid.New()
+	held.SequenceNumber = 2
+
+	repo := new(mockOutboxRepo)
+	publisher := new(mockEventPublisher)
+	offsets := &fakeRelayOffsets{offsets: map[string]uint64{}}
+	stream := &fakeStream{messages: pgoutputUpdates(0x230,
+		map[string]string{"id": requeued.ID.String(), "status": string(domain.OutboxStatusPending)},
+		// The relay's own updates are not published again
+		map[string]string{"id": uuid.NewString(), "status": string(domain.OutboxStatusProcessed)},
+	)}
+	relay, _ := newTestRelay(repo, publisher, offsets, stream)
+
+	repo.On("GetMessage", mock.Anything, requeued.ID).Return(&requeued, nil)
+	repo.On("HasUnpublishedPredecessor", mock.Anything, mock.Anything).Return(false, nil)
+	repo.On("ListHeldMessages", mock.Anything, orderID, int64(1)).Return([]domain.OutboxMessage{held}, nil)
+	publisher.On("PublishEnvelope", mock.Anything, mock.Anything).Return(nil)
+	repo.On("MarkMessageAsProcessed", mock.Anything, mock.Anything).Return(nil)
+
+	relayed, err := relay.run(context.Background())
+	assert.ErrorIs(t, err, errStreamEnded)
+	assert.Equal(t, 1, relayed)
+
+	// The requeued message is published before the message held back behind it
+	require.Len(t, publisher.Calls, 2)
+	assert.Equal(t, requeued.ID.String(), publisher.Calls[0].Arguments.Get(1).(ports.Envelope).ID)
+	assert.Equal(t, held.ID.String(), publisher.Calls[1].Arguments.Get(1).(ports.Envelope).ID)
+	repo.AssertExpectations(t)
+}
+
+func TestRelayMarksMessageFailedAfterRetries(t *testing.T) {
+	repo := new(mockOutboxRepo)
+	publisher := new(mockEventPublisher)
+	relay, _ := newTestRelay(repo, publisher, &fakeRelayOffsets{}, &fakeStream{})
+
+	msg := domain.OutboxMessage{
+		ID:        uuid.New(),
+		EventType: events.OrderCreatedEventType,
+		Payload:   []byte(`{}`),
+	}
+	publisher.On("PublishEnvelope", mock.Anything, mock.Anything).Return(errors.New("broker unavailable"))
+	repo.On("MarkMessageAsFailed", mock.Anything, msg.ID, mock.Anything).Return(nil)
+
+	require.NoError(t, relay.relayMessage(context.Background(), &fakeStream{}, 0, msg))
+	publisher.AssertNumberOfCalls(t, "PublishEnvelope", 2)
+	repo.AssertCalled(t, "MarkMessageAsFailed", mock.Anything, msg.ID, mock.Anything)
+}
@@ -0,0 +1,111 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"order-service/internal/app/ports"
+	"order-service/internal/infrastructure/config"
+	"time"
+)
+
+// RetentionReport counts the messages or events removed by one run of a retention job
+type RetentionReport struct {
+	// Cutoff is the time the removed messages were processed before
+	Cutoff   time.Time
+	Archived int64
+	Deleted  int64
+	Batches  int
+}
+
+// OutboxRetentionJob keeps the outbox table small by moving processed messages
+// to the archive table, or deleting them, once they are older than the retention period.
+// Messages are removed in bounded batches so no statement holds locks for long.
+type OutboxRetentionJob struct {
+	repo      ports.OutboxRetentionRepository
+	period    time.Duration
+	interval  time.Duration
+	batchSize int
+	archive   bool
+}
+
+// NewOutboxRetentionJob creates a retention job. It does nothing when cfg.RetentionPeriod is zero.
+func NewOutboxRetentionJob(repo ports.OutboxRetentionRepository, cfg config.OutboxWorkerConfig) *OutboxRetentionJob {
+	if cfg.RetentionInterval <= 0 {
+		cfg.RetentionInterval = time.Hour
+	}
+	if cfg.RetentionBatchSize <= 0 {
+		cfg.RetentionBatchSize = 1000
+	}
+
+	return &OutboxRetentionJob{
+		repo:      repo,
+		period:    cfg.RetentionPeriod,
+		interval:  cfg.RetentionInterval,
+		batchSize: cfg.RetentionBatchSize,
+		archive:   cfg.RetentionArchive,
+	}
+}
+
+// Start removes old messages right away and then every interval until the context is cancelled
+func (j *OutboxRetentionJob) Start(ctx context.Context) error {
+	if j.period <= 0 {
+		log.Println("Outbox retention disabled, processed messages are kept")
+		return nil
+	}
+
+	log.Printf("Starting outbox retention job, removing messages processed more than %s ago", j.period)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := j.RunOnce(ctx)
+		if err != nil {
+			log.Printf("Error removing processed outbox messages: %v", err)
+		}
+		if report.Archived > 0 || report.Deleted > 0 {
+			log.Printf("Outbox retention: archived %d and deleted %d messages processed before %s in %d batches",
+				report.Archived, report.Deleted, report.Cutoff.Format(time.RFC3339), report.Batches)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Outbox retention job stopping due to context cancellation")
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce removes the messages processed before the retention period, batch by batch,
+// until none are left. The report counts what was removed, also when an error stopped the run.
+func (j *OutboxRetentionJob) RunOnce(ctx context.Context) (RetentionReport, error) {
+	report := RetentionReport{Cutoff: time.Now().Add(-j.period)}
+
+	for ctx.Err() == nil {
+		var removed int64
+		var err error
+		if j.archive {
+			removed, err = j.repo.ArchiveProcessedMessages(ctx, report.Cutoff, j.batchSize)
+			report.Archived += removed
+		} else {
+			removed, err = j.repo.DeleteProcessedMessages(ctx, report.Cutoff, j.batchSize)
+			report.Deleted += removed
+		}
+		if err != nil {
+			return report, fmt.Errorf("failed to remove processed messages: %w", err)
+		}
+
+		if removed > 0 {
+			report.Batches++
+		}
+
+		// A partial batch means no old messages are left
+		if removed < int64(j.batchSize) {
+			break
+		}
+	}
+
+	return report, ctx.Err()
+}
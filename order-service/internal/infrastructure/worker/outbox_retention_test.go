@@ -0,0 +1,77 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"order-service/internal/infrastructure/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockRetentionRepo struct {
+	mock.Mock
+}
+
+func (m *mockRetentionRepo) ArchiveProcessedMessages(ctx context.Context, before time.Time, limit int) (int64, error) {
+	args := m.Called(ctx, before, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockRetentionRepo) DeleteProcessedMessages(ctx context.Context, before time.Time, limit int) (int64, error) {
+	args := m.Called(ctx, before, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestRetentionArchivesInBatches(t *testing.T) {
+	repo := new(mockRetentionRepo)
+	job := NewOutboxRetentionJob(repo, config.OutboxWorkerConfig{
+		RetentionPeriod:    24 * time.Hour,
+		RetentionBatchSize: 100,
+		RetentionArchive:   true,
+	})
+
+	// Every batch uses the same cutoff, one retention period ago
+	cutoff := mock.MatchedBy(func(before time.Time) bool {
+		age := time.Since(before)
+		return age >= 24*time.Hour && age < 25*time.Hour
+	})
+	repo.On("ArchiveProcessedMessages", mock.Anything, cutoff, 100).Return(int64(100), nil).Twice()
+	repo.On("ArchiveProcessedMessages", mock.Anything, cutoff, 100).Return(int64(42), nil).Once()
+
+	report, err := job.RunOnce(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(242), report.Archived)
+	assert.Zero(t, report.Deleted)
+	assert.Equal(t, 3, report.Batches)
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "DeleteProcessedMessages", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRetentionDeletesWithoutArchive(t *testing.T) {
+	repo := new(mockRetentionRepo)
+	job := NewOutboxRetentionJob(repo, config.OutboxWorkerConfig{
+		RetentionPeriod:    time.Hour,
+		RetentionBatchSize: 10,
+	})
+
+	repo.On("DeleteProcessedMessages", mock.Anything, mock.Anything, 10).Return(int64(10), nil).Once()
+	repo.On("DeleteProcessedMessages", mock.Anything, mock.Anything, 10).Return(int64(0), errors.New("connection reset")).Once()
+
+	// Batches removed before the error are still reported
+	report, err := job.RunOnce(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, int64(10), report.Deleted)
+	assert.Equal(t, 1, report.Batches)
+}
+
+func TestRetentionDisabledWithoutPeriod(t *testing.T) {
+	repo := new(mockRetentionRepo)
+	job := NewOutboxRetentionJob(repo, config.OutboxWorkerConfig{})
+
+	assert.NoError(t, job.Start(context.Background()))
+	repo.AssertNotCalled(t, "ArchiveProcessedMessages", mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "DeleteProcessedMessages", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -2,44 +2,156 @@ package worker
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"order-service/internal/app/ports"
 	"order-service/internal/domain"
 	"order-service/internal/events"
+	"order-service/internal/infrastructure/config"
+	"os"
+	"strconv"
+	"sync"
 	"time"
 )
 
-// OutboxProcessor handles processing and publishing of outbox messages
+// OutboxProcessor handles processing and publishing of outbox messages.
+// It runs a pool of workers that each claim their own batch of messages, so
+// several workers and several service replicas can share one outbox table.
+// Messages that run out of attempts are forwarded to the dead-letter topic.
+// How a message is published is looked up in the event registry by its type.
+// With a batch publisher each claimed batch is published at once, in one Kafka transaction.
 type OutboxProcessor struct {
 	outboxRepo      ports.OutboxRepository
-	eventPublisher  ports.EventPublisher
+	eventPublisher  ports.EnvelopePublisher
+	batchPublisher  ports.BatchPublisher
+	deadLetters     ports.DeadLetterPublisher
+	registry        *events.Registry
 	batchSize       int
 	processInterval time.Duration
 	maxRetries      int
+	workers         int
+	leaseDuration   time.Duration
+	backoff         Backoff
+	instanceID      string
+
+	notifier           Notifier
+	notifyPollInterval time.Duration
+
+	deadLetterQueue      string
+	deadLetterMaxRetries int
 }
 
-// NewOutboxProcessor creates a new outbox processor
+// NewOutboxProcessor creates a new outbox processor.
+// Failed messages are only forwarded when cfg.DeadLetterQueue names a topic.
+// With cfg.Transactional batches are published with PublishBatch when the publisher supports it.
 func NewOutboxProcessor(
 	outboxRepo ports.OutboxRepository,
-	eventPublisher ports.EventPublisher,
-	batchSize int,
-	processInterval time.Duration,
-	maxRetries int,
+	eventPublisher ports.EnvelopePublisher,
+	deadLetters ports.DeadLetterPublisher,
+	registry *events.Registry,
+	cfg config.OutboxWorkerConfig,
 ) *OutboxProcessor {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.ProcessInterval <= 0 {
+		cfg.ProcessInterval = 5 * time.Second
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.LeaseDuration <= 0 {
+		cfg.LeaseDuration = 30 * time.Second
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.NotifyPollInterval <= 0 {
+		cfg.NotifyPollInterval = time.Minute
+	}
+	if cfg.DeadLetterMaxRetries <= 0 {
+		cfg.DeadLetterMaxRetries = 5
+	}
+	if cfg.DeadLetterQueue != "" && deadLetters == nil {
+		log.Printf("No dead-letter publisher, failed messages are not forwarded to %s", cfg.DeadLetterQueue)
+		cfg.DeadLetterQueue = ""
+	}
+
+	var batchPublisher ports.BatchPublisher
+	if cfg.Transactional {
+		var ok bool
+		if batchPublisher, ok = eventPublisher.(ports.BatchPublisher); !ok {
+			log.Println("Event publisher cannot publish batches, outbox messages are published one by one")
+		}
+	}
+
+	backoff := Backoff{
+		Initial:    cfg.RetryInitialBackoff,
+		Max:        cfg.RetryMaxBackoff,
+		Multiplier: cfg.RetryBackoffMultiplier,
+		Jitter:     cfg.RetryJitter,
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "order-service"
+	}
+
 	return &OutboxProcessor{
 		outboxRepo:      outboxRepo,
 		eventPublisher:  eventPublisher,
-		batchSize:       batchSize,
-		processInterval: processInterval,
-		maxRetries:      maxRetries,
+		batchPublisher:  batchPublisher,
+		deadLetters:     deadLetters,
+		registry:        registry,
+		batchSize:       cfg.BatchSize,
+		processInterval: cfg.ProcessInterval,
+		maxRetries:      cfg.MaxRetries,
+		workers:         cfg.Workers,
+		leaseDuration:   cfg.LeaseDuration,
+		backoff:         backoff.withDefaults(),
+		instanceID:      fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+
+		notifyPollInterval: cfg.NotifyPollInterval,
+
+		deadLetterQueue:      cfg.DeadLetterQueue,
+		deadLetterMaxRetries: cfg.DeadLetterMaxRetries,
 	}
 }
 
-// Start begins the outbox processing loop
+// Start runs the worker pool until the context is cancelled
 func (p *OutboxProcessor) Start(ctx context.Context) error {
-	log.Println("Starting outbox processor...")
+	log.Printf("Starting outbox processor with %d workers...", p.workers)
+
+	// Messages parked by a replica that did not know their type can be published now
+	unparked, err := p.outboxRepo.UnparkMessages(ctx, p.registry.Types())
+	if err != nil {
+		log.Printf("Failed to unpark outbox messages: %v", err)
+	} else if unparked > 0 {
+		log.Printf("Unparked %d outbox messages", unparked)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func(workerID string) {
+			defer wg.Done()
+			p.runWorker(ctx, workerID)
+		}(fmt.Sprintf("%s-%d", p.instanceID, i))
+	}
+
+	wg.Wait()
+	log.Println("Outbox processor stopping due to context cancellation")
+	return ctx.Err()
+}
+
+// StartDeadLetters only forwards failed messages to the dead-letter topic until the context
+// is cancelled. It is used instead of Start while an OutboxRelay publishes the messages.
+func (p *OutboxProcessor) StartDeadLetters(ctx context.Context) error {
+	if p.deadLetterQueue == "" {
+		return nil
+	}
+	log.Printf("Starting outbox dead-letter forwarding to %s...", p.deadLetterQueue)
 
 	ticker := time.NewTicker(p.processInterval)
 	defer ticker.Stop()
@@ -47,52 +159,109 @@ func (p *OutboxProcessor) Start(ctx context.Context) error {
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("Outbox processor stopping due to context cancellation")
+			log.Println("Outbox dead-letter forwarding stopping due to context cancellation")
 			return ctx.Err()
 		case <-ticker.C:
-			if err := p.processOutboxMessages(ctx); err != nil {
-				log.Printf("Error processing outbox messages: %v", err)
-				// Continue processing on next tick
+		}
+
+		if err := p.forwardDeadLetters(ctx, p.instanceID); err != nil {
+			log.Printf("Error forwarding dead letters: %v", err)
+		}
+	}
+}
+
+// UseNotifier makes the workers wake up on notifications instead of only polling.
+// While the notifier is connected the workers poll every notifyPollInterval as a safety net.
+func (p *OutboxProcessor) UseNotifier(notifier Notifier) {
+	p.notifier = notifier
+}
+
+// runWorker claims and processes batches of messages on every tick or notification
+func (p *OutboxProcessor) runWorker(ctx context.Context, workerID string) {
+	var notifications <-chan struct{}
+	if p.notifier != nil {
+		notifications = p.notifier.Notifications()
+	}
+
+	timer := time.NewTimer(p.pollInterval())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-notifications:
+		}
+
+		claimed, err := p.processOutboxMessages(ctx, workerID)
+		if err != nil {
+			log.Printf("Worker %s: error processing outbox messages: %v", workerID, err)
+			// Continue processing on next tick
+		}
+
+		if p.deadLetterQueue != "" {
+			if err := p.forwardDeadLetters(ctx, workerID); err != nil {
+				log.Printf("Worker %s: error forwarding dead letters: %v", workerID, err)
 			}
 		}
+
+		// Claim again right away while there is work. Besides the rest of a full batch this
+		// picks up the messages that were held back behind the ones just published.
+		next := p.pollInterval()
+		if claimed > 0 {
+			next = 0
+		}
+		timer.Reset(next)
 	}
 }
 
-// processOutboxMessages fetches and processes pending outbox messages
-func (p *OutboxProcessor) processOutboxMessages(ctx context.Context) error {
-	// Get unprocessed messages
-	messages, err := p.outboxRepo.GetPendingMessages(ctx, p.batchSize)
+// pollInterval returns how long a worker waits for a notification before polling
+func (p *OutboxProcessor) pollInterval() time.Duration {
+	if p.notifier != nil && p.notifier.Connected() {
+		return p.notifyPollInterval
+	}
+	return p.processInterval
+}
+
+// processOutboxMessages claims and processes a batch of pending outbox messages.
+// It returns the number of claimed messages.
+func (p *OutboxProcessor) processOutboxMessages(ctx context.Context, workerID string) (int, error) {
+	// Claim unprocessed messages, other workers skip them until the lease expires.
+	// The deadline is taken before claiming so it never outlasts the lease in the database.
+	deadline := time.Now().Add(p.leaseDuration)
+	messages, err := p.outboxRepo.ClaimPendingMessages(ctx, workerID, p.batchSize, p.leaseDuration)
 	if err != nil {
-		return fmt.Errorf("failed to get pending outbox messages: %w", err)
+		return 0, fmt.Errorf("failed to claim pending outbox messages: %w", err)
 	}
 
 	if len(messages) == 0 {
 		// No messages to process
-		return nil
+		return 0, nil
 	}
 
-	log.Printf("Processing %d outbox messages", len(messages))
+	log.Printf("Worker %s: processing %d outbox messages", workerID, len(messages))
+
+	if p.batchPublisher != nil {
+		p.publishBatch(ctx, workerID, messages, deadline)
+		return len(messages), nil
+	}
 
 	for _, msg := range messages {
-		// Process each message in its own context to isolate failures
-		err := p.processMessage(ctx, msg)
-		if err != nil {
-			log.Printf("Failed to process message %s: %v", msg.ID, err)
-			// Update message with failed attempt
-			updateErr := p.outboxRepo.IncrementAttempt(ctx, msg.ID)
-			if updateErr != nil {
-				log.Printf("Failed to update attempt count for message %s: %v", msg.ID, updateErr)
-			}
+		// Stop before the lease runs out, the remaining messages are claimed again later
+		if time.Now().After(deadline) {
+			log.Printf("Worker %s: lease expired, leaving %s for the next claim", workerID, msg.ID)
+			break
+		}
 
-			// Check if max retries reached
-			if msg.AttemptCount+1 >= p.maxRetries {
-				log.Printf("Message %s reached max retry count, marking as failed", msg.ID)
-				deadLetterErr := p.outboxRepo.MarkMessageAsFailed(ctx, msg.ID, err.Error())
-				if deadLetterErr != nil {
-					log.Printf("Failed to mark message as failed: %v", deadLetterErr)
-				}
+		// Process each message in its own context to isolate failures
+		if err := p.processMessage(ctx, msg); err != nil {
+			if unpublishable(err) {
+				p.parkMessage(ctx, msg, err)
+				continue
 			}
 
+			p.handleFailure(ctx, msg, err)
 			// Continue with next message
 			continue
 		}
@@ -104,42 +273,171 @@ func (p *OutboxProcessor) processOutboxMessages(ctx context.Context) error {
 		}
 	}
 
-	return nil
+	return len(messages), nil
 }
 
-// processMessage processes a single outbox message
-func (p *OutboxProcessor) processMessage(ctx context.Context, msg domain.OutboxMessage) error {
-	// Handle different event types
-	switch msg.EventType {
-	case "order.created":
-		var event events.OrderCreatedEvent
-		if err := json.Unmarshal(msg.Payload, &event); err != nil {
-			return fmt.Errorf("failed to unmarshal order.created event: %w", err)
+// publishBatch publishes the claimed messages at once. A message that cannot be decoded
+// is handled on its own. The others succeed or fail together in a transaction, otherwise
+// the messages published before the failed one are processed and the rest failed.
+func (p *OutboxProcessor) publishBatch(ctx context.Context, workerID string, messages []domain.OutboxMessage, deadline time.Time) {
+	batch := make([]domain.OutboxMessage, 0, len(messages))
+	envelopes := make([]ports.Envelope, 0, len(messages))
+	for _, msg := range messages {
+		envelope, err := newEnvelope(p.registry, msg)
+		if err != nil {
+			if unpublishable(err) {
+				p.parkMessage(ctx, msg, err)
+			} else {
+				p.handleFailure(ctx, msg, err)
+			}
+			continue
 		}
+		batch = append(batch, msg)
+		envelopes = append(envelopes, envelope)
+	}
+
+	if len(batch) == 0 {
+		return
+	}
+
+	// The whole batch is claimed again later
+	if time.Now().After(deadline) {
+		log.Printf("Worker %s: lease expired, leaving %d messages for the next claim", workerID, len(batch))
+		return
+	}
 
-		// Publish to the message broker
-		if err := p.eventPublisher.Publish(ctx, msg.EventType, event); err != nil {
-			return fmt.Errorf("failed to publish event to broker: %w", err)
+	published := len(batch)
+	err := p.batchPublisher.PublishBatch(ctx, envelopes)
+	if err != nil {
+		published = 0
+		var batchErr *ports.BatchError
+		if errors.As(err, &batchErr) {
+			published = batchErr.Index
 		}
+	}
 
-	// case "order.updated":
-	// 	var event domain.OrderUpdatedEvent
-	// 	if err := json.Unmarshal(msg.Payload, &event); err != nil {
-	// 		return fmt.Errorf("failed to unmarshal order.updated event: %w", err)
-	// 	}
+	for _, msg := range batch[:published] {
+		if err := p.outboxRepo.MarkMessageAsProcessed(ctx, msg.ID); err != nil {
+			log.Printf("Failed to mark message %s as processed: %v", msg.ID, err)
+		}
+	}
 
-	// 	// Publish to the message broker
-	// 	if err := p.eventPublisher.Publish(ctx, msg.EventType, event); err != nil {
-	// 		return fmt.Errorf("failed to publish event to broker: %w", err)
-	// 	}
+	if err != nil {
+		err = fmt.Errorf("failed to publish batch to broker: %w", err)
+		for _, msg := range batch[published:] {
+			p.handleFailure(ctx, msg, err)
+		}
+	}
+}
 
-	// Add more event types as needed
+// unpublishable reports whether the message has an event type or schema version this worker
+// does not know, most likely because a newer release of the service wrote it
+func unpublishable(err error) bool {
+	return errors.Is(err, events.ErrUnknownEventType) || errors.Is(err, events.ErrUnknownSchemaVersion)
+}
 
-	default:
-		log.Printf("Unknown event type: %s", msg.EventType)
-		// We'll still mark it as processed since we can't do anything with it
-		return nil
+// parkMessage keeps a message of an unknown type or version until a worker that knows it picks it up
+func (p *OutboxProcessor) parkMessage(ctx context.Context, msg domain.OutboxMessage, err error) {
+	log.Printf("Parking message %s: %v", msg.ID, err)
+	if parkErr := p.outboxRepo.ParkMessage(ctx, msg.ID, err.Error()); parkErr != nil {
+		log.Printf("Failed to park message %s: %v", msg.ID, parkErr)
+	}
+}
+
+// handleFailure schedules a retry of a message that could not be published,
+// or moves it to the FAILED state once it used up all its attempts
+func (p *OutboxProcessor) handleFailure(ctx context.Context, msg domain.OutboxMessage, err error) {
+	attempts := msg.AttemptCount + 1
+	if attempts >= p.maxRetries {
+		log.Printf("Message %s failed %d times, marking as failed: %v", msg.ID, attempts, err)
+		if markErr := p.outboxRepo.MarkMessageAsFailed(ctx, msg.ID, err.Error()); markErr != nil {
+			log.Printf("Failed to mark message %s as failed: %v", msg.ID, markErr)
+		}
+		return
+	}
+
+	delay := p.backoff.Delay(attempts)
+	log.Printf("Failed to process message %s (attempt %d), retrying in %s: %v", msg.ID, attempts, delay, err)
+	if retryErr := p.outboxRepo.ScheduleRetry(ctx, msg.ID, delay, err.Error()); retryErr != nil {
+		log.Printf("Failed to schedule retry of message %s: %v", msg.ID, retryErr)
+	}
+}
+
+// forwardDeadLetters claims failed messages and forwards them to the dead-letter topic.
+// A message that cannot be forwarded is tried again on later ticks, up to deadLetterMaxRetries times.
+func (p *OutboxProcessor) forwardDeadLetters(ctx context.Context, workerID string) error {
+	deadline := time.Now().Add(p.leaseDuration)
+	messages, err := p.outboxRepo.ClaimDeadLetters(ctx, workerID, p.batchSize, p.deadLetterMaxRetries, p.leaseDuration)
+	if err != nil {
+		return fmt.Errorf("failed to claim dead letters: %w", err)
+	}
+
+	for _, msg := range messages {
+		if time.Now().After(deadline) {
+			break
+		}
+
+		if err := p.deadLetters.PublishDeadLetter(ctx, p.deadLetterQueue, msg); err != nil {
+			log.Printf("Failed to forward message %s to %s: %v", msg.ID, p.deadLetterQueue, err)
+			if recordErr := p.outboxRepo.RecordDeadLetterFailure(ctx, msg.ID); recordErr != nil {
+				log.Printf("Failed to record dead-letter failure of message %s: %v", msg.ID, recordErr)
+			}
+			continue
+		}
+
+		if err := p.outboxRepo.MarkMessageAsDeadLettered(ctx, msg.ID); err != nil {
+			log.Printf("Failed to mark message %s as dead-lettered: %v", msg.ID, err)
+		}
 	}
 
 	return nil
 }
+
+// processMessage publishes a single outbox message as described by its registered event type.
+// Payloads written with an older schema version are upcast to the current one.
+func (p *OutboxProcessor) processMessage(ctx context.Context, msg domain.OutboxMessage) error {
+	return publishMessage(ctx, p.registry, p.eventPublisher, msg)
+}
+
+// publishMessage publishes the outbox message to the topic of its registered event type,
+// keyed and with headers as the event definition describes
+func publishMessage(ctx context.Context, registry *events.Registry, publisher ports.EnvelopePublisher, msg domain.OutboxMessage) error {
+	envelope, err := newEnvelope(registry, msg)
+	if err != nil {
+		return err
+	}
+
+	// Publish to the message broker
+	if err := publisher.PublishEnvelope(ctx, envelope); err != nil {
+		return fmt.Errorf("failed to publish event to broker: %w", err)
+	}
+
+	return nil
+}
+
+// newEnvelope decodes the outbox message as the current version of its registered event type.
+// The event keeps the message ID, so consumers recognize events published more than once.
+func newEnvelope(registry *events.Registry, msg domain.OutboxMessage) (ports.Envelope, error) {
+	def, err := registry.Lookup(msg.EventType)
+	if err != nil {
+		return ports.Envelope{}, err
+	}
+
+	event, err := def.Decode(msg.Payload, msg.SchemaVersion)
+	if err != nil {
+		return ports.Envelope{}, err
+	}
+
+	return ports.Envelope{
+		ID:            msg.ID.String(),
+		Topic:         def.Topic,
+		Key:           def.Key(event),
+		AggregateID:   msg.AggregateID.String(),
+		EventType:     def.Type,
+		SchemaVersion: def.Version,
+		Extensions: map[string]string{
+			events.ExtensionSequence: strconv.FormatInt(msg.SequenceNumber, 10),
+		},
+		Event: event,
+	}, nil
+}
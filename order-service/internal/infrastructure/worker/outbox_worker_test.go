@@ -0,0 +1,483 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"order-service/internal/app/ports"
+	"order-service/internal/domain"
+	"order-service/internal/events"
+	"order-service/internal/infrastructure/config"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockOutboxRepo struct {
+	mock.Mock
+}
+
+func (m *mockOutboxRepo) CreateMessage(ctx context.Context, aggregateID uuid.UUID, eventType string, payload interface{}) error {
+	args := m.Called(ctx, aggregateID, eventType, payload)
+	return args.Error(0)
+}
+
+func (m *mockOutboxRepo) ClaimPendingMessages(ctx context.Context, workerID string, limit int, lease time.Duration) ([]domain.OutboxMessage, error) {
+	args := m.Called(ctx, workerID, limit, lease)
+	return args.Get(0).([]domain.OutboxMessage), args.Error(1)
+}
+
+func (m *mockOutboxRepo) MarkMessageAsProcessed(ctx context.Context, messageID uuid.UUID) error {
+	args := m.Called(ctx, messageID)
+	return args.Error(0)
+}
+
+func (m *mockOutboxRepo) MarkMessageAsFailed(ctx context.Context, messageID uuid.UUID, reason string) error {
+	args := m.Called(ctx, messageID, reason)
+	return args.Error(0)
+}
+
+func (m *mockOutboxRepo) ScheduleRetry(ctx context.Context, messageID uuid.UUID, delay time.Duration, lastError string) error {
+	args := m.Called(ctx, messageID, delay, lastError)
+	return args.Error(0)
+}
+
+func (m *mockOutboxRepo) ClaimDeadLetters(ctx context.Context, workerID string, limit, maxAttempts int, lease time.Duration) ([]domain.OutboxMessage, error) {
+	args := m.Called(ctx, workerID, limit, maxAttempts, lease)
+	return args.Get(0).([]domain.OutboxMessage), args.Error(1)
+}
+
+func (m *mockOutboxRepo) MarkMessageAsDeadLettered(ctx context.Context, messageID uuid.UUID) error {
+	args := m.Called(ctx, messageID)
+	return args.Error(0)
+}
+
+func (m *mockOutboxRepo) RecordDeadLetterFailure(ctx context.Context, messageID uuid.UUID) error {
+	args := m.Called(ctx, messageID)
+	return args.Error(0)
+}
+
+func (m *mockOutboxRepo) ParkMessage(ctx context.Context, messageID uuid.UUID, reason string) error {
+	args := m.Called(ctx, messageID, reason)
+	return args.Error(0)
+}
+
+func (m *mockOutboxRepo) UnparkMessages(ctx context.Context, eventTypes []string) (int64, error) {
+	args := m.Called(ctx, eventTypes)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockOutboxRepo) GetMessage(ctx context.Context, messageID uuid.UUID) (*domain.OutboxMessage, error) {
+	args := m.Called(ctx, messageID)
+	if msg, ok := args.Get(0).(*domain.OutboxMessage); ok {
+		return msg, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *mockOutboxRepo) HasUnpublishedPredecessor(ctx context.Context, msg domain.OutboxMessage) (bool, error) {
+	args := m.Called(ctx, msg)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockOutboxRepo) ListHeldMessages(ctx context.Context, aggregateID uuid.UUID, after int64) ([]domain.OutboxMessage, error) {
+	args := m.Called(ctx, aggregateID, after)
+	return args.Get(0).([]domain.OutboxMessage), args.Error(1)
+}
+
+type mockEventPublisher struct {
+	mock.Mock
+}
+
+func (m *mockEventPublisher) Publish(ctx context.Context, topic string, event interface{}) error {
+	args := m.Called(ctx, topic, event)
+	return args.Error(0)
+}
+
+func (m *mockEventPublisher) PublishEnvelope(ctx context.Context, envelope ports.Envelope) error {
+	args := m.Called(ctx, envelope)
+	return args.Error(0)
+}
+
+// envelopeOf matches an envelope with the metadata of the expected one, whatever its event
+func envelopeOf(expected ports.Envelope) interface{} {
+	return mock.MatchedBy(func(envelope ports.Envelope) bool {
+		envelope.Event = nil
+		return reflect.DeepEqual(expected, envelope)
+	})
+}
+
+type mockBatchPublisher struct {
+	mockEventPublisher
+}
+
+func (m *mockBatchPublisher) PublishBatch(ctx context.Context, envelopes []ports.Envelope) error {
+	args := m.Called(ctx, envelopes)
+	return args.Error(0)
+}
+
+type mockDeadLetterPublisher struct {
+	mock.Mock
+}
+
+func (m *mockDeadLetterPublisher) PublishDeadLetter(ctx context.Context, topic string, msg domain.OutboxMessage) error {
+	args := m.Called(ctx, topic, msg)
+	return args.Error(0)
+}
+
+type fakeNotifier struct {
+	notifications chan struct{}
+	connected     bool
+}
+
+func (n *fakeNotifier) Notifications() <-chan struct{} { return n.notifications }
+
+func (n *fakeNotifier) Connected() bool { return n.connected }
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: 10 * time.Second, Multiplier: 2}
+
+	assert.Equal(t, time.Second, b.Delay(1))
+	assert.Equal(t, 2*time.Second, b.Delay(2))
+	assert.Equal(t, 8*time.Second, b.Delay(4))
+	assert.Equal(t, 10*time.Second, b.Delay(5))
+	assert.Equal(t, 10*time.Second, b.Delay(1000))
+
+	b.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay := b.Delay(3)
+		assert.GreaterOrEqual(t, delay, 2*time.Second)
+		assert.LessOrEqual(t, delay, 6*time.Second)
+	}
+
+	// Unset fields fall back to the defaults, zero jitter stays disabled
+	assert.Equal(t, Backoff{Initial: time.Second, Max: 5 * time.Minute, Multiplier: 2}, Backoff{}.withDefaults())
+}
+
+func TestProcessOutboxMessagesRetries(t *testing.T) {
+	orderID := uuid.New()
+	payload, _ := json.Marshal(events.OrderCreatedEvent{
+		OrderID:    orderID,
+		TotalPrice: domain.MustParseMoney("10.00", domain.CurrencyUSD),
+	})
+	publishErr := errors.New("broker unavailable")
+	// The recorded error wraps the publish error
+	lastError := mock.MatchedBy(func(reason string) bool { return strings.HasSuffix(reason, publishErr.Error()) })
+
+	testCases := []struct {
+		name         string
+		attemptCount int
+		publishErr   error
+		expectRetry  bool
+		expectFailed bool
+	}{
+		{name: "Success - Message published", attemptCount: 0},
+		{name: "Failure - Retry scheduled", attemptCount: 1, publishErr: publishErr, expectRetry: true},
+		{name: "Failure - Out of attempts", attemptCount: 2, publishErr: publishErr, expectFailed: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mockOutboxRepo)
+			publisher := new(mockEventPublisher)
+
+			processor := NewOutboxProcessor(repo, publisher, nil, events.NewDefaultRegistry(), config.OutboxWorkerConfig{
+				BatchSize:           10,
+				MaxRetries:          3,
+				LeaseDuration:       time.Minute,
+				RetryInitialBackoff: time.Second,
+				RetryMaxBackoff:     time.Minute,
+			})
+			processor.backoff.Jitter = 0
+
+			msg := domain.OutboxMessage{
+				ID:             uuid.New(),
+				AggregateID:    orderID,
+				SequenceNumber: 3,
+				EventType:      events.OrderCreatedEventType,
+				Payload:        payload,
+				AttemptCount:   tc.attemptCount,
+			}
+
+			repo.On("ClaimPendingMessages", mock.Anything, "worker-0", 10, time.Minute).Return([]domain.OutboxMessage{msg}, nil)
+			envelope := ports.Envelope{
+				ID:            msg.ID.String(),
+				Topic:         events.OrderCreatedEventType,
+				Key:           orderID.String(),
+				AggregateID:   orderID.String(),
+				EventType:     events.OrderCreatedEventType,
+				SchemaVersion: events.OrderCreatedEventVersion,
+				Extensions: map[string]string{
+					events.ExtensionSequence: "3",
+				},
+			}
+			publisher.On("PublishEnvelope", mock.Anything, envelopeOf(envelope)).Return(tc.publishErr)
+			repo.On("MarkMessageAsProcessed", mock.Anything, msg.ID).Return(nil)
+			repo.On("ScheduleRetry", mock.Anything, msg.ID, 2*time.Second, lastError).Return(nil)
+			repo.On("MarkMessageAsFailed", mock.Anything, msg.ID, lastError).Return(nil)
+
+			claimed, err := processor.processOutboxMessages(context.Background(), "worker-0")
+			assert.NoError(t, err)
+			assert.Equal(t, 1, claimed)
+
+			if tc.expectRetry {
+				repo.AssertCalled(t, "ScheduleRetry", mock.Anything, msg.ID, 2*time.Second, lastError)
+			} else {
+				repo.AssertNotCalled(t, "ScheduleRetry", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+
+			if tc.expectFailed {
+				repo.AssertCalled(t, "MarkMessageAsFailed", mock.Anything, msg.ID, lastError)
+			} else {
+				repo.AssertNotCalled(t, "MarkMessageAsFailed", mock.Anything, mock.Anything, mock.Anything)
+			}
+
+			if tc.publishErr == nil {
+				repo.AssertCalled(t, "MarkMessageAsProcessed", mock.Anything, msg.ID)
+			} else {
+				repo.AssertNotCalled(t, "MarkMessageAsProcessed", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestProcessOutboxMessagesParksUnknownTypes(t *testing.T) {
+	repo := new(mockOutboxRepo)
+	publisher := new(mockEventPublisher)
+
+	processor := NewOutboxProcessor(repo, publisher, nil, events.NewDefaultRegistry(), config.OutboxWorkerConfig{
+		BatchSize:     10,
+		LeaseDuration: time.Minute,
+	})
+
+	msg := domain.OutboxMessage{ID: uuid.New(), EventType: "order.refunded", Payload: []byte(`{}`)}
+
+	repo.On("ClaimPendingMessages", mock.Anything, "worker-0", 10, time.Minute).Return([]domain.OutboxMessage{msg}, nil)
+	repo.On("ParkMessage", mock.Anything, msg.ID, mock.Anything).Return(nil)
+
+	_, err := processor.processOutboxMessages(context.Background(), "worker-0")
+	assert.NoError(t, err)
+
+	// Unknown types are neither published, retried nor marked as processed
+	repo.AssertCalled(t, "ParkMessage", mock.Anything, msg.ID, mock.Anything)
+	repo.AssertNotCalled(t, "MarkMessageAsProcessed", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "ScheduleRetry", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	publisher.AssertNotCalled(t, "PublishEnvelope", mock.Anything, mock.Anything)
+}
+
+func TestProcessOutboxMessagesUpcastsPayloads(t *testing.T) {
+	repo := new(mockOutboxRepo)
+	publisher := new(mockEventPublisher)
+
+	processor := NewOutboxProcessor(repo, publisher, nil, events.NewDefaultRegistry(), config.OutboxWorkerConfig{
+		BatchSize:     10,
+		LeaseDuration: time.Minute,
+	})
+
+	orderID := uuid.New()
+	// Written before prices were money amounts
+	old := domain.OutboxMessage{
+		ID:            uuid.New(),
+		AggregateID:   orderID,
+		EventType:     events.OrderItemRemovedEventType,
+		Payload:       []byte(`{"OrderID":"` + orderID.String() + `","TotalPrice":56.480000000000004}`),
+		SchemaVersion: 1,
+	}
+	// Written by a newer release of the service
+	newer := domain.OutboxMessage{
+		ID:            uuid.New(),
+		AggregateID:   orderID,
+		EventType:     events.OrderItemRemovedEventType,
+		Payload:       []byte(`{}`),
+		SchemaVersion: events.OrderItemRemovedEventVersion + 1,
+	}
+
+	repo.On("ClaimPendingMessages", mock.Anything, "worker-0", 10, time.Minute).Return([]domain.OutboxMessage{old, newer}, nil)
+	publisher.On("PublishEnvelope", mock.Anything, mock.MatchedBy(func(envelope ports.Envelope) bool {
+		event, ok := envelope.Event.(*events.OrderItemRemovedEvent)
+		return ok && envelope.SchemaVersion == events.OrderItemRemovedEventVersion &&
+			event.TotalPrice.Equal(domain.MustParseMoney("56.48", domain.CurrencyUSD))
+	})).Return(nil)
+	repo.On("MarkMessageAsProcessed", mock.Anything, old.ID).Return(nil)
+	repo.On("ParkMessage", mock.Anything, newer.ID, mock.Anything).Return(nil)
+
+	_, err := processor.processOutboxMessages(context.Background(), "worker-0")
+	assert.NoError(t, err)
+
+	// The old payload is published in the current version, the newer one waits for a newer worker
+	publisher.AssertNumberOfCalls(t, "PublishEnvelope", 1)
+	repo.AssertCalled(t, "MarkMessageAsProcessed", mock.Anything, old.ID)
+	repo.AssertCalled(t, "ParkMessage", mock.Anything, newer.ID, mock.Anything)
+}
+
+func TestProcessOutboxMessagesPublishesBatch(t *testing.T) {
+	payload, _ := json.Marshal(events.OrderCreatedEvent{OrderID: uuid.New()})
+	first := domain.OutboxMessage{ID: uuid.New(), EventType: events.OrderCreatedEventType, Payload: payload}
+	second := domain.OutboxMessage{ID: uuid.New(), EventType: events.OrderCreatedEventType, Payload: payload}
+	unknown := domain.OutboxMessage{ID: uuid.New(), EventType: "order.refunded", Payload: []byte(`{}`)}
+	batch := mock.MatchedBy(func(envelopes []ports.Envelope) bool {
+		return len(envelopes) == 2 &&
+			envelopes[0].ID == first.ID.String() &&
+			envelopes[1].ID == second.ID.String()
+	})
+
+	testCases := []struct {
+		name              string
+		publishErr        error
+		expectedProcessed int
+	}{
+		{name: "Success - Batch committed", expectedProcessed: 2},
+		{name: "Failure - Every message retried", publishErr: errors.New("transaction aborted")},
+		{
+			name:              "Failure - Published messages processed, the rest retried",
+			publishErr:        &ports.BatchError{Index: 1, Err: errors.New("not enough replicas")},
+			expectedProcessed: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mockOutboxRepo)
+			publisher := new(mockBatchPublisher)
+
+			processor := NewOutboxProcessor(repo, publisher, nil, events.NewDefaultRegistry(), config.OutboxWorkerConfig{
+				BatchSize:     10,
+				MaxRetries:    3,
+				LeaseDuration: time.Minute,
+				Transactional: true,
+			})
+
+			repo.On("ClaimPendingMessages", mock.Anything, "worker-0", 10, time.Minute).Return([]domain.OutboxMessage{first, unknown, second}, nil)
+			repo.On("ParkMessage", mock.Anything, unknown.ID, mock.Anything).Return(nil)
+			publisher.On("PublishBatch", mock.Anything, batch).Return(tc.publishErr)
+			repo.On("MarkMessageAsProcessed", mock.Anything, mock.Anything).Return(nil)
+			repo.On("ScheduleRetry", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+			claimed, err := processor.processOutboxMessages(context.Background(), "worker-0")
+			assert.NoError(t, err)
+			assert.Equal(t, 3, claimed)
+
+			publisher.AssertNumberOfCalls(t, "PublishBatch", 1)
+			publisher.AssertNotCalled(t, "PublishEnvelope", mock.Anything, mock.Anything)
+			repo.AssertCalled(t, "ParkMessage", mock.Anything, unknown.ID, mock.Anything)
+			repo.AssertNumberOfCalls(t, "MarkMessageAsProcessed", tc.expectedProcessed)
+			repo.AssertNumberOfCalls(t, "ScheduleRetry", 2-tc.expectedProcessed)
+			if tc.expectedProcessed == 1 {
+				repo.AssertCalled(t, "MarkMessageAsProcessed", mock.Anything, first.ID)
+				repo.AssertCalled(t, "ScheduleRetry", mock.Anything, second.ID, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestForwardDeadLetters(t *testing.T) {
+	repo := new(mockOutboxRepo)
+	deadLetters := new(mockDeadLetterPublisher)
+
+	processor := NewOutboxProcessor(repo, new(mockEventPublisher), deadLetters, events.NewDefaultRegistry(), config.OutboxWorkerConfig{
+		BatchSize:            10,
+		LeaseDuration:        time.Minute,
+		DeadLetterQueue:      "outbox-dlq",
+		DeadLetterMaxRetries: 3,
+	})
+
+	forwarded := domain.OutboxMessage{ID: uuid.New(), EventType: events.OrderCreatedEventType, Status: domain.OutboxStatusFailed}
+	rejected := domain.OutboxMessage{ID: uuid.New(), EventType: events.OrderCancelledEventType, Status: domain.OutboxStatusFailed}
+
+	repo.On("ClaimDeadLetters", mock.Anything, "worker-0", 10, 3, time.Minute).Return([]domain.OutboxMessage{forwarded, rejected}, nil)
+	deadLetters.On("PublishDeadLetter", mock.Anything, "outbox-dlq", forwarded).Return(nil)
+	deadLetters.On("PublishDeadLetter", mock.Anything, "outbox-dlq", rejected).Return(errors.New("broker unavailable"))
+	repo.On("MarkMessageAsDeadLettered", mock.Anything, forwarded.ID).Return(nil)
+	repo.On("RecordDeadLetterFailure", mock.Anything, rejected.ID).Return(nil)
+
+	err := processor.forwardDeadLetters(context.Background(), "worker-0")
+	assert.NoError(t, err)
+
+	repo.AssertExpectations(t)
+	deadLetters.AssertExpectations(t)
+	repo.AssertNotCalled(t, "MarkMessageAsDeadLettered", mock.Anything, rejected.ID)
+}
+
+func TestStartDeadLettersOnlyForwardsDeadLetters(t *testing.T) {
+	repo := new(mockOutboxRepo)
+	deadLetters := new(mockDeadLetterPublisher)
+
+	processor := NewOutboxProcessor(repo, new(mockEventPublisher), deadLetters, events.NewDefaultRegistry(), config.OutboxWorkerConfig{
+		ProcessInterval: time.Millisecond,
+		DeadLetterQueue: "outbox-dlq",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	failed := domain.OutboxMessage{ID: uuid.New(), Status: domain.OutboxStatusFailed}
+	repo.On("ClaimDeadLetters", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]domain.OutboxMessage{failed}, nil).Once()
+	repo.On("ClaimDeadLetters", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]domain.OutboxMessage(nil), nil)
+	deadLetters.On("PublishDeadLetter", mock.Anything, "outbox-dlq", failed).Return(nil)
+	repo.On("MarkMessageAsDeadLettered", mock.Anything, failed.ID).Run(func(mock.Arguments) { cancel() }).Return(nil)
+
+	assert.ErrorIs(t, processor.StartDeadLetters(ctx), context.Canceled)
+
+	// Pending messages are left to the relay
+	repo.AssertNotCalled(t, "ClaimPendingMessages", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "UnparkMessages", mock.Anything, mock.Anything)
+	deadLetters.AssertExpectations(t)
+}
+
+func TestDeadLetterQueueRequiresPublisher(t *testing.T) {
+	processor := NewOutboxProcessor(new(mockOutboxRepo), new(mockEventPublisher), nil, events.NewDefaultRegistry(), config.OutboxWorkerConfig{
+		DeadLetterQueue: "outbox-dlq",
+	})
+
+	assert.Empty(t, processor.deadLetterQueue)
+}
+
+func TestWorkerWakesOnNotification(t *testing.T) {
+	repo := new(mockOutboxRepo)
+	notifier := &fakeNotifier{notifications: make(chan struct{}, 1), connected: true}
+
+	processor := NewOutboxProcessor(repo, new(mockEventPublisher), nil, events.NewDefaultRegistry(), config.OutboxWorkerConfig{
+		BatchSize:          10,
+		ProcessInterval:    time.Hour,
+		NotifyPollInterval: time.Hour,
+		LeaseDuration:      time.Minute,
+	})
+	processor.UseNotifier(notifier)
+
+	claims := make(chan struct{}, 1)
+	repo.On("ClaimPendingMessages", mock.Anything, "worker-0", 10, time.Minute).
+		Run(func(mock.Arguments) { claims <- struct{}{} }).
+		Return([]domain.OutboxMessage{}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go processor.runWorker(ctx, "worker-0")
+
+	notifier.notifications <- struct{}{}
+	select {
+	case <-claims:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not wake up on the notification")
+	}
+}
+
+func TestPollIntervalFallsBackWhenDisconnected(t *testing.T) {
+	notifier := &fakeNotifier{connected: true}
+
+	processor := NewOutboxProcessor(new(mockOutboxRepo), new(mockEventPublisher), nil, events.NewDefaultRegistry(), config.OutboxWorkerConfig{
+		ProcessInterval:    5 * time.Second,
+		NotifyPollInterval: time.Minute,
+	})
+	assert.Equal(t, 5*time.Second, processor.pollInterval())
+
+	processor.UseNotifier(notifier)
+	assert.Equal(t, time.Minute, processor.pollInterval())
+
+	notifier.connected = false
+	assert.Equal(t, 5*time.Second, processor.pollInterval())
+}
@@ -9,17 +9,19 @@ import (
 
 // Request DTOs
 
-// CreateOrderRequest represents the request to create a new order
+// CreateOrderRequest represents the request to create a new order.
+// Currency is optional and defaults to the currency of the first item.
 type CreateOrderRequest struct {
 	CustomerID string                   `json:"customer_id"`
+	Currency   string                   `json:"currency"`
 	Items      []CreateOrderItemRequest `json:"items"`
 }
 
 // CreateOrderItemRequest represents an item in the order creation request
 type CreateOrderItemRequest struct {
-	ProductID string  `json:"product_id"`
-	Quantity  int     `json:"quantity"`
-	Price     float64 `json:"price"`
+	ProductID string       `json:"product_id"`
+	Quantity  int          `json:"quantity"`
+	Price     domain.Money `json:"price"`
 }
 
 // UpdateOrderStatusRequest represents the request to update an order's status
@@ -27,17 +29,26 @@ type UpdateOrderStatusRequest struct {
 	Status string `json:"status"`
 }
 
+// AddOrderItemRequest represents the request to add an item to an existing order
+type AddOrderItemRequest struct {
+	ProductID string       `json:"product_id"`
+	Quantity  int          `json:"quantity"`
+	Price     domain.Money `json:"price"`
+}
+
 // Response DTOs
 
 // OrderResponse represents the response format for an order
 type OrderResponse struct {
-	ID         uuid.UUID
-	CustomerID string
-	Status     string
-	TotalPrice float64
-	Items      []OrderItemResponse
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
+	ID            uuid.UUID
+	CustomerID    string
+	Status        string
+	Currency      string
+	TotalPrice    domain.Money
+	ExchangeRates []domain.ExchangeRate
+	Items         []OrderItemResponse
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
 }
 
 // OrderItemResonse represents an item in the order reponse
@@ -45,7 +56,7 @@ type OrderItemResponse struct {
 	ID        uuid.UUID
 	ProductID string
 	Quantity  int
-	Price     float64
+	Price     domain.Money
 }
 
 // Conversion functions
@@ -63,14 +74,34 @@ func OrderToResponse(order *domain.Order) OrderResponse {
 	}
 
 	return OrderResponse{
-		ID:         order.ID,
-		CustomerID: order.CustomerID,
-		Status:     string(order.Status),
-		TotalPrice: order.TotalPrice,
-		Items:      itemResponses,
-		CreatedAt:  order.CreatedAt,
-		UpdatedAt:  order.UpdatedAt,
+		ID:            order.ID,
+		CustomerID:    order.CustomerID,
+		Status:        string(order.Status),
+		Currency:      string(order.Currency),
+		TotalPrice:    order.TotalPrice,
+		ExchangeRates: order.ExchangeRates,
+		Items:         itemResponses,
+		CreatedAt:     order.CreatedAt,
+		UpdatedAt:     order.UpdatedAt,
 	}
 }
 
-// OrderLi
+// OrderListResponse represents a page of orders
+type OrderListResponse struct {
+	Orders []OrderResponse
+	// NextCursor is passed as the cursor query parameter to get the next page
+	NextCursor string `json:",omitempty"`
+}
+
+// OrdersToListResponse converts a page of domain orders to response DTO
+func OrdersToListResponse(page *domain.OrderPage) OrderListResponse {
+	responses := make([]OrderResponse, 0, len(page.Orders))
+	for _, order := range page.Orders {
+		responses = append(responses, OrderToResponse(order))
+	}
+
+	return OrderListResponse{
+		Orders:     responses,
+		NextCursor: page.NextCursor,
+	}
+}
@@ -0,0 +1,100 @@
+package dto
+
+import (
+	"encoding/json"
+	"order-service/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Request DTOs
+
+// UpdateOutboxPayloadRequest represents the request to replace the payload of a failed message
+type UpdateOutboxPayloadRequest struct {
+	Payload json.RawMessage `json:"payload"`
+}
+
+// OutboxFilterRequest selects the failed messages to requeue or discard.
+// At least one criterion is required.
+type OutboxFilterRequest struct {
+	IDs          []uuid.UUID `json:"ids"`
+	EventType    string      `json:"event_type"`
+	FailedAfter  time.Time   `json:"failed_after"`
+	FailedBefore time.Time   `json:"failed_before"`
+}
+
+// ToFilter converts the request to a domain filter
+func (r OutboxFilterRequest) ToFilter() domain.OutboxMessageFilter {
+	return domain.OutboxMessageFilter{
+		IDs:          r.IDs,
+		EventType:    r.EventType,
+		FailedAfter:  r.FailedAfter,
+		FailedBefore: r.FailedBefore,
+	}
+}
+
+// Response DTOs
+
+// OutboxMessageResponse represents the response format for an outbox message
+type OutboxMessageResponse struct {
+	ID                 uuid.UUID
+	AggregateID        uuid.UUID
+	SequenceNumber     int64
+	EventType          string
+	SchemaVersion      int
+	Payload            json.RawMessage
+	Status             string
+	AttemptCount       int
+	LastError          string
+	CreatedAt          time.Time
+	FailedAt           *time.Time `json:",omitempty"`
+	DeadLetteredAt     *time.Time `json:",omitempty"`
+	DeadLetterAttempts int
+}
+
+// OutboxMessageListResponse represents a list of failed outbox messages
+type OutboxMessageListResponse struct {
+	Messages []OutboxMessageResponse
+}
+
+// OutboxBulkResponse reports how many messages a requeue or discard affected
+type OutboxBulkResponse struct {
+	Affected int64
+}
+
+// OutboxMessageToResponse converts a domain outbox message to response DTO
+func OutboxMessageToResponse(msg domain.OutboxMessage) OutboxMessageResponse {
+	optionalTime := func(t time.Time) *time.Time {
+		if t.IsZero() {
+			return nil
+		}
+		return &t
+	}
+
+	return OutboxMessageResponse{
+		ID:                 msg.ID,
+		AggregateID:        msg.AggregateID,
+		SequenceNumber:     msg.SequenceNumber,
+		EventType:          msg.EventType,
+		SchemaVersion:      msg.SchemaVersion,
+		Payload:            json.RawMessage(msg.Payload),
+		Status:             string(msg.Status),
+		AttemptCount:       msg.AttemptCount,
+		LastError:          msg.LastError,
+		CreatedAt:          msg.CreatedAt,
+		FailedAt:           optionalTime(msg.FailedAt),
+		DeadLetteredAt:     optionalTime(msg.DeadLetteredAt),
+		DeadLetterAttempts: msg.DeadLetterAttempts,
+	}
+}
+
+// OutboxMessagesToListResponse converts domain outbox messages to response DTO
+func OutboxMessagesToListResponse(messages []domain.OutboxMessage) OutboxMessageListResponse {
+	responses := make([]OutboxMessageResponse, 0, len(messages))
+	for _, msg := range messages {
+		responses = append(responses, OutboxMessageToResponse(msg))
+	}
+
+	return OutboxMessageListResponse{Messages: responses}
+}
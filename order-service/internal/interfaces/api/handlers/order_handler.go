@@ -2,11 +2,17 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"order-service/internal/app/ports"
 	"order-service/internal/domain"
 	"order-service/internal/interfaces/api/dto"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
@@ -27,18 +33,29 @@ func NewOrderHandler(orderUseCase ports.OrderUseCase) *OrderHandler {
 func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var req dto.CreateOrderRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadGateway, errorResponse("invalid request body"))
+		writeJSON(w, http.StatusBadRequest, errorResponse("invalid request body"))
 		return
 	}
 
 	// Validate request
 	if req.CustomerID == "" {
-		writeJSON(w, http.StatusBadGateway, errorResponse("invalid request body"))
+		writeJSON(w, http.StatusBadRequest, errorResponse("invalid request body"))
 		return
 	}
 
 	if len(req.Items) == 0 {
-		writeJSON(w, http.StatusBadGateway, errorResponse("order must have at least one item"))
+		writeJSON(w, http.StatusBadRequest, errorResponse("order must have at least one item"))
+		return
+	}
+
+	var currency domain.Currency
+	if req.Currency != "" {
+		parsed, err := domain.ParseCurrency(req.Currency)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+		currency = parsed
 	}
 
 	// Convert DTO to domain model
@@ -53,7 +70,7 @@ func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create order
-	order, err := h.orderUseCase.CreateOrder(r.Context(), req.CustomerID, items)
+	order, err := h.orderUseCase.CreateOrder(r.Context(), req.CustomerID, currency, items)
 	if err != nil {
 		handleError(w, err)
 		return
@@ -65,6 +82,191 @@ func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// Get handles retrieving a single order
+func (h *OrderHandler) Get(w http.ResponseWriter, r *http.Request) {
+	order, err := h.orderUseCase.GetOrder(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dto.OrderToResponse(order))
+}
+
+// List handles listing orders page by page.
+// Supported query parameters are limit, cursor, sort, customer_id, status,
+// created_after, created_before, min_total, max_total and currency.
+// Sorting by total_price requires a currency.
+func (h *OrderHandler) List(w http.ResponseWriter, r *http.Request) {
+	query, err := orderListQuery(r)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	page, err := h.orderUseCase.ListOrders(r.Context(), query)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dto.OrdersToListResponse(page))
+}
+
+// orderListQuery builds an order listing query from the request's query parameters
+func orderListQuery(r *http.Request) (domain.OrderListQuery, error) {
+	params := r.URL.Query()
+	query := domain.OrderListQuery{
+		Filter: domain.OrderFilter{CustomerID: params.Get("customer_id")},
+	}
+
+	limit, err := queryInt(r, "limit", domain.DefaultOrderPageSize)
+	if err != nil {
+		return query, fmt.Errorf("%w: invalid limit", domain.ErrInvalidFilter)
+	}
+	query.Limit = limit
+
+	if sort := params.Get("sort"); sort != "" {
+		if query.Sort, err = domain.ParseOrderSort(sort); err != nil {
+			return query, err
+		}
+	}
+
+	if token := params.Get("cursor"); token != "" {
+		cursor, err := domain.DecodeOrderCursor(token)
+		if err != nil {
+			return query, err
+		}
+		query.After = &cursor
+	}
+
+	// Statuses may be repeated or comma separated
+	for _, value := range params["status"] {
+		for _, s := range strings.Split(value, ",") {
+			status, err := domain.ParseOrderStatus(strings.TrimSpace(s))
+			if err != nil {
+				return query, err
+			}
+			query.Filter.Statuses = append(query.Filter.Statuses, status)
+		}
+	}
+
+	if query.Filter.CreatedAfter, err = queryTime(r, "created_after"); err != nil {
+		return query, err
+	}
+
+	if query.Filter.CreatedBefore, err = queryTime(r, "created_before"); err != nil {
+		return query, err
+	}
+
+	if value := params.Get("currency"); value != "" {
+		if query.Filter.Currency, err = domain.ParseCurrency(value); err != nil {
+			return query, err
+		}
+	}
+
+	minTotal, maxTotal := params.Get("min_total"), params.Get("max_total")
+	if minTotal == "" && maxTotal == "" {
+		return query, nil
+	}
+
+	currency := query.Filter.Currency
+	if currency == "" {
+		return query, fmt.Errorf("%w: currency is required with min_total and max_total", domain.ErrInvalidCurrency)
+	}
+
+	if minTotal != "" {
+		amount, err := domain.ParseMoney(minTotal, currency)
+		if err != nil {
+			return query, err
+		}
+		query.Filter.MinTotal = &amount
+	}
+
+	if maxTotal != "" {
+		amount, err := domain.ParseMoney(maxTotal, currency)
+		if err != nil {
+			return query, err
+		}
+		query.Filter.MaxTotal = &amount
+	}
+
+	return query, nil
+}
+
+// UpdateStatus handles changing the status of an order
+func (h *OrderHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdateOrderStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse("invalid request body"))
+		return
+	}
+
+	status, err := domain.ParseOrderStatus(req.Status)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if err := h.orderUseCase.UpdateOrderStatus(r.Context(), id, status); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	h.writeOrder(w, r, id, http.StatusOK)
+}
+
+// AddItem handles adding an item to an order
+func (h *OrderHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	var req dto.AddOrderItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse("invalid request body"))
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if err := h.orderUseCase.AddOrderItem(r.Context(), id, req.ProductID, int32(req.Quantity), req.Price); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	h.writeOrder(w, r, id, http.StatusCreated)
+}
+
+// RemoveItem handles removing an item from an order
+func (h *OrderHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := h.orderUseCase.RemoveOrderItem(r.Context(), id, chi.URLParam(r, "itemID")); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	h.writeOrder(w, r, id, http.StatusOK)
+}
+
+// Cancel handles cancelling an order
+func (h *OrderHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := h.orderUseCase.CancelOrder(r.Context(), id); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	h.writeOrder(w, r, id, http.StatusOK)
+}
+
+// writeOrder loads the order and writes it as the response
+func (h *OrderHandler) writeOrder(w http.ResponseWriter, r *http.Request, id string, status int) {
+	order, err := h.orderUseCase.GetOrder(r.Context(), id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, status, dto.OrderToResponse(order))
+}
+
 // Helper function
 
 // writeJSON writes a JSON response to the given response writer
@@ -78,6 +280,31 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	}
 }
 
+// queryInt reads an integer query parameter, falling back to the default when it is absent
+func queryInt(r *http.Request, key string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	return strconv.Atoi(value)
+}
+
+// queryTime reads an RFC 3339 timestamp query parameter, returning the zero time when it is absent
+func queryTime(r *http.Request, key string) (time.Time, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid %s", domain.ErrInvalidFilter, key)
+	}
+
+	return t, nil
+}
+
 // errorResponse creates a standardized error response
 func errorResponse(message string) map[string]string {
 	return map[string]string{"error": message}
@@ -85,9 +312,37 @@ func errorResponse(message string) map[string]string {
 
 // handleError handles domain-specific errors and returns appropriate HTTP responses
 func handleError(w http.ResponseWriter, err error) {
-	switch err {
-	case domain.ErrOrderNotFound:
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound):
 		writeJSON(w, http.StatusNotFound, errorResponse("order not found"))
+	case errors.Is(err, domain.ErrOrderItemNotFound):
+		writeJSON(w, http.StatusNotFound, errorResponse("order item not found"))
+	case errors.Is(err, domain.ErrOutboxMessageNotFound):
+		writeJSON(w, http.StatusNotFound, errorResponse("outbox message not found"))
+	case errors.Is(err, domain.ErrInvalidStatusTransition),
+		errors.Is(err, domain.ErrOrderNotModifiable),
+		errors.Is(err, domain.ErrOutboxMessageNotFailed):
+		writeJSON(w, http.StatusConflict, errorResponse(err.Error()))
+	case errors.Is(err, domain.ErrInvalidOrderID),
+		errors.Is(err, domain.ErrInvalidOrderItemID),
+		errors.Is(err, domain.ErrInvalidOrderStatus),
+		errors.Is(err, domain.ErrInvalidCustomerID),
+		errors.Is(err, domain.ErrInvalidProductID),
+		errors.Is(err, domain.ErrInvalidQuantity),
+		errors.Is(err, domain.ErrInvalidPrice),
+		errors.Is(err, domain.ErrInvalidAmount),
+		errors.Is(err, domain.ErrInvalidCurrency),
+		errors.Is(err, domain.ErrCurrencyMismatch),
+		errors.Is(err, domain.ErrMoneyOverflow),
+		errors.Is(err, domain.ErrExchangeRateNotFound),
+		errors.Is(err, domain.ErrInvalidExchangeRate),
+		errors.Is(err, domain.ErrInvalidCursor),
+		errors.Is(err, domain.ErrInvalidOrderSort),
+		errors.Is(err, domain.ErrInvalidFilter),
+		errors.Is(err, domain.ErrInvalidOutboxFilter),
+		errors.Is(err, domain.ErrInvalidOutboxPayload),
+		errors.Is(err, domain.ErrEmptyOrderItems):
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
 	default:
 		writeJSON(w, http.StatusInternalServerError, errorResponse("internal server error"))
 	}
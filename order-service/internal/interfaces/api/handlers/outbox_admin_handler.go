@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"order-service/internal/app/ports"
+	"order-service/internal/domain"
+	"order-service/internal/interfaces/api/dto"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// OutboxAdminHandler handles operator requests for failed outbox messages
+type OutboxAdminHandler struct {
+	adminUseCase ports.OutboxAdminUseCase
+}
+
+// NewOutboxAdminHandler creates a new outbox admin handler
+func NewOutboxAdminHandler(adminUseCase ports.OutboxAdminUseCase) *OutboxAdminHandler {
+	return &OutboxAdminHandler{
+		adminUseCase: adminUseCase,
+	}
+}
+
+// List handles listing failed messages.
+// Supported query parameters are limit, id, event_type, failed_after and failed_before.
+func (h *OutboxAdminHandler) List(w http.ResponseWriter, r *http.Request) {
+	filter, err := outboxFilter(r)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	limit, err := queryInt(r, "limit", domain.DefaultOutboxPageSize)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse("invalid limit"))
+		return
+	}
+
+	messages, err := h.adminUseCase.ListFailedMessages(r.Context(), filter, limit)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dto.OutboxMessagesToListResponse(messages))
+}
+
+// Get handles inspecting a single message
+func (h *OutboxAdminHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := messageID(w, r)
+	if !ok {
+		return
+	}
+
+	msg, err := h.adminUseCase.GetMessage(r.Context(), id)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dto.OutboxMessageToResponse(*msg))
+}
+
+// UpdatePayload handles replacing the payload of a failed message
+func (h *OutboxAdminHandler) UpdatePayload(w http.ResponseWriter, r *http.Request) {
+	id, ok := messageID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateOutboxPayloadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Payload) == 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse("invalid request body"))
+		return
+	}
+
+	if err := h.adminUseCase.UpdatePayload(r.Context(), id, req.Payload); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	h.Get(w, r)
+}
+
+// Requeue handles requeueing a single failed message
+func (h *OutboxAdminHandler) Requeue(w http.ResponseWriter, r *http.Request) {
+	h.applyToMessage(w, r, h.adminUseCase.Requeue)
+}
+
+// Discard handles discarding a single failed message
+func (h *OutboxAdminHandler) Discard(w http.ResponseWriter, r *http.Request) {
+	h.applyToMessage(w, r, h.adminUseCase.Discard)
+}
+
+// RequeueMatching handles requeueing the failed messages selected by the request body
+func (h *OutboxAdminHandler) RequeueMatching(w http.ResponseWriter, r *http.Request) {
+	h.applyToMatching(w, r, h.adminUseCase.Requeue)
+}
+
+// DiscardMatching handles discarding the failed messages selected by the request body
+func (h *OutboxAdminHandler) DiscardMatching(w http.ResponseWriter, r *http.Request) {
+	h.applyToMatching(w, r, h.adminUseCase.Discard)
+}
+
+type bulkAction func(ctx context.Context, filter domain.OutboxMessageFilter) (int64, error)
+
+// applyToMessage runs a bulk action for the message in the URL and writes the updated message
+func (h *OutboxAdminHandler) applyToMessage(w http.ResponseWriter, r *http.Request, action bulkAction) {
+	id, ok := messageID(w, r)
+	if !ok {
+		return
+	}
+
+	affected, err := action(r.Context(), domain.OutboxMessageFilter{IDs: []uuid.UUID{id}})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	if affected == 0 {
+		// Either the message does not exist or it is not failed
+		if _, err := h.adminUseCase.GetMessage(r.Context(), id); err != nil {
+			handleError(w, err)
+			return
+		}
+		handleError(w, domain.ErrOutboxMessageNotFailed)
+		return
+	}
+
+	h.Get(w, r)
+}
+
+// applyToMatching runs a bulk action for the filter in the request body
+func (h *OutboxAdminHandler) applyToMatching(w http.ResponseWriter, r *http.Request, action bulkAction) {
+	var req dto.OutboxFilterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse("invalid request body"))
+		return
+	}
+
+	affected, err := action(r.Context(), req.ToFilter())
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dto.OutboxBulkResponse{Affected: affected})
+}
+
+// messageID reads the message ID from the URL, writing a bad request response when it is invalid
+func messageID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse("invalid message ID"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// outboxFilter builds a filter of failed messages from the request's query parameters
+func outboxFilter(r *http.Request) (domain.OutboxMessageFilter, error) {
+	params := r.URL.Query()
+	filter := domain.OutboxMessageFilter{EventType: params.Get("event_type")}
+
+	for _, value := range params["id"] {
+		for _, raw := range strings.Split(value, ",") {
+			id, err := uuid.Parse(strings.TrimSpace(raw))
+			if err != nil {
+				return filter, fmt.Errorf("%w: invalid id %q", domain.ErrInvalidOutboxFilter, raw)
+			}
+			filter.IDs = append(filter.IDs, id)
+		}
+	}
+
+	for key, target := range map[string]*time.Time{
+		"failed_after":  &filter.FailedAfter,
+		"failed_before": &filter.FailedBefore,
+	} {
+		value := params.Get(key)
+		if value == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("%w: invalid %s", domain.ErrInvalidOutboxFilter, key)
+		}
+		*target = t
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"order-service/internal/app/tracing"
+	"strings"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+// HeaderCorrelationID carries the correlation ID of a request across services
+const HeaderCorrelationID = "X-Correlation-ID"
+
+// Correlation puts the tracing IDs of the request into its context, so the events it causes can
+// be tied back to it. The correlation ID is taken from the X-Correlation-ID header or generated,
+// and echoed in the response. The request ID is the causation ID and the trace ID comes from a
+// W3C traceparent header. It has to run after chi's RequestID middleware.
+func Correlation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		correlationID := r.Header.Get(HeaderCorrelationID)
+		if correlationID == "" {
+			correlationID = uuid.NewString()
+		}
+		w.Header().Set(HeaderCorrelationID, correlationID)
+		ctx = tracing.WithCorrelationID(ctx, correlationID)
+
+		if requestID := chimiddleware.GetReqID(ctx); requestID != "" {
+			ctx = tracing.WithCausationID(ctx, requestID)
+		}
+		if traceID := traceIDFromParent(r.Header.Get("traceparent")); traceID != "" {
+			ctx = tracing.WithTraceID(ctx, traceID)
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// traceIDFromParent returns the trace ID of a traceparent header of the form
+// version-traceid-parentid-flags, empty when the header is missing or malformed
+func traceIDFromParent(traceparent string) string {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) < 4 || len(parts[1]) != 32 || parts[1] == strings.Repeat("0", 32) {
+		return ""
+	}
+	return parts[1]
+}
@@ -16,6 +16,7 @@ func Setup(orderHandler *handlers.OrderHandler) *chi.Mux {
 
 	// Apply global middleware
 	r.Use(middleware.RequestID)
+	r.Use(customMiddleware.Correlation)
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
@@ -27,6 +28,48 @@ func Setup(orderHandler *handlers.OrderHandler) *chi.Mux {
 	r.Route("/api/v1", func(r chi.Router) {
 		r.Route("/orders", func(r chi.Router) {
 			r.Post("/", orderHandler.Create) // Create a new order
+			r.Get("/", orderHandler.List)    // List orders
+
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", orderHandler.Get)                         // Get an order
+				r.Patch("/status", orderHandler.UpdateStatus)        // Change the order status
+				r.Post("/items", orderHandler.AddItem)               // Add an item to the order
+				r.Delete("/items/{itemID}", orderHandler.RemoveItem) // Remove an item from the order
+				r.Post("/cancel", orderHandler.Cancel)               // Cancel the order
+			})
+		})
+	})
+
+	return r
+}
+
+// SetupAdmin configures and returns the router of the operator endpoints.
+// They repair and drop outbox messages, so they are served on an internal listener
+// of their own instead of the public API, and are not open to cross-origin requests.
+func SetupAdmin(outboxAdminHandler *handlers.OutboxAdminHandler) *chi.Mux {
+	r := chi.NewRouter()
+
+	r.Use(middleware.RequestID)
+	r.Use(customMiddleware.Correlation)
+	r.Use(middleware.RealIP)
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(customMiddleware.ContentTypeJSON)
+
+	r.Route("/api/v1", func(r chi.Router) {
+		// Operator tooling for outbox messages that ran out of attempts
+		r.Route("/admin/outbox/messages", func(r chi.Router) {
+			r.Get("/", outboxAdminHandler.List)                    // List failed messages
+			r.Post("/requeue", outboxAdminHandler.RequeueMatching) // Requeue failed messages by filter
+			r.Post("/discard", outboxAdminHandler.DiscardMatching) // Discard failed messages by filter
+
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", outboxAdminHandler.Get)                  // Inspect a message
+				r.Put("/payload", outboxAdminHandler.UpdatePayload) // Edit the payload of a failed message
+				r.Post("/requeue", outboxAdminHandler.Requeue)      // Requeue a failed message
+				r.Post("/discard", outboxAdminHandler.Discard)      // Discard a failed message
+			})
 		})
 	})
 
@@ -0,0 +1,52 @@
+package consumer
+
+import (
+	"context"
+	"kafkaconsumer"
+	"order-service/internal/app/ports"
+	"order-service/internal/events"
+	"order-service/internal/infrastructure/messaging/kafka"
+)
+
+// OrderSagaConsumerName records the saga events in the inbox, each is applied to its order once
+const OrderSagaConsumerName = "order-saga"
+
+// OrderSagaHandler handles the outcomes the inventory and the payment service publish for orders
+type OrderSagaHandler struct {
+	uow  ports.UnitOfWork
+	saga ports.OrderSagaUseCase
+}
+
+// NewOrderSagaHandler creates a new order saga handler
+func NewOrderSagaHandler(uow ports.UnitOfWork, saga ports.OrderSagaUseCase) *OrderSagaHandler {
+	return &OrderSagaHandler{
+		uow:  uow,
+		saga: saga,
+	}
+}
+
+// Register registers the handlers of the saga events with the consumer
+func (h *OrderSagaHandler) Register(consumer *kafkaconsumer.Consumer) {
+	consumer.Register(events.ProductsReservedEventType, handle(h, func(ctx context.Context, factory ports.RepositoryFactory, e events.ProductsReservedEvent) error {
+		return h.saga.ProductsReserved(ctx, factory, e.OrderID)
+	}))
+	consumer.Register(events.ProductsReservationFailedEventType, handle(h, func(ctx context.Context, factory ports.RepositoryFactory, e events.ProductsReservationFailedEvent) error {
+		return h.saga.ProductsReservationFailed(ctx, factory, e.OrderID, e.Reason)
+	}))
+	consumer.Register(events.PaymentApprovedEventType, handle(h, func(ctx context.Context, factory ports.RepositoryFactory, e events.PaymentApprovedEvent) error {
+		return h.saga.PaymentApproved(ctx, factory, e.OrderID)
+	}))
+	consumer.Register(events.PaymentDeclinedEventType, handle(h, func(ctx context.Context, factory ports.RepositoryFactory, e events.PaymentDeclinedEvent) error {
+		return h.saga.PaymentDeclined(ctx, factory, e.OrderID, e.Reason)
+	}))
+}
+
+// handle decodes the payload of an event and applies the saga step in the transaction
+// recording the event in the inbox
+func handle[T any](h *OrderSagaHandler, step func(ctx context.Context, factory ports.RepositoryFactory, payload T) error) kafkaconsumer.Handler {
+	return kafkaconsumer.Typed(func(ctx context.Context, event kafkaconsumer.Event, payload T) error {
+		return kafka.Idempotent(h.uow, OrderSagaConsumerName, func(ctx context.Context, event kafkaconsumer.Event, factory ports.RepositoryFactory) error {
+			return step(ctx, factory, payload)
+		}).Handle(ctx, event)
+	})
+}
@@ -0,0 +1,227 @@
+package schemaregistry
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hamba/avro/v2"
+)
+
+// avroFormat encodes records in the Avro binary encoding
+type avroFormat struct{}
+
+func (avroFormat) Type() SchemaType { return TypeAvro }
+
+func (avroFormat) ContentType() string { return "application/vnd.confluent.avro" }
+
+func (avroFormat) Compile(schema string) (Codec, error) {
+	parsed, err := parseAvro(schema)
+	if err != nil {
+		return nil, err
+	}
+	return avroCodec{schema: parsed}, nil
+}
+
+func (avroFormat) Compatible(reader, writer string) error {
+	readerSchema, err := parseAvro(reader)
+	if err != nil {
+		return err
+	}
+	writerSchema, err := parseAvro(writer)
+	if err != nil {
+		return err
+	}
+	if err := avro.NewSchemaCompatibility().Compatible(readerSchema, writerSchema); err != nil {
+		return fmt.Errorf("%w: %v", ErrIncompatibleSchema, err)
+	}
+	return nil
+}
+
+// parseAvro parses a schema with a cache of its own, so versions of a record
+// with the same name do not clash
+func parseAvro(schema string) (avro.Schema, error) {
+	parsed, err := avro.ParseWithCache(schema, "", &avro.SchemaCache{})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	return parsed, nil
+}
+
+// avroCodec converts values through their JSON form, whose numbers and
+// timestamps are turned into the types the schema expects
+type avroCodec struct {
+	schema avro.Schema
+}
+
+func (c avroCodec) Encode(v any) ([]byte, error) {
+	value, err := jsonValue(v)
+	if err != nil {
+		return nil, err
+	}
+	native, err := avroNative(c.schema, value)
+	if err != nil {
+		return nil, err
+	}
+	return avro.Marshal(c.schema, native)
+}
+
+func (c avroCodec) Decode(data []byte, v any) error {
+	var native any
+	if err := avro.Unmarshal(c.schema, data, &native); err != nil {
+		return err
+	}
+	return fromJSON(native, v)
+}
+
+// avroNative converts a JSON value into the Go type the Avro encoder expects for the schema
+func avroNative(schema avro.Schema, value any) (any, error) {
+	switch s := schema.(type) {
+	case *avro.RefSchema:
+		return avroNative(s.Schema(), value)
+
+	case *avro.RecordSchema:
+		fields, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected an object, got %T", s.FullName(), value)
+		}
+		record := make(map[string]any, len(s.Fields()))
+		for _, field := range s.Fields() {
+			fieldValue, ok := fields[field.Name()]
+			if !ok {
+				if !field.HasDefault() {
+					return nil, fmt.Errorf("%s: missing field %s", s.FullName(), field.Name())
+				}
+				record[field.Name()] = field.Default()
+				continue
+			}
+			native, err := avroNative(field.Type(), fieldValue)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.Name(), err)
+			}
+			record[field.Name()] = native
+		}
+		return record, nil
+
+	case *avro.ArraySchema:
+		if value == nil {
+			return []any{}, nil
+		}
+		items, ok := value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("expected an array, got %T", value)
+		}
+		array := make([]any, len(items))
+		for i, item := range items {
+			native, err := avroNative(s.Items(), item)
+			if err != nil {
+				return nil, err
+			}
+			array[i] = native
+		}
+		return array, nil
+
+	case *avro.MapSchema:
+		if value == nil {
+			return map[string]any{}, nil
+		}
+		entries, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected an object, got %T", value)
+		}
+		result := make(map[string]any, len(entries))
+		for key, entry := range entries {
+			native, err := avroNative(s.Values(), entry)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = native
+		}
+		return result, nil
+
+	case *avro.UnionSchema:
+		if value == nil && s.Nullable() {
+			return nil, nil
+		}
+		// The first branch the value converts to is written
+		for _, branch := range s.Types() {
+			if branch.Type() == avro.Null {
+				continue
+			}
+			if native, err := avroNative(branch, value); err == nil {
+				return native, nil
+			}
+		}
+		return nil, fmt.Errorf("%v matches no branch of the union", value)
+
+	case *avro.PrimitiveSchema:
+		return avroPrimitive(s, value)
+
+	case *avro.EnumSchema:
+		symbol, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected a symbol, got %T", s.FullName(), value)
+		}
+		return symbol, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported avro type %s", schema.Type())
+	}
+}
+
+// avroPrimitive converts a JSON scalar. Timestamps are read from their RFC 3339 form.
+func avroPrimitive(s *avro.PrimitiveSchema, value any) (any, error) {
+	if logical := s.Logical(); logical != nil && s.Type() == avro.Long {
+		switch logical.Type() {
+		case avro.TimestampMillis, avro.TimestampMicros:
+			text, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected a timestamp, got %T", value)
+			}
+			return time.Parse(time.RFC3339Nano, text)
+		}
+	}
+
+	switch s.Type() {
+	case avro.Null:
+		if value != nil {
+			return nil, fmt.Errorf("expected null, got %T", value)
+		}
+		return nil, nil
+	case avro.Boolean:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case avro.String:
+		if text, ok := value.(string); ok {
+			return text, nil
+		}
+	case avro.Bytes:
+		if text, ok := value.(string); ok {
+			return []byte(text), nil
+		}
+	case avro.Int, avro.Long:
+		if number, ok := value.(json.Number); ok {
+			n, err := number.Int64()
+			if err != nil {
+				return nil, err
+			}
+			if s.Type() == avro.Int {
+				return int(n), nil
+			}
+			return n, nil
+		}
+	case avro.Float, avro.Double:
+		if number, ok := value.(json.Number); ok {
+			f, err := number.Float64()
+			if err != nil {
+				return nil, err
+			}
+			if s.Type() == avro.Float {
+				return float32(f), nil
+			}
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("expected %s, got %T", s.Type(), value)
+}
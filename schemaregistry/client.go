@@ -0,0 +1,245 @@
+package schemaregistry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Registry stores the versions of the schemas registered under each subject
+type Registry interface {
+	// Register registers the schema under the subject and returns its ID.
+	// Registering a schema again returns the ID it already has.
+	Register(ctx context.Context, subject string, schema Schema) (int, error)
+	// SchemaByID returns the schema with the ID
+	SchemaByID(ctx context.Context, id int) (Schema, error)
+	// LatestSchema returns the latest version registered under the subject
+	LatestSchema(ctx context.Context, subject string) (SchemaMetadata, error)
+	// Compatible reports whether the schema follows the compatibility rule of the subject.
+	// Any schema is compatible with a subject that has no versions yet.
+	Compatible(ctx context.Context, subject string, schema Schema) (bool, error)
+}
+
+// contentType is the media type of the schema registry REST API
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+// Registry API error codes
+const (
+	errorSubjectNotFound = 40401
+	errorVersionNotFound = 40402
+	errorSchemaNotFound  = 40403
+	errorInvalidSchema   = 42201
+)
+
+// ClientConfig configures the connection to a Confluent compatible schema registry
+type ClientConfig struct {
+	URL      string
+	Username string
+	Password string
+	Timeout  time.Duration
+}
+
+// Client is a Registry backed by the REST API of a Confluent compatible schema registry.
+// Schemas are immutable, so the schemas it fetched and the IDs it registered are cached.
+type Client struct {
+	baseURL  string
+	username string
+	password string
+	http     *http.Client
+
+	mu         sync.RWMutex
+	schemas    map[int]Schema
+	registered map[string]int
+}
+
+// NewClient creates a client of the registry at the configured URL
+func NewClient(cfg ClientConfig) *Client {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(cfg.URL, "/"),
+		username:   cfg.Username,
+		password:   cfg.Password,
+		http:       &http.Client{Timeout: timeout},
+		schemas:    make(map[int]Schema),
+		registered: make(map[string]int),
+	}
+}
+
+// schemaRequest is the body of register and compatibility requests
+type schemaRequest struct {
+	Schema     string     `json:"schema"`
+	SchemaType SchemaType `json:"schemaType,omitempty"`
+}
+
+func newSchemaRequest(schema Schema) schemaRequest {
+	request := schemaRequest{Schema: schema.Schema, SchemaType: schema.Type}
+	// The registry defaults to Avro and older versions reject the type
+	if request.SchemaType == TypeAvro {
+		request.SchemaType = ""
+	}
+	return request
+}
+
+// schemaResponse is returned by the registry for schemas and subject versions
+type schemaResponse struct {
+	ID         int        `json:"id"`
+	Subject    string     `json:"subject"`
+	Version    int        `json:"version"`
+	Schema     string     `json:"schema"`
+	SchemaType SchemaType `json:"schemaType"`
+}
+
+func (r schemaResponse) schema() Schema {
+	schemaType := r.SchemaType
+	if schemaType == "" {
+		schemaType = TypeAvro
+	}
+	return Schema{Type: schemaType, Schema: r.Schema}
+}
+
+// errorResponse is returned by the registry for failed requests
+type errorResponse struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+// Register implements Registry.
+func (c *Client) Register(ctx context.Context, subject string, schema Schema) (int, error) {
+	key := subject + "\x00" + string(schema.Type) + "\x00" + schema.Schema
+	c.mu.RLock()
+	id, ok := c.registered[key]
+	c.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	var response schemaResponse
+	path := "/subjects/" + url.PathEscape(subject) + "/versions"
+	if err := c.do(ctx, http.MethodPost, path, newSchemaRequest(schema), &response); err != nil {
+		return 0, fmt.Errorf("failed to register schema under %s: %w", subject, err)
+	}
+
+	c.mu.Lock()
+	c.registered[key] = response.ID
+	c.schemas[response.ID] = schema
+	c.mu.Unlock()
+	return response.ID, nil
+}
+
+// SchemaByID implements Registry.
+func (c *Client) SchemaByID(ctx context.Context, id int) (Schema, error) {
+	c.mu.RLock()
+	schema, ok := c.schemas[id]
+	c.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	var response schemaResponse
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &response); err != nil {
+		return Schema{}, fmt.Errorf("failed to get schema %d: %w", id, err)
+	}
+
+	schema = response.schema()
+	c.mu.Lock()
+	c.schemas[id] = schema
+	c.mu.Unlock()
+	return schema, nil
+}
+
+// LatestSchema implements Registry.
+func (c *Client) LatestSchema(ctx context.Context, subject string) (SchemaMetadata, error) {
+	var response schemaResponse
+	path := "/subjects/" + url.PathEscape(subject) + "/versions/latest"
+	if err := c.do(ctx, http.MethodGet, path, nil, &response); err != nil {
+		return SchemaMetadata{}, fmt.Errorf("failed to get latest schema of %s: %w", subject, err)
+	}
+	return SchemaMetadata{
+		Schema:  response.schema(),
+		ID:      response.ID,
+		Subject: response.Subject,
+		Version: response.Version,
+	}, nil
+}
+
+// Compatible implements Registry.
+func (c *Client) Compatible(ctx context.Context, subject string, schema Schema) (bool, error) {
+	var response struct {
+		IsCompatible bool `json:"is_compatible"`
+	}
+	path := "/compatibility/subjects/" + url.PathEscape(subject) + "/versions/latest"
+	err := c.do(ctx, http.MethodPost, path, newSchemaRequest(schema), &response)
+	if err == nil {
+		return response.IsCompatible, nil
+	}
+	if errors.Is(err, ErrNotFound) {
+		return true, nil
+	}
+	return false, fmt.Errorf("failed to check compatibility with %s: %w", subject, err)
+}
+
+// do sends a request to the registry and decodes the response into out
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		encoded, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", contentType)
+	if in != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return registryError(resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// registryError maps an error response to the package errors
+func registryError(resp *http.Response) error {
+	var response errorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil || response.Message == "" {
+		response.Message = resp.Status
+	}
+
+	switch {
+	case response.ErrorCode == errorSubjectNotFound, response.ErrorCode == errorVersionNotFound,
+		response.ErrorCode == errorSchemaNotFound:
+		return fmt.Errorf("%w: %s", ErrNotFound, response.Message)
+	case resp.StatusCode == http.StatusConflict:
+		return fmt.Errorf("%w: %s", ErrIncompatibleSchema, response.Message)
+	case response.ErrorCode == errorInvalidSchema:
+		return fmt.Errorf("%w: %s", ErrInvalidSchema, response.Message)
+	default:
+		return fmt.Errorf("schema registry returned %d: %s", resp.StatusCode, response.Message)
+	}
+}
@@ -0,0 +1,158 @@
+package schemaregistry_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"schemaregistry"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRegistryServer answers the registry REST API from a MemoryRegistry and counts the requests
+type fakeRegistryServer struct {
+	registry *schemaregistry.MemoryRegistry
+	requests int
+}
+
+func (s *fakeRegistryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests++
+	w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	if user, password, _ := r.BasicAuth(); user != "order-service" || password != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var request struct {
+		Schema     string                    `json:"schema"`
+		SchemaType schemaregistry.SchemaType `json:"schemaType"`
+	}
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&request)
+	}
+	if request.SchemaType == "" {
+		request.SchemaType = schemaregistry.TypeAvro
+	}
+	schema := schemaregistry.Schema{Type: request.SchemaType, Schema: request.Schema}
+
+	ctx := r.Context()
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/subjects/orders-value/versions":
+		id, err := s.registry.Register(ctx, "orders-value", schema)
+		if err != nil {
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]any{"error_code": 409, "message": err.Error()})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": id})
+
+	case r.Method == http.MethodPost && r.URL.Path == "/compatibility/subjects/orders-value/versions/latest":
+		if _, err := s.registry.LatestSchema(ctx, "orders-value"); err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]any{"error_code": 40401, "message": "Subject not found"})
+			return
+		}
+		compatible, _ := s.registry.Compatible(ctx, "orders-value", schema)
+		_ = json.NewEncoder(w).Encode(map[string]any{"is_compatible": compatible})
+
+	case r.Method == http.MethodGet && r.URL.Path == "/subjects/orders-value/versions/latest":
+		latest, err := s.registry.LatestSchema(ctx, "orders-value")
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]any{"error_code": 40401, "message": "Subject not found"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"subject": latest.Subject, "id": latest.ID, "version": latest.Version,
+			"schema": latest.Schema.Schema, "schemaType": latest.Type,
+		})
+
+	case r.Method == http.MethodGet && r.URL.Path == "/schemas/ids/1":
+		found, _ := s.registry.SchemaByID(ctx, 1)
+		// The registry leaves the type of Avro schemas out
+		_ = json.NewEncoder(w).Encode(map[string]any{"schema": found.Schema})
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error_code": 40403, "message": "Schema not found"})
+	}
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeRegistryServer{registry: schemaregistry.NewMemoryRegistry(schemaregistry.CompatibilityBackward)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := schemaregistry.NewClient(schemaregistry.ClientConfig{
+		URL:      server.URL + "/",
+		Username: "order-service",
+		Password: "secret",
+	})
+	v1 := schemaregistry.Schema{
+		Type:   schemaregistry.TypeAvro,
+		Schema: `{"type": "record", "name": "Order", "fields": [{"name": "id", "type": "string"}]}`,
+	}
+
+	compatible, err := client.Compatible(ctx, "orders-value", v1)
+	require.NoError(t, err)
+	assert.True(t, compatible, "a new subject accepts any schema")
+
+	id, err := client.Register(ctx, "orders-value", v1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, id)
+
+	latest, err := client.LatestSchema(ctx, "orders-value")
+	require.NoError(t, err)
+	assert.Equal(t, schemaregistry.SchemaMetadata{Schema: v1, ID: 1, Subject: "orders-value", Version: 1}, latest)
+
+	// Registered schemas are cached
+	requests := fake.requests
+	again, err := client.Register(ctx, "orders-value", v1)
+	require.NoError(t, err)
+	assert.Equal(t, id, again)
+	schema, err := client.SchemaByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, v1, schema)
+	assert.Equal(t, requests, fake.requests)
+
+	incompatible := schemaregistry.Schema{
+		Type: schemaregistry.TypeAvro,
+		Schema: `{"type": "record", "name": "Order", "fields": [
+			{"name": "id", "type": "string"}, {"name": "note", "type": "string"}]}`,
+	}
+	compatible, err = client.Compatible(ctx, "orders-value", incompatible)
+	require.NoError(t, err)
+	assert.False(t, compatible)
+	_, err = client.Register(ctx, "orders-value", incompatible)
+	assert.ErrorIs(t, err, schemaregistry.ErrIncompatibleSchema)
+
+	_, err = client.SchemaByID(ctx, 7)
+	assert.ErrorIs(t, err, schemaregistry.ErrNotFound)
+}
+
+func TestClientFetchesSchemasOfOtherProducers(t *testing.T) {
+	ctx := context.Background()
+	registry := schemaregistry.NewMemoryRegistry(schemaregistry.CompatibilityBackward)
+	v1 := schemaregistry.Schema{
+		Type:   schemaregistry.TypeAvro,
+		Schema: `{"type": "record", "name": "Order", "fields": [{"name": "id", "type": "string"}]}`,
+	}
+	_, err := registry.Register(ctx, "orders-value", v1)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(&fakeRegistryServer{registry: registry})
+	defer server.Close()
+	client := schemaregistry.NewClient(schemaregistry.ClientConfig{URL: server.URL, Username: "order-service", Password: "secret"})
+
+	schema, err := client.SchemaByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, v1, schema)
+
+	unauthorized := schemaregistry.NewClient(schemaregistry.ClientConfig{URL: server.URL})
+	_, err = unauthorized.SchemaByID(ctx, 1)
+	assert.ErrorContains(t, err, "401")
+}
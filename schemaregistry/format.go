@@ -0,0 +1,89 @@
+package schemaregistry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Format encodes records with the schemas of one schema type
+type Format interface {
+	Type() SchemaType
+	// ContentType is the content type of payloads in the wire format
+	ContentType() string
+	// Compile parses a schema into the codec of its records
+	Compile(schema string) (Codec, error)
+	// Compatible checks that records written with the writer schema can be read with the reader schema
+	Compatible(reader, writer string) error
+}
+
+// Codec encodes and decodes the records of one schema. Values that are not
+// native to the format are converted through their JSON form.
+type Codec interface {
+	Encode(v any) ([]byte, error)
+	Decode(data []byte, v any) error
+}
+
+// Formats supported by the serializers
+var (
+	Avro       Format = avroFormat{}
+	Protobuf   Format = protobufFormat{}
+	JSONSchema Format = jsonSchemaFormat{}
+)
+
+// ParseFormat parses "avro", "protobuf" or "json"
+func ParseFormat(name string) (Format, error) {
+	switch name {
+	case "avro":
+		return Avro, nil
+	case "protobuf":
+		return Protobuf, nil
+	case "json":
+		return JSONSchema, nil
+	default:
+		return nil, fmt.Errorf("unknown schema format %q", name)
+	}
+}
+
+// formatOf returns the format of a schema type, the registry leaves the type of Avro schemas empty
+func formatOf(schemaType SchemaType) (Format, error) {
+	switch schemaType {
+	case "", TypeAvro:
+		return Avro, nil
+	case TypeProtobuf:
+		return Protobuf, nil
+	case TypeJSON:
+		return JSONSchema, nil
+	default:
+		return nil, fmt.Errorf("unknown schema type %q", schemaType)
+	}
+}
+
+// jsonValue returns the JSON form of a value as generic maps, slices and json.Numbers
+func jsonValue(v any) (any, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON(encoded)
+}
+
+// decodeJSON decodes a JSON document keeping numbers exact
+func decodeJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// fromJSON sets v from the JSON form of a decoded record
+func fromJSON(value any, v any) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, v)
+}
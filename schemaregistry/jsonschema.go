@@ -0,0 +1,196 @@
+package schemaregistry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// jsonSchemaFormat encodes records as JSON validated against a JSON Schema
+type jsonSchemaFormat struct{}
+
+func (jsonSchemaFormat) Type() SchemaType { return TypeJSON }
+
+// ContentType has no +json suffix, since the wire format header makes the payload no JSON document
+func (jsonSchemaFormat) ContentType() string { return "application/vnd.confluent.jsonschema" }
+
+func (jsonSchemaFormat) Compile(schema string) (Codec, error) {
+	document, err := jsonschema.UnmarshalJSON(strings.NewReader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+
+	const location = "urn:schemaregistry:schema"
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(location, document); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	compiled, err := compiler.Compile(location)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	return jsonSchemaCodec{schema: compiled}, nil
+}
+
+func (jsonSchemaFormat) Compatible(reader, writer string) error {
+	var readerSchema, writerSchema map[string]any
+	if err := json.Unmarshal([]byte(reader), &readerSchema); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	if err := json.Unmarshal([]byte(writer), &writerSchema); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+
+	check := jsonSchemaCheck{readerRoot: readerSchema, writerRoot: writerSchema}
+	check.compare("#", readerSchema, writerSchema)
+	if len(check.problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrIncompatibleSchema, strings.Join(check.problems, "; "))
+	}
+	return nil
+}
+
+// jsonSchemaCodec validates records on encoding, decoding trusts the writer to have done so
+type jsonSchemaCodec struct {
+	schema *jsonschema.Schema
+}
+
+func (c jsonSchemaCodec) Encode(v any) ([]byte, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	document, err := jsonschema.UnmarshalJSON(bytes.NewReader(encoded))
+	if err != nil {
+		return nil, err
+	}
+	if err := c.schema.Validate(document); err != nil {
+		return nil, fmt.Errorf("record does not match its schema: %w", err)
+	}
+	return encoded, nil
+}
+
+func (c jsonSchemaCodec) Decode(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// jsonSchemaCheck collects the ways documents valid against the writer schema may be invalid
+// against the reader schema. It covers types, enums, required and additional properties and
+// array items, following local references.
+type jsonSchemaCheck struct {
+	readerRoot, writerRoot map[string]any
+	problems               []string
+}
+
+func (c *jsonSchemaCheck) compare(path string, reader, writer map[string]any) {
+	reader = resolveJSONRef(c.readerRoot, reader)
+	writer = resolveJSONRef(c.writerRoot, writer)
+
+	if readerTypes := jsonTypes(reader); len(readerTypes) > 0 {
+		writerTypes := jsonTypes(writer)
+		if len(writerTypes) == 0 {
+			c.problems = append(c.problems, fmt.Sprintf("%s: type is restricted to %s", path, strings.Join(readerTypes, ", ")))
+		}
+		for _, writerType := range writerTypes {
+			accepted := slices.Contains(readerTypes, writerType) ||
+				writerType == "integer" && slices.Contains(readerTypes, "number")
+			if !accepted {
+				c.problems = append(c.problems, fmt.Sprintf("%s: type %s is no longer accepted", path, writerType))
+			}
+		}
+	}
+
+	if readerEnum, ok := reader["enum"].([]any); ok {
+		writerEnum, ok := writer["enum"].([]any)
+		if !ok {
+			c.problems = append(c.problems, fmt.Sprintf("%s: values are restricted to an enum", path))
+		}
+		for _, value := range writerEnum {
+			if !slices.ContainsFunc(readerEnum, func(v any) bool { return reflect.DeepEqual(v, value) }) {
+				c.problems = append(c.problems, fmt.Sprintf("%s: value %v is no longer accepted", path, value))
+			}
+		}
+	}
+
+	readerProperties, _ := reader["properties"].(map[string]any)
+	writerProperties, _ := writer["properties"].(map[string]any)
+	writerRequired := jsonRequired(writer)
+	for _, name := range slices.Sorted(maps.Keys(jsonRequired(reader))) {
+		if !writerRequired[name] {
+			c.problems = append(c.problems, fmt.Sprintf("%s: property %s is required but may be missing", path, name))
+		}
+	}
+	if additional, ok := reader["additionalProperties"].(bool); ok && !additional {
+		for _, name := range slices.Sorted(maps.Keys(writerProperties)) {
+			if _, ok := readerProperties[name]; !ok {
+				c.problems = append(c.problems, fmt.Sprintf("%s: property %s is not allowed", path, name))
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(readerProperties)) {
+		readerProperty, _ := readerProperties[name].(map[string]any)
+		writerProperty, ok := writerProperties[name].(map[string]any)
+		if ok && readerProperty != nil {
+			c.compare(path+"/"+name, readerProperty, writerProperty)
+		}
+	}
+
+	readerItems, _ := reader["items"].(map[string]any)
+	writerItems, _ := writer["items"].(map[string]any)
+	if readerItems != nil && writerItems != nil {
+		c.compare(path+"/items", readerItems, writerItems)
+	}
+}
+
+// resolveJSONRef follows local references like "#/$defs/item"
+func resolveJSONRef(root, node map[string]any) map[string]any {
+	for depth := 0; depth < 32; depth++ {
+		ref, ok := node["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return node
+		}
+		target := root
+		for _, name := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			target, _ = target[name].(map[string]any)
+		}
+		if target == nil {
+			return node
+		}
+		node = target
+	}
+	return node
+}
+
+// jsonTypes returns the types a schema allows, none when it allows any
+func jsonTypes(node map[string]any) []string {
+	switch t := node["type"].(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var types []string
+		for _, name := range t {
+			if s, ok := name.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	default:
+		return nil
+	}
+}
+
+func jsonRequired(node map[string]any) map[string]bool {
+	required := make(map[string]bool)
+	names, _ := node["required"].([]any)
+	for _, name := range names {
+		if s, ok := name.(string); ok {
+			required[s] = true
+		}
+	}
+	return required
+}
@@ -0,0 +1,161 @@
+package schemaregistry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// MemoryRegistry is a Registry kept in memory, standing in for a schema registry in tests.
+// It checks compatibility like the registry does.
+type MemoryRegistry struct {
+	mu            sync.Mutex
+	compatibility Compatibility
+	// schemas holds the schema with ID i at index i-1
+	schemas  []Schema
+	subjects map[string][]int
+}
+
+// NewMemoryRegistry creates an empty registry enforcing the compatibility rule on every subject,
+// backward when empty
+func NewMemoryRegistry(compatibility Compatibility) *MemoryRegistry {
+	if compatibility == "" {
+		compatibility = CompatibilityBackward
+	}
+	return &MemoryRegistry{
+		compatibility: compatibility,
+		subjects:      make(map[string][]int),
+	}
+}
+
+// Register implements Registry.
+func (r *MemoryRegistry) Register(ctx context.Context, subject string, schema Schema) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions := r.subjects[subject]
+	for _, id := range versions {
+		if r.schemas[id-1] == schema {
+			return id, nil
+		}
+	}
+
+	format, err := formatOf(schema.Type)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := format.Compile(schema.Schema); err != nil {
+		return 0, err
+	}
+	if err := r.check(format, versions, schema); err != nil {
+		return 0, fmt.Errorf("failed to register schema under %s: %w", subject, err)
+	}
+
+	id := r.schemaID(schema)
+	r.subjects[subject] = append(versions, id)
+	return id, nil
+}
+
+// schemaID returns the ID of a schema registered under another subject, or assigns the next one
+func (r *MemoryRegistry) schemaID(schema Schema) int {
+	for i, registered := range r.schemas {
+		if registered == schema {
+			return i + 1
+		}
+	}
+	r.schemas = append(r.schemas, schema)
+	return len(r.schemas)
+}
+
+// SchemaByID implements Registry.
+func (r *MemoryRegistry) SchemaByID(ctx context.Context, id int) (Schema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id < 1 || id > len(r.schemas) {
+		return Schema{}, fmt.Errorf("%w: id %d", ErrNotFound, id)
+	}
+	return r.schemas[id-1], nil
+}
+
+// LatestSchema implements Registry.
+func (r *MemoryRegistry) LatestSchema(ctx context.Context, subject string) (SchemaMetadata, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions := r.subjects[subject]
+	if len(versions) == 0 {
+		return SchemaMetadata{}, fmt.Errorf("%w: subject %s", ErrNotFound, subject)
+	}
+	id := versions[len(versions)-1]
+	return SchemaMetadata{Schema: r.schemas[id-1], ID: id, Subject: subject, Version: len(versions)}, nil
+}
+
+// Compatible implements Registry.
+func (r *MemoryRegistry) Compatible(ctx context.Context, subject string, schema Schema) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	format, err := formatOf(schema.Type)
+	if err != nil {
+		return false, err
+	}
+	err = r.check(format, r.subjects[subject], schema)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, ErrIncompatibleSchema):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// check checks a new schema against the registered versions the compatibility rule covers
+func (r *MemoryRegistry) check(format Format, versions []int, schema Schema) error {
+	if r.compatibility == CompatibilityNone || len(versions) == 0 {
+		return nil
+	}
+
+	var backward, forward, transitive bool
+	switch r.compatibility {
+	case CompatibilityBackward:
+		backward = true
+	case CompatibilityBackwardTransitive:
+		backward, transitive = true, true
+	case CompatibilityForward:
+		forward = true
+	case CompatibilityForwardTransitive:
+		forward, transitive = true, true
+	case CompatibilityFull:
+		backward, forward = true, true
+	case CompatibilityFullTransitive:
+		backward, forward, transitive = true, true, true
+	default:
+		return fmt.Errorf("unknown compatibility %q", r.compatibility)
+	}
+	if !transitive {
+		versions = versions[len(versions)-1:]
+	}
+
+	for _, id := range versions {
+		previous := r.schemas[id-1]
+		if previous.Type != schema.Type {
+			return fmt.Errorf("%w: schema type changed from %s to %s", ErrIncompatibleSchema, previous.Type, schema.Type)
+		}
+		// Backward: consumers on the new schema read records written with the old one
+		if backward {
+			if err := format.Compatible(schema.Schema, previous.Schema); err != nil {
+				return err
+			}
+		}
+		// Forward: consumers still on the old schema read records written with the new one
+		if forward {
+			if err := format.Compatible(previous.Schema, schema.Schema); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
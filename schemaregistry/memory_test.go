@@ -0,0 +1,163 @@
+package schemaregistry_test
+
+import (
+	"context"
+	"schemaregistry"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRegistryAssignsIDs(t *testing.T) {
+	ctx := context.Background()
+	registry := schemaregistry.NewMemoryRegistry(schemaregistry.CompatibilityNone)
+	v1 := schemaregistry.Schema{Type: schemaregistry.TypeJSON, Schema: `{"type": "object"}`}
+	v2 := schemaregistry.Schema{Type: schemaregistry.TypeJSON, Schema: `{"type": "string"}`}
+
+	id, err := registry.Register(ctx, "orders-value", v1)
+	require.NoError(t, err)
+	again, err := registry.Register(ctx, "orders-value", v1)
+	require.NoError(t, err)
+	assert.Equal(t, id, again)
+
+	// Subjects share the ID of the same schema
+	shared, err := registry.Register(ctx, "payments-value", v1)
+	require.NoError(t, err)
+	assert.Equal(t, id, shared)
+
+	next, err := registry.Register(ctx, "orders-value", v2)
+	require.NoError(t, err)
+	assert.NotEqual(t, id, next)
+
+	latest, err := registry.LatestSchema(ctx, "orders-value")
+	require.NoError(t, err)
+	assert.Equal(t, schemaregistry.SchemaMetadata{Schema: v2, ID: next, Subject: "orders-value", Version: 2}, latest)
+
+	schema, err := registry.SchemaByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, v1, schema)
+
+	_, err = registry.LatestSchema(ctx, "unknown-value")
+	assert.ErrorIs(t, err, schemaregistry.ErrNotFound)
+	_, err = registry.Register(ctx, "orders-value", schemaregistry.Schema{Type: schemaregistry.TypeAvro, Schema: `{`})
+	assert.ErrorIs(t, err, schemaregistry.ErrInvalidSchema)
+}
+
+func TestMemoryRegistryChecksCompatibility(t *testing.T) {
+	tests := []struct {
+		name          string
+		schemaType    schemaregistry.SchemaType
+		compatibility schemaregistry.Compatibility
+		v1, v2        string
+		compatible    bool
+	}{
+		{
+			name:          "avro field with default",
+			schemaType:    schemaregistry.TypeAvro,
+			compatibility: schemaregistry.CompatibilityFull,
+			v1:            `{"type": "record", "name": "Order", "fields": [{"name": "id", "type": "string"}]}`,
+			v2: `{"type": "record", "name": "Order", "fields": [
+				{"name": "id", "type": "string"}, {"name": "note", "type": "string", "default": ""}]}`,
+			compatible: true,
+		},
+		{
+			name:          "avro field without default",
+			schemaType:    schemaregistry.TypeAvro,
+			compatibility: schemaregistry.CompatibilityBackward,
+			v1:            `{"type": "record", "name": "Order", "fields": [{"name": "id", "type": "string"}]}`,
+			v2: `{"type": "record", "name": "Order", "fields": [
+				{"name": "id", "type": "string"}, {"name": "note", "type": "string"}]}`,
+		},
+		{
+			name:          "avro field without default read by old consumers",
+			schemaType:    schemaregistry.TypeAvro,
+			compatibility: schemaregistry.CompatibilityForward,
+			v1:            `{"type": "record", "name": "Order", "fields": [{"name": "id", "type": "string"}]}`,
+			v2: `{"type": "record", "name": "Order", "fields": [
+				{"name": "id", "type": "string"}, {"name": "note", "type": "string"}]}`,
+			compatible: true,
+		},
+		{
+			name:          "json optional property",
+			schemaType:    schemaregistry.TypeJSON,
+			compatibility: schemaregistry.CompatibilityBackward,
+			v1:            `{"type": "object", "properties": {"id": {"type": "string"}}, "required": ["id"]}`,
+			v2:            `{"type": "object", "properties": {"id": {"type": "string"}, "note": {"type": "string"}}, "required": ["id"]}`,
+			compatible:    true,
+		},
+		{
+			name:          "json renamed property",
+			schemaType:    schemaregistry.TypeJSON,
+			compatibility: schemaregistry.CompatibilityBackward,
+			v1:            `{"type": "object", "properties": {"SageID": {"type": "string"}}, "required": ["SageID"]}`,
+			v2:            `{"type": "object", "properties": {"SagaID": {"type": "string"}}, "required": ["SagaID"]}`,
+		},
+		{
+			name:          "json changed type",
+			schemaType:    schemaregistry.TypeJSON,
+			compatibility: schemaregistry.CompatibilityBackward,
+			v1: `{"type": "object", "$defs": {"quantity": {"type": "number"}},
+				"properties": {"quantity": {"$ref": "#/$defs/quantity"}}}`,
+			v2: `{"type": "object", "properties": {"quantity": {"type": "string"}}}`,
+		},
+		{
+			name:          "json closed object",
+			schemaType:    schemaregistry.TypeJSON,
+			compatibility: schemaregistry.CompatibilityForward,
+			v1:            `{"type": "object", "properties": {"id": {"type": "string"}}, "additionalProperties": false}`,
+			v2:            `{"type": "object", "properties": {"id": {"type": "string"}, "note": {"type": "string"}}}`,
+		},
+		{
+			name:          "protobuf new field",
+			schemaType:    schemaregistry.TypeProtobuf,
+			compatibility: schemaregistry.CompatibilityFullTransitive,
+			v1:            `syntax = "proto3"; message Order { string id = 1; }`,
+			v2:            `syntax = "proto3"; message Order { string id = 1; int32 quantity = 2; }`,
+			compatible:    true,
+		},
+		{
+			name:          "protobuf changed field type",
+			schemaType:    schemaregistry.TypeProtobuf,
+			compatibility: schemaregistry.CompatibilityBackward,
+			v1:            `syntax = "proto3"; message Order { string id = 1; }`,
+			v2:            `syntax = "proto3"; message Order { int64 id = 1; }`,
+		},
+		{
+			name:          "protobuf removed message",
+			schemaType:    schemaregistry.TypeProtobuf,
+			compatibility: schemaregistry.CompatibilityBackward,
+			v1:            `syntax = "proto3"; message Order { string id = 1; }`,
+			v2:            `syntax = "proto3"; message Payment { string id = 1; }`,
+		},
+		{
+			name:          "anything without compatibility",
+			schemaType:    schemaregistry.TypeProtobuf,
+			compatibility: schemaregistry.CompatibilityNone,
+			v1:            `syntax = "proto3"; message Order { string id = 1; }`,
+			v2:            `syntax = "proto3"; message Order { int64 id = 1; }`,
+			compatible:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			registry := schemaregistry.NewMemoryRegistry(tt.compatibility)
+			_, err := registry.Register(ctx, "orders-value", schemaregistry.Schema{Type: tt.schemaType, Schema: tt.v1})
+			require.NoError(t, err)
+
+			v2 := schemaregistry.Schema{Type: tt.schemaType, Schema: tt.v2}
+			compatible, err := registry.Compatible(ctx, "orders-value", v2)
+			require.NoError(t, err)
+			assert.Equal(t, tt.compatible, compatible)
+
+			_, err = registry.Register(ctx, "orders-value", v2)
+			if tt.compatible {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, schemaregistry.ErrIncompatibleSchema)
+			}
+		})
+	}
+}
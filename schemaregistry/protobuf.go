@@ -0,0 +1,233 @@
+package schemaregistry
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// protoFile is the name schemas are compiled under, imports of the well-known types resolve
+const protoFile = "schema.proto"
+
+// protobufFormat encodes records as the first message of a .proto schema
+type protobufFormat struct{}
+
+func (protobufFormat) Type() SchemaType { return TypeProtobuf }
+
+func (protobufFormat) ContentType() string { return "application/vnd.confluent.protobuf" }
+
+func (protobufFormat) Compile(schema string) (Codec, error) {
+	file, err := parseProto(schema)
+	if err != nil {
+		return nil, err
+	}
+	if file.Messages().Len() == 0 {
+		return nil, fmt.Errorf("%w: schema declares no message", ErrInvalidSchema)
+	}
+	return protobufCodec{file: file}, nil
+}
+
+func (protobufFormat) Compatible(reader, writer string) error {
+	readerFile, err := parseProto(reader)
+	if err != nil {
+		return err
+	}
+	writerFile, err := parseProto(writer)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	compareProtoMessages(&problems, readerFile, writerFile.Messages())
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrIncompatibleSchema, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func parseProto(schema string) (protoreflect.FileDescriptor, error) {
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{protoFile: schema}),
+		}),
+	}
+	files, err := compiler.Compile(context.Background(), protoFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	return files[0], nil
+}
+
+// protobufCodec writes the first message of the file. As in the Confluent wire format the
+// payload starts with the indexes of the message in the file, which for the first message
+// shrink to a single zero.
+type protobufCodec struct {
+	file protoreflect.FileDescriptor
+}
+
+func (c protobufCodec) Encode(v any) ([]byte, error) {
+	descriptor := c.file.Messages().Get(0)
+
+	message, ok := v.(proto.Message)
+	if ok {
+		if name := message.ProtoReflect().Descriptor().FullName(); name != descriptor.FullName() {
+			return nil, fmt.Errorf("cannot encode %s with the schema of %s", name, descriptor.FullName())
+		}
+	} else {
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		dynamic := dynamicpb.NewMessage(descriptor)
+		if err := protojson.Unmarshal(encoded, dynamic); err != nil {
+			return nil, fmt.Errorf("record does not match its schema: %w", err)
+		}
+		message = dynamic
+	}
+
+	payload, err := proto.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{0}, payload...), nil
+}
+
+func (c protobufCodec) Decode(data []byte, v any) error {
+	descriptor, payload, err := c.readIndexes(data)
+	if err != nil {
+		return err
+	}
+
+	if message, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(payload, message)
+	}
+
+	dynamic := dynamicpb.NewMessage(descriptor)
+	if err := proto.Unmarshal(payload, dynamic); err != nil {
+		return err
+	}
+	encoded, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(dynamic)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, v)
+}
+
+// readIndexes reads the zigzag varint encoded indexes leading to the message in the file
+func (c protobufCodec) readIndexes(data []byte) (protoreflect.MessageDescriptor, []byte, error) {
+	count, n := binary.Varint(data)
+	if n <= 0 || count < 0 {
+		return nil, nil, ErrUnknownWireFormat
+	}
+	data = data[n:]
+	if count == 0 {
+		return c.file.Messages().Get(0), data, nil
+	}
+
+	messages := c.file.Messages()
+	var descriptor protoreflect.MessageDescriptor
+	for i := int64(0); i < count; i++ {
+		index, n := binary.Varint(data)
+		if n <= 0 || index < 0 || int(index) >= messages.Len() {
+			return nil, nil, fmt.Errorf("%w: unknown message index", ErrUnknownWireFormat)
+		}
+		data = data[n:]
+		descriptor = messages.Get(int(index))
+		messages = descriptor.Messages()
+	}
+	return descriptor, data, nil
+}
+
+// compareProtoMessages collects the changes of the reader that break reading messages of the writer:
+// removed messages and fields whose number now carries an incompatible type
+func compareProtoMessages(problems *[]string, reader protoreflect.FileDescriptor, writerMessages protoreflect.MessageDescriptors) {
+	for i := 0; i < writerMessages.Len(); i++ {
+		writerMessage := writerMessages.Get(i)
+		found := findProtoMessage(reader.Messages(), writerMessage.FullName())
+		if found == nil {
+			*problems = append(*problems, fmt.Sprintf("message %s was removed", writerMessage.FullName()))
+			continue
+		}
+
+		writerFields := writerMessage.Fields()
+		for j := 0; j < writerFields.Len(); j++ {
+			writerField := writerFields.Get(j)
+			readerField := found.Fields().ByNumber(writerField.Number())
+			if readerField == nil {
+				continue
+			}
+			if !protoFieldsCompatible(readerField, writerField) {
+				*problems = append(*problems, fmt.Sprintf("field %d of %s changed from %s to %s",
+					writerField.Number(), writerMessage.FullName(), protoFieldType(writerField), protoFieldType(readerField)))
+			}
+		}
+
+		compareProtoMessages(problems, reader, writerMessage.Messages())
+	}
+}
+
+// findProtoMessage looks up a message by name among the messages and their nested messages
+func findProtoMessage(messages protoreflect.MessageDescriptors, name protoreflect.FullName) protoreflect.MessageDescriptor {
+	for i := 0; i < messages.Len(); i++ {
+		if messages.Get(i).FullName() == name {
+			return messages.Get(i)
+		}
+		if nested := findProtoMessage(messages.Get(i).Messages(), name); nested != nil {
+			return nested
+		}
+	}
+	return nil
+}
+
+// protoFieldsCompatible reports whether the reader decodes the values the writer encodes.
+// Kinds sharing a wire encoding can replace each other, as the registry allows.
+func protoFieldsCompatible(reader, writer protoreflect.FieldDescriptor) bool {
+	if reader.IsList() != writer.IsList() || reader.IsMap() != writer.IsMap() {
+		return false
+	}
+	if protoKindGroup(reader.Kind()) != protoKindGroup(writer.Kind()) {
+		return false
+	}
+	switch writer.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return reader.Message().FullName() == writer.Message().FullName()
+	}
+	return true
+}
+
+func protoKindGroup(kind protoreflect.Kind) string {
+	switch kind {
+	case protoreflect.Int32Kind, protoreflect.Uint32Kind, protoreflect.Int64Kind, protoreflect.Uint64Kind,
+		protoreflect.BoolKind, protoreflect.EnumKind:
+		return "varint"
+	case protoreflect.Sint32Kind, protoreflect.Sint64Kind:
+		return "zigzag"
+	case protoreflect.Fixed32Kind, protoreflect.Sfixed32Kind:
+		return "fixed32"
+	case protoreflect.Fixed64Kind, protoreflect.Sfixed64Kind:
+		return "fixed64"
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return "bytes"
+	default:
+		return kind.String()
+	}
+}
+
+func protoFieldType(field protoreflect.FieldDescriptor) string {
+	name := field.Kind().String()
+	if field.Message() != nil {
+		name = string(field.Message().FullName())
+	}
+	if field.IsList() {
+		return "repeated " + name
+	}
+	return name
+}
@@ -0,0 +1,89 @@
+// Package schemaregistry serializes event payloads with schemas kept in a schema registry.
+// Payloads use the Confluent wire format, so they carry the ID of their writer schema and
+// consumers decode them with exactly the schema they were written with.
+package schemaregistry
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SchemaType is the format of a schema, named as by the Confluent schema registry
+type SchemaType string
+
+const (
+	TypeAvro     SchemaType = "AVRO"
+	TypeProtobuf SchemaType = "PROTOBUF"
+	TypeJSON     SchemaType = "JSON"
+)
+
+// Schema is a schema in its text form
+type Schema struct {
+	Type   SchemaType
+	Schema string
+}
+
+// SchemaMetadata is a schema registered under a subject
+type SchemaMetadata struct {
+	Schema
+	ID      int
+	Subject string
+	Version int
+}
+
+var (
+	// ErrNotFound is returned for unknown subjects, versions and schema IDs
+	ErrNotFound = errors.New("schema not found")
+	// ErrIncompatibleSchema is returned for schemas that break the compatibility of their subject
+	ErrIncompatibleSchema = errors.New("incompatible schema")
+	// ErrInvalidSchema is returned for schemas that cannot be parsed
+	ErrInvalidSchema = errors.New("invalid schema")
+	// ErrNoSchema is returned when serializing a record no schema was given for
+	ErrNoSchema = errors.New("no schema for record")
+)
+
+// Compatibility is the rule new schemas of a subject must follow, named as by the
+// Confluent schema registry. Transitive rules hold against all versions instead of the latest.
+type Compatibility string
+
+const (
+	CompatibilityNone               Compatibility = "NONE"
+	CompatibilityBackward           Compatibility = "BACKWARD"
+	CompatibilityBackwardTransitive Compatibility = "BACKWARD_TRANSITIVE"
+	CompatibilityForward            Compatibility = "FORWARD"
+	CompatibilityForwardTransitive  Compatibility = "FORWARD_TRANSITIVE"
+	CompatibilityFull               Compatibility = "FULL"
+	CompatibilityFullTransitive     Compatibility = "FULL_TRANSITIVE"
+)
+
+// SubjectNameStrategy names the subject the schema of a record published to a topic is registered under
+type SubjectNameStrategy func(topic, record string) string
+
+// TopicNameStrategy registers one schema per topic, for topics carrying a single record type
+func TopicNameStrategy(topic, record string) string {
+	return topic + "-value"
+}
+
+// RecordNameStrategy registers one schema per record, whatever topic it is published to
+func RecordNameStrategy(topic, record string) string {
+	return record
+}
+
+// TopicRecordNameStrategy registers one schema per record and topic
+func TopicRecordNameStrategy(topic, record string) string {
+	return topic + "-" + record
+}
+
+// ParseSubjectNameStrategy parses "topic", "record" or "topic_record", topic_record when empty
+func ParseSubjectNameStrategy(name string) (SubjectNameStrategy, error) {
+	switch name {
+	case "", "topic_record":
+		return TopicRecordNameStrategy, nil
+	case "topic":
+		return TopicNameStrategy, nil
+	case "record":
+		return RecordNameStrategy, nil
+	default:
+		return nil, fmt.Errorf("unknown subject name strategy %q", name)
+	}
+}
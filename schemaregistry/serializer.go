@@ -0,0 +1,164 @@
+package schemaregistry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Serializer encodes records in the wire format with the schema of their record name.
+// Before a schema is first used for a subject it is checked against the compatibility rule
+// of the subject and registered, so an incompatible change fails on publish instead of in
+// the consumers.
+type Serializer struct {
+	registry    Registry
+	format      Format
+	subjectName SubjectNameStrategy
+	schemas     map[string]Schema
+	codecs      map[string]Codec
+
+	mu  sync.Mutex
+	ids map[string]int
+}
+
+// NewSerializer compiles the schemas, keyed by record name, of the format
+func NewSerializer(registry Registry, format Format, subjectName SubjectNameStrategy, schemas map[string]string) (*Serializer, error) {
+	s := &Serializer{
+		registry:    registry,
+		format:      format,
+		subjectName: subjectName,
+		schemas:     make(map[string]Schema, len(schemas)),
+		codecs:      make(map[string]Codec, len(schemas)),
+		ids:         make(map[string]int),
+	}
+	for record, schema := range schemas {
+		codec, err := format.Compile(schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile schema of %s: %w", record, err)
+		}
+		s.schemas[record] = Schema{Type: format.Type(), Schema: schema}
+		s.codecs[record] = codec
+	}
+	return s, nil
+}
+
+// ContentType returns the content type of the payloads
+func (s *Serializer) ContentType() string {
+	return s.format.ContentType()
+}
+
+// Serialize encodes a record published to the topic
+func (s *Serializer) Serialize(ctx context.Context, topic, record string, v any) ([]byte, error) {
+	codec, ok := s.codecs[record]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrNoSchema, record)
+	}
+
+	id, err := s.schemaID(ctx, s.subjectName(topic, record), record)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := codec.Encode(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", record, err)
+	}
+	return append(appendHeader(make([]byte, 0, headerSize+len(payload)), id), payload...), nil
+}
+
+// schemaID returns the ID of the record's schema under the subject, registering it on first use
+func (s *Serializer) schemaID(ctx context.Context, subject, record string) (int, error) {
+	s.mu.Lock()
+	id, ok := s.ids[subject]
+	s.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	schema := s.schemas[record]
+	compatible, err := s.registry.Compatible(ctx, subject, schema)
+	if err != nil {
+		return 0, err
+	}
+	if !compatible {
+		return 0, fmt.Errorf("%w: schema of %s breaks the compatibility of %s", ErrIncompatibleSchema, record, subject)
+	}
+	id, err = s.registry.Register(ctx, subject, schema)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	s.ids[subject] = id
+	s.mu.Unlock()
+	return id, nil
+}
+
+// Deserializer decodes payloads in the wire format with the schema they were written with,
+// whichever format it has
+type Deserializer struct {
+	registry Registry
+
+	mu     sync.Mutex
+	codecs map[int]Codec
+}
+
+// NewDeserializer creates a deserializer looking up writer schemas in the registry
+func NewDeserializer(registry Registry) *Deserializer {
+	return &Deserializer{registry: registry, codecs: make(map[int]Codec)}
+}
+
+// Accepts reports whether the content type is one of the wire format payloads
+func (d *Deserializer) Accepts(contentType string) bool {
+	for _, format := range []Format{Avro, Protobuf, JSONSchema} {
+		if format.ContentType() == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// Deserialize decodes the payload into v
+func (d *Deserializer) Deserialize(ctx context.Context, data []byte, v any) error {
+	id, payload, err := readHeader(data)
+	if err != nil {
+		return err
+	}
+
+	codec, err := d.codec(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := codec.Decode(payload, v); err != nil {
+		return fmt.Errorf("failed to decode record of schema %d: %w", id, err)
+	}
+	return nil
+}
+
+// codec returns the codec of the schema with the ID, compiling it on first use
+func (d *Deserializer) codec(ctx context.Context, id int) (Codec, error) {
+	d.mu.Lock()
+	codec, ok := d.codecs[id]
+	d.mu.Unlock()
+	if ok {
+		return codec, nil
+	}
+
+	schema, err := d.registry.SchemaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	format, err := formatOf(schema.Type)
+	if err != nil {
+		return nil, err
+	}
+	codec, err = format.Compile(schema.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema %d: %w", id, err)
+	}
+
+	d.mu.Lock()
+	d.codecs[id] = codec
+	d.mu.Unlock()
+	return codec, nil
+}
@@ -0,0 +1,134 @@
+package schemaregistry_test
+
+import (
+	"context"
+	"encoding/json"
+	"schemaregistry"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderCreated struct {
+	OrderID  string   `json:"order_id"`
+	Quantity int32    `json:"quantity"`
+	Products []string `json:"products"`
+}
+
+var orderCreatedSchemas = map[schemaregistry.Format]string{
+	schemaregistry.Avro: `{
+		"type": "record",
+		"name": "OrderCreated",
+		"fields": [
+			{"name": "order_id", "type": "string"},
+			{"name": "quantity", "type": "int"},
+			{"name": "products", "type": {"type": "array", "items": "string"}}
+		]
+	}`,
+	schemaregistry.Protobuf: `
+		syntax = "proto3";
+		package orders;
+		message OrderCreated {
+			string order_id = 1;
+			int32 quantity = 2;
+			repeated string products = 3;
+		}`,
+	schemaregistry.JSONSchema: `{
+		"type": "object",
+		"properties": {
+			"order_id": {"type": "string"},
+			"quantity": {"type": "integer"},
+			"products": {"type": "array", "items": {"type": "string"}}
+		},
+		"required": ["order_id", "quantity", "products"],
+		"additionalProperties": false
+	}`,
+}
+
+func TestSerializerRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	order := orderCreated{OrderID: "order-1", Quantity: 2, Products: []string{"product-1", "product-2"}}
+
+	for format, schema := range orderCreatedSchemas {
+		t.Run(string(format.Type()), func(t *testing.T) {
+			registry := schemaregistry.NewMemoryRegistry(schemaregistry.CompatibilityBackward)
+			serializer, err := schemaregistry.NewSerializer(registry, format, schemaregistry.TopicRecordNameStrategy,
+				map[string]string{"order.created": schema})
+			require.NoError(t, err)
+
+			data, err := serializer.Serialize(ctx, "orders", "order.created", order)
+			require.NoError(t, err)
+
+			id, err := schemaregistry.SchemaID(data)
+			require.NoError(t, err)
+			latest, err := registry.LatestSchema(ctx, "orders-order.created")
+			require.NoError(t, err)
+			assert.Equal(t, latest.ID, id)
+			assert.Equal(t, format.Type(), latest.Type)
+
+			deserializer := schemaregistry.NewDeserializer(registry)
+			assert.True(t, deserializer.Accepts(serializer.ContentType()))
+
+			var decoded orderCreated
+			require.NoError(t, deserializer.Deserialize(ctx, data, &decoded))
+			assert.Equal(t, order, decoded)
+
+			// Consumers can also take the record's JSON form
+			var raw json.RawMessage
+			require.NoError(t, deserializer.Deserialize(ctx, data, &raw))
+			assert.JSONEq(t, `{"order_id": "order-1", "quantity": 2, "products": ["product-1", "product-2"]}`, string(raw))
+		})
+	}
+}
+
+func TestSerializerRejectsIncompatibleSchema(t *testing.T) {
+	ctx := context.Background()
+	registry := schemaregistry.NewMemoryRegistry(schemaregistry.CompatibilityBackward)
+
+	v1, err := schemaregistry.NewSerializer(registry, schemaregistry.JSONSchema, schemaregistry.TopicRecordNameStrategy,
+		map[string]string{"order.created": orderCreatedSchemas[schemaregistry.JSONSchema]})
+	require.NoError(t, err)
+	_, err = v1.Serialize(ctx, "orders", "order.created", orderCreated{OrderID: "order-1", Products: []string{}})
+	require.NoError(t, err)
+
+	// Renaming a required field leaves records of the first version without it
+	v2, err := schemaregistry.NewSerializer(registry, schemaregistry.JSONSchema, schemaregistry.TopicRecordNameStrategy,
+		map[string]string{"order.created": `{
+			"type": "object",
+			"properties": {"id": {"type": "string"}},
+			"required": ["id"]
+		}`})
+	require.NoError(t, err)
+	_, err = v2.Serialize(ctx, "orders", "order.created", map[string]string{"id": "order-1"})
+	assert.ErrorIs(t, err, schemaregistry.ErrIncompatibleSchema)
+}
+
+func TestSerializerValidatesRecords(t *testing.T) {
+	registry := schemaregistry.NewMemoryRegistry(schemaregistry.CompatibilityBackward)
+
+	for format, schema := range orderCreatedSchemas {
+		t.Run(string(format.Type()), func(t *testing.T) {
+			serializer, err := schemaregistry.NewSerializer(registry, format, schemaregistry.TopicRecordNameStrategy,
+				map[string]string{"order.created": schema})
+			require.NoError(t, err)
+
+			_, err = serializer.Serialize(context.Background(), "orders", "order.created", map[string]any{"order": "order-1"})
+			assert.Error(t, err)
+
+			_, err = serializer.Serialize(context.Background(), "orders", "order.cancelled", orderCreated{})
+			assert.ErrorIs(t, err, schemaregistry.ErrNoSchema)
+		})
+	}
+}
+
+func TestDeserializerRejectsUnknownPayloads(t *testing.T) {
+	deserializer := schemaregistry.NewDeserializer(schemaregistry.NewMemoryRegistry(""))
+
+	var decoded orderCreated
+	err := deserializer.Deserialize(context.Background(), []byte(`{"order_id": "order-1"}`), &decoded)
+	assert.ErrorIs(t, err, schemaregistry.ErrUnknownWireFormat)
+
+	err = deserializer.Deserialize(context.Background(), []byte{0, 0, 0, 0, 42, 1}, &decoded)
+	assert.ErrorIs(t, err, schemaregistry.ErrNotFound)
+}
@@ -0,0 +1,39 @@
+package schemaregistry
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// magicByte starts every payload in the Confluent wire format
+const magicByte = 0
+
+// headerSize is the magic byte followed by the big endian schema ID
+const headerSize = 5
+
+// ErrUnknownWireFormat is returned for payloads that are not in the Confluent wire format
+var ErrUnknownWireFormat = errors.New("payload is not in the schema registry wire format")
+
+// appendHeader appends the wire format header of the schema ID
+func appendHeader(buf []byte, id int) []byte {
+	buf = append(buf, magicByte)
+	return binary.BigEndian.AppendUint32(buf, uint32(id))
+}
+
+// readHeader splits a payload into its schema ID and the encoded record
+func readHeader(data []byte) (int, []byte, error) {
+	if len(data) < headerSize || data[0] != magicByte {
+		return 0, nil, ErrUnknownWireFormat
+	}
+	return int(binary.BigEndian.Uint32(data[1:headerSize])), data[headerSize:], nil
+}
+
+// SchemaID returns the ID of the schema a payload was written with
+func SchemaID(data []byte) (int, error) {
+	id, _, err := readHeader(data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema id: %w", err)
+	}
+	return id, nil
+}